@@ -0,0 +1,73 @@
+package incache
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// accessStats records reads of an item, see WithAccessTracking.
+type accessStats struct {
+	// Unix nanoseconds.
+	lastAccess int64
+	hits       uint64
+}
+
+func (s *accessStats) record(now time.Time) {
+	atomic.StoreInt64(&s.lastAccess, now.UnixNano())
+	atomic.AddUint64(&s.hits, 1)
+}
+
+// load returns the time of the last read and the number of reads.
+func (s *accessStats) load() (time.Time, uint64) {
+	hits := atomic.LoadUint64(&s.hits)
+	if hits == 0 {
+		return time.Time{}, 0
+	}
+
+	return time.Unix(0, atomic.LoadInt64(&s.lastAccess)), hits
+}
+
+// accessStatsLocked returns the statistics of the item being stored.
+// Overwriting the key keeps its statistics.
+// It must be called with the write lock held.
+func (c *Cache) accessStatsLocked(old Item, exists bool) *accessStats {
+	if exists && old.access != nil {
+		return old.access
+	}
+
+	return &accessStats{}
+}
+
+// mostAccessed returns up to n keys with the highest numbers of reads,
+// see TopKeys.
+func (c *Cache) mostAccessed(n int) []KeyCount {
+	c.mu.RLock()
+
+	counts := []KeyCount{}
+	for key, item := range c.items {
+		if item.access == nil {
+			continue
+		}
+
+		if _, hits := item.access.load(); hits > 0 {
+			counts = append(counts, KeyCount{Key: key, Count: hits})
+		}
+	}
+
+	c.mu.RUnlock()
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+
+		return counts[i].Key < counts[j].Key
+	})
+
+	if n < len(counts) {
+		counts = counts[:n]
+	}
+
+	return counts
+}
@@ -0,0 +1,63 @@
+package incache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccessTracking(t *testing.T) {
+	cache := New(WithTTL(0), WithAccessTracking())
+	defer cache.Close()
+
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+
+	info, ok := cache.Inspect("key1")
+	require.True(t, ok)
+	assert.Zero(t, info.Hits)
+	assert.True(t, info.LastAccess.IsZero())
+
+	before := time.Now()
+	cache.Get("key1")
+	cache.Get("key1")
+	cache.Get("key2")
+	cache.Get("key3")
+
+	info, _ = cache.Inspect("key1")
+	assert.EqualValues(t, 2, info.Hits)
+	assert.False(t, info.LastAccess.Before(before.Truncate(time.Microsecond)))
+
+	// Overwriting the key keeps its statistics.
+	cache.Set("key1", "value3")
+	info, _ = cache.Inspect("key1")
+	assert.EqualValues(t, 2, info.Hits)
+
+	assert.Equal(t, []KeyCount{{Key: "key1", Count: 2}, {Key: "key2", Count: 1}}, cache.TopKeys(10))
+	assert.Equal(t, []KeyCount{{Key: "key1", Count: 2}}, cache.TopKeys(1))
+}
+
+func TestAccessTrackingReadOptimized(t *testing.T) {
+	cache := New(WithTTL(0), WithAccessTracking(), WithReadOptimizedStorage())
+	defer cache.Close()
+
+	cache.Set("key1", "value1")
+	cache.Get("key1")
+
+	info, _ := cache.Inspect("key1")
+	assert.EqualValues(t, 1, info.Hits)
+}
+
+func TestAccessTrackingDisabled(t *testing.T) {
+	cache := New(WithTTL(0))
+	defer cache.Close()
+
+	cache.Set("key1", "value1")
+	cache.Get("key1")
+
+	info, _ := cache.Inspect("key1")
+	assert.Zero(t, info.Hits)
+	assert.Nil(t, cache.TopKeys(10))
+}
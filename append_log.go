@@ -0,0 +1,457 @@
+package incache
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// appendLogMagic starts every append-only log.
+var appendLogMagic = []byte("incache-aof 1\n")
+
+// appendLogSyncInterval is how often the log is written out and synced to
+// the disk, so at most this much of the latest operations is lost on a crash.
+const appendLogSyncInterval = time.Second
+
+// Operations of log records.
+const (
+	logSet byte = iota
+	logDelete
+	logFlush
+)
+
+// Every record of the log is encoded as:
+//
+//	length   uvarint, the length of the payload
+//	checksum 4 bytes, CRC-32 of the payload, little endian
+//	payload  op (1 byte), key length (uvarint), key, TTL in nanoseconds
+//	         (varint), expiration time in Unix nanoseconds (varint, zero if
+//	         the item never expires) and the encoded value
+//
+// A torn record at the end of the log, left by a crash in the middle of a
+// write, is ignored on replay.
+
+// appendLog appends operations of the cache to a file and periodically
+// rewrites the file with the current contents of the cache, so it doesn't
+// grow forever.
+type appendLog struct {
+	path            string
+	compactInterval time.Duration
+	codec           Codec
+
+	mu   sync.Mutex
+	file *os.File
+	w    *bufio.Writer
+	buf  []byte
+
+	closeCh   chan struct{}
+	doneCh    chan struct{}
+	closeOnce sync.Once
+}
+
+func newAppendLog(path string, compactInterval time.Duration, codec Codec) *appendLog {
+	if codec == nil {
+		codec = GobValueCodec
+	}
+
+	return &appendLog{
+		path:            path,
+		compactInterval: compactInterval,
+		codec:           codec,
+
+		closeCh: make(chan struct{}),
+		doneCh:  make(chan struct{}),
+	}
+}
+
+// open replays the log into the cache if the file exists, and compacts it,
+// so the replayed operations aren't stored twice.
+func (l *appendLog) open(c *Cache) error {
+	if err := c.replayLog(l.path, l.codec); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return l.compact(c)
+}
+
+// append writes the record to the log. It must be called with the write
+// lock of the cache held, so records are written in the order of the
+// operations.
+func (l *appendLog) append(op byte, key string, item Item, value interface{}) error {
+	payload := append(l.buf[:0], op)
+	payload = appendUvarint(payload, uint64(len(key)))
+	payload = append(payload, key...)
+
+	if op == logSet {
+		var expiresAt int64
+		if !item.ExpiresAt.IsZero() {
+			expiresAt = item.ExpiresAt.UnixNano()
+		}
+
+		payload = appendVarint(payload, int64(item.TTL))
+		payload = appendVarint(payload, expiresAt)
+
+		data, err := l.codec.Encode(value)
+		if err != nil {
+			return err
+		}
+
+		payload = append(payload, data...)
+	}
+
+	l.buf = payload
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.w == nil {
+		return errors.New("incache: append-only log is closed")
+	}
+
+	return writeLogRecord(l.w, payload)
+}
+
+func writeLogRecord(w io.Writer, payload []byte) error {
+	var header [binary.MaxVarintLen64 + 4]byte
+
+	n := binary.PutUvarint(header[:], uint64(len(payload)))
+	binary.LittleEndian.PutUint32(header[n:], crc32.ChecksumIEEE(payload))
+
+	if _, err := w.Write(header[:n+4]); err != nil {
+		return err
+	}
+
+	_, err := w.Write(payload)
+
+	return err
+}
+
+// appendUvarint and appendVarint work like the functions of encoding/binary
+// added in Go 1.19.
+func appendUvarint(buf []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	return append(buf, tmp[:binary.PutUvarint(tmp[:], v)]...)
+}
+
+func appendVarint(buf []byte, v int64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	return append(buf, tmp[:binary.PutVarint(tmp[:], v)]...)
+}
+
+// sync writes out the buffered records and syncs the file to the disk.
+func (l *appendLog) sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.w == nil {
+		return nil
+	}
+
+	if err := l.w.Flush(); err != nil {
+		return err
+	}
+
+	return l.file.Sync()
+}
+
+// compact rewrites the log with the items of the cache, so it only contains
+// a record per live item. The new log atomically replaces the old one.
+func (l *appendLog) compact(c *Cache) error {
+	// Writes are blocked during the rewrite, so no operation is lost between
+	// reading the items and switching to the new file.
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	dir, name := filepath.Split(l.path)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := os.CreateTemp(dir, name+".tmp*")
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(tmp)
+
+	err = c.writeLogLocked(w, l.codec)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), l.path)
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file != nil {
+		l.w.Flush()
+		l.file.Close()
+	}
+
+	l.file, l.w = tmp, w
+
+	return nil
+}
+
+// writeLogLocked writes the header and a record per live item to w.
+// It must be called with at least the read lock held.
+func (c *Cache) writeLogLocked(w io.Writer, codec Codec) error {
+	if _, err := w.Write(appendLogMagic); err != nil {
+		return err
+	}
+
+	scratch := &appendLog{codec: codec, w: bufio.NewWriterSize(w, 16)}
+	for _, entry := range c.liveItemsLocked() {
+		item := entry.item
+
+		if err := scratch.append(logSet, entry.key, item, item.Value); err != nil {
+			return fmt.Errorf("incache: write log record %q: %w", entry.key, err)
+		}
+	}
+
+	return scratch.w.Flush()
+}
+
+func (l *appendLog) start(c *Cache) {
+	go func() {
+		defer close(l.doneCh)
+
+		syncTicker := time.NewTicker(appendLogSyncInterval)
+		defer syncTicker.Stop()
+
+		var compactCh <-chan time.Time
+		if l.compactInterval > 0 {
+			compactTicker := time.NewTicker(l.compactInterval)
+			defer compactTicker.Stop()
+
+			compactCh = compactTicker.C
+		}
+
+		for {
+			select {
+			case <-syncTicker.C:
+				if err := l.sync(); err != nil {
+					c.config.debugf("[aof] failed to sync '%s': %v", l.path, err)
+				}
+			case <-compactCh:
+				if err := l.compact(c); err != nil {
+					c.config.debugf("[aof] failed to compact '%s': %v", l.path, err)
+				}
+			case <-l.closeCh:
+				if err := l.sync(); err != nil {
+					c.config.debugf("[aof] failed to sync '%s': %v", l.path, err)
+				}
+
+				l.mu.Lock()
+				l.file.Close()
+				l.w = nil
+				l.mu.Unlock()
+
+				return
+			}
+		}
+	}()
+}
+
+// close stops the background process and waits until the log is synced.
+// It's safe to call it multiple times.
+func (l *appendLog) close() {
+	l.closeOnce.Do(func() {
+		close(l.closeCh)
+	})
+
+	<-l.doneCh
+}
+
+// logSetLocked appends the stored item to the log.
+// It must be called with the write lock held.
+func (c *Cache) logSetLocked(key string, item Item) {
+	if c.appendLog == nil {
+		return
+	}
+
+	if err := c.appendLog.append(logSet, key, item, c.value(item.Value)); err != nil {
+		c.config.debugf("[aof] failed to append the key: '%s': %v", key, err)
+	}
+}
+
+// logDeleteLocked appends the removal of the key, or of all keys if op is
+// logFlush, to the log. It must be called with the write lock held.
+func (c *Cache) logDeleteLocked(op byte, key string) {
+	if c.appendLog == nil {
+		return
+	}
+
+	if err := c.appendLog.append(op, key, Item{}, nil); err != nil {
+		c.config.debugf("[aof] failed to append the deletion of the key: '%s': %v", key, err)
+	}
+}
+
+// replayLog applies the records of the log at path to the cache.
+// A torn record at the end of the log stops the replay without an error.
+func (c *Cache) replayLog(path string, codec Codec) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	// No record can be larger than the file, which bounds the lengths read
+	// from a damaged log.
+	maxSize := uint64(info.Size())
+
+	r := bufio.NewReader(file)
+
+	magic := make([]byte, len(appendLogMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != string(appendLogMagic) {
+		return fmt.Errorf("incache: '%s' isn't an append-only log", path)
+	}
+
+	c.mu.Lock()
+	defer c.unlock()
+
+	for n := 0; ; n++ {
+		payload, err := readLogRecord(r, maxSize)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			c.config.debugf("[aof] stopped the replay of '%s' at the record %d: %v", path, n, err)
+			return nil
+		}
+
+		if err := c.applyLogRecordLocked(payload, codec); err != nil {
+			return fmt.Errorf("incache: replay record %d of '%s': %w", n, path, err)
+		}
+	}
+}
+
+var errTornLogRecord = errors.New("torn record")
+
+// readLogRecord reads a record of at most maxSize bytes. Empty records are
+// never written, so they're treated as torn: the CRC of an empty payload is
+// zero, so zeroed bytes would pass the check otherwise.
+func readLogRecord(r *bufio.Reader, maxSize uint64) ([]byte, error) {
+	size, err := binary.ReadUvarint(r)
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil || size == 0 || size > maxSize {
+		return nil, errTornLogRecord
+	}
+
+	var checksum [4]byte
+	if _, err := io.ReadFull(r, checksum[:]); err != nil {
+		return nil, errTornLogRecord
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, errTornLogRecord
+	}
+
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(checksum[:]) {
+		return nil, errTornLogRecord
+	}
+
+	return payload, nil
+}
+
+// applyLogRecordLocked must be called with the write lock held.
+func (c *Cache) applyLogRecordLocked(payload []byte, codec Codec) error {
+	if len(payload) == 0 {
+		return errors.New("empty record")
+	}
+
+	op := payload[0]
+	payload = payload[1:]
+
+	size, n := binary.Uvarint(payload)
+	if n <= 0 || uint64(len(payload)-n) < size {
+		return errors.New("invalid key")
+	}
+
+	key := string(payload[n : n+int(size)])
+	payload = payload[n+int(size):]
+
+	switch op {
+	case logFlush:
+		c.flushAllLocked()
+	case logDelete:
+		if _, ok := c.items[key]; ok {
+			c.evictLocked(key, reasonDeleted)
+		}
+	case logSet:
+		ttl, n := binary.Varint(payload)
+		if n <= 0 {
+			return errors.New("invalid TTL")
+		}
+		payload = payload[n:]
+
+		expiresAt, n := binary.Varint(payload)
+		if n <= 0 {
+			return errors.New("invalid expiration time")
+		}
+
+		value, err := codec.Decode(payload[n:])
+		if err != nil {
+			return err
+		}
+
+		item := Item{Value: value, TTL: time.Duration(ttl)}
+		if expiresAt != 0 {
+			item.ExpiresAt = monotonic(time.Unix(0, expiresAt))
+		}
+
+		if item.Expired() {
+			return nil
+		}
+
+		c.setItemLocked(key, item)
+	default:
+		return fmt.Errorf("unknown operation %d", op)
+	}
+
+	return nil
+}
+
+// WithAppendOnlyLog makes the cache append every set and deletion to the
+// log file at path, which is replayed when the cache is created, so the
+// cache survives restarts with finer-grained durability than
+// WithAutoSnapshot. The log is synced to the disk every second and on Close.
+//
+// Every compactInterval, the log is rewritten with the current items, so it
+// doesn't grow forever. Writes are blocked during the rewrite. Zero interval
+// disables periodic compaction, the log is still compacted on start.
+//
+// Values are encoded with the codec set by WithValueCodec, GobValueCodec by
+// default. Errors are only reported in debug mode.
+func WithAppendOnlyLog(path string, compactInterval time.Duration) configFunc {
+	return func(config *Config) {
+		config.appendLogPath = path
+		config.appendLogCompactInterval = compactInterval
+	}
+}
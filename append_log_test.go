@@ -0,0 +1,222 @@
+package incache
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendOnlyLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.aof")
+
+	cache := New(WithTTL(0), WithAppendOnlyLog(path, 0))
+	cache.Set("key1", "value1")
+	cache.SetWithTTL("key2", "value2", time.Minute)
+	cache.Set("key3", "value3")
+	cache.Delete("key3")
+	cache.Set("key1", "value1.1")
+	cache.Close()
+
+	restored := New(WithTTL(0), WithAppendOnlyLog(path, 0))
+	defer restored.Close()
+
+	assert.Equal(t, 2, restored.Len())
+	assert.Equal(t, "value1.1", restored.Get("key1"))
+	assert.Equal(t, "value2", restored.Get("key2"))
+	assert.Nil(t, restored.Get("key3"))
+
+	info, _ := restored.Inspect("key2")
+	assert.Equal(t, time.Minute, info.TTL)
+	assert.InDelta(t, time.Minute, time.Until(info.ExpiresAt), float64(5*time.Second))
+}
+
+func TestAppendOnlyLogFlushAll(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.aof")
+
+	cache := New(WithTTL(0), WithAppendOnlyLog(path, 0))
+	cache.Set("key1", "value1")
+	cache.FlushAll()
+	cache.Set("key2", "value2")
+	cache.Close()
+
+	restored := New(WithTTL(0), WithAppendOnlyLog(path, 0))
+	defer restored.Close()
+
+	assert.Equal(t, []string{"key2"}, restored.Keys())
+}
+
+func TestAppendOnlyLogExpireAndPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.aof")
+
+	cache := New(WithTTL(0), WithAppendOnlyLog(path, 0))
+	cache.Set("key1", "value1")
+	cache.SetWithTTL("key2", "value2", time.Minute)
+	cache.SetWithTTL("key3", "value3", time.Minute)
+	cache.Expire("key1", time.Hour)
+	cache.Persist("key2")
+	cache.Expire("key3", 0)
+	cache.Close()
+
+	restored := New(WithTTL(0), WithAppendOnlyLog(path, 0))
+	defer restored.Close()
+
+	info, ok := restored.Inspect("key1")
+	assert.True(t, ok)
+	assert.Equal(t, time.Hour, info.TTL)
+	assert.InDelta(t, time.Hour, time.Until(info.ExpiresAt), float64(5*time.Second))
+
+	info, ok = restored.Inspect("key2")
+	assert.True(t, ok)
+	assert.Zero(t, info.TTL)
+	assert.True(t, info.ExpiresAt.IsZero())
+
+	assert.False(t, restored.Has("key3"))
+}
+
+func TestAppendOnlyLogSkipsExpiredItems(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.aof")
+
+	cache := New(WithAppendOnlyLog(path, 0), WithCleanupInterval(0))
+	cache.SetWithTTL("key1", "value1", 10*time.Millisecond)
+	cache.Set("key2", "value2")
+	cache.Close()
+
+	time.Sleep(20 * time.Millisecond)
+
+	restored := New(WithAppendOnlyLog(path, 0), WithCleanupInterval(0))
+	defer restored.Close()
+
+	assert.Equal(t, []string{"key2"}, restored.Keys())
+}
+
+func TestAppendOnlyLogIgnoresTornRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.aof")
+
+	cache := New(WithTTL(0), WithAppendOnlyLog(path, 0))
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+	cache.Close()
+
+	// Simulate a crash in the middle of writing the last record.
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(path, data[:len(data)-3], 0o600))
+
+	restored := New(WithTTL(0), WithAppendOnlyLog(path, 0))
+	defer restored.Close()
+
+	assert.Equal(t, 1, restored.Len())
+}
+
+func TestAppendOnlyLogIgnoresDamagedTail(t *testing.T) {
+	huge := make([]byte, binary.MaxVarintLen64)
+	huge = huge[:binary.PutUvarint(huge, 1<<62)]
+
+	for name, tail := range map[string][]byte{
+		"zero bytes":  make([]byte, 16),
+		"huge length": append(huge, 0, 0, 0, 0),
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cache.aof")
+
+			cache := New(WithTTL(0), WithAppendOnlyLog(path, 0))
+			cache.Set("key1", "value1")
+			cache.Close()
+
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(path, append(data, tail...), 0o600))
+
+			restored := New(WithTTL(0), WithAppendOnlyLog(path, 0))
+			defer restored.Close()
+
+			assert.Equal(t, "value1", restored.Get("key1"))
+		})
+	}
+}
+
+func TestAppendOnlyLogCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.aof")
+
+	cache := New(WithTTL(0), WithAppendOnlyLog(path, 0))
+	for i := 0; i < 100; i++ {
+		cache.Set("key1", i)
+	}
+	assert.NoError(t, cache.appendLog.sync())
+
+	before, err := os.Stat(path)
+	assert.NoError(t, err)
+
+	assert.NoError(t, cache.appendLog.compact(cache))
+	cache.Set("key2", "value2")
+	cache.Close()
+
+	after, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Less(t, after.Size(), before.Size())
+
+	restored := New(WithTTL(0), WithAppendOnlyLog(path, 0))
+	defer restored.Close()
+
+	assert.Equal(t, 99, restored.Get("key1"))
+	assert.Equal(t, "value2", restored.Get("key2"))
+}
+
+func TestAppendOnlyLogPeriodicCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.aof")
+
+	cache := New(WithTTL(0), WithAppendOnlyLog(path, 10*time.Millisecond))
+	defer cache.Close()
+
+	for i := 0; i < 100; i++ {
+		cache.Set("key1", i)
+	}
+
+	assert.Eventually(t, func() bool {
+		info, err := os.Stat(path)
+		return err == nil && info.Size() < 100
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestAppendOnlyLogWithValueCodec(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.aof")
+
+	codec := NewJSONCodec(codecUser{})
+
+	cache := New(WithTTL(0), WithValueCodec(codec), WithAppendOnlyLog(path, 0))
+	cache.Set("user", codecUser{Name: "alice"})
+	cache.Close()
+
+	restored := New(WithTTL(0), WithValueCodec(codec), WithAppendOnlyLog(path, 0))
+	defer restored.Close()
+
+	assert.Equal(t, codecUser{Name: "alice"}, restored.Get("user"))
+}
+
+func TestAppendOnlyLogInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.aof")
+	assert.NoError(t, os.WriteFile(path, []byte("not a log"), 0o600))
+
+	cache := New(WithAppendOnlyLog(path, 0))
+	defer cache.Close()
+
+	// The file isn't overwritten, so its contents can be recovered.
+	assert.Nil(t, cache.appendLog)
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "not a log", string(data))
+}
+
+func TestAppendOnlyLogValidation(t *testing.T) {
+	_, err := NewWithError(WithAppendOnlyLog(filepath.Join(t.TempDir(), "cache.aof"), -time.Second))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+
+	_, err = NewWithError(WithAppendOnlyLog(filepath.Join(t.TempDir(), "missing", "cache.aof"), 0))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
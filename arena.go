@@ -0,0 +1,233 @@
+//go:build incache_arena
+
+package incache
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrEntryTooLarge is returned by Arena when an entry doesn't fit in
+// the arena.
+var ErrEntryTooLarge = errors.New("incache: entry is larger than the arena")
+
+// Size of the header of an entry in the arena: hash of the key, expiration
+// time in Unix nanoseconds, length of the key and length of the value.
+const arenaHeaderSize = 8 + 8 + 4 + 4
+
+// Arena is an experimental storage engine for large caches of serialized
+// values, similar to freecache. Entries are copied into a single byte slice
+// allocated up front and used as a ring buffer, and indexed by a map from
+// the hashes of keys to offsets. Neither contains pointers, so the garbage
+// collector doesn't scan the entries, and caches of several gigabytes don't
+// make GC pauses longer.
+//
+// It's only built with the incache_arena build tag. Values are copied in
+// and out, so they have to be serialized, e.g. with a Codec. When the arena
+// is full, the oldest entries are overwritten, no matter how often they're
+// read. Overwritten and deleted entries keep their space until the ring
+// buffer wraps around. Keys whose 64-bit hashes collide replace each other.
+type Arena struct {
+	ttl time.Duration
+
+	mu  sync.Mutex
+	buf []byte
+	// Absolute offsets of the oldest entry and of the end of the newest one.
+	// The position in buf is the offset modulo its length.
+	head, tail uint64
+	// Absolute offsets of the entries by hash of the key.
+	index map[uint64]uint64
+}
+
+// NewArena returns an arena of size bytes. Entries are stored with
+// the default TTL ttl, TTL <= 0 means that they don't expire.
+func NewArena(size int, ttl time.Duration) *Arena {
+	return &Arena{
+		ttl:   ttl,
+		buf:   make([]byte, size),
+		index: make(map[uint64]uint64),
+	}
+}
+
+// Set stores the value of key with the default TTL of the arena.
+func (a *Arena) Set(key string, value []byte) error {
+	return a.SetWithTTL(key, value, a.ttl)
+}
+
+// SetWithTTL stores the value of key for ttl, TTL <= 0 means that
+// it doesn't expire. The value is copied. ErrEntryTooLarge is returned if
+// the key and the value don't fit in the arena.
+func (a *Arena) SetWithTTL(key string, value []byte, ttl time.Duration) error {
+	size := uint64(arenaHeaderSize + len(key) + len(value))
+	if size > uint64(len(a.buf)) {
+		return ErrEntryTooLarge
+	}
+
+	var expiresAt int64
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl).UnixNano()
+	}
+
+	hash := hashKey(key)
+
+	var header [arenaHeaderSize]byte
+	binary.LittleEndian.PutUint64(header[0:], hash)
+	binary.LittleEndian.PutUint64(header[8:], uint64(expiresAt))
+	binary.LittleEndian.PutUint32(header[16:], uint32(len(key)))
+	binary.LittleEndian.PutUint32(header[20:], uint32(len(value)))
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.makeRoom(size)
+
+	offset := a.tail
+	a.write(offset, header[:])
+	a.writeString(offset+arenaHeaderSize, key)
+	a.write(offset+arenaHeaderSize+uint64(len(key)), value)
+	a.tail += size
+
+	a.index[hash] = offset
+
+	return nil
+}
+
+// Get returns a copy of the value of key. The second value reports whether
+// the key exists and isn't expired.
+func (a *Arena) Get(key string) ([]byte, bool) {
+	hash := hashKey(key)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	offset, ok := a.index[hash]
+	if !ok {
+		return nil, false
+	}
+
+	expiresAt, keyLen, valueLen := a.header(offset)
+	if keyLen != len(key) || !a.equal(offset+arenaHeaderSize, key) {
+		return nil, false
+	}
+
+	if expiresAt != 0 && time.Now().UnixNano() > expiresAt {
+		delete(a.index, hash)
+		return nil, false
+	}
+
+	value := make([]byte, valueLen)
+	a.read(offset+arenaHeaderSize+uint64(keyLen), value)
+
+	return value, true
+}
+
+// Delete deletes the value of key and reports whether it existed.
+func (a *Arena) Delete(key string) bool {
+	hash := hashKey(key)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	offset, ok := a.index[hash]
+	if !ok {
+		return false
+	}
+
+	_, keyLen, _ := a.header(offset)
+	if keyLen != len(key) || !a.equal(offset+arenaHeaderSize, key) {
+		return false
+	}
+
+	delete(a.index, hash)
+
+	return true
+}
+
+// Len returns the number of entries in the arena, including expired ones
+// that weren't overwritten yet.
+func (a *Arena) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return len(a.index)
+}
+
+// makeRoom drops the oldest entries until size bytes can be appended.
+// It must be called with the lock held.
+func (a *Arena) makeRoom(size uint64) {
+	for a.tail+size-a.head > uint64(len(a.buf)) {
+		var header [arenaHeaderSize]byte
+		a.read(a.head, header[:])
+
+		hash := binary.LittleEndian.Uint64(header[0:])
+		keyLen := binary.LittleEndian.Uint32(header[16:])
+		valueLen := binary.LittleEndian.Uint32(header[20:])
+
+		// The index may already point to a newer entry of the key.
+		if offset, ok := a.index[hash]; ok && offset == a.head {
+			delete(a.index, hash)
+		}
+
+		a.head += arenaHeaderSize + uint64(keyLen) + uint64(valueLen)
+	}
+}
+
+// header decodes the header of the entry at offset.
+func (a *Arena) header(offset uint64) (expiresAt int64, keyLen, valueLen int) {
+	var header [arenaHeaderSize]byte
+	a.read(offset, header[:])
+
+	expiresAt = int64(binary.LittleEndian.Uint64(header[8:]))
+	keyLen = int(binary.LittleEndian.Uint32(header[16:]))
+	valueLen = int(binary.LittleEndian.Uint32(header[20:]))
+
+	return expiresAt, keyLen, valueLen
+}
+
+// The helpers below access the ring buffer at absolute offsets, wrapping
+// around its end.
+
+func (a *Arena) write(offset uint64, data []byte) {
+	n := copy(a.buf[offset%uint64(len(a.buf)):], data)
+	copy(a.buf, data[n:])
+}
+
+func (a *Arena) writeString(offset uint64, s string) {
+	n := copy(a.buf[offset%uint64(len(a.buf)):], s)
+	copy(a.buf, s[n:])
+}
+
+func (a *Arena) read(offset uint64, dst []byte) {
+	n := copy(dst, a.buf[offset%uint64(len(a.buf)):])
+	copy(dst[n:], a.buf)
+}
+
+// equal reports whether the bytes at offset are equal to s.
+func (a *Arena) equal(offset uint64, s string) bool {
+	pos := offset % uint64(len(a.buf))
+
+	first := a.buf[pos:]
+	if len(first) >= len(s) {
+		return string(first[:len(s)]) == s
+	}
+
+	return string(first) == s[:len(first)] && string(a.buf[:len(s)-len(first)]) == s[len(first):]
+}
+
+// hashKey returns the 64-bit FNV-1a hash of the key.
+func hashKey(key string) uint64 {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+
+	hash := uint64(offset64)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint64(key[i])
+		hash *= prime64
+	}
+
+	return hash
+}
@@ -0,0 +1,147 @@
+//go:build incache_arena
+
+package incache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArena(t *testing.T) {
+	arena := NewArena(1024, 0)
+
+	require.NoError(t, arena.Set("key1", []byte("value1")))
+	require.NoError(t, arena.Set("key2", []byte("value2")))
+	require.NoError(t, arena.Set("key1", []byte("value1.1")))
+
+	value, ok := arena.Get("key1")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("value1.1"), value)
+
+	value, ok = arena.Get("key2")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("value2"), value)
+
+	_, ok = arena.Get("key3")
+	assert.False(t, ok)
+
+	assert.Equal(t, 2, arena.Len())
+
+	assert.True(t, arena.Delete("key2"))
+	assert.False(t, arena.Delete("key2"))
+
+	_, ok = arena.Get("key2")
+	assert.False(t, ok)
+	assert.Equal(t, 1, arena.Len())
+}
+
+func TestArenaCopiesValues(t *testing.T) {
+	arena := NewArena(1024, 0)
+
+	value := []byte("value1")
+	require.NoError(t, arena.Set("key1", value))
+	value[0] = 'V'
+
+	got, _ := arena.Get("key1")
+	assert.Equal(t, []byte("value1"), got)
+}
+
+func TestArenaTTL(t *testing.T) {
+	arena := NewArena(1024, time.Millisecond)
+
+	require.NoError(t, arena.Set("key1", []byte("value1")))
+	require.NoError(t, arena.SetWithTTL("key2", []byte("value2"), time.Hour))
+	require.NoError(t, arena.SetWithTTL("key3", []byte("value3"), 0))
+
+	time.Sleep(2 * time.Millisecond)
+
+	_, ok := arena.Get("key1")
+	assert.False(t, ok)
+
+	_, ok = arena.Get("key2")
+	assert.True(t, ok)
+
+	_, ok = arena.Get("key3")
+	assert.True(t, ok)
+}
+
+func TestArenaOverwritesOldestEntries(t *testing.T) {
+	// Every entry takes 24 + 5 + 7 = 36 bytes, so 10 entries fit, and the
+	// size isn't a multiple of it, so entries wrap around the end.
+	arena := NewArena(370, 0)
+
+	for i := 0; i < 25; i++ {
+		require.NoError(t, arena.Set(fmt.Sprintf("key%02d", i), []byte(fmt.Sprintf("value%02d", i))))
+	}
+
+	assert.Equal(t, 10, arena.Len())
+
+	for i := 0; i < 25; i++ {
+		value, ok := arena.Get(fmt.Sprintf("key%02d", i))
+
+		if i < 15 {
+			assert.False(t, ok, i)
+			continue
+		}
+
+		assert.True(t, ok, i)
+		assert.Equal(t, []byte(fmt.Sprintf("value%02d", i)), value)
+	}
+}
+
+func TestArenaKeepsNewerEntryOfOverwrittenKey(t *testing.T) {
+	// Every entry takes 24 + 4 + 6 = 34 bytes, so 3 entries fit.
+	arena := NewArena(110, 0)
+
+	require.NoError(t, arena.Set("key1", []byte("value1")))
+	require.NoError(t, arena.Set("key2", []byte("value2")))
+	require.NoError(t, arena.Set("key1", []byte("value3")))
+
+	// Dropping the first entry of key1 doesn't remove its newer entry.
+	require.NoError(t, arena.Set("key3", []byte("value4")))
+
+	value, ok := arena.Get("key1")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("value3"), value)
+
+	_, ok = arena.Get("key2")
+	assert.True(t, ok)
+	assert.Equal(t, 3, arena.Len())
+}
+
+func TestArenaEntryTooLarge(t *testing.T) {
+	arena := NewArena(32, 0)
+
+	assert.ErrorIs(t, arena.Set("key1", make([]byte, 8)), ErrEntryTooLarge)
+	assert.NoError(t, arena.Set("key1", make([]byte, 4)))
+}
+
+func TestArenaConcurrentAccess(t *testing.T) {
+	arena := NewArena(4096, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 1000; j++ {
+				key := fmt.Sprint("key", (i+j)%50)
+
+				_ = arena.Set(key, []byte(key))
+				if value, ok := arena.Get(key); ok {
+					assert.Equal(t, key, string(value))
+				}
+				arena.Delete(key)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,139 @@
+package incache
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// SaveSnapshotFile atomically writes the snapshot to the file at path:
+// the snapshot is written to a temporary file in the same directory first,
+// which then replaces the target file.
+func (c *Cache) SaveSnapshotFile(path string, codec SnapshotCodec) error {
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := os.CreateTemp(dir, name+".tmp*")
+	if err != nil {
+		return err
+	}
+
+	if err := c.writeSnapshotFile(tmp, codec); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return nil
+}
+
+// LoadSnapshotFile loads the snapshot stored in the file at path.
+// See LoadSnapshot for details.
+func (c *Cache) LoadSnapshotFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return c.LoadSnapshot(file)
+}
+
+func (c *Cache) writeSnapshotFile(file *os.File, codec SnapshotCodec) error {
+	if err := c.SaveSnapshot(file, codec); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
+
+// The structure is supposed to control a background process that
+// periodically persists the cache to a file.
+type autoSnapshotter struct {
+	path     string
+	interval time.Duration
+	codec    SnapshotCodec
+
+	closeCh   chan struct{}
+	doneCh    chan struct{}
+	closeOnce sync.Once
+}
+
+func newAutoSnapshotter(path string, interval time.Duration, codec SnapshotCodec) *autoSnapshotter {
+	return &autoSnapshotter{
+		path:     path,
+		interval: interval,
+		codec:    codec,
+
+		closeCh: make(chan struct{}),
+		doneCh:  make(chan struct{}),
+	}
+}
+
+// restore loads the snapshot into the cache if the file exists.
+func (s *autoSnapshotter) restore(c *Cache) {
+	err := c.LoadSnapshotFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return
+	}
+
+	if err != nil {
+		c.config.debugf("[snapshot] failed to restore from '%s': %v", s.path, err)
+		return
+	}
+
+	c.config.debugf("[snapshot] restored from '%s'", s.path)
+}
+
+func (s *autoSnapshotter) save(c *Cache) {
+	if err := c.SaveSnapshotFile(s.path, s.codec); err != nil {
+		c.config.debugf("[snapshot] failed to save to '%s': %v", s.path, err)
+		return
+	}
+
+	c.config.debugf("[snapshot] saved to '%s'", s.path)
+}
+
+func (s *autoSnapshotter) start(c *Cache) {
+	go func() {
+		defer close(s.doneCh)
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.save(c)
+			case <-s.closeCh:
+				s.save(c)
+				return
+			}
+		}
+	}()
+}
+
+// close stops the background process and waits until the final snapshot
+// is saved. It's safe to call it multiple times.
+func (s *autoSnapshotter) close() {
+	s.closeOnce.Do(func() {
+		close(s.closeCh)
+	})
+
+	<-s.doneCh
+}
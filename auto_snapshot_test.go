@@ -0,0 +1,88 @@
+package incache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+
+	source := New()
+	source.Set("key1", "value1")
+
+	require.NoError(t, source.SaveSnapshotFile(path, GobCodec))
+
+	cache := New()
+	require.NoError(t, cache.LoadSnapshotFile(path))
+	assert.Equal(t, "value1", cache.Get("key1"))
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary files must be removed")
+}
+
+func TestSaveSnapshotFileError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "cache.snapshot")
+
+	cache := New()
+	assert.Error(t, cache.SaveSnapshotFile(path, GobCodec))
+	assert.Error(t, cache.LoadSnapshotFile(path))
+}
+
+func TestAutoSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+
+	cache := New(WithAutoSnapshot(path, 5*time.Millisecond))
+	cache.Set("key1", "value1")
+
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, time.Second, 5*time.Millisecond)
+
+	cache.Set("key2", "value2")
+	cache.Close()
+	cache.Close()
+
+	restored := New(WithAutoSnapshot(path, time.Hour))
+	defer restored.Close()
+
+	assert.Equal(t, "value1", restored.Get("key1"))
+	assert.Equal(t, "value2", restored.Get("key2"))
+}
+
+func TestAutoSnapshotWithoutFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+
+	cache := New(WithAutoSnapshot(path, time.Hour))
+	assert.Zero(t, cache.Len())
+
+	cache.Close()
+
+	_, err := os.Stat(path)
+	assert.NoError(t, err)
+}
+
+func TestAutoSnapshotInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+	require.NoError(t, os.WriteFile(path, []byte("garbage"), 0o600))
+
+	var logs []string
+	cache := New(
+		WithAutoSnapshot(path, time.Hour),
+		WithDebug(),
+		WithDebugf(func(format string, v ...any) {
+			logs = append(logs, format)
+		}),
+	)
+	defer cache.Close()
+
+	assert.Zero(t, cache.Len())
+	assert.Contains(t, logs, "[snapshot] failed to restore from '%s': %v")
+}
@@ -0,0 +1,60 @@
+package incache
+
+import (
+	"context"
+	"time"
+)
+
+// Backend is a storage behind the cache, e.g. a database or a remote cache.
+type Backend interface {
+	// Get returns the value of key along with its remaining TTL.
+	// Zero TTL means that the value never expires.
+	// ErrNotFound is returned if the key doesn't exist.
+	Get(ctx context.Context, key string) (value interface{}, ttl time.Duration, err error)
+	// Set stores the value of key for ttl. Zero TTL means that the value
+	// never expires.
+	Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+	// Delete deletes the value of key. Deleting a missing key isn't an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// BatchBackend is implemented by backends that are able to apply several
+// operations at once, which is used by the write-behind mode to flush
+// pending operations.
+type BatchBackend interface {
+	Backend
+	WriteBatch(ctx context.Context, ops []BackendOp) error
+}
+
+// BackendOp is a single write operation applied to a backend.
+type BackendOp struct {
+	Key string
+	// Delete reports whether the key has to be deleted, otherwise Value is
+	// stored for TTL.
+	Delete bool
+	Value  interface{}
+	TTL    time.Duration
+}
+
+// applyBackendOps writes ops to the backend, in a single batch if it's supported.
+func applyBackendOps(ctx context.Context, backend Backend, ops []BackendOp) error {
+	if batch, ok := backend.(BatchBackend); ok {
+		return batch.WriteBatch(ctx, ops)
+	}
+
+	for _, op := range ops {
+		var err error
+
+		if op.Delete {
+			err = backend.Delete(ctx, op.Key)
+		} else {
+			err = backend.Set(ctx, op.Key, op.Value, op.TTL)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package incache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var errBackendDown = errors.New("backend is down")
+
+// memoryBackend is a Backend used in tests.
+type memoryBackend struct {
+	mu      sync.Mutex
+	items   map[string]Item
+	batches [][]BackendOp
+	down    bool
+}
+
+func newMemoryBackend() *memoryBackend {
+	return &memoryBackend{items: make(map[string]Item)}
+}
+
+func (b *memoryBackend) Get(_ context.Context, key string) (interface{}, time.Duration, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.down {
+		return nil, 0, errBackendDown
+	}
+
+	item, ok := b.items[key]
+	if !ok || item.Expired() {
+		return nil, 0, ErrNotFound
+	}
+
+	return item.Value, item.remainingTTL(), nil
+}
+
+func (b *memoryBackend) Set(_ context.Context, key string, value interface{}, ttl time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.down {
+		return errBackendDown
+	}
+
+	b.items[key] = newItem(value, ttl)
+
+	return nil
+}
+
+func (b *memoryBackend) Delete(_ context.Context, key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.down {
+		return errBackendDown
+	}
+
+	delete(b.items, key)
+
+	return nil
+}
+
+func (b *memoryBackend) setDown(down bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.down = down
+}
+
+func (b *memoryBackend) get(key string) (interface{}, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	item, ok := b.items[key]
+	return item.Value, ok
+}
+
+func (b *memoryBackend) len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.items)
+}
+
+// batchMemoryBackend additionally implements BatchBackend.
+type batchMemoryBackend struct {
+	*memoryBackend
+}
+
+func (b batchMemoryBackend) WriteBatch(ctx context.Context, ops []BackendOp) error {
+	b.mu.Lock()
+	b.batches = append(b.batches, ops)
+	b.mu.Unlock()
+
+	for _, op := range ops {
+		if op.Delete {
+			_ = b.Delete(ctx, op.Key)
+		} else {
+			_ = b.Set(ctx, op.Key, op.Value, op.TTL)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package benchmarks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/dgraph-io/ristretto"
+	gocache "github.com/patrickmn/go-cache"
+
+	"github.com/wittyjudge/incache"
+)
+
+// Cache is the subset of cache operations the workloads use.
+type Cache interface {
+	Get(key string) (interface{}, bool)
+	Set(key string, value interface{})
+	Close()
+}
+
+// Adapter creates a cache for a benchmark. size is the number of distinct
+// keys of the workload.
+type Adapter struct {
+	Name string
+	New  func(size int) Cache
+}
+
+// Caches lists the compared caches.
+var Caches = []Adapter{
+	{Name: "incache", New: func(size int) Cache {
+		return &incacheAdapter{incache.New(incache.WithTTL(0))}
+	}},
+	{Name: "incache-read-optimized", New: func(size int) Cache {
+		return &incacheAdapter{incache.New(incache.WithTTL(0), incache.WithReadOptimizedStorage())}
+	}},
+	{Name: "incache-bounded", New: func(size int) Cache {
+		return &incacheAdapter{incache.New(incache.WithTTL(0), incache.WithMaxEntries(size/2))}
+	}},
+	{Name: "sync.Map", New: func(size int) Cache {
+		return &syncMap{}
+	}},
+	{Name: "locked-map", New: func(size int) Cache {
+		return &lockedMap{items: make(map[string]interface{}, size)}
+	}},
+	{Name: "go-cache", New: func(size int) Cache {
+		return &goCache{gocache.New(gocache.NoExpiration, 0)}
+	}},
+	{Name: "ristretto", New: func(size int) Cache {
+		cache, err := ristretto.NewCache(&ristretto.Config{
+			NumCounters: int64(size) * 10,
+			MaxCost:     int64(size),
+			BufferItems: 64,
+			// Every item costs 1, so MaxCost is the number of items.
+			IgnoreInternalCost: true,
+		})
+		if err != nil {
+			panic(err)
+		}
+
+		return &ristrettoCache{cache}
+	}},
+	{Name: "bigcache", New: func(size int) Cache {
+		config := bigcache.DefaultConfig(time.Hour)
+		config.MaxEntriesInWindow = size
+		config.CleanWindow = 0
+
+		cache, err := bigcache.New(context.Background(), config)
+		if err != nil {
+			panic(err)
+		}
+
+		return &bigCache{cache}
+	}},
+}
+
+type incacheAdapter struct {
+	cache *incache.Cache
+}
+
+func (a *incacheAdapter) Get(key string) (interface{}, bool) {
+	value := a.cache.Get(key)
+	return value, value != nil
+}
+
+func (a *incacheAdapter) Set(key string, value interface{}) {
+	a.cache.Set(key, value)
+}
+
+func (a *incacheAdapter) Close() {
+	a.cache.Close()
+}
+
+type syncMap struct {
+	m sync.Map
+}
+
+func (m *syncMap) Get(key string) (interface{}, bool) {
+	return m.m.Load(key)
+}
+
+func (m *syncMap) Set(key string, value interface{}) {
+	m.m.Store(key, value)
+}
+
+func (m *syncMap) Close() {}
+
+// lockedMap is a map guarded by sync.RWMutex, the simplest cache possible.
+type lockedMap struct {
+	mu    sync.RWMutex
+	items map[string]interface{}
+}
+
+func (m *lockedMap) Get(key string) (interface{}, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	value, ok := m.items[key]
+
+	return value, ok
+}
+
+func (m *lockedMap) Set(key string, value interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.items[key] = value
+}
+
+func (m *lockedMap) Close() {}
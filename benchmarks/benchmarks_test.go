@@ -0,0 +1,103 @@
+package benchmarks
+
+import (
+	"sync/atomic"
+	"testing"
+)
+
+func BenchmarkCaches(b *testing.B) {
+	for _, workload := range Workloads {
+		keys := workload.KeySet()
+
+		for _, adapter := range Caches {
+			b.Run(workload.Name+"/"+adapter.Name, func(b *testing.B) {
+				cache := adapter.New(workload.Keys)
+				defer cache.Close()
+
+				// Prefill half of the keys, so reads both hit and miss.
+				for i := 0; i < len(keys); i += 2 {
+					cache.Set(keys[i], i)
+				}
+
+				if w, ok := cache.(interface{ Wait() }); ok {
+					w.Wait()
+				}
+
+				var (
+					seed         int64
+					hits, misses uint64
+				)
+
+				b.ReportAllocs()
+				b.ResetTimer()
+
+				b.RunParallel(func(pb *testing.PB) {
+					next := workload.Generator(atomic.AddInt64(&seed, 1))
+
+					var h, m uint64
+					for pb.Next() {
+						i, read := next()
+						if !read {
+							cache.Set(keys[i], i)
+							continue
+						}
+
+						if _, ok := cache.Get(keys[i]); ok {
+							h++
+						} else {
+							m++
+						}
+					}
+
+					atomic.AddUint64(&hits, h)
+					atomic.AddUint64(&misses, m)
+				})
+
+				if total := hits + misses; total > 0 {
+					b.ReportMetric(float64(hits)/float64(total)*100, "hit%")
+				}
+			})
+		}
+	}
+}
+
+func TestCaches(t *testing.T) {
+	for _, adapter := range Caches {
+		t.Run(adapter.Name, func(t *testing.T) {
+			cache := adapter.New(10)
+			defer cache.Close()
+
+			if _, ok := cache.Get("key"); ok {
+				t.Fatal("empty cache returned a value")
+			}
+
+			cache.Set("key", 1)
+
+			if w, ok := cache.(interface{ Wait() }); ok {
+				w.Wait()
+			}
+
+			if value, ok := cache.Get("key"); !ok || value != 1 {
+				t.Fatalf("got %v, %v, want 1, true", value, ok)
+			}
+		})
+	}
+}
+
+func TestGenerator(t *testing.T) {
+	workload := Workload{Keys: 100, ReadRatio: 0.5, Zipf: 1.1}
+	next := workload.Generator(1)
+
+	counts := make([]int, workload.Keys)
+	for i := 0; i < 10000; i++ {
+		key, _ := next()
+		counts[key]++
+	}
+
+	// The first key is the most popular one with Zipfian distribution.
+	for key, count := range counts[1:] {
+		if count > counts[0] {
+			t.Fatalf("key %d was picked %d times, more than the first one", key+1, count)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+// Package benchmarks compares incache with sync.Map, go-cache, ristretto and
+// bigcache across read-heavy, write-heavy and Zipfian workloads.
+//
+// Run the comparison with:
+//
+//	go test -bench . -benchmem
+//
+// Every benchmark is named "workload/cache" and reports the hit rate besides
+// the usual metrics, so the results can be compared with benchstat.
+//
+// Other caches are added by implementing Cache and listing the adapter in
+// Caches.
+package benchmarks
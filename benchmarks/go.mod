@@ -0,0 +1,17 @@
+module github.com/wittyjudge/incache/benchmarks
+
+go 1.19
+
+require (
+	github.com/allegro/bigcache/v3 v3.1.0
+	github.com/dgraph-io/ristretto v0.2.0
+	github.com/patrickmn/go-cache v2.1.0+incompatible
+	github.com/wittyjudge/incache v0.1.0
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.2.0 // indirect
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	golang.org/x/sys v0.25.0 // indirect
+)
@@ -0,0 +1,73 @@
+package benchmarks
+
+import (
+	"encoding/binary"
+
+	"github.com/allegro/bigcache/v3"
+	"github.com/dgraph-io/ristretto"
+	gocache "github.com/patrickmn/go-cache"
+)
+
+type goCache struct {
+	cache *gocache.Cache
+}
+
+func (c *goCache) Get(key string) (interface{}, bool) {
+	return c.cache.Get(key)
+}
+
+func (c *goCache) Set(key string, value interface{}) {
+	c.cache.Set(key, value, gocache.DefaultExpiration)
+}
+
+func (c *goCache) Close() {}
+
+// ristrettoCache buffers writes, so a value may be missing right after Set.
+// Wait applies the pending writes.
+type ristrettoCache struct {
+	cache *ristretto.Cache
+}
+
+func (c *ristrettoCache) Get(key string) (interface{}, bool) {
+	return c.cache.Get(key)
+}
+
+func (c *ristrettoCache) Set(key string, value interface{}) {
+	c.cache.Set(key, value, 1)
+}
+
+func (c *ristrettoCache) Wait() {
+	c.cache.Wait()
+}
+
+func (c *ristrettoCache) Close() {
+	c.cache.Close()
+}
+
+// bigCache only stores bytes, so the int values used by the workloads are
+// encoded, which is the price of keeping the values out of the heap.
+type bigCache struct {
+	cache *bigcache.BigCache
+}
+
+func (c *bigCache) Get(key string) (interface{}, bool) {
+	data, err := c.cache.Get(key)
+	if err != nil {
+		return nil, false
+	}
+
+	value, _ := binary.Varint(data)
+
+	return int(value), true
+}
+
+func (c *bigCache) Set(key string, value interface{}) {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(buf[:], int64(value.(int)))
+
+	c.cache.Set(key, buf[:n])
+}
+
+func (c *bigCache) Close() {
+	c.cache.Close()
+}
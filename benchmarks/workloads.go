@@ -0,0 +1,52 @@
+package benchmarks
+
+import (
+	"math/rand"
+	"strconv"
+)
+
+// Workload describes the mix of operations and the distribution of keys.
+type Workload struct {
+	Name string
+	// Number of distinct keys.
+	Keys int
+	// Fraction of operations that are reads, the rest are writes.
+	ReadRatio float64
+	// Zipf skews the distribution of keys, so a few keys are accessed most
+	// of the time, as in real traffic. Zero means uniform distribution.
+	Zipf float64
+}
+
+// Workloads lists the compared workloads.
+var Workloads = []Workload{
+	{Name: "read-heavy", Keys: 100_000, ReadRatio: 0.9},
+	{Name: "write-heavy", Keys: 100_000, ReadRatio: 0.1},
+	{Name: "zipfian", Keys: 100_000, ReadRatio: 0.9, Zipf: 1.1},
+}
+
+// KeySet returns the keys of the workload.
+func (w Workload) KeySet() []string {
+	keys := make([]string, w.Keys)
+	for i := range keys {
+		keys[i] = "key:" + strconv.Itoa(i)
+	}
+
+	return keys
+}
+
+// Generator returns a function that picks the index of the next key and
+// whether the operation is a read. It isn't safe for concurrent use, every
+// goroutine needs its own generator.
+func (w Workload) Generator(seed int64) func() (int, bool) {
+	r := rand.New(rand.NewSource(seed))
+
+	next := func() int { return r.Intn(w.Keys) }
+	if w.Zipf > 1 {
+		zipf := rand.NewZipf(r, w.Zipf, 1, uint64(w.Keys-1))
+		next = func() int { return int(zipf.Uint64()) }
+	}
+
+	return func() (int, bool) {
+		return next(), r.Float64() < w.ReadRatio
+	}
+}
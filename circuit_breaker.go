@@ -0,0 +1,192 @@
+package incache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of loading the value when the circuit
+// breaker of the loader is open and there is no stale value to serve,
+// see WithLoaderCircuitBreaker.
+var ErrCircuitOpen = errors.New("incache: loader circuit is open")
+
+// CircuitState is the state of the circuit breaker of the loader.
+type CircuitState int
+
+const (
+	// CircuitClosed means that values are loaded as usual.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen means that loads are short-circuited until the cool-down
+	// period passes.
+	CircuitOpen
+	// CircuitHalfOpen means that the cool-down period has passed and
+	// a single load is let through to probe the origin.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// circuitBreaker stops calling a failing loader after a number of
+// consecutive failures, until the cool-down period passes.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	// Set while the probe load of the half-open state is in flight.
+	probing bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+	}
+}
+
+// allow reports whether a load can be performed. Once the cool-down period
+// passes, a single load is allowed to probe the origin.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+
+		b.state = CircuitHalfOpen
+		b.probing = true
+
+		return true
+	case CircuitHalfOpen:
+		if b.probing {
+			return false
+		}
+
+		b.probing = true
+
+		return true
+	default:
+		return true
+	}
+}
+
+// success closes the circuit.
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = CircuitClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// release ends the probe load without changing the state.
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+// failure records a failed load and reports whether it opened the circuit.
+func (b *circuitBreaker) failure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+
+	if b.state == CircuitOpen || (b.state == CircuitClosed && b.failures < b.threshold) {
+		return false
+	}
+
+	b.state = CircuitOpen
+	b.openedAt = time.Now()
+
+	return true
+}
+
+func (b *circuitBreaker) currentState() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen && time.Since(b.openedAt) >= b.cooldown {
+		return CircuitHalfOpen
+	}
+
+	return b.state
+}
+
+// LoaderCircuitState returns the state of the circuit breaker of the
+// loader. It's always CircuitClosed when the circuit breaker isn't enabled,
+// see WithLoaderCircuitBreaker.
+func (c *Cache) LoaderCircuitState() CircuitState {
+	if c.loader == nil || c.loader.breaker == nil {
+		return CircuitClosed
+	}
+
+	return c.loader.breaker.currentState()
+}
+
+// shortCircuit is called instead of the loader while the circuit is open.
+// It returns the stale value of key if there is one.
+func (c *Cache) shortCircuit(key string) (interface{}, error) {
+	c.metrics.incrementShortCircuits()
+
+	if value := c.staleValue(key); value != nil {
+		return value, nil
+	}
+
+	return nil, ErrCircuitOpen
+}
+
+// recordLoad updates the circuit breaker with the result of a load.
+func (c *Cache) recordLoad(err error) {
+	breaker := c.loader.breaker
+	if breaker == nil {
+		return
+	}
+
+	switch {
+	case err == nil:
+		breaker.success()
+	case errors.Is(err, context.Canceled):
+		// The caller gave up, which says nothing about the origin.
+		breaker.release()
+	case breaker.failure():
+		c.metrics.incrementCircuitOpens()
+		c.config.debugf("[load] the loader circuit is open for %s", breaker.cooldown)
+	}
+}
+
+// staleValue returns the value of key, even if it's expired.
+func (c *Cache) staleValue(key string) interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	item, ok := c.items[key]
+	if !ok {
+		return nil
+	}
+
+	return c.copyValue(c.value(item.Value))
+}
@@ -0,0 +1,143 @@
+package incache
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoaderCircuitBreaker(t *testing.T) {
+	errOrigin := errors.New("origin is down")
+
+	var (
+		calls int32
+		fail  int32 = 1
+	)
+
+	cache := New(
+		WithMetrics(),
+		WithLoaderCircuitBreaker(2, 20*time.Millisecond),
+		WithLoader(func(_ context.Context, key string) (interface{}, time.Duration, error) {
+			atomic.AddInt32(&calls, 1)
+
+			if atomic.LoadInt32(&fail) == 1 {
+				return nil, 0, errOrigin
+			}
+
+			return "value", 0, nil
+		}),
+	)
+
+	for i := 0; i < 2; i++ {
+		_, err := cache.GetContext(context.Background(), "key1")
+		assert.ErrorIs(t, err, errOrigin)
+	}
+
+	assert.Equal(t, CircuitOpen, cache.LoaderCircuitState())
+
+	_, err := cache.GetContext(context.Background(), "key1")
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+	assert.EqualValues(t, 1, cache.Metrics().CircuitOpens())
+	assert.EqualValues(t, 1, cache.Metrics().ShortCircuits())
+
+	time.Sleep(25 * time.Millisecond)
+	assert.Equal(t, CircuitHalfOpen, cache.LoaderCircuitState())
+
+	// The failed probe opens the circuit again.
+	_, err = cache.GetContext(context.Background(), "key1")
+	assert.ErrorIs(t, err, errOrigin)
+	assert.Equal(t, CircuitOpen, cache.LoaderCircuitState())
+	assert.EqualValues(t, 2, cache.Metrics().CircuitOpens())
+
+	time.Sleep(25 * time.Millisecond)
+	atomic.StoreInt32(&fail, 0)
+
+	value, err := cache.GetContext(context.Background(), "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+	assert.Equal(t, CircuitClosed, cache.LoaderCircuitState())
+}
+
+func TestLoaderCircuitBreakerPanickingProbe(t *testing.T) {
+	var calls int32
+
+	cache := New(
+		WithLoaderCircuitBreaker(1, 10*time.Millisecond),
+		WithLoader(func(_ context.Context, key string) (interface{}, time.Duration, error) {
+			switch atomic.AddInt32(&calls, 1) {
+			case 1:
+				return nil, 0, errors.New("origin is down")
+			case 2:
+				panic("loader bug")
+			default:
+				return "value", 0, nil
+			}
+		}),
+	)
+
+	_, err := cache.GetContext(context.Background(), "key1")
+	assert.Error(t, err)
+	assert.Equal(t, CircuitOpen, cache.LoaderCircuitState())
+
+	time.Sleep(15 * time.Millisecond)
+
+	// The panicking probe counts as a failure instead of leaving the
+	// breaker probing forever.
+	_, err = cache.GetContext(context.Background(), "key1")
+	assert.ErrorContains(t, err, "loader panicked: loader bug")
+	assert.Equal(t, CircuitOpen, cache.LoaderCircuitState())
+
+	time.Sleep(15 * time.Millisecond)
+
+	value, err := cache.GetContext(context.Background(), "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+	assert.Equal(t, CircuitClosed, cache.LoaderCircuitState())
+}
+
+func TestLoaderCircuitBreakerServesStale(t *testing.T) {
+	cache := New(
+		WithCleanupInterval(0),
+		WithLoaderCircuitBreaker(1, time.Minute),
+		WithLoader(func(_ context.Context, key string) (interface{}, time.Duration, error) {
+			return nil, 0, errors.New("origin is down")
+		}),
+	)
+
+	cache.SetWithTTL("key1", "stale", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	_, err := cache.GetContext(context.Background(), "key1")
+	assert.Error(t, err)
+
+	value, err := cache.GetContext(context.Background(), "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "stale", value)
+
+	// The stale value isn't stored as a fresh one.
+	assert.True(t, cache.items["key1"].Expired())
+}
+
+func TestLoaderCircuitBreakerIgnoresCanceledLoads(t *testing.T) {
+	cache := New(
+		WithLoaderCircuitBreaker(1, time.Minute),
+		WithLoader(func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+			return nil, 0, context.Canceled
+		}),
+	)
+
+	_, err := cache.GetContext(context.Background(), "key1")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, CircuitClosed, cache.LoaderCircuitState())
+}
+
+func TestCircuitStateString(t *testing.T) {
+	assert.Equal(t, "closed", CircuitClosed.String())
+	assert.Equal(t, "open", CircuitOpen.String())
+	assert.Equal(t, "half-open", CircuitHalfOpen.String())
+}
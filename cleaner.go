@@ -1,44 +1,161 @@
 package incache
 
-import "time"
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
 
 type expiredDeleter interface {
-	DeleteExpired()
+	// cleanup deletes expired items, see Cache.cleanup.
+	cleanup()
+	// hasExpiring reports whether there are items that can expire.
+	hasExpiring() bool
 }
 
 // The structure is supposed to control an automatic cleanup background
-// process that calls DeleteExpired() method every time specified
+// process that calls cleanup() method every time specified
 // in cleanupInterval variable.
+//
+// The process is started lazily by wake, and stops by itself once there
+// are no items that can expire, so idle caches don't pay for tickers.
 type cleaner struct {
-	cleanupInterval time.Duration
+	// Accessed atomically, see setInterval.
+	cleanupInterval int64
+	// target returns the deleter, or nil once it's garbage-collected.
+	// The goroutine doesn't hold a strong reference, see weakRef.
+	target func() expiredDeleter
+	// Set while the cleanup goroutine is running.
+	running int32
+	// Set while the cleanup is paused, ticks are skipped.
+	paused int32
+	// Updated while the process is running, if set, see WithCoarseClock.
+	clock *coarseClock
 
-	closeCh chan struct{}
+	// Signals the running process that the interval was changed.
+	resetCh   chan struct{}
+	closeCh   chan struct{}
+	closeOnce sync.Once
 }
 
-func newCleaner(cleanupInterval time.Duration) *cleaner {
+func newCleaner(cleanupInterval time.Duration, target func() expiredDeleter) *cleaner {
 	return &cleaner{
-		cleanupInterval: cleanupInterval,
+		cleanupInterval: int64(cleanupInterval),
+		target:          target,
 
+		resetCh: make(chan struct{}, 1),
 		closeCh: make(chan struct{}),
 	}
 }
 
-func (c *cleaner) start(ed expiredDeleter) {
-	go func() {
-		ticker := time.NewTicker(c.cleanupInterval)
-		defer ticker.Stop()
+// wake starts the cleanup process, unless it's already running or closed.
+// It's cheap to call it on every insertion of an item with TTL.
+func (c *cleaner) wake() {
+	if atomic.LoadInt32(&c.running) == 1 {
+		return
+	}
+
+	select {
+	case <-c.closeCh:
+		return
+	default:
+	}
+
+	if atomic.CompareAndSwapInt32(&c.running, 0, 1) {
+		go c.run()
+	}
+}
+
+func (c *cleaner) run() {
+	ticker := time.NewTicker(c.interval())
+	defer ticker.Stop()
+
+	var clockC <-chan time.Time
+	if c.clock != nil {
+		clockTicker := time.NewTicker(c.clock.resolution)
+		defer clockTicker.Stop()
+
+		clockC = clockTicker.C
 
-		for {
-			select {
-			case <-ticker.C:
-				ed.DeleteExpired()
-			case <-c.closeCh:
+		c.clock.start()
+		defer c.clock.stop()
+	}
+
+	for {
+		select {
+		case <-clockC:
+			c.clock.update()
+		case <-ticker.C:
+			if !c.tick() {
 				return
 			}
+		case <-c.resetCh:
+			ticker.Reset(c.interval())
+		case <-c.closeCh:
+			atomic.StoreInt32(&c.running, 0)
+			return
 		}
-	}()
+	}
+}
+
+// tick runs the cleanup and reports whether the process has to continue.
+func (c *cleaner) tick() bool {
+	ed := c.target()
+	if ed == nil {
+		atomic.StoreInt32(&c.running, 0)
+		return false
+	}
+
+	if atomic.LoadInt32(&c.paused) == 0 {
+		ed.cleanup()
+	}
+
+	return ed.hasExpiring() || !c.stop(ed)
+}
+
+// stop marks the process as stopped and reports whether it has to exit.
+// Items inserted before the mark are seen by the second check, and items
+// inserted after it start a new process by wake.
+func (c *cleaner) stop(ed expiredDeleter) bool {
+	atomic.StoreInt32(&c.running, 0)
+
+	if ed.hasExpiring() && atomic.CompareAndSwapInt32(&c.running, 0, 1) {
+		return false
+	}
+
+	return true
+}
+
+func (c *cleaner) interval() time.Duration {
+	return time.Duration(atomic.LoadInt64(&c.cleanupInterval))
+}
+
+// setInterval changes the interval of the cleanup, it must be positive.
+// The running process picks it up immediately.
+func (c *cleaner) setInterval(interval time.Duration) {
+	atomic.StoreInt64(&c.cleanupInterval, int64(interval))
+
+	select {
+	case c.resetCh <- struct{}{}:
+	default:
+	}
+}
+
+func (c *cleaner) isRunning() bool {
+	return atomic.LoadInt32(&c.running) == 1
+}
+
+func (c *cleaner) pause() {
+	atomic.StoreInt32(&c.paused, 1)
+}
+
+func (c *cleaner) resume() {
+	atomic.StoreInt32(&c.paused, 0)
 }
 
+// close stops the cleanup process. It's safe to call it multiple times.
 func (c *cleaner) close() {
-	c.closeCh <- struct{}{}
+	c.closeOnce.Do(func() {
+		close(c.closeCh)
+	})
 }
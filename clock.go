@@ -0,0 +1,62 @@
+package incache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// coarseClock caches the current time, so checks of expiration on the Get
+// path don't call time.Now, see WithCoarseClock.
+//
+// The time is updated by the cleaner while it's running. Otherwise, e.g.
+// when the cleanup is disabled or there are no items that can expire, the
+// clock is inactive and now falls back to time.Now.
+type coarseClock struct {
+	resolution time.Duration
+	// Holds time.Time with a reading of the monotonic clock.
+	time atomic.Value
+	// Set while the time is updated in the background.
+	active int32
+}
+
+func newCoarseClock(resolution time.Duration) *coarseClock {
+	clock := &coarseClock{resolution: resolution}
+	clock.update()
+
+	return clock
+}
+
+// now returns the cached time, which is behind the current one by at most
+// the resolution of the clock.
+func (c *coarseClock) now() time.Time {
+	if atomic.LoadInt32(&c.active) == 0 {
+		return time.Now()
+	}
+
+	return c.time.Load().(time.Time)
+}
+
+func (c *coarseClock) update() {
+	c.time.Store(time.Now())
+}
+
+// start marks the clock as updated in the background.
+func (c *coarseClock) start() {
+	c.update()
+	atomic.StoreInt32(&c.active, 1)
+}
+
+// stop makes the clock fall back to time.Now.
+func (c *coarseClock) stop() {
+	atomic.StoreInt32(&c.active, 0)
+}
+
+// now returns the time to check expiration of items against on the Get
+// path, see WithCoarseClock.
+func (c *Cache) now() time.Time {
+	if c.clock != nil {
+		return c.clock.now()
+	}
+
+	return time.Now()
+}
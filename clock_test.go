@@ -0,0 +1,55 @@
+package incache
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCoarseClock(t *testing.T) {
+	cache := New(WithCoarseClock(time.Hour), WithCleanupInterval(time.Hour))
+	defer cache.Close()
+
+	cache.SetWithTTL("key", "value", 10*time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&cache.clock.active) == 1
+	}, time.Second, time.Millisecond)
+
+	time.Sleep(20 * time.Millisecond)
+
+	// The cached time isn't updated for an hour.
+	assert.Equal(t, "value", cache.Get("key"))
+
+	cache.Close()
+
+	require.Eventually(t, func() bool {
+		return atomic.LoadInt32(&cache.clock.active) == 0
+	}, time.Second, time.Millisecond)
+
+	assert.Nil(t, cache.Get("key"))
+}
+
+func TestCoarseClockWithoutCleanup(t *testing.T) {
+	cache := New(WithCoarseClock(time.Hour), WithCleanupInterval(0))
+	defer cache.Close()
+
+	cache.SetWithTTL("key", "value", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	assert.Nil(t, cache.Get("key"))
+}
+
+func TestCoarseClockUpdates(t *testing.T) {
+	cache := New(WithCoarseClock(time.Millisecond), WithCleanupInterval(time.Hour))
+	defer cache.Close()
+
+	cache.SetWithTTL("key", "value", 10*time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		return cache.Get("key") == nil
+	}, time.Second, time.Millisecond)
+}
@@ -0,0 +1,118 @@
+package incache
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// keyedItem is an item along with its key.
+type keyedItem struct {
+	key  string
+	item Item
+}
+
+// Clone returns an independent copy of the cache with the same options and
+// items. Items keep their TTL, expiration time and priority, and are stored
+// in the same order, so they're evicted in the same order too. Values are
+// copied with DeepCopy, or with the function set by WithValueCopier.
+//
+// Snapshots, the append-only log, write-behind and trace recording aren't
+// enabled for the copy, so it doesn't write to the same file, backend or
+// trace. Event handlers registered with
+// OnInsertion and similar methods aren't copied either, hooks are.
+func (c *Cache) Clone() *Cache {
+	c.mu.RLock()
+
+	config := c.config
+	config.ttl = c.defaultTTL()
+	config.cleanupInterval = time.Duration(atomic.LoadInt64(&c.cleanupInterval))
+	config.snapshotPath = ""
+	config.appendLogPath = ""
+	config.writeBehindBackend = nil
+	config.traceWriter = nil
+
+	items := c.liveItemsLocked()
+
+	c.mu.RUnlock()
+
+	clone := newCache(config)
+
+	clone.mu.Lock()
+	defer clone.unlock()
+
+	for _, ki := range items {
+		if config.valueCopier == nil {
+			// Otherwise, the value is copied by setItemLocked.
+			ki.item.Value = DeepCopy(ki.item.Value)
+		}
+
+		clone.setItemLocked(ki.key, ki.item)
+	}
+
+	return clone
+}
+
+// Merge stores the items of other in the cache, keeping their TTL,
+// expiration time and priority. Expired items are skipped.
+//
+// When a key is present in both caches, the entry returned by conflict is
+// stored, which allows to keep either of the values or to combine them.
+// conflict is called while the lock is held, so it must not use the cache.
+// Nil conflict means that the items of other overwrite the existing ones.
+//
+// Values aren't copied, use Clone beforehand to merge independent copies.
+func (c *Cache) Merge(other *Cache, conflict func(current, incoming Entry) Entry) {
+	if other == c {
+		return
+	}
+
+	other.mu.RLock()
+	items := other.liveItemsLocked()
+	other.mu.RUnlock()
+
+	c.mu.Lock()
+	defer c.unlock()
+
+	for _, ki := range items {
+		if conflict != nil {
+			if _, ok := c.liveItemLocked(ki.key); ok {
+				resolved := conflict(c.entryLocked(ki.key), Entry{
+					Key:       ki.key,
+					Value:     ki.item.Value,
+					TTL:       ki.item.TTL,
+					ExpiresAt: ki.item.ExpiresAt,
+				})
+
+				ki.item.Value = resolved.Value
+				ki.item.TTL = resolved.TTL
+				ki.item.ExpiresAt = monotonic(resolved.ExpiresAt)
+			}
+		}
+
+		c.setItemLocked(ki.key, ki.item)
+	}
+}
+
+// liveItemsLocked returns the items that haven't expired with decompressed
+// values, in the order they were stored.
+// It must be called with at least the read lock held.
+func (c *Cache) liveItemsLocked() []keyedItem {
+	now := time.Now()
+
+	items := make([]keyedItem, 0, len(c.items))
+	for key, item := range c.items {
+		if item.expiredAt(now) {
+			continue
+		}
+
+		item.Value = c.value(item.Value)
+		items = append(items, keyedItem{key: key, item: item})
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].item.version < items[j].item.version
+	})
+
+	return items
+}
@@ -0,0 +1,101 @@
+package incache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClone(t *testing.T) {
+	cache := New(WithMaxEntries(4), WithCleanupInterval(0))
+
+	cache.SetWithTTL("key1", []string{"a"}, time.Minute)
+	cache.Set("key2", "value2")
+	cache.SetWithPriority("key3", "value3", 1)
+	cache.SetWithTTL("expired", "value", time.Millisecond)
+
+	time.Sleep(5 * time.Millisecond)
+
+	clone := cache.Clone()
+	defer clone.Close()
+
+	assert.Equal(t, []string{"a"}, clone.Get("key1"))
+	assert.Equal(t, "value2", clone.Get("key2"))
+	assert.False(t, clone.Has("expired"))
+	assert.Equal(t, cache.items["key1"].ExpiresAt, clone.items["key1"].ExpiresAt)
+	assert.Equal(t, time.Minute, clone.items["key1"].TTL)
+
+	// Values are independent.
+	clone.Get("key1").([]string)[0] = "b"
+	assert.Equal(t, []string{"a"}, cache.Get("key1"))
+
+	clone.Set("key4", "value4")
+	assert.False(t, cache.Has("key4"))
+
+	clone.Set("key5", "value5")
+
+	// The eviction order is preserved: key1 is the oldest item with the
+	// lowest priority.
+	assert.False(t, clone.Has("key1"))
+	assert.True(t, clone.Has("key3"))
+}
+
+func TestCloneDisablesPersistence(t *testing.T) {
+	backend := newMemoryBackend()
+	cache := New(WithWriteBehind(backend, time.Hour, 10))
+	defer cache.Close()
+
+	clone := cache.Clone()
+	defer clone.Close()
+
+	assert.Nil(t, clone.writeBehind)
+	assert.Nil(t, clone.snapshotter)
+}
+
+func TestMerge(t *testing.T) {
+	cache := New()
+	other := New()
+
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+	other.Set("key2", "other2")
+	other.SetWithTTL("key3", "other3", time.Minute)
+
+	cache.Merge(other, nil)
+
+	assert.Equal(t, "value1", cache.Get("key1"))
+	assert.Equal(t, "other2", cache.Get("key2"))
+	assert.Equal(t, "other3", cache.Get("key3"))
+	assert.Equal(t, other.items["key3"].ExpiresAt, cache.items["key3"].ExpiresAt)
+}
+
+func TestMergeConflict(t *testing.T) {
+	cache := New()
+	other := New()
+
+	cache.Set("key1", 1)
+	other.Set("key1", 2)
+	other.Set("key2", 3)
+
+	var conflicts []string
+	cache.Merge(other, func(current, incoming Entry) Entry {
+		conflicts = append(conflicts, current.Key)
+		incoming.Value = current.Value.(int) + incoming.Value.(int)
+
+		return incoming
+	})
+
+	assert.Equal(t, []string{"key1"}, conflicts)
+	assert.Equal(t, 3, cache.Get("key1"))
+	assert.Equal(t, 3, cache.Get("key2"))
+}
+
+func TestMergeItself(t *testing.T) {
+	cache := New()
+	cache.Set("key1", "value1")
+
+	cache.Merge(cache, nil)
+
+	assert.Equal(t, "value1", cache.Get("key1"))
+}
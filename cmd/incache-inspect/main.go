@@ -0,0 +1,191 @@
+// Command incache-inspect examines snapshot files written by
+// incache.Cache.SaveSnapshot or by automatic snapshots, without loading
+// them into a cache.
+//
+// Usage:
+//
+//	incache-inspect keys  [-prefix p] FILE        lists keys
+//	incache-inspect show  [-prefix p] FILE [KEY]  shows entries with values
+//	incache-inspect stats [-prefix p] FILE        prints summary statistics
+//
+// Only snapshots encoded with the codecs built into incache, e.g. gob, can
+// be read. Values of custom types have to be registered with gob.Register,
+// so such snapshots are better inspected by a program that registers them.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/wittyjudge/incache"
+)
+
+const usage = `usage: incache-inspect <command> [-prefix p] FILE [KEY]
+
+commands:
+  keys   lists keys
+  show   shows entries with values, optionally only the given key
+  stats  prints summary statistics
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, time.Now()); err != nil {
+		fmt.Fprintln(os.Stderr, "incache-inspect:", err)
+		os.Exit(1)
+	}
+}
+
+// run executes the command given by args, writing the output to w.
+// now is used to tell expired entries apart.
+func run(args []string, w io.Writer, now time.Time) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no command\n%s", usage)
+	}
+
+	command := args[0]
+
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	prefix := flags.String("prefix", "", "only include keys with the prefix")
+
+	if err := flags.Parse(args[1:]); err != nil {
+		return fmt.Errorf("%v\n%s", err, usage)
+	}
+
+	if flags.NArg() == 0 {
+		return fmt.Errorf("no snapshot file\n%s", usage)
+	}
+
+	entries, codec, err := readSnapshot(flags.Arg(0), *prefix)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "keys":
+		for _, entry := range entries {
+			fmt.Fprintln(w, entry.Key)
+		}
+
+		return nil
+	case "show":
+		if key := flags.Arg(1); key != "" {
+			entries = filterKey(entries, key)
+			if len(entries) == 0 {
+				return fmt.Errorf("key %q not found", key)
+			}
+		}
+
+		return show(w, entries, now)
+	case "stats":
+		return stats(w, entries, codec, now)
+	default:
+		return fmt.Errorf("unknown command %q\n%s", command, usage)
+	}
+}
+
+// readSnapshot returns the entries of the snapshot file with the prefix,
+// sorted by key, along with the name of the codec.
+func readSnapshot(path, prefix string) ([]incache.SnapshotEntry, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+
+	all, codec, err := incache.ReadSnapshot(f)
+	if err != nil {
+		return nil, codec, err
+	}
+
+	entries := all[:0]
+	for _, entry := range all {
+		if strings.HasPrefix(entry.Key, prefix) {
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+
+	return entries, codec, nil
+}
+
+func filterKey(entries []incache.SnapshotEntry, key string) []incache.SnapshotEntry {
+	for _, entry := range entries {
+		if entry.Key == key {
+			return []incache.SnapshotEntry{entry}
+		}
+	}
+
+	return nil
+}
+
+func show(w io.Writer, entries []incache.SnapshotEntry, now time.Time) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "KEY\tTYPE\tTTL\tEXPIRES AT\tVALUE")
+
+	for _, entry := range entries {
+		ttl, expiresAt := "-", "-"
+		if !entry.ExpiresAt.IsZero() {
+			ttl = entry.TTL.String()
+			expiresAt = entry.ExpiresAt.Format(time.RFC3339)
+
+			if now.After(entry.ExpiresAt) {
+				expiresAt += " (expired)"
+			}
+		}
+
+		fmt.Fprintf(tw, "%s\t%T\t%s\t%s\t%v\n", entry.Key, entry.Value, ttl, expiresAt, entry.Value)
+	}
+
+	return tw.Flush()
+}
+
+func stats(w io.Writer, entries []incache.SnapshotEntry, codec string, now time.Time) error {
+	var (
+		expired, expiring int
+		types             = make(map[string]int)
+	)
+
+	for _, entry := range entries {
+		types[fmt.Sprintf("%T", entry.Value)]++
+
+		if entry.ExpiresAt.IsZero() {
+			continue
+		}
+
+		expiring++
+
+		if now.After(entry.ExpiresAt) {
+			expired++
+		}
+	}
+
+	fmt.Fprintf(w, "codec:          %s\n", codec)
+	fmt.Fprintf(w, "entries:        %d\n", len(entries))
+	fmt.Fprintf(w, "with TTL:       %d\n", expiring)
+	fmt.Fprintf(w, "expired:        %d\n", expired)
+	fmt.Fprintln(w, "value types:")
+
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-14s%d\n", name+":", types[name])
+	}
+
+	return nil
+}
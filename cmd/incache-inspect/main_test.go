@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wittyjudge/incache"
+)
+
+func writeSnapshot(t *testing.T) string {
+	t.Helper()
+
+	cache := incache.New()
+	cache.Set("users:1", "alice")
+	cache.SetWithTTL("users:2", "bob", time.Hour)
+	cache.Set("config", 42)
+
+	path := filepath.Join(t.TempDir(), "cache.snapshot")
+
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	require.NoError(t, cache.SaveSnapshot(f, incache.GobCodec))
+
+	return path
+}
+
+func TestKeys(t *testing.T) {
+	path := writeSnapshot(t)
+
+	var out bytes.Buffer
+	require.NoError(t, run([]string{"keys", path}, &out, time.Now()))
+	assert.Equal(t, "config\nusers:1\nusers:2\n", out.String())
+
+	out.Reset()
+	require.NoError(t, run([]string{"keys", "-prefix", "users:", path}, &out, time.Now()))
+	assert.Equal(t, "users:1\nusers:2\n", out.String())
+}
+
+func TestShow(t *testing.T) {
+	path := writeSnapshot(t)
+
+	var out bytes.Buffer
+	require.NoError(t, run([]string{"show", path, "users:2"}, &out, time.Now()))
+	assert.Regexp(t, `(?m)^users:2\s+string\s+1h0m0s\s+\S+\s+bob$`, out.String())
+	assert.NotContains(t, out.String(), "alice")
+
+	out.Reset()
+	require.NoError(t, run([]string{"show", path, "users:2"}, &out, time.Now().Add(2*time.Hour)))
+	assert.Contains(t, out.String(), "(expired)")
+
+	assert.Error(t, run([]string{"show", path, "missing"}, &out, time.Now()))
+}
+
+func TestStats(t *testing.T) {
+	path := writeSnapshot(t)
+
+	var out bytes.Buffer
+	require.NoError(t, run([]string{"stats", path}, &out, time.Now().Add(2*time.Hour)))
+
+	assert.Contains(t, out.String(), "codec:          gob\n")
+	assert.Contains(t, out.String(), "entries:        3\n")
+	assert.Contains(t, out.String(), "expired:        3\n")
+	assert.Contains(t, out.String(), "  int:          1\n")
+	assert.Contains(t, out.String(), "  string:       2\n")
+}
+
+func TestRunErrors(t *testing.T) {
+	var out bytes.Buffer
+
+	assert.Error(t, run(nil, &out, time.Now()))
+	assert.Error(t, run([]string{"keys"}, &out, time.Now()))
+	assert.Error(t, run([]string{"unknown", writeSnapshot(t)}, &out, time.Now()))
+	assert.Error(t, run([]string{"keys", filepath.Join(t.TempDir(), "missing")}, &out, time.Now()))
+}
@@ -0,0 +1,205 @@
+package incache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Codec encodes values to bytes and back. It's used to persist values in
+// snapshots and to compress values of any type (see WithValueCodec), and by
+// the peers package to send values over the network.
+type Codec interface {
+	Encode(value interface{}) ([]byte, error)
+	Decode(data []byte) (interface{}, error)
+}
+
+// ErrUnknownValueType is returned by RegisteredCodec for data encoded with
+// a codec of a type that isn't registered.
+var ErrUnknownValueType = errors.New("incache: unknown value type")
+
+type codecFuncs struct {
+	encode func(value interface{}) ([]byte, error)
+	decode func(data []byte) (interface{}, error)
+}
+
+func (c codecFuncs) Encode(value interface{}) ([]byte, error) {
+	return c.encode(value)
+}
+
+func (c codecFuncs) Decode(data []byte) (interface{}, error) {
+	return c.decode(data)
+}
+
+// NewCodec returns a codec that uses the given functions, e.g. to encode
+// values with a third-party library:
+//
+//	codec := incache.NewCodec(
+//		func(v interface{}) ([]byte, error) { return msgpack.Marshal(v) },
+//		func(data []byte) (interface{}, error) {
+//			var user User
+//			err := msgpack.Unmarshal(data, &user)
+//			return user, err
+//		},
+//	)
+func NewCodec(encode func(value interface{}) ([]byte, error), decode func(data []byte) (interface{}, error)) Codec {
+	return codecFuncs{encode: encode, decode: decode}
+}
+
+// GobValueCodec encodes values of any type with encoding/gob. Concrete types
+// of values, except the basic ones, have to be registered with gob.Register.
+var GobValueCodec Codec = gobValueCodec{}
+
+type gobValueCodec struct{}
+
+func (gobValueCodec) Encode(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&value); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (gobValueCodec) Decode(data []byte) (interface{}, error) {
+	var value interface{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// JSONValueCodec encodes values with encoding/json. Values are decoded as
+// generic JSON values: map[string]interface{}, []interface{}, string,
+// float64, bool or nil. Use NewJSONCodec to decode values of a concrete type.
+var JSONValueCodec Codec = NewJSONCodec(nil)
+
+type jsonCodec struct {
+	typ reflect.Type
+}
+
+// NewJSONCodec returns a codec that encodes values with encoding/json and
+// decodes them into values of the same type as prototype. With a nil
+// prototype, it works like JSONValueCodec.
+func NewJSONCodec(prototype interface{}) Codec {
+	return jsonCodec{typ: reflect.TypeOf(prototype)}
+}
+
+func (c jsonCodec) Encode(value interface{}) ([]byte, error) {
+	return json.Marshal(value)
+}
+
+func (c jsonCodec) Decode(data []byte) (interface{}, error) {
+	if c.typ == nil {
+		var value interface{}
+		err := json.Unmarshal(data, &value)
+
+		return value, err
+	}
+
+	ptr := reflect.New(c.typ)
+	if err := json.Unmarshal(data, ptr.Interface()); err != nil {
+		return nil, err
+	}
+
+	return ptr.Elem().Interface(), nil
+}
+
+var (
+	typeCodecsMu sync.RWMutex
+	typeCodecs   = map[string]typeCodec{}
+)
+
+type typeCodec struct {
+	typ   reflect.Type
+	codec Codec
+}
+
+// RegisterCodec makes RegisteredCodec encode values of the same type as
+// prototype with codec. If a codec for the type is already registered,
+// it's replaced.
+func RegisterCodec(prototype interface{}, codec Codec) {
+	typ := reflect.TypeOf(prototype)
+
+	typeCodecsMu.Lock()
+	defer typeCodecsMu.Unlock()
+
+	typeCodecs[typ.String()] = typeCodec{typ: typ, codec: codec}
+}
+
+func codecByType(name string) (typeCodec, bool) {
+	typeCodecsMu.RLock()
+	defer typeCodecsMu.RUnlock()
+
+	codec, ok := typeCodecs[name]
+	return codec, ok
+}
+
+// RegisteredCodec encodes every value with the codec registered for its type
+// with RegisterCodec, and values of other types with GobValueCodec. The name
+// of the type is stored along with the value, so the same codec is picked to
+// decode it.
+var RegisteredCodec Codec = registeredCodec{}
+
+type registeredCodec struct{}
+
+func (registeredCodec) Encode(value interface{}) ([]byte, error) {
+	var name string
+	codec := GobValueCodec
+
+	if value != nil {
+		if tc, ok := codecByType(reflect.TypeOf(value).String()); ok && tc.typ == reflect.TypeOf(value) {
+			name, codec = tc.typ.String(), tc.codec
+		}
+	}
+
+	data, err := codec.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(name)+len(data))
+	buf = append(buf[:binary.PutUvarint(buf, uint64(len(name)))], name...)
+
+	return append(buf, data...), nil
+}
+
+func (registeredCodec) Decode(data []byte) (interface{}, error) {
+	n, size := binary.Uvarint(data)
+	if size <= 0 || uint64(len(data)-size) < n {
+		return nil, errors.New("incache: invalid encoded value")
+	}
+
+	name := string(data[size : size+int(n)])
+	data = data[size+int(n):]
+
+	if name == "" {
+		return GobValueCodec.Decode(data)
+	}
+
+	tc, ok := codecByType(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownValueType, name)
+	}
+
+	return tc.codec.Decode(data)
+}
+
+// WithValueCodec sets the codec that encodes values of the cache:
+//
+//   - snapshots store values encoded with the codec as []byte, so the
+//     snapshot codec doesn't need to know their types. Snapshots have to be
+//     loaded by a cache with the same codec;
+//   - with WithCompression, values of any type are encoded with the codec
+//     and compressed, not only []byte values.
+func WithValueCodec(codec Codec) configFunc {
+	return func(config *Config) {
+		config.valueCodec = codec
+	}
+}
@@ -0,0 +1,154 @@
+package incache
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type codecUser struct {
+	Name string
+	Age  int
+}
+
+func TestGobValueCodec(t *testing.T) {
+	data, err := GobValueCodec.Encode("value1")
+	assert.NoError(t, err)
+
+	value, err := GobValueCodec.Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "value1", value)
+}
+
+func TestJSONCodec(t *testing.T) {
+	data, err := JSONValueCodec.Encode(codecUser{Name: "alice", Age: 30})
+	assert.NoError(t, err)
+
+	value, err := JSONValueCodec.Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"Name": "alice", "Age": float64(30)}, value)
+
+	value, err = NewJSONCodec(codecUser{}).Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, codecUser{Name: "alice", Age: 30}, value)
+
+	value, err = NewJSONCodec(&codecUser{}).Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, &codecUser{Name: "alice", Age: 30}, value)
+
+	_, err = NewJSONCodec(codecUser{}).Decode([]byte("{"))
+	assert.Error(t, err)
+}
+
+func TestNewCodec(t *testing.T) {
+	codec := NewCodec(
+		func(value interface{}) ([]byte, error) { return []byte(strings.ToUpper(value.(string))), nil },
+		func(data []byte) (interface{}, error) { return strings.ToLower(string(data)), nil },
+	)
+
+	data, err := codec.Encode("value1")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("VALUE1"), data)
+
+	value, err := codec.Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "value1", value)
+}
+
+type upperCodec struct{}
+
+func (upperCodec) Encode(value interface{}) ([]byte, error) {
+	return []byte(strings.ToUpper(string(value.(codecName)))), nil
+}
+
+func (upperCodec) Decode(data []byte) (interface{}, error) {
+	return codecName(strings.ToLower(string(data))), nil
+}
+
+type codecName string
+
+func TestRegisteredCodec(t *testing.T) {
+	RegisterCodec(codecName(""), upperCodec{})
+
+	data, err := RegisteredCodec.Encode(codecName("alice"))
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasSuffix(data, []byte("ALICE")))
+
+	value, err := RegisteredCodec.Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, codecName("alice"), value)
+
+	// Values of other types fall back to gob.
+	data, err = RegisteredCodec.Encode(42)
+	assert.NoError(t, err)
+
+	value, err = RegisteredCodec.Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, 42, value)
+
+	_, err = RegisteredCodec.Decode([]byte{5, 'a'})
+	assert.Error(t, err)
+
+	_, err = RegisteredCodec.Decode(append([]byte{7}, "unknown"...))
+	assert.ErrorIs(t, err, ErrUnknownValueType)
+}
+
+func TestValueCodecCompression(t *testing.T) {
+	cache := New(WithCompression(64, nil), WithValueCodec(NewJSONCodec(codecUser{})))
+
+	user := codecUser{Name: strings.Repeat("alice", 50), Age: 30}
+	cache.Set("user", user)
+	cache.Set("small", codecUser{Name: "bob"})
+
+	assert.IsType(t, encodedValue{}, cache.items["user"].Value)
+	assert.IsType(t, codecUser{}, cache.items["small"].Value)
+	assert.Equal(t, user, cache.Get("user"))
+
+	info, _ := cache.Inspect("user")
+	assert.True(t, info.Compressed)
+	assert.Equal(t, "incache.codecUser", info.ValueType)
+	assert.Less(t, info.Size, uint64(len(user.Name)))
+}
+
+func TestValueCodecCompressionFailure(t *testing.T) {
+	codec := NewCodec(
+		func(value interface{}) ([]byte, error) { return nil, errors.New("encode") },
+		func(data []byte) (interface{}, error) { return nil, errors.New("decode") },
+	)
+	cache := New(WithCompression(1, nil), WithValueCodec(codec))
+
+	cache.Set("key1", 42)
+	assert.Equal(t, 42, cache.items["key1"].Value)
+	assert.Equal(t, 42, cache.Get("key1"))
+}
+
+func TestValueCodecSnapshot(t *testing.T) {
+	codec := NewJSONCodec(codecUser{})
+
+	cache := New(WithValueCodec(codec))
+	cache.SetWithTTL("user", codecUser{Name: "alice", Age: 30}, time.Minute)
+
+	var buf bytes.Buffer
+	assert.NoError(t, cache.SaveSnapshot(&buf, GobCodec))
+
+	// gob doesn't need to know the type, the values are stored as bytes.
+	entries, _, err := ReadSnapshot(bytes.NewReader(buf.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte(`{"Name":"alice","Age":30}`), entries[0].Value)
+
+	restored := New(WithValueCodec(codec))
+	assert.NoError(t, restored.LoadSnapshot(bytes.NewReader(buf.Bytes())))
+	assert.Equal(t, codecUser{Name: "alice", Age: 30}, restored.Get("user"))
+
+	// A snapshot of values that weren't encoded can't be loaded.
+	plain := New()
+	plain.Set("key1", "value1")
+
+	buf.Reset()
+	assert.NoError(t, plain.SaveSnapshot(&buf, GobCodec))
+	assert.Error(t, restored.LoadSnapshot(&buf))
+}
@@ -0,0 +1,16 @@
+//go:build !go1.24
+
+package incache
+
+// weakRef returns a function that returns the cache. Weak pointers aren't
+// available before Go 1.24, so the cache stays reachable until Close.
+func weakRef(c *Cache) func() *Cache {
+	return func() *Cache { return c }
+}
+
+// stopOnCollect does nothing before Go 1.24, Close has to be called
+// to stop background goroutines.
+func stopOnCollect(c *Cache) {}
+
+// stopCleanerOnCollect does nothing before Go 1.24, see stopOnCollect.
+func stopCleanerOnCollect(c *Cache, cl *cleaner) {}
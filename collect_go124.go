@@ -0,0 +1,63 @@
+//go:build go1.24
+
+package incache
+
+import (
+	"runtime"
+	"weak"
+)
+
+// weakRef returns a function that returns the cache while it's reachable,
+// and nil once it's garbage-collected, so background goroutines don't keep
+// the cache alive.
+func weakRef(c *Cache) func() *Cache {
+	return weak.Make(c).Value
+}
+
+// backgroundWorkers are stopped when the cache is garbage-collected.
+// They must not reference the cache.
+type backgroundWorkers struct {
+	cleaner       *cleaner
+	memoryWatcher *memoryWatcher
+	reporters     []*metricsReporter
+	events        *eventPool
+}
+
+// stopOnCollect makes sure that a cache which is garbage-collected without
+// Close doesn't leak the cleaner and event workers goroutines.
+//
+// Automatic snapshots, the append-only log and write-behind mode keep the
+// cache reachable until Close is called, since they have to persist its
+// contents.
+func stopOnCollect(c *Cache) {
+	workers := backgroundWorkers{cleaner: c.cleaner, memoryWatcher: c.memoryWatcher, reporters: c.reporters, events: c.eventHandlers.pool}
+	if workers.cleaner == nil && workers.memoryWatcher == nil && len(workers.reporters) == 0 && workers.events == nil {
+		return
+	}
+
+	runtime.AddCleanup(c, func(workers backgroundWorkers) {
+		if workers.cleaner != nil {
+			workers.cleaner.close()
+		}
+
+		if workers.memoryWatcher != nil {
+			workers.memoryWatcher.close()
+		}
+
+		for _, reporter := range workers.reporters {
+			reporter.close()
+		}
+
+		if workers.events != nil {
+			workers.events.close()
+		}
+	}, workers)
+}
+
+// stopCleanerOnCollect stops a cleaner started after New, see
+// Cache.SetCleanupInterval, when the cache is garbage-collected.
+func stopCleanerOnCollect(c *Cache, cl *cleaner) {
+	runtime.AddCleanup(c, func(cl *cleaner) {
+		cl.close()
+	}, cl)
+}
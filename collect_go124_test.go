@@ -0,0 +1,68 @@
+//go:build go1.24
+
+package incache
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectedCacheStopsBackgroundWorkers(t *testing.T) {
+	var (
+		cleaner *cleaner
+		events  *eventPool
+	)
+
+	func() {
+		cache := New(WithCleanupInterval(time.Millisecond), WithEventWorkers(1, 1, EventOverflowDrop))
+		cache.SetWithTTL("key1", "value1", time.Hour)
+
+		cleaner = cache.cleaner
+		events = cache.eventHandlers.pool
+	}()
+
+	assert.Eventually(t, func() bool {
+		runtime.GC()
+
+		select {
+		case <-cleaner.closeCh:
+		default:
+			return false
+		}
+
+		events.mu.RLock()
+		defer events.mu.RUnlock()
+
+		return events.closed
+	}, time.Second, time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		return !cleaner.isRunning()
+	}, time.Second, time.Millisecond)
+}
+
+func TestCollectedCacheStopsReconfiguredCleaner(t *testing.T) {
+	var cleaner *cleaner
+
+	func() {
+		cache := New(WithCleanupInterval(0))
+		cache.SetCleanupInterval(time.Millisecond)
+		cache.SetWithTTL("key1", "value1", time.Hour)
+
+		cleaner = cache.cleaner
+	}()
+
+	assert.Eventually(t, func() bool {
+		runtime.GC()
+
+		select {
+		case <-cleaner.closeCh:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, time.Millisecond)
+}
@@ -0,0 +1,55 @@
+package incache
+
+import "time"
+
+const (
+	// Maps are only rebuilt after they held at least that many items,
+	// rebuilding small maps isn't worth it.
+	compactMinPeak = 1024
+	// Maps are rebuilt when the number of items falls below
+	// 1/compactRatio of their peak.
+	compactRatio = 4
+)
+
+// compactLocked rebuilds the maps when the number of items falls well
+// below their peak, e.g. after DeleteExpired removed most of them.
+// Go maps never shrink, so the memory of their buckets is only released
+// this way. The cost of the rebuild is amortized by the deletions that
+// preceded it. It must be called with the write lock held.
+func (c *Cache) compactLocked() {
+	if c.peakLen < compactMinPeak || len(c.items) >= c.peakLen/compactRatio {
+		return
+	}
+
+	c.config.debugf("[compact] items: %d, peak: %d", len(c.items), c.peakLen)
+
+	items := make(map[string]Item, len(c.items))
+	for key, item := range c.items {
+		items[key] = item
+	}
+
+	expirations := make(map[string]time.Time, len(c.expirationsQueue))
+	for key, expiresAt := range c.expirationsQueue {
+		expirations[key] = expiresAt
+	}
+
+	c.items = items
+	c.expirationsQueue = expirations
+	c.peakLen = len(items)
+
+	if c.evictionQueue != nil {
+		c.evictionQueue.compact()
+	}
+
+	for _, limit := range c.namespaceLimits {
+		limit.queue.compact()
+	}
+}
+
+// trackPeakLocked records the peak number of items, see compactLocked.
+// It must be called with the write lock held.
+func (c *Cache) trackPeakLocked() {
+	if len(c.items) > c.peakLen {
+		c.peakLen = len(c.items)
+	}
+}
@@ -0,0 +1,60 @@
+package incache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompactAfterMassDeletion(t *testing.T) {
+	cache := New(WithTTL(0), WithCleanupInterval(0), WithMaxEntries(10000))
+
+	for i := 0; i < 2000; i++ {
+		cache.SetWithTTL(fmt.Sprint("key", i), i, time.Millisecond)
+	}
+	cache.Set("persistent", "value")
+
+	assert.Equal(t, 2001, cache.peakLen)
+
+	time.Sleep(2 * time.Millisecond)
+	assert.Equal(t, 2000, cache.DeleteExpired())
+
+	assert.Equal(t, 1, cache.peakLen)
+	assert.Equal(t, "value", cache.Get("persistent"))
+	assert.Len(t, cache.evictionQueue.index, 1)
+	assert.Empty(t, cache.expirationsQueue)
+}
+
+func TestCompactIgnoresSmallMaps(t *testing.T) {
+	cache := New(WithTTL(0), WithCleanupInterval(0))
+
+	for i := 0; i < 100; i++ {
+		cache.Set(fmt.Sprint("key", i), i)
+	}
+
+	for i := 0; i < 99; i++ {
+		cache.Delete(fmt.Sprint("key", i))
+	}
+
+	assert.Equal(t, 100, cache.peakLen)
+}
+
+func TestCompactKeepsIndexesConsistent(t *testing.T) {
+	cache := New(WithTTL(0), WithCleanupInterval(0), WithMaxEntries(2000))
+
+	for i := 0; i < 2000; i++ {
+		cache.Set(fmt.Sprint("key", i), i)
+	}
+
+	// Evicts all but key1999 and key1998.
+	cache.EvictN(1998)
+	assert.Equal(t, 2, cache.peakLen)
+
+	cache.Set("key2000", 2000)
+	assert.Equal(t, 3, cache.Len())
+
+	cache.Resize(2)
+	assert.ElementsMatch(t, []string{"key1999", "key2000"}, cache.Keys())
+}
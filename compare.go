@@ -0,0 +1,85 @@
+package incache
+
+import (
+	"reflect"
+	"time"
+)
+
+// CompareAndSwap sets the key to hold the new value with the default TTL,
+// if it currently holds a value equal to old, and reports whether the swap
+// happened. Values are compared with ==, unless a comparator is configured
+// with WithComparator.
+func (c *Cache) CompareAndSwap(key string, old, new interface{}) bool {
+	return c.compareAndSwap(key, old, new, c.defaultTTL())
+}
+
+// CompareAndSwapWithTTL works similar to CompareAndSwap method, but with
+// an opportunity to adjust a ttl for the new value manually.
+func (c *Cache) CompareAndSwapWithTTL(key string, old, new interface{}, ttl time.Duration) bool {
+	return c.compareAndSwap(key, old, new, ttl)
+}
+
+// CompareAndDelete deletes the value of key, if it's equal to old, and
+// reports whether the deletion happened.
+func (c *Cache) CompareAndDelete(key string, old interface{}) bool {
+	c.mu.Lock()
+	defer c.unlock()
+
+	if !c.compareLocked(key, old) {
+		return false
+	}
+
+	c.evictLocked(key, reasonDeleted)
+
+	return true
+}
+
+func (c *Cache) compareAndSwap(key string, old, new interface{}, ttl time.Duration) bool {
+	c.hooks.beforeSet(key, new, ttl)
+
+	if !c.compareAndStore(key, old, new, ttl) {
+		return false
+	}
+
+	c.hooks.afterSet(key, new, ttl)
+
+	return true
+}
+
+func (c *Cache) compareAndStore(key string, old, new interface{}, ttl time.Duration) bool {
+	c.mu.Lock()
+	defer c.unlock()
+
+	if !c.compareLocked(key, old) {
+		return false
+	}
+
+	c.setLocked(key, new, ttl)
+
+	return true
+}
+
+// compareLocked reports whether the key holds a value equal to old.
+// It must be called with at least the read lock held.
+func (c *Cache) compareLocked(key string, old interface{}) bool {
+	item, ok := c.items[key]
+	if !ok || item.Expired() {
+		return false
+	}
+
+	return c.config.comparator(c.value(item.Value), old)
+}
+
+// defaultComparator compares values with ==. Values of uncomparable types,
+// e.g. slices, are never equal instead of causing a panic.
+func defaultComparator(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	if !reflect.TypeOf(a).Comparable() || !reflect.TypeOf(b).Comparable() {
+		return false
+	}
+
+	return a == b
+}
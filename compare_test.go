@@ -0,0 +1,75 @@
+package incache
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareAndSwap(t *testing.T) {
+	cache := New(WithTTL(0))
+
+	assert.False(t, cache.CompareAndSwap("key1", nil, "value1"))
+	assert.False(t, cache.Has("key1"))
+
+	cache.Set("key1", "value1")
+
+	assert.False(t, cache.CompareAndSwap("key1", "value2", "value3"))
+	assert.Equal(t, "value1", cache.Get("key1"))
+
+	assert.True(t, cache.CompareAndSwap("key1", "value1", "value2"))
+	assert.Equal(t, "value2", cache.Get("key1"))
+
+	assert.True(t, cache.CompareAndSwapWithTTL("key1", "value2", "value3", time.Minute))
+
+	info, _ := cache.Inspect("key1")
+	assert.Equal(t, time.Minute, info.TTL)
+}
+
+func TestCompareAndSwapExpired(t *testing.T) {
+	cache := New(WithTTL(time.Millisecond), WithCleanupInterval(0))
+
+	cache.Set("key1", "value1")
+	time.Sleep(2 * time.Millisecond)
+
+	assert.False(t, cache.CompareAndSwap("key1", "value1", "value2"))
+}
+
+func TestCompareAndDelete(t *testing.T) {
+	cache := New(WithTTL(0))
+
+	cache.Set("key1", "value1")
+
+	assert.False(t, cache.CompareAndDelete("key1", "value2"))
+	assert.True(t, cache.Has("key1"))
+
+	assert.True(t, cache.CompareAndDelete("key1", "value1"))
+	assert.False(t, cache.Has("key1"))
+
+	assert.False(t, cache.CompareAndDelete("key1", "value1"))
+}
+
+func TestCompareUncomparableValues(t *testing.T) {
+	cache := New(WithTTL(0))
+
+	cache.Set("key1", []byte("value1"))
+
+	assert.False(t, cache.CompareAndSwap("key1", []byte("value1"), []byte("value2")))
+	assert.False(t, cache.CompareAndDelete("key1", []byte("value1")))
+}
+
+func TestWithComparator(t *testing.T) {
+	cache := New(WithTTL(0), WithComparator(func(a, b interface{}) bool {
+		x, _ := a.([]byte)
+		y, _ := b.([]byte)
+
+		return bytes.Equal(x, y)
+	}))
+
+	cache.Set("key1", []byte("value1"))
+
+	assert.True(t, cache.CompareAndSwap("key1", []byte("value1"), []byte("value2")))
+	assert.True(t, cache.CompareAndDelete("key1", []byte("value2")))
+}
@@ -0,0 +1,154 @@
+package incache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// Compressor compresses values of type []byte, see WithCompression.
+type Compressor interface {
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+// GzipCompressor compresses values with gzip using the best speed level.
+var GzipCompressor Compressor = gzipCompressor{}
+
+type gzipCompressor struct{}
+
+func (gzipCompressor) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w, err := gzip.NewWriterLevel(&buf, gzip.BestSpeed)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (gzipCompressor) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+// compressedValue is a []byte value stored in the compressed form.
+type compressedValue []byte
+
+// encodedValue is a value encoded with the codec set by WithValueCodec
+// and stored in the compressed form.
+type encodedValue struct {
+	data []byte
+	// Go type of the original value.
+	valueType string
+}
+
+// compress compresses the value if it's a byte slice larger than the
+// threshold configured with WithCompression. Values of other types are
+// compressed if a codec is set with WithValueCodec. The value is stored
+// as is when encoding or compression fails or doesn't make it smaller.
+func (c *Cache) compress(value interface{}) interface{} {
+	if c.config.compressionThreshold <= 0 {
+		return value
+	}
+
+	data, ok := value.([]byte)
+	if !ok {
+		return c.encode(value)
+	}
+
+	if len(data) < c.config.compressionThreshold {
+		return value
+	}
+
+	compressed, ok := c.compressBytes(data)
+	if !ok {
+		return value
+	}
+
+	return compressedValue(compressed)
+}
+
+// encode encodes the value with the codec set by WithValueCodec and
+// compresses it.
+func (c *Cache) encode(value interface{}) interface{} {
+	if c.config.valueCodec == nil {
+		return value
+	}
+
+	data, err := c.config.valueCodec.Encode(value)
+	if err != nil {
+		c.config.debugf("[compress] failed to encode the value: %v", err)
+		return value
+	}
+
+	if len(data) < c.config.compressionThreshold {
+		return value
+	}
+
+	compressed, ok := c.compressBytes(data)
+	if !ok {
+		return value
+	}
+
+	return encodedValue{data: compressed, valueType: fmt.Sprintf("%T", value)}
+}
+
+// compressBytes compresses the data and reports whether it became smaller.
+func (c *Cache) compressBytes(data []byte) ([]byte, bool) {
+	compressed, err := c.config.compressor.Compress(data)
+	if err != nil {
+		c.config.debugf("[compress] failed to compress the value: %v", err)
+		return nil, false
+	}
+
+	return compressed, len(compressed) < len(data)
+}
+
+// value returns the stored value in its original form.
+// Values that can't be decompressed are reported as missing.
+func (c *Cache) value(stored interface{}) interface{} {
+	switch v := stored.(type) {
+	case compressedValue:
+		data, err := c.config.compressor.Decompress(v)
+		if err != nil {
+			c.config.debugf("[compress] failed to decompress the value: %v", err)
+			return nil
+		}
+
+		return data
+	case encodedValue:
+		data, err := c.config.compressor.Decompress(v.data)
+		if err != nil {
+			c.config.debugf("[compress] failed to decompress the value: %v", err)
+			return nil
+		}
+
+		value, err := c.config.valueCodec.Decode(data)
+		if err != nil {
+			c.config.debugf("[compress] failed to decode the value: %v", err)
+			return nil
+		}
+
+		return value
+	case weakValue:
+		return v.value()
+	default:
+		return stored
+	}
+}
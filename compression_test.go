@@ -0,0 +1,87 @@
+package incache
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompression(t *testing.T) {
+	cache := New(WithCompression(64, nil))
+
+	large := bytes.Repeat([]byte("value"), 100)
+	small := []byte("value")
+
+	cache.Set("large", large)
+	cache.Set("small", small)
+	cache.Set("string", "value")
+
+	assert.IsType(t, compressedValue{}, cache.items["large"].Value)
+	assert.Less(t, estimateSize(cache.items["large"].Value), uint64(len(large)))
+	assert.Equal(t, small, cache.items["small"].Value)
+	assert.Equal(t, "value", cache.items["string"].Value)
+
+	assert.Equal(t, large, cache.Get("large"))
+	assert.Equal(t, small, cache.Get("small"))
+	assert.Equal(t, "value", cache.Get("string"))
+
+	info, _ := cache.Inspect("large")
+	assert.True(t, info.Compressed)
+	assert.Equal(t, "[]uint8", info.ValueType)
+}
+
+func TestCompressionKeepsIncompressibleValues(t *testing.T) {
+	cache := New(WithCompression(1, nil))
+
+	value := []byte{0x1f}
+	cache.Set("key1", value)
+
+	assert.Equal(t, value, cache.items["key1"].Value)
+}
+
+func TestCompressionEvents(t *testing.T) {
+	cache := New(WithCompression(1, nil), WithCleanupInterval(0))
+
+	first := bytes.Repeat([]byte("a"), 100)
+	second := bytes.Repeat([]byte("b"), 100)
+
+	events := cache.Events()
+
+	cache.Set("key1", first)
+	cache.Set("key1", second)
+	cache.Delete("key1")
+	cache.Close()
+
+	var received []Event
+	for event := range events {
+		received = append(received, event)
+	}
+
+	assert.Len(t, received, 3)
+	assert.Equal(t, first, received[0].Value)
+	assert.Equal(t, first, received[1].OldValue)
+	assert.Equal(t, second, received[1].Value)
+	assert.Equal(t, second, received[2].Value)
+}
+
+type failingCompressor struct{}
+
+func (failingCompressor) Compress(data []byte) ([]byte, error) {
+	return nil, errors.New("compress")
+}
+
+func (failingCompressor) Decompress(data []byte) ([]byte, error) {
+	return nil, errors.New("decompress")
+}
+
+func TestCompressionFailure(t *testing.T) {
+	cache := New(WithCompression(1, failingCompressor{}))
+
+	value := bytes.Repeat([]byte("a"), 100)
+	cache.Set("key1", value)
+
+	assert.Equal(t, value, cache.items["key1"].Value)
+	assert.Equal(t, value, cache.Get("key1"))
+}
@@ -0,0 +1,60 @@
+package incache
+
+import (
+	"context"
+	"time"
+)
+
+// GetOrCompute returns the value of key. If the key doesn't exist, fn is
+// called to compute the value, which is stored with the default TTL and
+// returned. A nil value returned by fn isn't stored.
+//
+// Only one goroutine computes a missing value, others wait for its result.
+// Computations of different keys don't block each other.
+func (c *Cache) GetOrCompute(key string, fn func() interface{}) interface{} {
+	return c.getOrCompute(key, c.defaultTTL(), fn)
+}
+
+// GetOrComputeWithTTL works similar to GetOrCompute method, but with
+// an opportunity to adjust a ttl for the computed value manually.
+func (c *Cache) GetOrComputeWithTTL(key string, ttl time.Duration, fn func() interface{}) interface{} {
+	return c.getOrCompute(key, ttl, fn)
+}
+
+func (c *Cache) getOrCompute(key string, ttl time.Duration, fn func() interface{}) interface{} {
+	c.hooks.beforeGet(key)
+
+	value := c.lookup(key)
+	if value == nil {
+		value, _ = c.computing.do(context.Background(), key, func() (interface{}, error) {
+			// The value could be stored while we were waiting for the call.
+			if value := c.peek(key); value != nil {
+				return value, nil
+			}
+
+			value := fn()
+			if value != nil {
+				c.set(key, value, ttl)
+			}
+
+			return value, nil
+		})
+	}
+
+	c.hooks.afterGet(key, value)
+
+	return value
+}
+
+// peek returns the value of key without affecting metrics.
+func (c *Cache) peek(key string) interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	item, ok := c.items[key]
+	if !ok || item.Expired() {
+		return nil
+	}
+
+	return c.value(item.Value)
+}
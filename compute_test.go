@@ -0,0 +1,97 @@
+package incache
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetOrCompute(t *testing.T) {
+	cache := New(WithTTL(0))
+
+	calls := 0
+	compute := func() interface{} {
+		calls++
+		return "value1"
+	}
+
+	assert.Equal(t, "value1", cache.GetOrCompute("key1", compute))
+	assert.Equal(t, "value1", cache.GetOrCompute("key1", compute))
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, "value1", cache.Get("key1"))
+
+	assert.Nil(t, cache.GetOrCompute("key2", func() interface{} { return nil }))
+	assert.False(t, cache.Has("key2"))
+}
+
+func TestGetOrComputeWithTTL(t *testing.T) {
+	cache := New(WithTTL(0))
+
+	cache.GetOrComputeWithTTL("key1", time.Minute, func() interface{} { return "value1" })
+
+	info, ok := cache.Inspect("key1")
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, info.TTL)
+}
+
+func TestGetOrComputeComputesOnce(t *testing.T) {
+	cache := New(WithTTL(0))
+
+	var (
+		calls   int32
+		wg      sync.WaitGroup
+		release = make(chan struct{})
+	)
+
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			value := cache.GetOrCompute("key1", func() interface{} {
+				atomic.AddInt32(&calls, 1)
+				<-release
+				return "value1"
+			})
+			assert.Equal(t, "value1", value)
+		}()
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+}
+
+func TestGetOrComputeDoesNotBlockOtherKeys(t *testing.T) {
+	cache := New(WithTTL(0))
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	go cache.GetOrCompute("slow", func() interface{} {
+		close(started)
+		<-release
+		return "value"
+	})
+
+	<-started
+
+	done := make(chan interface{})
+	go func() {
+		done <- cache.GetOrCompute("fast", func() interface{} { return "value" })
+	}()
+
+	select {
+	case value := <-done:
+		assert.Equal(t, "value", value)
+	case <-time.After(time.Second):
+		t.Fatal("computation of another key is blocked")
+	}
+}
@@ -1,6 +1,7 @@
 package incache
 
 import (
+	"io"
 	"log"
 	"os"
 	"time"
@@ -8,12 +9,99 @@ import (
 
 // Config for cache.
 type Config struct {
-	ttl             time.Duration
+	ttl time.Duration
+	// Fraction of TTL by which it's randomized.
+	ttlJitter       float64
 	cleanupInterval time.Duration
-	enableMetrics   bool
-	enableDebug     bool
+	// Maximum number of items, zero means no limit.
+	maxEntries int
+	// Number of keys checked per round of the sampled cleanup.
+	cleanupSampleSize int
+	enableMetrics     bool
+	// Enables collection of operation latencies.
+	enableDetailedMetrics bool
+	enableDebug           bool
 	// It only works when debug if enabled
 	debugf func(format string, v ...any)
+	hooks  []Hook
+
+	// Records reads of every item, see WithAccessTracking.
+	accessTracking bool
+
+	snapshotPath     string
+	snapshotInterval time.Duration
+	snapshotCodec    SnapshotCodec
+
+	loader         LoaderFunc
+	loaderErrorTTL time.Duration
+	// Maximum TTL of cached loader errors, up to which the TTL grows for
+	// consecutive failures. Zero disables the growth.
+	loaderErrorMaxTTL time.Duration
+	// Maximum duration of a single load, zero means no limit.
+	loadTimeout time.Duration
+	// Number of consecutive loader failures that open the circuit,
+	// zero disables the circuit breaker.
+	circuitBreakerThreshold int
+	circuitBreakerCooldown  time.Duration
+
+	writeBehindBackend   Backend
+	writeBehindInterval  time.Duration
+	writeBehindQueueSize int
+
+	// Emits eviction events for items removed by FlushAll.
+	flushEvents bool
+
+	eventWorkers        int
+	eventQueueSize      int
+	eventOverflowPolicy EventOverflowPolicy
+	eventsBufferSize    int
+
+	// Compares values in CompareAndSwap and CompareAndDelete.
+	comparator func(a, b interface{}) bool
+
+	// Minimum size of []byte values that are compressed, zero disables
+	// compression.
+	compressionThreshold int
+	compressor           Compressor
+
+	// Mirrors items in a sync.Map, so Get doesn't take the lock.
+	readOptimized bool
+
+	// Resolution of the time Get checks expiration against, zero means
+	// that time.Now is called on every Get.
+	coarseClockResolution time.Duration
+
+	// Copies values on Set and Get, nil means that values are shared.
+	valueCopier func(v interface{}) interface{}
+
+	// Limits of stored entries, zero means no limit.
+	maxKeyLength     int
+	maxValueSize     uint64
+	rejectionHandler func(key string, value interface{}, err error)
+
+	// Memory usage of the process at which the cache starts shedding items
+	// and the usage it sheds them down to, zero high watermark disables it.
+	memoryHighWatermark uint64
+	memoryLowWatermark  uint64
+
+	// Reporters that metrics are periodically pushed to.
+	metricsReporters []metricsReporterConfig
+
+	// Returns the group of the key for per-group metrics.
+	keyGrouper func(key string) string
+
+	// Extractors of secondary indexes by name, see WithIndex.
+	indexes map[string]func(key string, value interface{}) (string, bool)
+
+	// Destination of the access trace, see WithTraceRecorder.
+	traceWriter io.Writer
+
+	// Encodes values in snapshots and for compression, see WithValueCodec.
+	valueCodec Codec
+
+	// Path of the append-only log, empty if it's disabled.
+	appendLogPath            string
+	appendLogCompactInterval time.Duration
 }
 
 type configFunc func(*Config)
@@ -26,6 +114,10 @@ func defaultConfig() Config {
 		enableMetrics:   false,
 		enableDebug:     false,
 		debugf:          log.New(os.Stdout, "[incache]", 0).Printf,
+		snapshotCodec:   GobCodec,
+
+		eventsBufferSize: defaultEventsBufferSize,
+		comparator:       defaultComparator,
 	}
 }
 
@@ -37,6 +129,26 @@ func WithTTL(ttl time.Duration) configFunc {
 	}
 }
 
+// WithTTLJitter randomizes TTL of every stored item by ±fraction, e.g.
+// with 0.1, a TTL of 10 minutes becomes anything between 9 and 11 minutes.
+// It prevents items written together from expiring at the same time and
+// stampeding the origin. Items without TTL aren't affected.
+func WithTTLJitter(fraction float64) configFunc {
+	return func(config *Config) {
+		config.ttlJitter = fraction
+	}
+}
+
+// WithMaxEntries limits the number of items in the cache. When the cache
+// is full, storing a new key evicts an item with the lowest priority (see
+// SetWithPriority), and among items with the same priority, the one stored
+// earliest. Zero means no limit.
+func WithMaxEntries(n int) configFunc {
+	return func(config *Config) {
+		config.maxEntries = n
+	}
+}
+
 // WithCleanupInterval sets the interval between removing expired items.
 // If the interval is less than or equal to 0, no automatic clearing
 // is performed.
@@ -46,6 +158,20 @@ func WithCleanupInterval(interval time.Duration) configFunc {
 	}
 }
 
+// WithSampledCleanup makes the cleaner check a random sample of sampleSize
+// keys with TTL per round, instead of scanning all of them, similar to Redis.
+// While more than a quarter of the sampled keys turn out to be expired,
+// the cleaner keeps sampling, for up to a quarter of cleanupInterval.
+//
+// The lock is only held for one round, which keeps tail latencies flat
+// on caches with millions of items, at the cost of expired items being
+// removed less promptly. Expired items are never returned anyway.
+func WithSampledCleanup(sampleSize int) configFunc {
+	return func(config *Config) {
+		config.cleanupSampleSize = sampleSize
+	}
+}
+
 // WithMetrics enables the collection of metrics that run throughout
 // the cache work.
 func WithMetrics() configFunc {
@@ -54,6 +180,30 @@ func WithMetrics() configFunc {
 	}
 }
 
+// WithDetailedMetrics enables the collection of metrics along with
+// latency distributions of Get, Set and Delete operations.
+//
+// Measuring latency requires reading the clock twice per operation,
+// so it has a small cost on every call.
+func WithDetailedMetrics() configFunc {
+	return func(conf *Config) {
+		conf.enableMetrics = true
+		conf.enableDetailedMetrics = true
+	}
+}
+
+// WithAccessTracking makes every item record the time it was last read
+// and the number of its reads. They're reported by Inspect, and TopKeys
+// reports the most read keys by them when metrics aren't enabled.
+//
+// The statistics are updated with atomics on every successful read and
+// take 16 bytes per item.
+func WithAccessTracking() configFunc {
+	return func(conf *Config) {
+		conf.accessTracking = true
+	}
+}
+
 // WithDebug enables debug mode.
 // Debug mode allows the caching system to log debug information.
 func WithDebug() configFunc {
@@ -69,3 +219,304 @@ func WithDebugf(fn func(format string, v ...any)) configFunc {
 		config.debugf = fn
 	}
 }
+
+// WithHook adds a hook that intercepts cache operations.
+// It can be used multiple times to build a chain of hooks.
+func WithHook(hook Hook) configFunc {
+	return func(config *Config) {
+		config.hooks = append(config.hooks, hook)
+	}
+}
+
+// WithAutoSnapshot makes the cache persist itself to the file at path
+// every interval, as well as on Close. If the file exists when the cache
+// is created, its contents are restored.
+//
+// Snapshots are written atomically, so a crash in the middle of writing
+// doesn't corrupt the previous snapshot. Errors are only reported
+// in debug mode.
+func WithAutoSnapshot(path string, interval time.Duration) configFunc {
+	return func(config *Config) {
+		config.snapshotPath = path
+		config.snapshotInterval = interval
+	}
+}
+
+// WithSnapshotCodec sets the codec used to encode automatic snapshots.
+// The default codec is GobCodec.
+func WithSnapshotCodec(codec SnapshotCodec) configFunc {
+	return func(config *Config) {
+		config.snapshotCodec = codec
+	}
+}
+
+// WithLoader turns the cache into a read-through cache: on a miss Get
+// transparently loads the value with fn and stores it. Concurrent misses
+// of the same key share a single call of fn.
+//
+// Get uses context.Background() and swallows errors, use GetContext to pass
+// a context and receive loader errors.
+func WithLoader(fn LoaderFunc) configFunc {
+	return func(config *Config) {
+		config.loader = fn
+	}
+}
+
+// WithLoaderErrorTTL makes the cache remember errors returned by the loader
+// for ttl, so a failing origin isn't called again for the same key until
+// ttl passes. By default errors aren't cached. Canceled and timed out loads
+// are never cached.
+//
+// It's a shorthand for WithErrorCaching(ttl, 0), without backoff. Both
+// options configure the same setting, so the one passed last wins.
+func WithLoaderErrorTTL(ttl time.Duration) configFunc {
+	return WithErrorCaching(ttl, 0)
+}
+
+// WithLoadTimeout limits the duration of a single call of the loader: the
+// context passed to the loader carries the values of the caller's one and
+// is canceled after d, so a slow origin can't pile up goroutines waiting
+// for a missing key. Loads that time out fail with context.DeadlineExceeded,
+// as long as the loader respects its context. The deadline of the caller
+// that started a shared load doesn't apply to it, see GetContext.
+func WithLoadTimeout(d time.Duration) configFunc {
+	return func(config *Config) {
+		config.loadTimeout = d
+	}
+}
+
+// WithLoaderCircuitBreaker stops calling the loader for cooldown after
+// failures consecutive failures, so a down origin gets time to recover.
+// While the circuit is open, stale values of expired items that weren't
+// removed yet are served, see GetStale, and ErrCircuitOpen is returned for
+// other keys. Once cooldown passes, a single load probes the origin and
+// closes the circuit if it succeeds. See Cache.LoaderCircuitState.
+func WithLoaderCircuitBreaker(failures int, cooldown time.Duration) configFunc {
+	return func(config *Config) {
+		config.circuitBreakerThreshold = failures
+		config.circuitBreakerCooldown = cooldown
+	}
+}
+
+// WithErrorCaching makes the cache remember errors returned by the loader
+// with exponential backoff: the first error of a key is cached for min,
+// and the TTL doubles with every consecutive failure of the key up to max,
+// so a down origin isn't hammered by every miss. A successful load resets
+// the TTL to min. Zero max disables the backoff, every error is cached
+// for min.
+//
+// It overrides WithLoaderErrorTTL passed before it, and vice versa.
+func WithErrorCaching(min, max time.Duration) configFunc {
+	return func(config *Config) {
+		config.loaderErrorTTL = min
+		config.loaderErrorMaxTTL = max
+	}
+}
+
+// WithWriteBehind enables write-behind mode: every set and deletion is
+// buffered and flushed to the backend in batches every interval.
+// Operations on the same key are coalesced, so only the latest one
+// is written.
+//
+// The queue holds up to queueSize distinct keys. When it's full, writes
+// trigger an immediate flush and block until there is a free space.
+// Operations that failed to be flushed are retried with the next flush.
+// After a failure, the next flush happens on the next interval, even if
+// the queue is full, so writers wait rather than hammer a failing backend.
+// Use Flush or Close to write out pending operations on shutdown.
+func WithWriteBehind(backend Backend, interval time.Duration, queueSize int) configFunc {
+	return func(config *Config) {
+		config.writeBehindBackend = backend
+		config.writeBehindInterval = interval
+		config.writeBehindQueueSize = queueSize
+	}
+}
+
+// WithFlushEvents makes FlushAll emit eviction events and call OnEvict hooks
+// for every removed item. By default, the cache is flushed silently.
+func WithFlushEvents() configFunc {
+	return func(config *Config) {
+		config.flushEvents = true
+	}
+}
+
+// WithEventWorkers makes event handlers run on a fixed number of workers
+// with a queue of queueSize events, instead of a separate goroutine per
+// event. The policy defines what happens when the queue is full.
+//
+// Handlers are submitted after the operation that triggered the event
+// releases the lock, so they can use the cache. With EventOverflowBlock,
+// a handler that modifies the cache waits for a free space in the queue
+// like any other writer, so the queue has to be large enough for the
+// events such handlers trigger, or all workers may end up waiting.
+func WithEventWorkers(workers, queueSize int, policy EventOverflowPolicy) configFunc {
+	return func(config *Config) {
+		config.eventWorkers = workers
+		config.eventQueueSize = queueSize
+		config.eventOverflowPolicy = policy
+	}
+}
+
+// WithEventsBuffer sets the capacity of the channel returned by Events.
+// Events are dropped when the buffer is full.
+func WithEventsBuffer(size int) configFunc {
+	return func(config *Config) {
+		config.eventsBufferSize = size
+	}
+}
+
+// WithComparator sets the function that compares values in CompareAndSwap
+// and CompareAndDelete. By default, values are compared with ==.
+func WithComparator(fn func(a, b interface{}) bool) configFunc {
+	return func(config *Config) {
+		config.comparator = fn
+	}
+}
+
+// WithCompression makes the cache compress values of type []byte that are
+// at least threshold bytes long. They are decompressed transparently when
+// read, so the compression is only visible in MemoryUsage.
+// A nil compressor means GzipCompressor. Values of other types are
+// compressed too if they are encoded with a codec set by WithValueCodec.
+func WithCompression(threshold int, compressor Compressor) configFunc {
+	return func(config *Config) {
+		if compressor == nil {
+			compressor = GzipCompressor
+		}
+
+		config.compressionThreshold = threshold
+		config.compressor = compressor
+	}
+}
+
+// WithReadOptimizedStorage mirrors items in a sync.Map, so Get and
+// GetMultiple read them without taking the lock of the cache. It speeds up
+// read-heavy workloads with many concurrent readers at the cost of slower
+// writes and extra memory for the mirror, so it's only worth it when the
+// vast majority of operations are reads.
+func WithReadOptimizedStorage() configFunc {
+	return func(config *Config) {
+		config.readOptimized = true
+	}
+}
+
+// WithCoarseClock makes Get check expiration of items against a cached
+// time, which the cleaner updates every resolution, instead of calling
+// time.Now on every read. It's only worth it for caches with extreme read
+// throughput.
+//
+// Items may be returned for up to resolution after they have expired.
+// The cached time is only used while the automatic cleanup is running,
+// otherwise Get falls back to time.Now.
+func WithCoarseClock(resolution time.Duration) configFunc {
+	return func(config *Config) {
+		config.coarseClockResolution = resolution
+	}
+}
+
+// WithValueCopier makes the cache copy values with fn when they are stored
+// and when they are returned by Get and similar methods, so callers can't
+// mutate the values shared through the cache. DeepCopy can be used as fn.
+//
+// Values passed to event handlers and hooks aren't copied.
+func WithValueCopier(fn func(v interface{}) interface{}) configFunc {
+	return func(config *Config) {
+		config.valueCopier = fn
+	}
+}
+
+// WithMaxKeyLength makes the cache reject entries with keys longer than
+// n bytes. Rejected entries aren't stored and the existing value of the key
+// is kept, see WithRejectionHandler.
+func WithMaxKeyLength(n int) configFunc {
+	return func(config *Config) {
+		config.maxKeyLength = n
+	}
+}
+
+// WithMaxValueSize makes the cache reject entries with values larger than
+// size bytes, which protects it from a single runaway payload. The size is
+// approximate: only strings and byte slices are measured exactly, other
+// values are measured shallowly. Rejected entries aren't stored and the
+// existing value of the key is kept, see WithRejectionHandler.
+func WithMaxValueSize(size uint64) configFunc {
+	return func(config *Config) {
+		config.maxValueSize = size
+	}
+}
+
+// WithRejectionHandler sets the function called with the entries rejected
+// because of the limits set by WithMaxKeyLength and WithMaxValueSize.
+// err wraps ErrKeyTooLong or ErrValueTooLarge. fn is called synchronously
+// after the lock of the cache is released, so it may use the cache.
+func WithRejectionHandler(fn func(key string, value interface{}, err error)) configFunc {
+	return func(config *Config) {
+		config.rejectionHandler = fn
+	}
+}
+
+// WithMemoryWatermark makes the cache shed items when the memory used by
+// the process reaches the high watermark, until the usage is expected to
+// fall to the low watermark. The usage is checked every second and measured
+// the same way as for the memory limit set by GOMEMLIMIT, see
+// debug.SetMemoryLimit. Items are shed in the order of EvictN.
+//
+// Zero high watermark means 90% of the memory limit, nothing is shed when
+// there is no limit then. Zero low watermark means 90% of the high one.
+func WithMemoryWatermark(high, low uint64) configFunc {
+	return func(config *Config) {
+		if high == 0 {
+			high = memoryLimit() / 10 * 9
+		}
+
+		if low == 0 {
+			low = high / 10 * 9
+		}
+
+		config.memoryHighWatermark = high
+		config.memoryLowWatermark = low
+	}
+}
+
+// WithIndex adds a secondary index called name, so values can be looked up
+// by a field other than the key with FindBy. extract returns the value the
+// entry is indexed under, or false when the entry shouldn't be indexed.
+// It's called with the stored value under the write lock on every Set, so
+// it must be fast and must not use the cache.
+func WithIndex(name string, extract func(key string, value interface{}) (string, bool)) configFunc {
+	return func(config *Config) {
+		if config.indexes == nil {
+			config.indexes = make(map[string]func(key string, value interface{}) (string, bool))
+		}
+
+		config.indexes[name] = extract
+	}
+}
+
+type metricsReporterConfig struct {
+	reporter MetricsReporter
+	interval time.Duration
+}
+
+// WithMetricsReporter makes the cache pass a report of its statistics to
+// the reporter every interval, so the telemetry can be pushed to systems
+// that don't scrape metrics, see StatsDReporter. Metrics have to be enabled
+// with WithMetrics to get non zero counters. The option can be used several
+// times to add more reporters. Reporting stops when the cache is closed.
+func WithMetricsReporter(reporter MetricsReporter, interval time.Duration) configFunc {
+	return func(config *Config) {
+		config.metricsReporters = append(config.metricsReporters, metricsReporterConfig{reporter: reporter, interval: interval})
+	}
+}
+
+// WithKeyGrouper makes the cache additionally tally insertions, hits and
+// misses by groups of keys defined by fn, e.g. the prefix of the key before
+// the first colon, which gives per-feature hit rates from one shared cache.
+// fn is called on every read and write, so it must be fast, and the number
+// of groups should be small. It only works when metrics are enabled,
+// see WithMetrics and Cache.GroupMetrics.
+func WithKeyGrouper(fn func(key string) string) configFunc {
+	return func(config *Config) {
+		config.keyGrouper = fn
+	}
+}
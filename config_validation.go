@@ -0,0 +1,128 @@
+package incache
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrInvalidConfig is returned by NewWithError when the options are invalid.
+var ErrInvalidConfig = errors.New("incache: invalid config")
+
+// NewWithError works similar to New, but it validates the options first,
+// so invalid combinations fail at construction instead of being silently
+// ignored. All returned errors wrap ErrInvalidConfig.
+func NewWithError(conf ...configFunc) (*Cache, error) {
+	config := newConfig(conf)
+
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	return newCache(config), nil
+}
+
+// validate checks that the config doesn't contain invalid values.
+func (c *Config) validate() error {
+	switch {
+	case c.ttlJitter < 0 || c.ttlJitter > 1:
+		return invalidConfig("TTL jitter must be between 0 and 1, got %v", c.ttlJitter)
+	case c.maxEntries < 0:
+		return invalidConfig("max entries must not be negative, got %d", c.maxEntries)
+	case c.cleanupSampleSize < 0:
+		return invalidConfig("cleanup sample size must not be negative, got %d", c.cleanupSampleSize)
+	case c.enableDebug && c.debugf == nil:
+		return invalidConfig("debug function must not be nil")
+	case c.loaderErrorTTL < 0:
+		return invalidConfig("loader error TTL must not be negative, got %s", c.loaderErrorTTL)
+	case c.loadTimeout < 0:
+		return invalidConfig("load timeout must not be negative, got %s", c.loadTimeout)
+	case c.circuitBreakerThreshold < 0:
+		return invalidConfig("circuit breaker threshold must not be negative, got %d", c.circuitBreakerThreshold)
+	case c.circuitBreakerThreshold > 0 && c.circuitBreakerCooldown <= 0:
+		return invalidConfig("circuit breaker cool-down must be positive, got %s", c.circuitBreakerCooldown)
+	case c.loaderErrorMaxTTL != 0 && c.loaderErrorMaxTTL < c.loaderErrorTTL:
+		return invalidConfig("max loader error TTL %s is below the min one %s", c.loaderErrorMaxTTL, c.loaderErrorTTL)
+	case c.writeBehindBackend != nil && c.writeBehindInterval <= 0:
+		return invalidConfig("write-behind interval must be positive, got %s", c.writeBehindInterval)
+	case c.writeBehindQueueSize < 0:
+		return invalidConfig("write-behind queue size must not be negative, got %d", c.writeBehindQueueSize)
+	case c.eventWorkers < 0:
+		return invalidConfig("number of event workers must not be negative, got %d", c.eventWorkers)
+	case c.eventQueueSize < 0:
+		return invalidConfig("event queue size must not be negative, got %d", c.eventQueueSize)
+	case c.eventsBufferSize < 0:
+		return invalidConfig("events buffer size must not be negative, got %d", c.eventsBufferSize)
+	case c.comparator == nil:
+		return invalidConfig("comparator must not be nil")
+	case c.compressionThreshold < 0:
+		return invalidConfig("compression threshold must not be negative, got %d", c.compressionThreshold)
+	case c.memoryLowWatermark > c.memoryHighWatermark:
+		return invalidConfig("low memory watermark %d is above the high one %d", c.memoryLowWatermark, c.memoryHighWatermark)
+	case !validReporters(c.metricsReporters):
+		return invalidConfig("metrics reporter must not be nil and its interval must be positive")
+	case c.coarseClockResolution < 0:
+		return invalidConfig("coarse clock resolution must not be negative, got %s", c.coarseClockResolution)
+	case c.maxKeyLength < 0:
+		return invalidConfig("max key length must not be negative, got %d", c.maxKeyLength)
+	}
+
+	if c.snapshotPath != "" {
+		if c.snapshotInterval <= 0 {
+			return invalidConfig("snapshot interval must be positive, got %s", c.snapshotInterval)
+		}
+
+		if c.snapshotCodec == nil {
+			return invalidConfig("snapshot codec must not be nil")
+		}
+
+		if err := checkWritable(c.snapshotPath); err != nil {
+			return invalidConfig("snapshot path isn't writable: %v", err)
+		}
+	}
+
+	if c.appendLogPath != "" {
+		if c.appendLogCompactInterval < 0 {
+			return invalidConfig("append-only log compaction interval must not be negative, got %s", c.appendLogCompactInterval)
+		}
+
+		if err := checkWritable(c.appendLogPath); err != nil {
+			return invalidConfig("append-only log path isn't writable: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func invalidConfig(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: "+format, append([]interface{}{ErrInvalidConfig}, args...)...)
+}
+
+// checkWritable checks that a file can be created next to path, the same
+// way SaveSnapshotFile does it.
+func checkWritable(path string) error {
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	tmp, err := os.CreateTemp(dir, name+".check*")
+	if err != nil {
+		return err
+	}
+
+	tmp.Close()
+
+	return os.Remove(tmp.Name())
+}
+
+func validReporters(reporters []metricsReporterConfig) bool {
+	for _, r := range reporters {
+		if r.reporter == nil || r.interval <= 0 {
+			return false
+		}
+	}
+
+	return true
+}
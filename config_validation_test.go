@@ -0,0 +1,54 @@
+package incache
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWithError(t *testing.T) {
+	cache, err := NewWithError(WithTTL(time.Minute), WithMaxEntries(10))
+	require.NoError(t, err)
+	defer cache.Close()
+
+	cache.Set("key1", "value1")
+	assert.Equal(t, "value1", cache.Get("key1"))
+}
+
+func TestNewWithErrorInvalidConfig(t *testing.T) {
+	tests := map[string][]configFunc{
+		"negative max entries":          {WithMaxEntries(-1)},
+		"too large TTL jitter":          {WithTTLJitter(1.5)},
+		"negative sample size":          {WithSampledCleanup(-1)},
+		"negative key length":           {WithMaxKeyLength(-1)},
+		"negative coarse clock":         {WithCoarseClock(-time.Millisecond)},
+		"nil comparator":                {WithComparator(nil)},
+		"max error TTL below min":       {WithErrorCaching(time.Minute, time.Second)},
+		"write-behind without interval": {WithWriteBehind(newMemoryBackend(), 0, 10)},
+		"snapshot without interval":     {WithAutoSnapshot(filepath.Join(t.TempDir(), "cache.snapshot"), 0)},
+		"unwritable snapshot path":      {WithAutoSnapshot(filepath.Join(t.TempDir(), "missing", "cache.snapshot"), time.Minute)},
+	}
+
+	for name, conf := range tests {
+		t.Run(name, func(t *testing.T) {
+			cache, err := NewWithError(conf...)
+
+			assert.Nil(t, cache)
+			assert.ErrorIs(t, err, ErrInvalidConfig)
+		})
+	}
+}
+
+func TestNewWithErrorWritableSnapshotPath(t *testing.T) {
+	dir := t.TempDir()
+
+	cache, err := NewWithError(WithAutoSnapshot(filepath.Join(dir, "cache.snapshot"), time.Minute))
+	require.NoError(t, err)
+	cache.Close()
+
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.check*"))
+	assert.Empty(t, matches)
+}
@@ -0,0 +1,38 @@
+package incache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"reflect"
+)
+
+// DeepCopy returns a deep copy of v made by encoding and decoding it with
+// gob, see WithValueCopier. Only exported fields of structs are copied.
+// Values that gob can't encode, e.g. functions or channels, are returned
+// as is.
+func DeepCopy(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return v
+	}
+
+	copied := reflect.New(reflect.TypeOf(v))
+	if err := gob.NewDecoder(&buf).DecodeValue(copied); err != nil {
+		return v
+	}
+
+	return copied.Elem().Interface()
+}
+
+// copyValue copies the value with the function set by WithValueCopier.
+func (c *Cache) copyValue(value interface{}) interface{} {
+	if c.config.valueCopier == nil || value == nil {
+		return value
+	}
+
+	return c.config.valueCopier(value)
+}
@@ -0,0 +1,53 @@
+package incache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type copyValue struct {
+	Name string
+	Tags []string
+}
+
+func TestValueCopier(t *testing.T) {
+	cache := New(WithValueCopier(DeepCopy))
+
+	value := &copyValue{Name: "value1", Tags: []string{"a"}}
+	cache.Set("key1", value)
+
+	value.Tags[0] = "changed"
+
+	received := cache.Get("key1").(*copyValue)
+	assert.Equal(t, []string{"a"}, received.Tags)
+
+	received.Name = "changed"
+	assert.Equal(t, "value1", cache.Get("key1").(*copyValue).Name)
+
+	stale, _, _ := cache.GetStale("key1")
+	assert.NotSame(t, received, stale)
+}
+
+func TestValuesAreSharedByDefault(t *testing.T) {
+	cache := New()
+
+	value := &copyValue{Name: "value1"}
+	cache.Set("key1", value)
+
+	assert.Same(t, value, cache.Get("key1"))
+}
+
+func TestDeepCopy(t *testing.T) {
+	value := map[string][]int{"key1": {1, 2}}
+
+	copied := DeepCopy(value).(map[string][]int)
+	copied["key1"][0] = 3
+
+	assert.Equal(t, 1, value["key1"][0])
+	assert.Equal(t, "value", DeepCopy("value"))
+	assert.Nil(t, DeepCopy(nil))
+
+	fn := func() {}
+	assert.NotNil(t, DeepCopy(fn))
+}
@@ -0,0 +1,92 @@
+package incache
+
+import (
+	"sync"
+	"time"
+)
+
+// The process-wide default cache used by the package-level functions.
+// It's created with the default configuration on first use, unless
+// it was configured by SetDefault.
+var (
+	defaultCacheMu sync.RWMutex
+	defaultCache   *Cache
+)
+
+// SetDefault makes the cache the default one used by the package-level
+// functions, e.g. incache.Set and incache.Get.
+//
+// The previous default cache isn't closed.
+func SetDefault(cache *Cache) {
+	defaultCacheMu.Lock()
+	defer defaultCacheMu.Unlock()
+
+	defaultCache = cache
+}
+
+// Default returns the default cache.
+func Default() *Cache {
+	defaultCacheMu.RLock()
+	cache := defaultCache
+	defaultCacheMu.RUnlock()
+
+	if cache != nil {
+		return cache
+	}
+
+	defaultCacheMu.Lock()
+	defer defaultCacheMu.Unlock()
+
+	if defaultCache == nil {
+		defaultCache = New()
+	}
+
+	return defaultCache
+}
+
+// Set sets the key to hold a value in the default cache.
+func Set(key string, value interface{}) {
+	Default().Set(key, value)
+}
+
+// SetWithTTL sets the key to hold a value in the default cache for ttl.
+func SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	Default().SetWithTTL(key, value, ttl)
+}
+
+// Get returns the value of key from the default cache.
+// If the key doesn't exist, nil value will be returned.
+func Get(key string) interface{} {
+	return Default().Get(key)
+}
+
+// GetDelete returns the value of key from the default cache and delete it.
+// If the key doesn't exist, nil value will be returned.
+func GetDelete(key string) interface{} {
+	return Default().GetDelete(key)
+}
+
+// Delete deletes the value of key from the default cache.
+func Delete(key string) {
+	Default().Delete(key)
+}
+
+// DeleteAll deletes all values stored in the default cache.
+func DeleteAll() {
+	Default().DeleteAll()
+}
+
+// Has checks if the key exists in the default cache.
+func Has(key string) bool {
+	return Default().Has(key)
+}
+
+// Keys returns slice of all existing keys in the default cache.
+func Keys() []string {
+	return Default().Keys()
+}
+
+// Len returns the number of stored elements in the default cache.
+func Len() int {
+	return Default().Len()
+}
@@ -0,0 +1,50 @@
+package incache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultCache(t *testing.T) {
+	previous := Default()
+	defer SetDefault(previous)
+
+	cache := New(WithTTL(0))
+	defer cache.Close()
+
+	SetDefault(cache)
+	assert.Same(t, cache, Default())
+
+	Set("key1", "value1")
+	SetWithTTL("key2", "value2", time.Minute)
+
+	assert.Equal(t, "value1", Get("key1"))
+	assert.Equal(t, "value1", cache.Get("key1"))
+	assert.True(t, Has("key2"))
+	assert.Equal(t, 2, Len())
+	assert.ElementsMatch(t, []string{"key1", "key2"}, Keys())
+
+	assert.Equal(t, "value2", GetDelete("key2"))
+	Delete("key1")
+	assert.Zero(t, Len())
+
+	Set("key3", "value3")
+	DeleteAll()
+	assert.Zero(t, cache.Len())
+}
+
+func TestDefaultCacheIsCreatedLazily(t *testing.T) {
+	previous := Default()
+	defer SetDefault(previous)
+
+	SetDefault(nil)
+
+	cache := Default()
+	defer cache.Close()
+
+	assert.NotNil(t, cache)
+	assert.Same(t, cache, Default())
+	assert.Equal(t, defaultConfig().ttl, cache.config.ttl)
+}
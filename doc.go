@@ -0,0 +1,44 @@
+// Package incache provides a simple thread-safe time-based cache.
+//
+// # Expiration
+//
+// Expiration is tracked with the monotonic clock, so changes of the system
+// clock, e.g. by NTP, don't expire items prematurely nor make them live
+// forever. Expiration times given as wall clock time, e.g. to
+// SetWithExpiresAt or ExpireAt, or restored from snapshots, are converted
+// to the monotonic clock when they are stored.
+//
+// # Concurrency
+//
+// All methods of Cache are safe for concurrent use by multiple goroutines.
+// Every operation on a single key is linearizable: it takes effect
+// atomically at some point between its invocation and its return, so
+// concurrent callers never observe a partially applied operation.
+//
+// In particular:
+//
+//   - Set, SetWithTTL, Get, Has and Delete are atomic.
+//   - SetGet and GetSet (and their TTL variants) perform the read and the
+//     write under a single critical section, so no other operation on the
+//     key can happen in between.
+//   - GetDelete is atomic: when several goroutines call it for the same
+//     key, exactly one of them receives the stored value.
+//   - Delete of an existing key evicts it exactly once, even if it races
+//     with other Delete or GetDelete calls.
+//
+// Operations that touch many keys (GetMultiple, Keys, Len, FlushAll and
+// DeleteExpired) are each safe to call concurrently, but only Keys, Len,
+// FlushAll and DeleteExpired observe the cache at a single point in time.
+// GetMultiple reads every key separately.
+//
+// Metrics counters are updated atomically, but they are independent of
+// each other, so a snapshot of several counters taken while the cache is
+// in use may not be mutually consistent.
+//
+// Event handlers are executed asynchronously in separate goroutines, so
+// the order in which they run is not guaranteed to match the order of
+// the operations that triggered them. Use Close to wait for all of them
+// to finish. The decision to evict an item and the value passed to
+// eviction handlers are taken under the same lock, so handlers always
+// receive the value that was actually removed.
+package incache
@@ -0,0 +1,125 @@
+package incache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// DumpFormat is the output format of Cache.Dump.
+type DumpFormat int
+
+const (
+	// DumpTable writes an aligned table with a summary line.
+	DumpTable DumpFormat = iota
+	// DumpJSON writes a JSON array of entries.
+	DumpJSON
+)
+
+type dumpEntry struct {
+	Key        string     `json:"key"`
+	ValueType  string     `json:"value_type"`
+	Size       uint64     `json:"size"`
+	TTL        string     `json:"ttl,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	Expired    bool       `json:"expired,omitempty"`
+	Compressed bool       `json:"compressed,omitempty"`
+}
+
+// Dump writes the metadata of all items, including the expired ones that
+// weren't removed yet, to w: keys, value types, approximate sizes, TTLs and
+// expiration times. Items are sorted by size in descending order, so the
+// ones that occupy the most memory come first. Values aren't written.
+//
+// Dump holds the read lock while it collects the items, but not while
+// writing them, so it can be used in a live process, e.g. from a debug
+// endpoint.
+func (c *Cache) Dump(w io.Writer, format DumpFormat) error {
+	c.mu.RLock()
+
+	infos := make([]EntryInfo, 0, len(c.items))
+	for key, item := range c.items {
+		infos = append(infos, newEntryInfo(key, item))
+	}
+
+	c.mu.RUnlock()
+
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Size != infos[j].Size {
+			return infos[i].Size > infos[j].Size
+		}
+
+		return infos[i].Key < infos[j].Key
+	})
+
+	switch format {
+	case DumpTable:
+		return dumpTable(w, infos)
+	case DumpJSON:
+		return dumpJSON(w, infos)
+	default:
+		return fmt.Errorf("incache: unknown dump format %d", format)
+	}
+}
+
+func dumpTable(w io.Writer, infos []EntryInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "KEY\tTYPE\tSIZE\tTTL\tEXPIRES AT")
+
+	var total uint64
+	for _, info := range infos {
+		ttl, expiresAt := "-", "-"
+		if !info.ExpiresAt.IsZero() {
+			ttl = info.TTL.String()
+			expiresAt = info.ExpiresAt.Format(time.RFC3339)
+
+			if info.Expired {
+				expiresAt += " (expired)"
+			}
+		}
+
+		valueType := info.ValueType
+		if info.Compressed {
+			valueType += " (compressed)"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\n", info.Key, valueType, info.Size, ttl, expiresAt)
+
+		total += info.Size
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "%d items, %d bytes\n", len(infos), total)
+
+	return err
+}
+
+func dumpJSON(w io.Writer, infos []EntryInfo) error {
+	entries := make([]dumpEntry, 0, len(infos))
+	for _, info := range infos {
+		entry := dumpEntry{
+			Key:        info.Key,
+			ValueType:  info.ValueType,
+			Size:       info.Size,
+			Expired:    info.Expired,
+			Compressed: info.Compressed,
+		}
+
+		if !info.ExpiresAt.IsZero() {
+			expiresAt := info.ExpiresAt
+			entry.TTL = info.TTL.String()
+			entry.ExpiresAt = &expiresAt
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return json.NewEncoder(w).Encode(entries)
+}
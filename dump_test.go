@@ -0,0 +1,62 @@
+package incache
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDumpTable(t *testing.T) {
+	cache := New(WithTTL(0), WithCleanupInterval(0))
+
+	cache.Set("small", "a")
+	cache.SetWithTTL("large", strings.Repeat("a", 100), time.Hour)
+	cache.SetWithTTL("expired", "ab", time.Millisecond)
+
+	time.Sleep(5 * time.Millisecond)
+
+	var buf bytes.Buffer
+	require.NoError(t, cache.Dump(&buf, DumpTable))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 5)
+
+	assert.Regexp(t, `^KEY\s+TYPE\s+SIZE\s+TTL\s+EXPIRES AT$`, lines[0])
+	assert.Regexp(t, `^large\s+string\s+100\s+1h0m0s\s+\S+$`, lines[1])
+	assert.Regexp(t, `^expired\s+string\s+2\s+1ms\s+\S+ \(expired\)$`, lines[2])
+	assert.Regexp(t, `^small\s+string\s+1\s+-\s+-$`, lines[3])
+	assert.Equal(t, "3 items, 103 bytes", lines[4])
+}
+
+func TestDumpJSON(t *testing.T) {
+	cache := New(WithTTL(0))
+
+	cache.Set("key1", "value1")
+	cache.SetWithTTL("key2", 42, time.Minute)
+
+	var buf bytes.Buffer
+	require.NoError(t, cache.Dump(&buf, DumpJSON))
+
+	var entries []map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entries))
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, "key2", entries[0]["key"])
+	assert.Equal(t, "int", entries[0]["value_type"])
+	assert.Equal(t, "1m0s", entries[0]["ttl"])
+	assert.Contains(t, entries[0], "expires_at")
+
+	assert.Equal(t, "key1", entries[1]["key"])
+	assert.NotContains(t, entries[1], "ttl")
+}
+
+func TestDumpUnknownFormat(t *testing.T) {
+	cache := New()
+
+	assert.Error(t, cache.Dump(&bytes.Buffer{}, DumpFormat(42)))
+}
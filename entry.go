@@ -0,0 +1,87 @@
+package incache
+
+import "time"
+
+// Entry is an item stored in the cache along with its key.
+type Entry struct {
+	Key   string
+	Value interface{}
+	// TTL the item was stored with. Zero means that the item never expires.
+	TTL       time.Duration
+	ExpiresAt time.Time
+}
+
+// OldestEntry returns the entry that was stored or updated the longest time
+// ago. Expired items are skipped. It scans all items, so it's meant for
+// debugging and occasional use, e.g. to spill the coldest data elsewhere.
+func (c *Cache) OldestEntry() (Entry, bool) {
+	return c.findByVersion(func(a, b uint64) bool { return a < b })
+}
+
+// NewestEntry returns the entry that was stored or updated most recently.
+// Expired items are skipped. See OldestEntry.
+func (c *Cache) NewestEntry() (Entry, bool) {
+	return c.findByVersion(func(a, b uint64) bool { return a > b })
+}
+
+// NextEvictionKey returns the key that would be evicted first when the
+// cache is full, see WithMaxEntries. It returns false when the number of
+// items isn't limited, since there is no eviction order then.
+//
+// Reads don't affect the order of eviction, so the returned key is the one
+// with the lowest priority that was stored earliest, rather than the least
+// recently used one.
+func (c *Cache) NextEvictionKey() (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.evictionQueue == nil {
+		return "", false
+	}
+
+	return c.evictionQueue.next()
+}
+
+// findByVersion returns the live entry whose version precedes the versions
+// of all other entries according to less.
+func (c *Cache) findByVersion(less func(a, b uint64) bool) (Entry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+
+	var (
+		found   bool
+		key     string
+		version uint64
+	)
+
+	for k, item := range c.items {
+		if item.expiredAt(now) {
+			continue
+		}
+
+		if !found || less(item.version, version) {
+			found, key, version = true, k, item.version
+		}
+	}
+
+	if !found {
+		return Entry{}, false
+	}
+
+	return c.entryLocked(key), true
+}
+
+// entryLocked returns the entry of key, which must exist.
+// It must be called with at least the read lock held.
+func (c *Cache) entryLocked(key string) Entry {
+	item := c.items[key]
+
+	return Entry{
+		Key:       key,
+		Value:     c.copyValue(c.value(item.Value)),
+		TTL:       item.TTL,
+		ExpiresAt: item.ExpiresAt,
+	}
+}
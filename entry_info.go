@@ -0,0 +1,75 @@
+package incache
+
+import (
+	"fmt"
+	"time"
+)
+
+// EntryInfo describes an item stored in the cache without exposing its value.
+type EntryInfo struct {
+	Key string
+	// ValueType is the Go type of the stored value, e.g. "string".
+	ValueType string
+	// Size is an approximate number of bytes occupied by the value.
+	Size uint64
+	// TTL the item was stored with. Zero means that the item never expires.
+	TTL       time.Duration
+	ExpiresAt time.Time
+	// Expired reports whether the TTL has already passed, but the item
+	// wasn't removed by the cleaner yet.
+	Expired bool
+	// Version of the item, see Cache.GetVersioned.
+	Version uint64
+	// Compressed reports whether the value is stored compressed,
+	// see WithCompression. Size is the compressed size then.
+	Compressed bool
+	// LastAccess is the time of the last successful read and Hits is
+	// the number of them. Both are zero without WithAccessTracking.
+	LastAccess time.Time
+	Hits       uint64
+}
+
+// Inspect returns metadata of the item stored by key.
+// It doesn't affect metrics and doesn't trigger hooks.
+func (c *Cache) Inspect(key string) (EntryInfo, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	item, ok := c.items[key]
+	if !ok {
+		return EntryInfo{}, false
+	}
+
+	return newEntryInfo(key, item), true
+}
+
+func newEntryInfo(key string, item Item) EntryInfo {
+	info := EntryInfo{
+		Key:       key,
+		ValueType: fmt.Sprintf("%T", item.Value),
+		Size:      estimateSize(item.Value),
+		TTL:       item.TTL,
+		ExpiresAt: item.ExpiresAt,
+		Expired:   item.Expired(),
+		Version:   item.version,
+	}
+
+	if item.access != nil {
+		info.LastAccess, info.Hits = item.access.load()
+	}
+
+	switch v := item.Value.(type) {
+	case compressedValue:
+		info.ValueType = fmt.Sprintf("%T", []byte(nil))
+		info.Compressed = true
+	case encodedValue:
+		info.ValueType = v.valueType
+		info.Compressed = true
+	case weakValue:
+		value := v.value()
+		info.ValueType = fmt.Sprintf("%T", value)
+		info.Size = estimateSize(value)
+	}
+
+	return info
+}
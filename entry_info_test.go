@@ -0,0 +1,47 @@
+package incache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspect(t *testing.T) {
+	cache := New(WithMetrics())
+
+	cache.SetWithTTL("key1", "value1", time.Minute)
+	cache.SetWithTTL("key2", 10, 0)
+
+	info, ok := cache.Inspect("key1")
+	require.True(t, ok)
+	assert.Equal(t, "key1", info.Key)
+	assert.Equal(t, "string", info.ValueType)
+	assert.EqualValues(t, 6, info.Size)
+	assert.Equal(t, time.Minute, info.TTL)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), info.ExpiresAt, time.Second)
+	assert.False(t, info.Expired)
+
+	info, ok = cache.Inspect("key2")
+	require.True(t, ok)
+	assert.Equal(t, "int", info.ValueType)
+	assert.True(t, info.ExpiresAt.IsZero())
+
+	_, ok = cache.Inspect("key3")
+	assert.False(t, ok)
+
+	assert.Zero(t, cache.Metrics().Hits())
+	assert.Zero(t, cache.Metrics().Misses())
+}
+
+func TestInspectExpired(t *testing.T) {
+	cache := New(WithCleanupInterval(0))
+
+	cache.SetWithTTL("key1", "value1", time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+
+	info, ok := cache.Inspect("key1")
+	require.True(t, ok)
+	assert.True(t, info.Expired)
+}
@@ -0,0 +1,58 @@
+package incache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOldestAndNewestEntry(t *testing.T) {
+	cache := New(WithTTL(0), WithCleanupInterval(0))
+
+	_, ok := cache.OldestEntry()
+	assert.False(t, ok)
+
+	_, ok = cache.NewestEntry()
+	assert.False(t, ok)
+
+	cache.SetWithTTL("expired", "value", time.Millisecond)
+	cache.Set("key1", "value1")
+	cache.SetWithTTL("key2", "value2", time.Hour)
+	cache.Set("key3", "value3")
+	cache.Set("key1", "value4")
+
+	time.Sleep(2 * time.Millisecond)
+
+	oldest, ok := cache.OldestEntry()
+	assert.True(t, ok)
+	assert.Equal(t, "key2", oldest.Key)
+	assert.Equal(t, "value2", oldest.Value)
+	assert.Equal(t, time.Hour, oldest.TTL)
+
+	newest, ok := cache.NewestEntry()
+	assert.True(t, ok)
+	assert.Equal(t, Entry{Key: "key1", Value: "value4"}, newest)
+}
+
+func TestNextEvictionKey(t *testing.T) {
+	cache := New(WithTTL(0), WithCleanupInterval(0))
+
+	cache.Set("key1", "value1")
+
+	_, ok := cache.NextEvictionKey()
+	assert.False(t, ok)
+
+	cache = New(WithTTL(0), WithCleanupInterval(0), WithMaxEntries(10))
+
+	_, ok = cache.NextEvictionKey()
+	assert.False(t, ok)
+
+	cache.SetWithPriority("key1", "value1", 1)
+	cache.Set("key2", "value2")
+	cache.Set("key3", "value3")
+
+	key, ok := cache.NextEvictionKey()
+	assert.True(t, ok)
+	assert.Equal(t, "key2", key)
+}
@@ -2,47 +2,177 @@ package incache
 
 import (
 	"sync"
+	"sync/atomic"
 )
 
 func defaultInsertionEvent(key string, value interface{}) {}
 func defaultEvictionEvent(key string, value interface{})  {}
 
 type eventHandlers struct {
+	mu          sync.RWMutex
 	wg          *sync.WaitGroup
-	onInsertion func(key string, value interface{})
-	onEviction  func(key string, value interface{})
+	pool        *eventPool
+	insertionFn func(key string, value interface{})
+	evictionFn  func(key string, value interface{})
+	// Optional handlers, insertionFn and evictionFn are used
+	// when they aren't set.
+	updateFn     func(key string, oldValue, newValue interface{})
+	expirationFn func(key string, value interface{})
+	// Set once any handler is registered, so events aren't collected
+	// for the default handlers.
+	registered int32
+}
+
+// handlerEvent is an event collected under the lock of the cache, whose
+// handler is called once the lock is released, see Cache.unlock.
+type handlerEvent struct {
+	typ      EventType
+	key      string
+	value    interface{}
+	oldValue interface{}
 }
 
 func newEventHandlers() *eventHandlers {
 	return &eventHandlers{
 		wg:          &sync.WaitGroup{},
-		onInsertion: defaultInsertionEvent,
-		onEviction:  defaultEvictionEvent,
+		insertionFn: defaultInsertionEvent,
+		evictionFn:  defaultEvictionEvent,
 	}
 }
 
 func (c *eventHandlers) OnInsertion(fn func(key string, value interface{})) {
-	c.onInsertion = func(key string, value interface{}) {
-		c.wg.Add(1)
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-		go func() {
-			fn(key, value)
-			c.wg.Done()
-		}()
-	}
+	atomic.StoreInt32(&c.registered, 1)
+	c.insertionFn = c.async(fn)
 }
 
 func (c *eventHandlers) OnEviction(fn func(key string, value interface{})) {
-	c.onEviction = func(key string, value interface{}) {
-		c.wg.Add(1)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	atomic.StoreInt32(&c.registered, 1)
+	c.evictionFn = c.async(fn)
+}
+
+func (c *eventHandlers) OnUpdate(fn func(key string, oldValue, newValue interface{})) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-		go func() {
-			fn(key, value)
-			c.wg.Done()
-		}()
+	atomic.StoreInt32(&c.registered, 1)
+	c.updateFn = func(key string, oldValue, newValue interface{}) {
+		c.run(key, func() { fn(key, oldValue, newValue) })
 	}
 }
 
+func (c *eventHandlers) OnExpiration(fn func(key string, value interface{})) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	atomic.StoreInt32(&c.registered, 1)
+	c.expirationFn = c.async(fn)
+}
+
+// withPool makes handlers be executed on the pool of workers instead of
+// separate goroutines.
+func (c *eventHandlers) withPool(workers, queueSize int, policy EventOverflowPolicy) {
+	c.pool = newEventPool(workers, queueSize, policy, c.wg)
+}
+
 func (c *eventHandlers) Wait() {
 	c.wg.Wait()
 }
+
+// close waits for the execution of all events and stops the workers.
+func (c *eventHandlers) close() {
+	if c.pool != nil {
+		c.pool.close()
+	}
+
+	c.wg.Wait()
+}
+
+// hasHandlers reports whether any handler was registered.
+func (c *eventHandlers) hasHandlers() bool {
+	return atomic.LoadInt32(&c.registered) == 1
+}
+
+// dispatch calls the handler of the event.
+func (c *eventHandlers) dispatch(event handlerEvent) {
+	switch event.typ {
+	case EventInsertion:
+		c.onInsertion(event.key, event.value)
+	case EventUpdate:
+		c.onUpdate(event.key, event.oldValue, event.value)
+	case EventExpiration:
+		c.onExpiration(event.key, event.value)
+	default:
+		c.onEviction(event.key, event.value)
+	}
+}
+
+func (c *eventHandlers) onInsertion(key string, value interface{}) {
+	c.mu.RLock()
+	fn := c.insertionFn
+	c.mu.RUnlock()
+
+	fn(key, value)
+}
+
+func (c *eventHandlers) onEviction(key string, value interface{}) {
+	c.mu.RLock()
+	fn := c.evictionFn
+	c.mu.RUnlock()
+
+	fn(key, value)
+}
+
+func (c *eventHandlers) onUpdate(key string, oldValue, newValue interface{}) {
+	c.mu.RLock()
+	fn, insertionFn := c.updateFn, c.insertionFn
+	c.mu.RUnlock()
+
+	if fn == nil {
+		insertionFn(key, newValue)
+		return
+	}
+
+	fn(key, oldValue, newValue)
+}
+
+func (c *eventHandlers) onExpiration(key string, value interface{}) {
+	c.mu.RLock()
+	fn, evictionFn := c.expirationFn, c.evictionFn
+	c.mu.RUnlock()
+
+	if fn == nil {
+		evictionFn(key, value)
+		return
+	}
+
+	fn(key, value)
+}
+
+// async wraps fn to be executed asynchronously, see run.
+func (c *eventHandlers) async(fn func(key string, value interface{})) func(key string, value interface{}) {
+	return func(key string, value interface{}) {
+		c.run(key, func() { fn(key, value) })
+	}
+}
+
+// run executes the handler of the event for key in a separate goroutine or
+// on the pool of workers, which is tracked by the wait group.
+func (c *eventHandlers) run(key string, fn func()) {
+	if c.pool != nil {
+		c.pool.submit(eventTask{key: key, fn: fn})
+		return
+	}
+
+	c.wg.Add(1)
+
+	go func() {
+		fn()
+		c.wg.Done()
+	}()
+}
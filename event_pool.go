@@ -0,0 +1,151 @@
+package incache
+
+import (
+	"fmt"
+	"log"
+	"sync"
+)
+
+// EventOverflowPolicy defines what happens with an event when the queue
+// of the event workers is full.
+type EventOverflowPolicy int
+
+const (
+	// EventOverflowBlock blocks the operation that triggered the event
+	// until there is a free space in the queue.
+	EventOverflowBlock EventOverflowPolicy = iota
+	// EventOverflowDrop silently drops the event.
+	EventOverflowDrop
+	// EventOverflowLog drops the event and logs it.
+	EventOverflowLog
+)
+
+var eventOverflowPolicyNames = [...]string{
+	EventOverflowBlock: "block",
+	EventOverflowDrop:  "drop",
+	EventOverflowLog:   "log",
+}
+
+func (p EventOverflowPolicy) String() string {
+	if p < 0 || int(p) >= len(eventOverflowPolicyNames) {
+		return fmt.Sprintf("EventOverflowPolicy(%d)", int(p))
+	}
+
+	return eventOverflowPolicyNames[p]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (p EventOverflowPolicy) MarshalText() ([]byte, error) {
+	if p < 0 || int(p) >= len(eventOverflowPolicyNames) {
+		return nil, fmt.Errorf("incache: unknown event overflow policy %d", int(p))
+	}
+
+	return []byte(eventOverflowPolicyNames[p]), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. It accepts "block",
+// "drop" and "log".
+func (p *EventOverflowPolicy) UnmarshalText(text []byte) error {
+	for policy, name := range eventOverflowPolicyNames {
+		if string(text) == name {
+			*p = EventOverflowPolicy(policy)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("incache: unknown event overflow policy %q", text)
+}
+
+type eventTask struct {
+	key string
+	fn  func()
+}
+
+// eventPool executes event handlers on a fixed number of workers.
+type eventPool struct {
+	tasks  chan eventTask
+	policy EventOverflowPolicy
+	wg     *sync.WaitGroup
+	logf   func(format string, v ...any)
+
+	// Guards tasks from being closed while events are submitted.
+	mu     sync.RWMutex
+	closed bool
+}
+
+func newEventPool(workers, queueSize int, policy EventOverflowPolicy, wg *sync.WaitGroup) *eventPool {
+	if workers < 1 {
+		workers = 1
+	}
+
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	p := &eventPool{
+		tasks:  make(chan eventTask, queueSize),
+		policy: policy,
+		wg:     wg,
+		logf:   log.Printf,
+	}
+
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+
+	return p
+}
+
+func (p *eventPool) work() {
+	for task := range p.tasks {
+		task.fn()
+		p.wg.Done()
+	}
+}
+
+// submit queues the event according to the overflow policy. After the pool
+// is closed, events are executed in separate goroutines.
+func (p *eventPool) submit(task eventTask) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	p.wg.Add(1)
+
+	if p.closed {
+		go func() {
+			task.fn()
+			p.wg.Done()
+		}()
+
+		return
+	}
+
+	if p.policy == EventOverflowBlock {
+		p.tasks <- task
+		return
+	}
+
+	select {
+	case p.tasks <- task:
+	default:
+		p.wg.Done()
+
+		if p.policy == EventOverflowLog {
+			p.logf("[incache] event queue is full, dropped the event for the key: '%s'", task.key)
+		}
+	}
+}
+
+// close stops the workers once the queued events are executed.
+// It's safe to call it multiple times.
+func (p *eventPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return
+	}
+
+	p.closed = true
+	close(p.tasks)
+}
@@ -0,0 +1,116 @@
+package incache
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventWorkers(t *testing.T) {
+	cache := New(WithTTL(0), WithEventWorkers(4, 16, EventOverflowBlock))
+
+	var insertions, evictions int32
+	cache.OnInsertion(func(_ string, _ interface{}) {
+		atomic.AddInt32(&insertions, 1)
+	})
+	cache.OnEviction(func(_ string, _ interface{}) {
+		atomic.AddInt32(&evictions, 1)
+	})
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprint("key", i)
+		cache.Set(key, i)
+		cache.Delete(key)
+	}
+
+	cache.Close()
+
+	assert.EqualValues(t, 1000, atomic.LoadInt32(&insertions))
+	assert.EqualValues(t, 1000, atomic.LoadInt32(&evictions))
+
+	// Events are still executed after the workers are stopped.
+	cache.Set("key", "value")
+	cache.Close()
+
+	assert.EqualValues(t, 1001, atomic.LoadInt32(&insertions))
+}
+
+func TestEventWorkersOverflow(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy EventOverflowPolicy
+		logged int
+	}{
+		{name: "drop", policy: EventOverflowDrop},
+		{name: "log", policy: EventOverflowLog, logged: 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := New(WithTTL(0), WithEventWorkers(1, 1, tt.policy))
+
+			var logged int32
+			cache.eventHandlers.pool.logf = func(_ string, _ ...any) {
+				atomic.AddInt32(&logged, 1)
+			}
+
+			release := make(chan struct{})
+			started := make(chan struct{})
+
+			var (
+				once     sync.Once
+				executed int32
+			)
+			cache.OnInsertion(func(_ string, _ interface{}) {
+				once.Do(func() { close(started) })
+				<-release
+				atomic.AddInt32(&executed, 1)
+			})
+
+			// The first event occupies the only worker.
+			cache.Set("key0", 0)
+			<-started
+
+			// The second one fills the queue, the rest are dropped.
+			for i := 1; i < 10; i++ {
+				cache.Set(fmt.Sprint("key", i), i)
+			}
+
+			close(release)
+			cache.Close()
+
+			assert.EqualValues(t, 2, atomic.LoadInt32(&executed))
+			assert.EqualValues(t, tt.logged, atomic.LoadInt32(&logged))
+			assert.Equal(t, 10, cache.Len())
+		})
+	}
+}
+
+func TestEventWorkersBlockingHandlerReadsCache(t *testing.T) {
+	cache := New(WithEventWorkers(1, 1, EventOverflowBlock))
+	defer cache.Close()
+
+	cache.OnInsertion(func(key string, value interface{}) {
+		time.Sleep(time.Millisecond)
+		cache.Has(key)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for i := 0; i < 100; i++ {
+			cache.Set(fmt.Sprint("key", i), i)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Set is blocked by the handler reading the cache")
+	}
+}
@@ -0,0 +1,151 @@
+package incache
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultEventsBufferSize is the default capacity of the Events channel.
+const defaultEventsBufferSize = 1024
+
+// EventType describes what happened with an item.
+type EventType int
+
+const (
+	// EventInsertion is sent when a new key is stored in the cache.
+	EventInsertion EventType = iota
+	// EventEviction is sent when an item is removed from the cache
+	// for any reason other than expiration.
+	EventEviction
+	// EventUpdate is sent when the value of an existing key is overwritten.
+	EventUpdate
+	// EventExpiration is sent when an item is removed because its TTL
+	// has passed.
+	EventExpiration
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EventInsertion:
+		return "insertion"
+	case EventEviction:
+		return "eviction"
+	case EventUpdate:
+		return "update"
+	case EventExpiration:
+		return "expiration"
+	default:
+		return "unknown"
+	}
+}
+
+// Event describes an activity of the cache.
+type Event struct {
+	Type  EventType
+	Key   string
+	Value interface{}
+	// OldValue is the overwritten value of an update.
+	OldValue interface{}
+	// Reason of an eviction, e.g. "deleted" or "expired".
+	// It's empty for other events.
+	Reason string
+	// Version of the stored item, or of the removed one for evictions and
+	// expirations, see Cache.GetVersioned.
+	Version uint64
+	Time    time.Time
+}
+
+// Events returns a channel that receives events about activity of the cache,
+// as an alternative to callbacks like OnInsertion and OnEviction.
+//
+// Every call returns the same channel. Events are only sent after the first
+// call. The channel is buffered (see WithEventsBuffer), and events are dropped
+// when the buffer is full, so operations of the cache never wait for
+// the consumer. The channel is closed by Close.
+func (c *Cache) Events() <-chan Event {
+	return c.events.subscribe()
+}
+
+// notifying reports whether anybody listens to events, so they don't
+// need to be created otherwise.
+func (c *Cache) notifying() bool {
+	return c.events.enabled() || c.watchers.enabled()
+}
+
+// notify delivers the event to the Events channel and to watchers.
+func (c *Cache) notify(event Event) {
+	c.events.publish(event)
+	c.watchers.publish(event)
+}
+
+// eventStream delivers events to the channel returned by Events.
+type eventStream struct {
+	// Set when the channel is created, so publishers don't need
+	// to take the lock when nobody is listening.
+	active int32
+
+	mu     sync.Mutex
+	ch     chan Event
+	size   int
+	closed bool
+}
+
+func newEventStream(size int) *eventStream {
+	return &eventStream{size: size}
+}
+
+func (s *eventStream) subscribe() <-chan Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ch == nil {
+		s.ch = make(chan Event, s.size)
+		if s.closed {
+			close(s.ch)
+		} else {
+			atomic.StoreInt32(&s.active, 1)
+		}
+	}
+
+	return s.ch
+}
+
+func (s *eventStream) enabled() bool {
+	return atomic.LoadInt32(&s.active) == 1
+}
+
+func (s *eventStream) publish(event Event) {
+	if !s.enabled() {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	select {
+	case s.ch <- event:
+	default:
+	}
+}
+
+// close closes the channel. It's safe to call it multiple times.
+func (s *eventStream) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	s.closed = true
+	atomic.StoreInt32(&s.active, 0)
+
+	if s.ch != nil {
+		close(s.ch)
+	}
+}
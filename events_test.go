@@ -0,0 +1,135 @@
+package incache
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvents(t *testing.T) {
+	cache := New(WithTTL(0))
+
+	cache.Set("ignored", "value")
+
+	events := cache.Events()
+	assert.Equal(t, events, cache.Events())
+
+	cache.Set("key1", "value1")
+	cache.SetWithTTL("key2", "value2", time.Millisecond)
+	cache.Set("key1", "value3")
+	cache.Delete("key1")
+	time.Sleep(2 * time.Millisecond)
+	cache.DeleteExpired()
+	cache.Close()
+
+	var received []Event
+	for event := range events {
+		assert.False(t, event.Time.IsZero())
+		event.Time = time.Time{}
+
+		received = append(received, event)
+	}
+
+	assert.Equal(t, []Event{
+		{Type: EventInsertion, Key: "key1", Value: "value1", Version: 2},
+		{Type: EventInsertion, Key: "key2", Value: "value2", Version: 3},
+		{Type: EventUpdate, Key: "key1", Value: "value3", OldValue: "value1", Version: 4},
+		{Type: EventEviction, Key: "key1", Value: "value3", Reason: "deleted", Version: 4},
+		{Type: EventExpiration, Key: "key2", Value: "value2", Reason: "expired", Version: 3},
+	}, received)
+}
+
+func TestBatchedEvictionEvents(t *testing.T) {
+	cache := New(WithTTL(0), WithMaxEntries(3))
+
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+	cache.Set("key3", "value3")
+
+	events := cache.Events()
+
+	cache.Resize(1)
+	cache.Close()
+
+	var received []Event
+	for event := range events {
+		event.Time = time.Time{}
+
+		received = append(received, event)
+	}
+
+	assert.Equal(t, []Event{
+		{Type: EventEviction, Key: "key1", Value: "value1", Reason: "capacity", Version: 1},
+		{Type: EventEviction, Key: "key2", Value: "value2", Reason: "capacity", Version: 2},
+	}, received)
+}
+
+func TestBatchedEvictionEventsAfterUnlock(t *testing.T) {
+	cache := New(WithTTL(0), WithMaxEntries(4), WithEventWorkers(1, 1, EventOverflowBlock))
+
+	for i := 0; i < 4; i++ {
+		cache.Set(fmt.Sprint("key", i), i)
+	}
+
+	// The handler reads the cache, so it would deadlock with the full queue
+	// if events were emitted while the lock is held.
+	var evicted int32
+	cache.OnEviction(func(key string, value interface{}) {
+		cache.Has(key)
+		atomic.AddInt32(&evicted, 1)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		cache.Resize(1)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Resize is blocked by event handlers")
+	}
+
+	cache.Close()
+	assert.Equal(t, int32(3), atomic.LoadInt32(&evicted))
+}
+
+func TestEventsBufferOverflow(t *testing.T) {
+	cache := New(WithTTL(0), WithEventsBuffer(2))
+
+	events := cache.Events()
+
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+	cache.Set("key3", "value3")
+	cache.Close()
+
+	var keys []string
+	for event := range events {
+		keys = append(keys, event.Key)
+	}
+
+	assert.Equal(t, []string{"key1", "key2"}, keys)
+}
+
+func TestEventsAfterClose(t *testing.T) {
+	cache := New(WithTTL(0))
+	cache.Close()
+
+	_, ok := <-cache.Events()
+	assert.False(t, ok)
+
+	cache.Set("key1", "value1")
+}
+
+func TestEventTypeString(t *testing.T) {
+	assert.Equal(t, "insertion", EventInsertion.String())
+	assert.Equal(t, "eviction", EventEviction.String())
+	assert.Equal(t, "update", EventUpdate.String())
+	assert.Equal(t, "expiration", EventExpiration.String())
+	assert.Equal(t, "unknown", EventType(-1).String())
+}
@@ -0,0 +1,117 @@
+package incache
+
+import "container/heap"
+
+// evictionQueue orders items for eviction when the cache is full: items
+// with lower priority first, and among items with the same priority,
+// the ones stored earlier first.
+type evictionQueue struct {
+	entries evictionHeap
+	index   map[string]*evictionEntry
+	// Incremented on every push, orders items with the same priority.
+	seq uint64
+}
+
+type evictionEntry struct {
+	key      string
+	priority int
+	seq      uint64
+	// Position of the entry in the heap.
+	pos int
+}
+
+func newEvictionQueue() *evictionQueue {
+	return &evictionQueue{
+		index: make(map[string]*evictionEntry),
+	}
+}
+
+// push adds the key to the queue or moves it to the end of its new priority.
+func (q *evictionQueue) push(key string, priority int) {
+	q.seq++
+
+	if entry, ok := q.index[key]; ok {
+		entry.priority = priority
+		entry.seq = q.seq
+		heap.Fix(&q.entries, entry.pos)
+
+		return
+	}
+
+	entry := &evictionEntry{key: key, priority: priority, seq: q.seq}
+	q.index[key] = entry
+	heap.Push(&q.entries, entry)
+}
+
+// remove removes the key from the queue, if it's there.
+func (q *evictionQueue) remove(key string) {
+	entry, ok := q.index[key]
+	if !ok {
+		return
+	}
+
+	delete(q.index, key)
+	heap.Remove(&q.entries, entry.pos)
+}
+
+// next returns the key that has to be evicted first.
+func (q *evictionQueue) next() (string, bool) {
+	if len(q.entries) == 0 {
+		return "", false
+	}
+
+	return q.entries[0].key, true
+}
+
+// compact rebuilds the index, so the memory of its buckets is released
+// after mass deletion, see Cache.compactLocked.
+func (q *evictionQueue) compact() {
+	index := make(map[string]*evictionEntry, len(q.index))
+	for key, entry := range q.index {
+		index[key] = entry
+	}
+
+	q.index = index
+	q.entries = append(evictionHeap(nil), q.entries...)
+}
+
+func (q *evictionQueue) reset() {
+	q.entries = nil
+	q.index = make(map[string]*evictionEntry)
+}
+
+// evictionHeap implements heap.Interface.
+type evictionHeap []*evictionEntry
+
+func (h evictionHeap) Len() int { return len(h) }
+
+func (h evictionHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority < h[j].priority
+	}
+
+	return h[i].seq < h[j].seq
+}
+
+func (h evictionHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].pos = i
+	h[j].pos = j
+}
+
+func (h *evictionHeap) Push(x any) {
+	entry := x.(*evictionEntry)
+	entry.pos = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *evictionHeap) Pop() any {
+	old := *h
+	n := len(old)
+
+	entry := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+
+	return entry
+}
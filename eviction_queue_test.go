@@ -0,0 +1,90 @@
+package incache
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvictionQueue(t *testing.T) {
+	q := newEvictionQueue()
+
+	q.push("key1", 1)
+	q.push("key2", 0)
+	q.push("key3", 0)
+	q.push("key4", 2)
+
+	key, ok := q.next()
+	assert.True(t, ok)
+	assert.Equal(t, "key2", key)
+
+	// Pushing the key again moves it to the end of its priority.
+	q.push("key2", 0)
+
+	key, _ = q.next()
+	assert.Equal(t, "key3", key)
+
+	q.remove("key3")
+	q.remove("key2")
+	q.remove("nokey")
+
+	key, _ = q.next()
+	assert.Equal(t, "key1", key)
+
+	q.reset()
+
+	_, ok = q.next()
+	assert.False(t, ok)
+}
+
+func TestMaxEntries(t *testing.T) {
+	cache := New(WithTTL(0), WithMaxEntries(3), WithMetrics())
+
+	for i := 0; i < 5; i++ {
+		cache.Set(fmt.Sprint("key", i), i)
+	}
+
+	assert.Equal(t, 3, cache.Len())
+	assert.ElementsMatch(t, []string{"key2", "key3", "key4"}, cache.Keys())
+	assert.EqualValues(t, 2, cache.Metrics().Evictions())
+
+	// Overwriting an existing key doesn't evict anything.
+	cache.Set("key2", "value")
+	assert.ElementsMatch(t, []string{"key2", "key3", "key4"}, cache.Keys())
+}
+
+func TestSetWithPriority(t *testing.T) {
+	cache := New(WithTTL(0), WithMaxEntries(3))
+
+	cache.SetWithPriority("expensive", "value", 10)
+	cache.Set("cheap1", "value")
+	cache.SetWithPriority("medium", "value", 5)
+	cache.Set("cheap2", "value")
+	cache.Set("cheap3", "value")
+	cache.Set("cheap4", "value")
+
+	assert.ElementsMatch(t, []string{"expensive", "medium", "cheap4"}, cache.Keys())
+
+	cache.SetWithPriority("expensive2", "value", 10)
+	cache.SetWithPriority("expensive3", "value", 10)
+
+	assert.ElementsMatch(t, []string{"expensive", "expensive2", "expensive3"}, cache.Keys())
+}
+
+func TestMaxEntriesAfterDeleteAndFlush(t *testing.T) {
+	cache := New(WithTTL(0), WithMaxEntries(2))
+
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+	cache.Delete("key1")
+	cache.Set("key3", "value3")
+
+	assert.ElementsMatch(t, []string{"key2", "key3"}, cache.Keys())
+
+	cache.FlushAll()
+	cache.Set("key4", "value4")
+	cache.Set("key5", "value5")
+
+	assert.ElementsMatch(t, []string{"key4", "key5"}, cache.Keys())
+}
@@ -0,0 +1,50 @@
+package incache
+
+// evictionReason describes why an item was removed from the cache.
+type evictionReason int
+
+const (
+	// The item was removed by one of the delete methods.
+	reasonDeleted evictionReason = iota
+	// The item was removed because its TTL has passed.
+	reasonExpired
+	// The item was removed by FlushAll.
+	reasonFlushed
+	// The item was removed to make room for a new one, see WithMaxEntries.
+	reasonCapacity
+	// The item was removed on demand by EvictN or Prune.
+	reasonPruned
+	// The item was removed because of memory pressure,
+	// see WithMemoryWatermark.
+	reasonMemory
+	// The value was reclaimed by the GC, see SetWeak.
+	reasonReclaimed
+)
+
+// batched reports whether items are removed for the reason in batches,
+// e.g. when a store evicts several items at once. Events of such evictions
+// are emitted after the lock is released, see Cache.unlock.
+func (r evictionReason) batched() bool {
+	return r == reasonCapacity || r == reasonPruned || r == reasonMemory
+}
+
+func (r evictionReason) String() string {
+	switch r {
+	case reasonDeleted:
+		return "deleted"
+	case reasonExpired:
+		return "expired"
+	case reasonFlushed:
+		return "flushed"
+	case reasonCapacity:
+		return "capacity"
+	case reasonPruned:
+		return "pruned"
+	case reasonMemory:
+		return "memory"
+	case reasonReclaimed:
+		return "reclaimed"
+	default:
+		return "unknown"
+	}
+}
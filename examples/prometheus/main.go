@@ -9,6 +9,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/wittyjudge/incache"
+	"github.com/wittyjudge/incache/incacheprom"
 )
 
 func main() {
@@ -32,54 +33,8 @@ func performCacheOperations(cache *incache.Cache) {
 }
 
 func registerAndExposeMetrics(cache *incache.Cache) {
-	// Get the cache metrics
-	metrics := cache.Metrics()
-
 	// Register cache metrics with Prometheus
-	prometheus.MustRegister(prometheus.NewCounterFunc(
-		prometheus.CounterOpts{
-			Name: "incache_items_inserted_total",
-			Help: "Number of items inserted",
-		},
-		func() float64 {
-			return float64(metrics.Insertions())
-		}))
-
-	prometheus.MustRegister(prometheus.NewCounterFunc(
-		prometheus.CounterOpts{
-			Name: "incache_items_hitted_total",
-			Help: "Number of items hitted",
-		},
-		func() float64 {
-			return float64(metrics.Hits())
-		}))
-
-	prometheus.MustRegister(prometheus.NewCounterFunc(
-		prometheus.CounterOpts{
-			Name: "incache_items_missed_total",
-			Help: "Number of items missed",
-		},
-		func() float64 {
-			return float64(metrics.Misses())
-		}))
-
-	prometheus.MustRegister(prometheus.NewCounterFunc(
-		prometheus.CounterOpts{
-			Name: "incache_items_evicted_total",
-			Help: "Number of items evicted",
-		},
-		func() float64 {
-			return float64(metrics.Evictions())
-		}))
-
-	prometheus.MustRegister(prometheus.NewCounterFunc(
-		prometheus.CounterOpts{
-			Name: "incache_items_count_current",
-			Help: "Number of items currently stored in cache",
-		},
-		func() float64 {
-			return float64(cache.Len())
-		}))
+	prometheus.MustRegister(incacheprom.NewCollector(cache, incacheprom.Opts{}))
 }
 
 func startMetricsServer() {
@@ -0,0 +1,115 @@
+package incache
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// expirationBucketBounds are upper bounds of the buckets of
+// ExpirationHistogram, besides the overflow one.
+var expirationBucketBounds = []time.Duration{
+	time.Second,
+	10 * time.Second,
+	time.Minute,
+	5 * time.Minute,
+	15 * time.Minute,
+	time.Hour,
+	6 * time.Hour,
+	24 * time.Hour,
+}
+
+// ExpirationBucket holds the number of items whose time to expiry is less
+// than or equal to UpperBound and bigger than the upper bound of the
+// previous bucket.
+type ExpirationBucket struct {
+	// UpperBound of the last bucket is math.MaxInt64, it collects everything
+	// that doesn't fit into other buckets.
+	UpperBound time.Duration
+	Count      int
+}
+
+// ExpiringWithin returns keys of the items that expire within d, sorted by
+// their expiration time. Items that have already expired, but weren't
+// removed yet, aren't included.
+func (c *Cache) ExpiringWithin(d time.Duration) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	deadline := now.Add(d)
+
+	keys := []string{}
+	for key, expiresAt := range c.expirationsQueue {
+		if expiresAt.After(now) && !expiresAt.After(deadline) {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return c.expirationsQueue[keys[i]].Before(c.expirationsQueue[keys[j]])
+	})
+
+	return keys
+}
+
+// ExpirationSummary describes the expiration of the items, see
+// Cache.ExpirationSummary.
+type ExpirationSummary struct {
+	// Buckets is the distribution of time to expiry, see
+	// Cache.ExpirationHistogram.
+	Buckets []ExpirationBucket
+	// Expiring is the number of the items counted in Buckets and
+	// RemainingTTL is the sum of their time to expiry.
+	Expiring     int
+	RemainingTTL time.Duration
+	// NeverExpiring is the number of the items without TTL.
+	NeverExpiring int
+}
+
+// ExpirationHistogram returns the distribution of time to expiry of
+// the items, which allows to anticipate mass expiration and tune the TTL
+// jitter, see WithTTLJitter. Items without TTL and items that have already
+// expired aren't counted.
+func (c *Cache) ExpirationHistogram() []ExpirationBucket {
+	return c.ExpirationSummary().Buckets
+}
+
+// ExpirationSummary returns the distribution of time to expiry of
+// the items along with the number of the items without TTL, e.g. to show
+// whether the cache is dominated by items that never expire.
+func (c *Cache) ExpirationSummary() ExpirationSummary {
+	buckets := make([]ExpirationBucket, len(expirationBucketBounds)+1)
+	for i, bound := range expirationBucketBounds {
+		buckets[i].UpperBound = bound
+	}
+	buckets[len(buckets)-1].UpperBound = math.MaxInt64
+
+	summary := ExpirationSummary{Buckets: buckets}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+	for _, item := range c.items {
+		if !item.CanExpire() {
+			summary.NeverExpiring++
+			continue
+		}
+
+		ttl := item.ExpiresAt.Sub(now)
+		if ttl <= 0 {
+			continue
+		}
+
+		i := sort.Search(len(expirationBucketBounds), func(i int) bool {
+			return ttl <= expirationBucketBounds[i]
+		})
+		buckets[i].Count++
+
+		summary.Expiring++
+		summary.RemainingTTL += ttl
+	}
+
+	return summary
+}
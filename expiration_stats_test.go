@@ -0,0 +1,86 @@
+package incache
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpiringWithin(t *testing.T) {
+	cache := New(WithTTL(0), WithCleanupInterval(0))
+
+	cache.SetWithTTL("key1", "value1", 30*time.Second)
+	cache.SetWithTTL("key2", "value2", 10*time.Second)
+	cache.SetWithTTL("key3", "value3", time.Hour)
+	cache.SetWithTTL("expired", "value", time.Millisecond)
+	cache.Set("persistent", "value")
+
+	time.Sleep(2 * time.Millisecond)
+
+	assert.Equal(t, []string{"key2", "key1"}, cache.ExpiringWithin(time.Minute))
+	assert.Equal(t, []string{"key2", "key1", "key3"}, cache.ExpiringWithin(24*time.Hour))
+	assert.Empty(t, cache.ExpiringWithin(time.Second))
+}
+
+func TestExpirationHistogram(t *testing.T) {
+	cache := New(WithTTL(0), WithCleanupInterval(0))
+
+	cache.SetWithTTL("key1", "value1", 500*time.Millisecond)
+	cache.SetWithTTL("key2", "value2", 30*time.Second)
+	cache.SetWithTTL("key3", "value3", 45*time.Second)
+	cache.SetWithTTL("key4", "value4", 48*time.Hour)
+	cache.SetWithTTL("expired", "value", time.Millisecond)
+	cache.Set("persistent", "value")
+
+	time.Sleep(2 * time.Millisecond)
+
+	histogram := cache.ExpirationHistogram()
+	assert.Len(t, histogram, 9)
+
+	assert.Equal(t, ExpirationBucket{UpperBound: time.Second, Count: 1}, histogram[0])
+	assert.Equal(t, ExpirationBucket{UpperBound: 10 * time.Second, Count: 0}, histogram[1])
+	assert.Equal(t, ExpirationBucket{UpperBound: time.Minute, Count: 2}, histogram[2])
+	assert.Equal(t, ExpirationBucket{UpperBound: math.MaxInt64, Count: 1}, histogram[8])
+}
+
+func TestExpirationSummary(t *testing.T) {
+	cache := New(WithTTL(0), WithCleanupInterval(0))
+
+	cache.SetWithTTL("key1", "value1", 30*time.Second)
+	cache.SetWithTTL("key2", "value2", 2*time.Hour)
+	cache.SetWithTTL("expired", "value", time.Millisecond)
+	cache.Set("persistent1", "value")
+	cache.Set("persistent2", "value")
+
+	time.Sleep(2 * time.Millisecond)
+
+	summary := cache.ExpirationSummary()
+	assert.Equal(t, 2, summary.Expiring)
+	assert.Equal(t, 2, summary.NeverExpiring)
+	assert.InDelta(t, float64(2*time.Hour+30*time.Second), float64(summary.RemainingTTL), float64(time.Second))
+	assert.Equal(t, 1, summary.Buckets[2].Count)
+	assert.Equal(t, 1, summary.Buckets[6].Count)
+}
+
+func TestExpirationSummaryWithUncollectedItems(t *testing.T) {
+	cache := New(WithTTL(0), WithCleanupInterval(0))
+
+	for i := 0; i < 3; i++ {
+		cache.SetWithTTL(fmt.Sprint("expired", i), "value", time.Millisecond)
+	}
+	cache.Set("persistent", "value")
+
+	time.Sleep(2 * time.Millisecond)
+
+	// A queue entry without an item doesn't affect the summary either.
+	cache.mu.Lock()
+	cache.expirationsQueue["missing"] = time.Now().Add(time.Hour)
+	cache.mu.Unlock()
+
+	summary := cache.ExpirationSummary()
+	assert.Zero(t, summary.Expiring)
+	assert.Equal(t, 1, summary.NeverExpiring)
+}
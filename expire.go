@@ -0,0 +1,95 @@
+package incache
+
+import "time"
+
+// Expire sets the TTL of an existing key without changing its value, and
+// reports whether the key exists. Similar to Redis, TTL <= 0 deletes the key.
+func (c *Cache) Expire(key string, ttl time.Duration) bool {
+	return c.expire(key, time.Now().Add(ttl), ttl)
+}
+
+// ExpireAt sets the expiration time of an existing key without changing its
+// value, and reports whether the key exists. Similar to Redis, a time in
+// the past deletes the key.
+func (c *Cache) ExpireAt(key string, t time.Time) bool {
+	t = monotonic(t)
+
+	return c.expire(key, t, time.Until(t))
+}
+
+func (c *Cache) expire(key string, t time.Time, ttl time.Duration) bool {
+	c.mu.Lock()
+	defer c.unlock()
+
+	item, ok := c.liveItemLocked(key)
+	if !ok {
+		return false
+	}
+
+	if ttl <= 0 {
+		c.evictLocked(key, reasonDeleted)
+		return true
+	}
+
+	item.TTL = ttl
+	item.ExpiresAt = t
+	c.updateExpirationLocked(key, item)
+
+	return true
+}
+
+// Persist removes the expiration of an existing key, so it never expires,
+// and reports whether the key had one.
+func (c *Cache) Persist(key string) bool {
+	c.mu.Lock()
+	defer c.unlock()
+
+	item, ok := c.liveItemLocked(key)
+	if !ok || !item.CanExpire() {
+		return false
+	}
+
+	item.TTL = 0
+	item.ExpiresAt = time.Time{}
+	c.updateExpirationLocked(key, item)
+
+	return true
+}
+
+// liveItemLocked returns the item of key, unless it doesn't exist or
+// is expired. It must be called with at least the read lock held.
+func (c *Cache) liveItemLocked(key string) (Item, bool) {
+	item, ok := c.items[key]
+	if !ok || item.Expired() {
+		return Item{}, false
+	}
+
+	return item, true
+}
+
+// updateExpirationLocked stores the item with changed expiration.
+// It must be called with the write lock held.
+func (c *Cache) updateExpirationLocked(key string, item Item) {
+	c.items[key] = item
+	c.mirrorLocked(key, item)
+
+	if item.CanExpire() {
+		c.expirationsQueue[key] = item.ExpiresAt
+
+		if c.cleaner != nil {
+			c.cleaner.wake()
+		}
+	} else {
+		delete(c.expirationsQueue, key)
+	}
+
+	if c.writeBehind != nil {
+		c.writeBehindLocked(BackendOp{Key: key, Value: c.value(item.Value), TTL: item.remainingTTL()})
+	}
+
+	// The log has no record for expiration changes, so the whole item is
+	// appended again.
+	c.logSetLocked(key, item)
+
+	c.config.debugf("[expire] key: '%s', expires at: %s", key, item.ExpiresAt)
+}
@@ -0,0 +1,72 @@
+package incache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpire(t *testing.T) {
+	cache := New(WithTTL(0), WithCleanupInterval(0))
+
+	assert.False(t, cache.Expire("key1", time.Minute))
+
+	cache.Set("key1", "value1")
+	_, version, _ := cache.GetVersioned("key1")
+
+	assert.True(t, cache.Expire("key1", time.Minute))
+
+	info, _ := cache.Inspect("key1")
+	assert.Equal(t, time.Minute, info.TTL)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), info.ExpiresAt, time.Second)
+	assert.Equal(t, info.ExpiresAt, cache.expirationsQueue["key1"])
+	assert.Equal(t, version, info.Version)
+	assert.Equal(t, "value1", cache.Get("key1"))
+
+	assert.True(t, cache.Expire("key1", time.Millisecond))
+	time.Sleep(2 * time.Millisecond)
+	assert.Nil(t, cache.Get("key1"))
+	assert.False(t, cache.Expire("key1", time.Minute))
+}
+
+func TestExpireDeletesWithNonPositiveTTL(t *testing.T) {
+	cache := New(WithTTL(0))
+
+	cache.Set("key1", "value1")
+
+	assert.True(t, cache.Expire("key1", 0))
+	assert.False(t, cache.Has("key1"))
+}
+
+func TestExpireAt(t *testing.T) {
+	cache := New(WithTTL(0))
+
+	cache.Set("key1", "value1")
+	at := time.Now().Add(time.Hour)
+
+	assert.True(t, cache.ExpireAt("key1", at))
+
+	info, _ := cache.Inspect("key1")
+	assert.Equal(t, at, info.ExpiresAt)
+
+	assert.True(t, cache.ExpireAt("key1", time.Now().Add(-time.Second)))
+	assert.False(t, cache.Has("key1"))
+}
+
+func TestPersist(t *testing.T) {
+	cache := New(WithTTL(0))
+
+	assert.False(t, cache.Persist("key1"))
+
+	cache.Set("key1", "value1")
+	assert.False(t, cache.Persist("key1"))
+
+	cache.Expire("key1", time.Minute)
+	assert.True(t, cache.Persist("key1"))
+
+	info, _ := cache.Inspect("key1")
+	assert.Zero(t, info.TTL)
+	assert.True(t, info.ExpiresAt.IsZero())
+	assert.NotContains(t, cache.expirationsQueue, "key1")
+}
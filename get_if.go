@@ -0,0 +1,39 @@
+package incache
+
+// GetIf returns the value of key if cond reports true for it, e.g. only if
+// the value is newer than the one the caller has. cond is called under the
+// read lock, so the value can't be replaced between the check and the read,
+// as it can between separate Has and Get calls. cond must not access
+// the cache.
+//
+// If the key doesn't exist or cond reports false, nil value and false are
+// returned and the read is counted as a miss in metrics.
+func (c *Cache) GetIf(key string, cond func(value interface{}) bool) (interface{}, bool) {
+	c.hooks.beforeGet(key)
+
+	value, ok := c.lookupIf(key, cond)
+	c.hooks.afterGet(key, value)
+
+	return value, ok
+}
+
+func (c *Cache) lookupIf(key string, cond func(value interface{}) bool) (interface{}, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	item, ok := c.items[key]
+	if !ok || item.Expired() || reclaimed(item.Value) {
+		c.countMiss(key)
+		return nil, false
+	}
+
+	value := c.copyValue(c.value(item.Value))
+	if !cond(value) {
+		c.countMiss(key)
+		return nil, false
+	}
+
+	c.countHit(key)
+
+	return value, true
+}
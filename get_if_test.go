@@ -0,0 +1,53 @@
+package incache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetIf(t *testing.T) {
+	cache := New(WithTTL(0), WithMetrics())
+	defer cache.Close()
+
+	cache.Set("key1", 2)
+
+	newerThan := func(version int) func(value interface{}) bool {
+		return func(value interface{}) bool {
+			return value.(int) > version
+		}
+	}
+
+	value, ok := cache.GetIf("key1", newerThan(1))
+	assert.True(t, ok)
+	assert.Equal(t, 2, value)
+
+	value, ok = cache.GetIf("key1", newerThan(2))
+	assert.False(t, ok)
+	assert.Nil(t, value)
+
+	value, ok = cache.GetIf("key2", newerThan(0))
+	assert.False(t, ok)
+	assert.Nil(t, value)
+
+	assert.EqualValues(t, 1, cache.Metrics().Hits())
+	assert.EqualValues(t, 2, cache.Metrics().Misses())
+}
+
+func TestGetIfExpired(t *testing.T) {
+	cache := New(WithCleanupInterval(0))
+	defer cache.Close()
+
+	cache.SetWithTTL("key1", "value1", time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+
+	called := false
+	_, ok := cache.GetIf("key1", func(value interface{}) bool {
+		called = true
+		return true
+	})
+
+	assert.False(t, ok)
+	assert.False(t, called)
+}
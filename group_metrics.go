@@ -0,0 +1,133 @@
+package incache
+
+import (
+	"strings"
+	"sync"
+)
+
+// GroupMetrics holds the metrics of a group of keys, see WithKeyGrouper.
+type GroupMetrics struct {
+	Insertions uint64
+	Hits       uint64
+	Misses     uint64
+}
+
+// GroupMetrics returns the metrics of the groups of keys defined by the
+// function set with WithKeyGrouper, by group name. Nil is returned when
+// metrics aren't enabled or there is no grouper.
+func (c *Cache) GroupMetrics() map[string]GroupMetrics {
+	if c.keyGroups == nil {
+		return nil
+	}
+
+	return c.keyGroups.snapshot()
+}
+
+// GroupByPrefix returns a key grouper for WithKeyGrouper that groups keys by
+// the part before the first separator, e.g. "users" for "users:1". Keys
+// without the separator are grouped under the empty name.
+func GroupByPrefix(separator string) func(key string) string {
+	return func(key string) string {
+		if i := strings.Index(key, separator); i >= 0 {
+			return key[:i]
+		}
+
+		return ""
+	}
+}
+
+// keyGroups tallies metrics by group of keys.
+type keyGroups struct {
+	group func(key string) string
+	// Counters by group name.
+	counters sync.Map
+}
+
+type groupCounters struct {
+	insertions counter
+	hits       counter
+	misses     counter
+}
+
+func newKeyGroups(group func(key string) string) *keyGroups {
+	return &keyGroups{group: group}
+}
+
+func (g *keyGroups) countersOf(key string) *groupCounters {
+	name := g.group(key)
+
+	if counters, ok := g.counters.Load(name); ok {
+		return counters.(*groupCounters)
+	}
+
+	counters, _ := g.counters.LoadOrStore(name, &groupCounters{})
+
+	return counters.(*groupCounters)
+}
+
+func (g *keyGroups) snapshot() map[string]GroupMetrics {
+	groups := make(map[string]GroupMetrics)
+
+	g.counters.Range(func(name, value any) bool {
+		counters := value.(*groupCounters)
+
+		groups[name.(string)] = GroupMetrics{
+			Insertions: counters.insertions.load(),
+			Hits:       counters.hits.load(),
+			Misses:     counters.misses.load(),
+		}
+
+		return true
+	})
+
+	return groups
+}
+
+func (g *keyGroups) reset() {
+	g.counters.Range(func(name, _ any) bool {
+		g.counters.Delete(name)
+		return true
+	})
+}
+
+// countInsertion records the insertion of key in metrics and the trace.
+func (c *Cache) countInsertion(key string) {
+	c.metrics.incrementInsertions()
+	c.trace(TraceSet, key, false)
+
+	if c.keyGroups != nil {
+		c.keyGroups.countersOf(key).insertions.increment()
+	}
+
+	if c.namespaces != nil {
+		c.namespaces.each(key, func(counters *groupCounters) { counters.insertions.increment() })
+	}
+}
+
+// countHit records the successful read of key in metrics and the trace.
+func (c *Cache) countHit(key string) {
+	c.metrics.incrementHits()
+	c.trace(TraceGet, key, true)
+
+	if c.keyGroups != nil {
+		c.keyGroups.countersOf(key).hits.increment()
+	}
+
+	if c.namespaces != nil {
+		c.namespaces.each(key, func(counters *groupCounters) { counters.hits.increment() })
+	}
+}
+
+// countMiss records the failed read of key in metrics and the trace.
+func (c *Cache) countMiss(key string) {
+	c.metrics.incrementMisses()
+	c.trace(TraceGet, key, false)
+
+	if c.keyGroups != nil {
+		c.keyGroups.countersOf(key).misses.increment()
+	}
+
+	if c.namespaces != nil {
+		c.namespaces.each(key, func(counters *groupCounters) { counters.misses.increment() })
+	}
+}
@@ -0,0 +1,47 @@
+package incache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupMetrics(t *testing.T) {
+	cache := New(WithMetrics(), WithKeyGrouper(GroupByPrefix(":")))
+
+	cache.Set("users:1", "user1")
+	cache.Set("users:2", "user2")
+	cache.Set("orders:1", "order1")
+	cache.Set("config", "value")
+
+	cache.Get("users:1")
+	cache.Get("users:3")
+	cache.Get("orders:1")
+	cache.GetStale("orders:2")
+
+	assert.Equal(t, map[string]GroupMetrics{
+		"users":  {Insertions: 2, Hits: 1, Misses: 1},
+		"orders": {Insertions: 1, Hits: 1, Misses: 1},
+		"":       {Insertions: 1},
+	}, cache.GroupMetrics())
+
+	assert.EqualValues(t, 4, cache.Metrics().Insertions())
+
+	cache.ResetMetrics()
+	assert.Empty(t, cache.GroupMetrics())
+}
+
+func TestGroupMetricsWithoutMetrics(t *testing.T) {
+	cache := New(WithKeyGrouper(GroupByPrefix(":")))
+
+	cache.Set("users:1", "user1")
+
+	assert.Nil(t, cache.GroupMetrics())
+}
+
+func TestGroupByPrefix(t *testing.T) {
+	group := GroupByPrefix(":")
+
+	assert.Equal(t, "users", group("users:1:name"))
+	assert.Equal(t, "", group("config"))
+}
@@ -0,0 +1,220 @@
+package incache
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Handler returns an HTTP handler that exposes the cache for operational
+// troubleshooting. All responses are encoded as JSON.
+//
+// Supported endpoints:
+//
+//	GET    /keys         lists keys, optionally filtered by the prefix query parameter
+//	GET    /scan         lists keys page by page, see Cache.Scan; takes the cursor,
+//	                     count and prefix query parameters
+//	GET    /keys/{key}   shows metadata of the item stored by key
+//	DELETE /keys/{key}   deletes the item stored by key
+//	POST   /flush        deletes all items
+//	GET    /stats        shows the number of items, memory usage and metrics
+//
+// The handler expects paths relative to the mount point, so use
+// http.StripPrefix to mount it:
+//
+//	http.Handle("/debug/incache/", http.StripPrefix("/debug/incache", cache.Handler()))
+//
+// The handler doesn't perform any authorization, don't expose it publicly.
+func (c *Cache) Handler() http.Handler {
+	return &handler{cache: c}
+}
+
+type handler struct {
+	cache *Cache
+}
+
+type entryInfoResponse struct {
+	Key       string     `json:"key"`
+	ValueType string     `json:"value_type"`
+	Size      uint64     `json:"size"`
+	TTL       string     `json:"ttl,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Expired   bool       `json:"expired"`
+}
+
+type statsResponse struct {
+	Len         int               `json:"len"`
+	MemoryUsage uint64            `json:"memory_usage"`
+	Metrics     map[string]uint64 `json:"metrics"`
+	// Distribution of time to expiry, see Cache.ExpirationHistogram.
+	Expiration []expirationBucketResponse `json:"expiration"`
+}
+
+type expirationBucketResponse struct {
+	// UpperBound of the last bucket is "+Inf".
+	UpperBound string `json:"upper_bound"`
+	Count      int    `json:"count"`
+}
+
+type scanResponse struct {
+	Keys []string `json:"keys"`
+	// Zero when the iteration is complete.
+	Cursor uint64 `json:"cursor"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := "/" + strings.TrimPrefix(r.URL.Path, "/")
+
+	switch {
+	case path == "/keys":
+		h.allowMethods(w, r, h.listKeys, http.MethodGet)
+	case path == "/scan":
+		h.allowMethods(w, r, h.scan, http.MethodGet)
+	case strings.HasPrefix(path, "/keys/"):
+		key := strings.TrimPrefix(path, "/keys/")
+
+		switch r.Method {
+		case http.MethodGet:
+			h.inspectKey(w, key)
+		case http.MethodDelete:
+			h.deleteKey(w, key)
+		default:
+			h.methodNotAllowed(w, http.MethodGet, http.MethodDelete)
+		}
+	case path == "/flush":
+		h.allowMethods(w, r, h.flush, http.MethodPost)
+	case path == "/stats":
+		h.allowMethods(w, r, h.stats, http.MethodGet)
+	default:
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "not found"})
+	}
+}
+
+func (h *handler) listKeys(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+
+	keys := []string{}
+	for _, key := range h.cache.Keys() {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	writeJSON(w, http.StatusOK, keys)
+}
+
+func (h *handler) scan(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var cursor uint64
+	if s := query.Get("cursor"); s != "" {
+		var err error
+		if cursor, err = strconv.ParseUint(s, 10, 64); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid cursor"})
+			return
+		}
+	}
+
+	var count int
+	if s := query.Get("count"); s != "" {
+		var err error
+		if count, err = strconv.Atoi(s); err != nil {
+			writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid count"})
+			return
+		}
+	}
+
+	keys, next := h.cache.Scan(cursor, count, query.Get("prefix"))
+
+	writeJSON(w, http.StatusOK, scanResponse{Keys: keys, Cursor: next})
+}
+
+func (h *handler) inspectKey(w http.ResponseWriter, key string) {
+	info, ok := h.cache.Inspect(key)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "key not found"})
+		return
+	}
+
+	response := entryInfoResponse{
+		Key:       info.Key,
+		ValueType: info.ValueType,
+		Size:      info.Size,
+		Expired:   info.Expired,
+	}
+
+	if !info.ExpiresAt.IsZero() {
+		response.TTL = info.TTL.String()
+		response.ExpiresAt = &info.ExpiresAt
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (h *handler) deleteKey(w http.ResponseWriter, key string) {
+	if !h.cache.delete(key) {
+		writeJSON(w, http.StatusNotFound, errorResponse{Error: "key not found"})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) flush(w http.ResponseWriter, _ *http.Request) {
+	h.cache.FlushAll()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) stats(w http.ResponseWriter, _ *http.Request) {
+	metrics := h.cache.Metrics()
+
+	expiration := []expirationBucketResponse{}
+	for _, bucket := range h.cache.ExpirationHistogram() {
+		upperBound := bucket.UpperBound.String()
+		if bucket.UpperBound == math.MaxInt64 {
+			upperBound = "+Inf"
+		}
+
+		expiration = append(expiration, expirationBucketResponse{UpperBound: upperBound, Count: bucket.Count})
+	}
+
+	writeJSON(w, http.StatusOK, statsResponse{
+		Len:         h.cache.Len(),
+		MemoryUsage: h.cache.MemoryUsage(),
+		Metrics:     metrics.AsMap(),
+		Expiration:  expiration,
+	})
+}
+
+func (h *handler) allowMethods(w http.ResponseWriter, r *http.Request, fn http.HandlerFunc, methods ...string) {
+	for _, method := range methods {
+		if r.Method == method {
+			fn(w, r)
+			return
+		}
+	}
+
+	h.methodNotAllowed(w, methods...)
+}
+
+func (h *handler) methodNotAllowed(w http.ResponseWriter, methods ...string) {
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,154 @@
+package incache
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveHandler(cache *Cache, method, target string) *httptest.ResponseRecorder {
+	handler := http.StripPrefix("/debug/incache", cache.Handler())
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(method, target, nil))
+
+	return recorder
+}
+
+func TestHandlerListKeys(t *testing.T) {
+	cache := New()
+
+	cache.Set("user:2", "value")
+	cache.Set("user:1", "value")
+	cache.Set("order:1", "value")
+
+	response := serveHandler(cache, http.MethodGet, "/debug/incache/keys")
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.Equal(t, "application/json", response.Header().Get("Content-Type"))
+	assert.JSONEq(t, `["order:1", "user:1", "user:2"]`, response.Body.String())
+
+	response = serveHandler(cache, http.MethodGet, "/debug/incache/keys?prefix=user:")
+	assert.JSONEq(t, `["user:1", "user:2"]`, response.Body.String())
+
+	response = serveHandler(cache, http.MethodGet, "/debug/incache/keys?prefix=none")
+	assert.JSONEq(t, `[]`, response.Body.String())
+}
+
+func TestHandlerScan(t *testing.T) {
+	cache := New()
+
+	cache.Set("user:1", "value")
+	cache.Set("user:2", "value")
+	cache.Set("order:1", "value")
+
+	response := serveHandler(cache, http.MethodGet, "/debug/incache/scan?count=1&prefix=user:")
+	require.Equal(t, http.StatusOK, response.Code)
+
+	var page scanResponse
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &page))
+	assert.Equal(t, []string{"user:1"}, page.Keys)
+	require.NotZero(t, page.Cursor)
+
+	response = serveHandler(cache, http.MethodGet, fmt.Sprintf("/debug/incache/scan?count=1&prefix=user:&cursor=%d", page.Cursor))
+	assert.JSONEq(t, `{"keys": ["user:2"], "cursor": 0}`, response.Body.String())
+
+	response = serveHandler(cache, http.MethodGet, "/debug/incache/scan?cursor=x")
+	assert.Equal(t, http.StatusBadRequest, response.Code)
+}
+
+func TestHandlerInspectKey(t *testing.T) {
+	cache := New()
+
+	cache.SetWithTTL("key1", "value1", time.Minute)
+	cache.SetWithTTL("key/2", 2, 0)
+
+	response := serveHandler(cache, http.MethodGet, "/debug/incache/keys/key1")
+	require.Equal(t, http.StatusOK, response.Code)
+
+	var info map[string]interface{}
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &info))
+	assert.Equal(t, "key1", info["key"])
+	assert.Equal(t, "string", info["value_type"])
+	assert.Equal(t, "1m0s", info["ttl"])
+	assert.Contains(t, info, "expires_at")
+	assert.Equal(t, false, info["expired"])
+
+	response = serveHandler(cache, http.MethodGet, "/debug/incache/keys/key%2F2")
+	require.Equal(t, http.StatusOK, response.Code)
+	assert.JSONEq(t, `{"key": "key/2", "value_type": "int", "size": 8, "expired": false}`, response.Body.String())
+
+	response = serveHandler(cache, http.MethodGet, "/debug/incache/keys/key3")
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
+
+func TestHandlerDeleteKey(t *testing.T) {
+	cache := New()
+
+	cache.Set("key1", "value1")
+
+	response := serveHandler(cache, http.MethodDelete, "/debug/incache/keys/key1")
+	assert.Equal(t, http.StatusNoContent, response.Code)
+	assert.False(t, cache.Has("key1"))
+
+	response = serveHandler(cache, http.MethodDelete, "/debug/incache/keys/key1")
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
+
+func TestHandlerFlush(t *testing.T) {
+	cache := New()
+
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+
+	response := serveHandler(cache, http.MethodPost, "/debug/incache/flush")
+	assert.Equal(t, http.StatusNoContent, response.Code)
+	assert.Zero(t, cache.Len())
+}
+
+func TestHandlerStats(t *testing.T) {
+	cache := New(WithMetrics())
+
+	cache.Set("key1", "value1")
+	cache.Get("key1")
+	cache.Get("key2")
+
+	response := serveHandler(cache, http.MethodGet, "/debug/incache/stats")
+	require.Equal(t, http.StatusOK, response.Code)
+
+	var stats statsResponse
+	require.NoError(t, json.Unmarshal(response.Body.Bytes(), &stats))
+	assert.Equal(t, 1, stats.Len)
+	assert.Equal(t, cache.MemoryUsage(), stats.MemoryUsage)
+	assert.EqualValues(t, 1, stats.Metrics["insertions"])
+	assert.EqualValues(t, 1, stats.Metrics["hits"])
+	assert.EqualValues(t, 1, stats.Metrics["misses"])
+
+	// The default TTL is 5 minutes.
+	assert.Len(t, stats.Expiration, 9)
+	assert.Equal(t, expirationBucketResponse{UpperBound: "5m0s", Count: 1}, stats.Expiration[3])
+	assert.Equal(t, "+Inf", stats.Expiration[8].UpperBound)
+}
+
+func TestHandlerErrors(t *testing.T) {
+	cache := New()
+
+	response := serveHandler(cache, http.MethodPost, "/debug/incache/keys")
+	assert.Equal(t, http.StatusMethodNotAllowed, response.Code)
+	assert.Equal(t, "GET", response.Header().Get("Allow"))
+
+	response = serveHandler(cache, http.MethodPut, "/debug/incache/keys/key1")
+	assert.Equal(t, http.StatusMethodNotAllowed, response.Code)
+	assert.Equal(t, "GET, DELETE", response.Header().Get("Allow"))
+
+	response = serveHandler(cache, http.MethodGet, "/debug/incache/flush")
+	assert.Equal(t, http.StatusMethodNotAllowed, response.Code)
+
+	response = serveHandler(cache, http.MethodGet, "/debug/incache/unknown")
+	assert.Equal(t, http.StatusNotFound, response.Code)
+}
@@ -0,0 +1,82 @@
+package incache
+
+import "time"
+
+// Hook allows to intercept cache operations, e.g. to add tracing, auditing
+// or custom metrics.
+//
+// Hooks are called synchronously in the goroutine that performs the
+// operation, but never while the cache lock is held, so it's safe to use
+// the cache from within a hook. Slow hooks slow down the cache operations.
+//
+// Embed NoopHook to implement only the methods you need.
+type Hook interface {
+	// BeforeGet is called before the value of key is looked up.
+	BeforeGet(key string)
+	// AfterGet is called after the value of key was looked up.
+	// found reports whether the value was found.
+	AfterGet(key string, value interface{}, found bool)
+	// BeforeSet is called before the value of key is stored.
+	BeforeSet(key string, value interface{}, ttl time.Duration)
+	// AfterSet is called after the value of key was stored.
+	AfterSet(key string, value interface{}, ttl time.Duration)
+	// OnEvict is called after the item was removed from the cache,
+	// either by deletion or due to expiration.
+	OnEvict(key string, value interface{})
+}
+
+// NoopHook implements Hook with methods that do nothing.
+type NoopHook struct{}
+
+func (NoopHook) BeforeGet(key string)                                       {}
+func (NoopHook) AfterGet(key string, value interface{}, found bool)         {}
+func (NoopHook) BeforeSet(key string, value interface{}, ttl time.Duration) {}
+func (NoopHook) AfterSet(key string, value interface{}, ttl time.Duration)  {}
+func (NoopHook) OnEvict(key string, value interface{})                      {}
+
+// hooks is a chain of hooks. Before* methods are called in the order the hooks
+// were registered, After* methods are called in the reverse order, so every
+// hook wraps the ones registered after it.
+type hooks []Hook
+
+func (h hooks) beforeGet(key string) {
+	for _, hook := range h {
+		hook.BeforeGet(key)
+	}
+}
+
+func (h hooks) afterGet(key string, value interface{}) {
+	for i := len(h) - 1; i >= 0; i-- {
+		h[i].AfterGet(key, value, value != nil)
+	}
+}
+
+func (h hooks) beforeSet(key string, value interface{}, ttl time.Duration) {
+	for _, hook := range h {
+		hook.BeforeSet(key, value, ttl)
+	}
+}
+
+func (h hooks) afterSet(key string, value interface{}, ttl time.Duration) {
+	for i := len(h) - 1; i >= 0; i-- {
+		h[i].AfterSet(key, value, ttl)
+	}
+}
+
+func (h hooks) onEvict(key string, value interface{}) {
+	for _, hook := range h {
+		hook.OnEvict(key, value)
+	}
+}
+
+// evictedItem holds an item removed while the cache lock was held,
+// so hooks can be notified after the lock is released.
+type evictedItem struct {
+	key     string
+	value   interface{}
+	version uint64
+	// Set when the event of the eviction wasn't emitted yet,
+	// see evictionReason.batched.
+	emit   bool
+	reason evictionReason
+}
@@ -0,0 +1,115 @@
+package incache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingHook struct {
+	NoopHook
+
+	name  string
+	mu    sync.Mutex
+	calls *[]string
+}
+
+func (h *recordingHook) record(format string, v ...interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	*h.calls = append(*h.calls, h.name+":"+fmt.Sprintf(format, v...))
+}
+
+func (h *recordingHook) BeforeGet(key string) {
+	h.record("BeforeGet(%s)", key)
+}
+
+func (h *recordingHook) AfterGet(key string, value interface{}, found bool) {
+	h.record("AfterGet(%s, %v, %t)", key, value, found)
+}
+
+func (h *recordingHook) BeforeSet(key string, value interface{}, ttl time.Duration) {
+	h.record("BeforeSet(%s, %v, %s)", key, value, ttl)
+}
+
+func (h *recordingHook) AfterSet(key string, value interface{}, ttl time.Duration) {
+	h.record("AfterSet(%s, %v, %s)", key, value, ttl)
+}
+
+func (h *recordingHook) OnEvict(key string, value interface{}) {
+	h.record("OnEvict(%s, %v)", key, value)
+}
+
+func TestHooks(t *testing.T) {
+	calls := []string{}
+	cache := New(WithTTL(0), WithHook(&recordingHook{name: "h", calls: &calls}))
+
+	cache.Set("key1", "value1")
+	cache.Get("key1")
+	cache.Get("key2")
+	cache.Delete("key1")
+
+	assert.Equal(t, []string{
+		"h:BeforeSet(key1, value1, 0s)",
+		"h:AfterSet(key1, value1, 0s)",
+		"h:BeforeGet(key1)",
+		"h:AfterGet(key1, value1, true)",
+		"h:BeforeGet(key2)",
+		"h:AfterGet(key2, <nil>, false)",
+		"h:OnEvict(key1, value1)",
+	}, calls)
+}
+
+func TestHooksChainOrder(t *testing.T) {
+	calls := []string{}
+	cache := New(
+		WithTTL(0),
+		WithHook(&recordingHook{name: "outer", calls: &calls}),
+		WithHook(&recordingHook{name: "inner", calls: &calls}),
+	)
+
+	cache.Set("key1", "value1")
+
+	assert.Equal(t, []string{
+		"outer:BeforeSet(key1, value1, 0s)",
+		"inner:BeforeSet(key1, value1, 0s)",
+		"inner:AfterSet(key1, value1, 0s)",
+		"outer:AfterSet(key1, value1, 0s)",
+	}, calls)
+}
+
+func TestHooksOnExpiration(t *testing.T) {
+	calls := []string{}
+	cache := New(WithCleanupInterval(0), WithHook(&recordingHook{name: "h", calls: &calls}))
+
+	cache.SetWithTTL("key1", "value1", time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	cache.DeleteExpired()
+
+	assert.Contains(t, calls, "h:OnEvict(key1, value1)")
+}
+
+type reentrantHook struct {
+	NoopHook
+
+	cache *Cache
+}
+
+func (h *reentrantHook) OnEvict(key string, value interface{}) {
+	h.cache.Set("evicted:"+key, value)
+}
+
+func TestHooksCanUseCache(t *testing.T) {
+	hook := &reentrantHook{}
+	cache := New(WithTTL(0), WithHook(hook))
+	hook.cache = cache
+
+	cache.Set("key1", "value1")
+	cache.GetDelete("key1")
+
+	assert.Equal(t, "value1", cache.Get("evicted:key1"))
+}
@@ -0,0 +1,297 @@
+// Package httpcache provides an HTTP middleware that caches GET responses
+// in a cache.
+//
+// Responses are cached when the status code is cacheable by default
+// (RFC 9110, section 15.1) and Cache-Control allows to store them in a shared
+// cache. The max-age and s-maxage directives of the response override the TTL.
+// Responses to requests with an Authorization header are cached only when the
+// response explicitly allows it with public, s-maxage or must-revalidate
+// (RFC 9111, section 3.5).
+// Requests with "Cache-Control: no-cache" skip the lookup, requests with
+// "Cache-Control: no-store" bypass the cache entirely. Cached responses carry
+// the Age header (RFC 9111, section 5.1).
+package httpcache
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wittyjudge/incache"
+)
+
+// Values of the X-Cache header added to responses.
+const (
+	cacheHeader = "X-Cache"
+	cacheHit    = "HIT"
+	cacheMiss   = "MISS"
+)
+
+// Options configures the middleware.
+type Options struct {
+	// TTL of responses without max-age or s-maxage directives.
+	// The default TTL of the cache is used if it's zero.
+	TTL time.Duration
+	// KeyFunc returns the cache key of the request.
+	// The host and the URL of the request are used if it's nil.
+	KeyFunc func(r *http.Request) string
+	// MaxBodySize limits the size of cached response bodies.
+	// There is no limit if it's zero.
+	MaxBodySize int
+}
+
+type response struct {
+	status int
+	header http.Header
+	body   []byte
+	// Time the response was stored, which its age is counted from.
+	storedAt time.Time
+}
+
+// Middleware returns a middleware that caches responses of the next handler.
+func Middleware(cache *incache.Cache, opts Options) func(http.Handler) http.Handler {
+	if opts.KeyFunc == nil {
+		opts.KeyFunc = keyFromURL
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			reqDirectives := parseCacheControl(r.Header.Get("Cache-Control"))
+			if _, ok := reqDirectives["no-store"]; ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := opts.KeyFunc(r)
+
+			if _, ok := reqDirectives["no-cache"]; !ok {
+				if resp, ok := cache.Get(key).(*response); ok {
+					resp.writeTo(w)
+					return
+				}
+			}
+
+			rec := &recorder{ResponseWriter: w, maxBodySize: opts.MaxBodySize}
+			w.Header().Set(cacheHeader, cacheMiss)
+			next.ServeHTTP(rec, r)
+
+			resp, ok := rec.response()
+			if !ok {
+				return
+			}
+
+			authorized := r.Header.Get("Authorization") != ""
+
+			ttl, ok := responseTTL(resp, opts.TTL, authorized)
+			if !ok {
+				return
+			}
+
+			if ttl == 0 {
+				cache.Set(key, resp)
+			} else {
+				cache.SetWithTTL(key, resp, ttl)
+			}
+		})
+	}
+}
+
+func (resp *response) writeTo(w http.ResponseWriter) {
+	header := w.Header()
+	for name, values := range resp.header {
+		header[name] = append([]string(nil), values...)
+	}
+	header.Set(cacheHeader, cacheHit)
+	header.Set("Age", strconv.FormatInt(resp.age(), 10))
+
+	w.WriteHeader(resp.status)
+	w.Write(resp.body)
+}
+
+// age returns the age of the response in seconds: the time it spent in
+// the cache plus the age reported by the origin, if any.
+func (resp *response) age() int64 {
+	age := int64(time.Since(resp.storedAt) / time.Second)
+
+	if received, err := strconv.ParseInt(resp.header.Get("Age"), 10, 64); err == nil && received > 0 {
+		age += received
+	}
+
+	return age
+}
+
+// responseTTL reports whether the response can be cached and for how long.
+// Responses to authorized requests can be cached only if they're explicitly
+// marked as shareable.
+func responseTTL(resp *response, defaultTTL time.Duration, authorized bool) (time.Duration, bool) {
+	if !cacheableStatus(resp.status) {
+		return 0, false
+	}
+
+	if resp.header.Get("Set-Cookie") != "" || resp.header.Get("Vary") != "" {
+		return 0, false
+	}
+
+	directives := parseCacheControl(resp.header.Get("Cache-Control"))
+
+	for _, directive := range []string{"no-store", "no-cache", "private"} {
+		if _, ok := directives[directive]; ok {
+			return 0, false
+		}
+	}
+
+	if authorized && !shareable(directives) {
+		return 0, false
+	}
+
+	for _, directive := range []string{"s-maxage", "max-age"} {
+		value, ok := directives[directive]
+		if !ok {
+			continue
+		}
+
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds <= 0 {
+			return 0, false
+		}
+
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	return defaultTTL, true
+}
+
+// shareable reports whether the directives allow storing a response to
+// a request with an Authorization header in a shared cache.
+func shareable(directives map[string]string) bool {
+	for _, directive := range []string{"public", "s-maxage", "must-revalidate"} {
+		if _, ok := directives[directive]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func cacheableStatus(status int) bool {
+	switch status {
+	case http.StatusOK,
+		http.StatusNonAuthoritativeInfo,
+		http.StatusNoContent,
+		http.StatusMultipleChoices,
+		http.StatusMovedPermanently,
+		http.StatusPermanentRedirect,
+		http.StatusNotFound,
+		http.StatusMethodNotAllowed,
+		http.StatusGone,
+		http.StatusRequestURITooLong,
+		http.StatusNotImplemented:
+		return true
+	default:
+		return false
+	}
+}
+
+func parseCacheControl(value string) map[string]string {
+	directives := make(map[string]string)
+
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, arg, _ := strings.Cut(part, "=")
+		directives[strings.ToLower(name)] = strings.Trim(arg, `"`)
+	}
+
+	return directives
+}
+
+// keyFromURL includes the host, so virtual hosts served by the same
+// middleware don't share entries.
+func keyFromURL(r *http.Request) string {
+	return r.Host + r.URL.RequestURI()
+}
+
+// recorder passes the response through, keeping a copy of it.
+type recorder struct {
+	http.ResponseWriter
+
+	status      int
+	header      http.Header
+	body        bytes.Buffer
+	maxBodySize int
+	tooLarge    bool
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	if rec.status != 0 {
+		return
+	}
+
+	rec.status = status
+	rec.header = rec.ResponseWriter.Header().Clone()
+	rec.header.Del(cacheHeader)
+
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.WriteHeader(http.StatusOK)
+	}
+
+	if !rec.tooLarge {
+		if rec.maxBodySize > 0 && rec.body.Len()+len(b) > rec.maxBodySize {
+			rec.tooLarge = true
+			rec.body = bytes.Buffer{}
+		} else {
+			rec.body.Write(b)
+		}
+	}
+
+	return rec.ResponseWriter.Write(b)
+}
+
+func (rec *recorder) response() (*response, bool) {
+	if rec.tooLarge {
+		return nil, false
+	}
+
+	if rec.status == 0 {
+		rec.WriteHeader(http.StatusOK)
+	}
+
+	return &response{
+		status:   rec.status,
+		header:   rec.header,
+		body:     rec.body.Bytes(),
+		storedAt: time.Now(),
+	}, true
+}
+
+// Flush sends the buffered data to the client, so streaming handlers keep
+// working behind the middleware.
+func (rec *recorder) Flush() {
+	if rec.status == 0 {
+		rec.WriteHeader(http.StatusOK)
+	}
+
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the original writer, which gives http.ResponseController
+// access to its other features.
+func (rec *recorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
@@ -0,0 +1,269 @@
+package httpcache
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wittyjudge/incache"
+)
+
+type testHandler struct {
+	calls        int
+	status       int
+	cacheControl string
+	body         string
+}
+
+func (h *testHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.calls++
+
+	w.Header().Set("Content-Type", "text/plain")
+	if h.cacheControl != "" {
+		w.Header().Set("Cache-Control", h.cacheControl)
+	}
+
+	if h.status != 0 {
+		w.WriteHeader(h.status)
+	}
+
+	fmt.Fprintf(w, "%s %d", h.body, h.calls)
+}
+
+func serve(handler http.Handler, method, target string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestMiddlewareCachesGetResponses(t *testing.T) {
+	cache := incache.New(incache.WithTTL(0))
+	defer cache.Close()
+
+	next := &testHandler{status: http.StatusNotFound, body: "missing"}
+	handler := Middleware(cache, Options{})(next)
+
+	rec := serve(handler, http.MethodGet, "/path?a=1")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "missing 1", rec.Body.String())
+	assert.Equal(t, "MISS", rec.Header().Get("X-Cache"))
+
+	rec = serve(handler, http.MethodGet, "/path?a=1")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, "missing 1", rec.Body.String())
+	assert.Equal(t, "text/plain", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "HIT", rec.Header().Get("X-Cache"))
+
+	rec = serve(handler, http.MethodGet, "/path?a=2")
+	assert.Equal(t, "missing 2", rec.Body.String())
+
+	rec = serve(handler, http.MethodPost, "/path?a=1")
+	assert.Equal(t, "missing 3", rec.Body.String())
+	assert.Empty(t, rec.Header().Get("X-Cache"))
+}
+
+func TestMiddlewareRespectsResponseCacheControl(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		cacheControl string
+		cached       bool
+	}{
+		{name: "no directives", cached: true},
+		{name: "public", cacheControl: "public", cached: true},
+		{name: "no-store", cacheControl: "no-store"},
+		{name: "no-cache", cacheControl: "no-cache"},
+		{name: "private", cacheControl: "private, max-age=60"},
+		{name: "zero max-age", cacheControl: "max-age=0"},
+		{name: "not cacheable status", status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := incache.New(incache.WithTTL(0))
+			defer cache.Close()
+
+			next := &testHandler{status: tt.status, cacheControl: tt.cacheControl}
+			handler := Middleware(cache, Options{})(next)
+
+			serve(handler, http.MethodGet, "/")
+			serve(handler, http.MethodGet, "/")
+
+			if tt.cached {
+				assert.Equal(t, 1, next.calls)
+			} else {
+				assert.Equal(t, 2, next.calls)
+			}
+		})
+	}
+}
+
+func TestMiddlewareRespectsRequestCacheControl(t *testing.T) {
+	cache := incache.New(incache.WithTTL(0))
+	defer cache.Close()
+
+	next := &testHandler{body: "body"}
+	handler := Middleware(cache, Options{})(next)
+
+	serve(handler, http.MethodGet, "/")
+
+	rec := serve(handler, http.MethodGet, "/", "Cache-Control", "no-store")
+	assert.Equal(t, "body 2", rec.Body.String())
+
+	rec = serve(handler, http.MethodGet, "/")
+	assert.Equal(t, "body 1", rec.Body.String())
+
+	rec = serve(handler, http.MethodGet, "/", "Cache-Control", "no-cache")
+	assert.Equal(t, "body 3", rec.Body.String())
+
+	rec = serve(handler, http.MethodGet, "/")
+	assert.Equal(t, "body 3", rec.Body.String())
+}
+
+func TestMiddlewareTTL(t *testing.T) {
+	cache := incache.New(incache.WithTTL(0))
+	defer cache.Close()
+
+	next := &testHandler{}
+	handler := Middleware(cache, Options{TTL: time.Millisecond})(next)
+
+	serve(handler, http.MethodGet, "/")
+	time.Sleep(2 * time.Millisecond)
+	serve(handler, http.MethodGet, "/")
+	assert.Equal(t, 2, next.calls)
+
+	next.cacheControl = "max-age=60"
+	serve(handler, http.MethodGet, "/max-age")
+
+	info, ok := cache.Inspect("example.com/max-age")
+	assert.True(t, ok)
+	assert.InDelta(t, time.Minute, info.TTL, float64(time.Second))
+}
+
+func TestMiddlewareMaxBodySize(t *testing.T) {
+	cache := incache.New(incache.WithTTL(0))
+	defer cache.Close()
+
+	next := &testHandler{body: "large body"}
+	handler := Middleware(cache, Options{MaxBodySize: 4})(next)
+
+	rec := serve(handler, http.MethodGet, "/")
+	assert.Equal(t, "large body 1", rec.Body.String())
+
+	rec = serve(handler, http.MethodGet, "/")
+	assert.Equal(t, "large body 2", rec.Body.String())
+}
+
+func TestMiddlewareKeyFunc(t *testing.T) {
+	cache := incache.New(incache.WithTTL(0))
+	defer cache.Close()
+
+	next := &testHandler{}
+	handler := Middleware(cache, Options{
+		KeyFunc: func(r *http.Request) string { return r.URL.Path },
+	})(next)
+
+	serve(handler, http.MethodGet, "/path?a=1")
+	serve(handler, http.MethodGet, "/path?a=2")
+
+	assert.Equal(t, 1, next.calls)
+	assert.True(t, cache.Has("/path"))
+}
+
+func TestMiddlewareAuthorizedRequests(t *testing.T) {
+	cache := incache.New(incache.WithTTL(0))
+	defer cache.Close()
+
+	next := &testHandler{body: "secret"}
+	handler := Middleware(cache, Options{})(next)
+
+	rec := serve(handler, http.MethodGet, "/me", "Authorization", "Bearer alice")
+	assert.Equal(t, "secret 1", rec.Body.String())
+
+	rec = serve(handler, http.MethodGet, "/me", "Authorization", "Bearer bob")
+	assert.Equal(t, "secret 2", rec.Body.String())
+	assert.Equal(t, "MISS", rec.Header().Get("X-Cache"))
+
+	for _, cacheControl := range []string{"public", "s-maxage=60", "must-revalidate"} {
+		cache.DeleteAll()
+
+		next := &testHandler{cacheControl: cacheControl, body: "shared"}
+		handler := Middleware(cache, Options{})(next)
+
+		serve(handler, http.MethodGet, "/me", "Authorization", "Bearer alice")
+		rec = serve(handler, http.MethodGet, "/me", "Authorization", "Bearer bob")
+		assert.Equal(t, "shared 1", rec.Body.String(), cacheControl)
+	}
+}
+
+func TestMiddlewareKeysIncludeHost(t *testing.T) {
+	cache := incache.New(incache.WithTTL(0))
+	defer cache.Close()
+
+	next := &testHandler{}
+	handler := Middleware(cache, Options{})(next)
+
+	rec := serve(handler, http.MethodGet, "http://a.example/path")
+	assert.Equal(t, " 1", rec.Body.String())
+
+	rec = serve(handler, http.MethodGet, "http://b.example/path")
+	assert.Equal(t, " 2", rec.Body.String())
+
+	rec = serve(handler, http.MethodGet, "http://a.example/path")
+	assert.Equal(t, " 1", rec.Body.String())
+	assert.Equal(t, "HIT", rec.Header().Get("X-Cache"))
+}
+
+func TestMiddlewareAge(t *testing.T) {
+	cache := incache.New(incache.WithTTL(0))
+	defer cache.Close()
+
+	handler := Middleware(cache, Options{})(&testHandler{})
+
+	rec := serve(handler, http.MethodGet, "/path")
+	assert.Empty(t, rec.Header().Get("Age"))
+
+	resp := cache.Get("example.com/path").(*response)
+	resp.storedAt = time.Now().Add(-90 * time.Second)
+
+	rec = serve(handler, http.MethodGet, "/path")
+	assert.Equal(t, "HIT", rec.Header().Get("X-Cache"))
+	assert.Equal(t, "90", rec.Header().Get("Age"))
+
+	// The age reported by the origin is added.
+	resp.header.Set("Age", "10")
+
+	rec = serve(handler, http.MethodGet, "/path")
+	assert.Equal(t, "100", rec.Header().Get("Age"))
+}
+
+func TestMiddlewareForwardsFlush(t *testing.T) {
+	cache := incache.New(incache.WithTTL(0))
+	defer cache.Close()
+
+	handler := Middleware(cache, Options{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("chunk"))
+
+		flusher, ok := w.(http.Flusher)
+		require.True(t, ok)
+		flusher.Flush()
+
+		_, ok = w.(interface{ Unwrap() http.ResponseWriter })
+		assert.True(t, ok)
+	}))
+
+	rec := serve(handler, http.MethodGet, "/path")
+	assert.True(t, rec.Flushed)
+	assert.Equal(t, "chunk", rec.Body.String())
+}
@@ -1,6 +1,7 @@
 package incache
 
 import (
+	"context"
 	"sync"
 	"time"
 )
@@ -21,20 +22,85 @@ type Cache struct {
 	mu               sync.RWMutex
 	items            map[string]Item
 	expirationsQueue expirationsQueue
-	cleaner          *cleaner
-	eventHandlers    *eventHandlers
+	// Only used when the number of items is limited.
+	evictionQueue *evictionQueue
+	// Only used with WithReadOptimizedStorage.
+	readIndex *readIndex
+	// Only used with WithCoarseClock.
+	clock         *coarseClock
+	cleaner       *cleaner
+	memoryWatcher *memoryWatcher
+	reporters     []*metricsReporter
+	tracer        *traceRecorder
+	snapshotter   *autoSnapshotter
+	appendLog     *appendLog
+	writeBehind   *writeBehind
+	eventHandlers *eventHandlers
+	events        *eventStream
+	watchers      *watchers
+	hooks         hooks
+	loader        *loader
+	// Deduplicates concurrent computations of GetOrCompute.
+	computing callGroup
+	// The version assigned to the last stored item.
+	lastVersion uint64
+	// The peak number of items since the maps were created.
+	peakLen int
+	// Maximum number of items, it can be changed at runtime by Resize,
+	// so it's guarded by mu rather than kept in config.
+	maxEntries int
+	// Default TTL and cleanup interval, they can be changed at runtime,
+	// so they are accessed atomically. See SetDefaultTTL.
+	ttl             int64
+	cleanupInterval int64
+	// Items evicted while the lock was held, waiting for hooks
+	// to be notified. See unlock.
+	evicted []evictedItem
+	// Items rejected while the lock was held, waiting for the rejection
+	// handler to be called. See unlock.
+	rejected []rejectedItem
+	// Maps of items detached by FlushAll while the lock was held, waiting
+	// for eviction events to be emitted. Only used with WithFlushEvents.
+	flushed []map[string]Item
+	// Operations of write-behind mode queued while the lock was held.
+	// See unlock.
+	pendingWrites []BackendOp
+	// Events whose handlers are called once the lock is released.
+	// See unlock.
+	handlerEvents []handlerEvent
 
 	config  Config
 	metrics metrics
+	// Only used when metrics are enabled.
+	topKeys *topKeys
+	// Only used when metrics are enabled and a grouper is set,
+	// see WithKeyGrouper.
+	keyGroups *keyGroups
+	// Only used when metrics are enabled, see NamespaceMetrics.
+	namespaces *namespaceCounters
+	// Secondary indexes by name, see WithIndex.
+	indexes map[string]*secondaryIndex
+	// Limits of the number of items by namespace prefix,
+	// see Namespace.SetMaxEntries.
+	namespaceLimits map[string]*namespaceLimit
 }
 
 // New creates new instance of the cache.
+// Use NewWithError to validate the options.
 func New(conf ...configFunc) *Cache {
+	return newCache(newConfig(conf))
+}
+
+func newConfig(conf []configFunc) Config {
 	config := defaultConfig()
 	for _, fn := range conf {
 		fn(&config)
 	}
 
+	return config
+}
+
+func newCache(config Config) *Cache {
 	if !config.enableDebug {
 		config.debugf = func(format string, v ...any) {}
 	}
@@ -44,42 +110,166 @@ func New(conf ...configFunc) *Cache {
 		items:            make(map[string]Item),
 		expirationsQueue: make(map[string]time.Time),
 		eventHandlers:    newEventHandlers(),
+		events:           newEventStream(config.eventsBufferSize),
+		watchers:         newWatchers(config.eventsBufferSize),
+		hooks:            config.hooks,
+
+		ttl:             int64(config.ttl),
+		cleanupInterval: int64(config.cleanupInterval),
+		maxEntries:      config.maxEntries,
 
 		config:  config,
 		metrics: newNoMetrics(),
+		indexes: newSecondaryIndexes(config.indexes),
+	}
+
+	if config.maxEntries > 0 {
+		cache.evictionQueue = newEvictionQueue()
+	}
+
+	if config.readOptimized {
+		cache.readIndex = newReadIndex()
+	}
+
+	if config.eventWorkers > 0 {
+		cache.eventHandlers.withPool(config.eventWorkers, config.eventQueueSize, config.eventOverflowPolicy)
+	}
+
+	if config.loader != nil {
+		cache.loader = newLoader(config.loader, config.loaderErrorTTL, config.loaderErrorMaxTTL)
+
+		if config.circuitBreakerThreshold > 0 {
+			cache.loader.breaker = newCircuitBreaker(config.circuitBreakerThreshold, config.circuitBreakerCooldown)
+		}
 	}
 
 	if config.enableMetrics {
-		cache.metrics = newRealMetrics()
+		cache.metrics = newRealMetrics(config.enableDetailedMetrics)
+		cache.topKeys = newTopKeys()
+		cache.namespaces = newNamespaceCounters()
+
+		if config.keyGrouper != nil {
+			cache.keyGroups = newKeyGroups(config.keyGrouper)
+		}
+	}
+
+	if config.coarseClockResolution > 0 {
+		cache.clock = newCoarseClock(config.coarseClockResolution)
 	}
 
 	if config.cleanupInterval > 0 {
-		cache.cleaner = newCleaner(config.cleanupInterval)
-		cache.cleaner.start(cache)
+		cache.cleaner = cache.newCleaner(config.cleanupInterval)
+	}
+
+	if config.memoryHighWatermark > 0 {
+		cache.memoryWatcher = newMemoryWatcher(config.memoryHighWatermark, config.memoryLowWatermark, weakRef(cache))
+		cache.memoryWatcher.start()
 	}
 
+	for _, r := range config.metricsReporters {
+		if r.reporter == nil || r.interval <= 0 {
+			continue
+		}
+
+		reporter := newMetricsReporter(r.reporter, r.interval, weakRef(cache))
+		reporter.start()
+
+		cache.reporters = append(cache.reporters, reporter)
+	}
+
+	if config.traceWriter != nil {
+		cache.tracer = newTraceRecorder(config.traceWriter)
+	}
+
+	if config.writeBehindBackend != nil && config.writeBehindInterval > 0 {
+		cache.writeBehind = newWriteBehind(config.writeBehindBackend, config.writeBehindInterval, config.writeBehindQueueSize)
+		cache.writeBehind.start(cache)
+	}
+
+	if config.snapshotPath != "" && config.snapshotInterval > 0 {
+		cache.snapshotter = newAutoSnapshotter(config.snapshotPath, config.snapshotInterval, config.snapshotCodec)
+		cache.snapshotter.restore(cache)
+		cache.snapshotter.start(cache)
+	}
+
+	if config.appendLogPath != "" {
+		appendLog := newAppendLog(config.appendLogPath, config.appendLogCompactInterval, config.valueCodec)
+
+		// The log is only assigned after the replay, so replayed operations
+		// aren't appended again.
+		if err := appendLog.open(cache); err != nil {
+			config.debugf("[aof] failed to open '%s': %v", config.appendLogPath, err)
+		} else {
+			cache.appendLog = appendLog
+			appendLog.start(cache)
+		}
+	}
+
+	stopOnCollect(cache)
+
 	return cache
 }
 
 // Close allows you to stop automatic cleaner manually and wait for the the
-// exeuction of all events.
+// exeuction of all events. If automatic snapshots are enabled, the final
+// snapshot is saved. If write-behind mode is enabled, pending writes are
+// flushed. The channels returned by Events, Watch and WatchPrefix are closed.
 //
 // There is no needs to run this function, if you don't use event and there is a
 // cleanupInterval <= 0, since cleaner whouldn't be run in this case.
+// It's safe to call Close multiple times.
+//
+// Since Go 1.24, the cleaner and event workers of a cache that is
+// garbage-collected without Close are stopped automatically.
 func (c *Cache) Close() {
-	if c.cleaner != nil {
+	if cleaner := c.getCleaner(); cleaner != nil {
 		c.config.debugf("[close] closing cleaner")
-		c.cleaner.close()
+		cleaner.close()
+	}
+
+	if c.memoryWatcher != nil {
+		c.memoryWatcher.close()
+	}
+
+	for _, reporter := range c.reporters {
+		reporter.close()
+	}
+
+	if c.snapshotter != nil {
+		c.config.debugf("[close] saving the final snapshot")
+		c.snapshotter.close()
+	}
+
+	if c.appendLog != nil {
+		c.config.debugf("[close] syncing the append-only log")
+		c.appendLog.close()
+	}
+
+	if c.writeBehind != nil {
+		c.config.debugf("[close] flushing pending writes")
+		c.writeBehind.close()
+
+		if err := c.writeBehind.flush(context.Background()); err != nil {
+			c.config.debugf("[close] failed to flush pending writes: %v", err)
+		}
+	}
+
+	if c.tracer != nil {
+		if err := c.tracer.flush(); err != nil {
+			c.config.debugf("[close] failed to flush the trace: %v", err)
+		}
 	}
 
 	c.config.debugf("[close] waiting for the execution of all events")
-	c.eventHandlers.Wait()
+	c.eventHandlers.close()
+	c.events.close()
+	c.watchers.close()
 }
 
 // Set sets the key to hold a value.
 // If key already holds a value, It will be overwritten.
 func (c *Cache) Set(key string, value interface{}) {
-	ttl := c.config.ttl
+	ttl := c.defaultTTL()
 
 	c.set(key, value, ttl)
 }
@@ -90,33 +280,63 @@ func (c *Cache) SetWithTTL(key string, value interface{}, ttl time.Duration) {
 	c.set(key, value, ttl)
 }
 
+// SetWithPriority works similar to Set method, but the item is stored with
+// the priority of eviction. When the cache is full (see WithMaxEntries),
+// items with higher priority are only evicted after all items with lower
+// priority. Items stored by other methods have zero priority.
+func (c *Cache) SetWithPriority(key string, value interface{}, priority int) {
+	c.setWithPriority(key, value, c.defaultTTL(), priority)
+}
+
+// SetWithExpiresAt works similar to Set method, but the item expires at
+// the given time, e.g. taken from a JWT or the Expires HTTP header, without
+// lossy conversion to a TTL. Zero time means that the item never expires.
+// Similar to Redis, a time in the past deletes the key instead.
+func (c *Cache) SetWithExpiresAt(key string, value interface{}, at time.Time) {
+	at = monotonic(at)
+
+	item := Item{Value: value, ExpiresAt: at}
+	if !at.IsZero() {
+		item.TTL = time.Until(at)
+
+		if item.TTL <= 0 {
+			c.delete(key)
+			return
+		}
+	}
+
+	c.hooks.beforeSet(key, value, item.TTL)
+
+	c.storeItem(key, item)
+	c.hooks.afterSet(key, value, item.TTL)
+}
+
 // SetGet sets the key to hold a value, and then returns it.
+// The write and the read are performed atomically.
 func (c *Cache) SetGet(key string, value interface{}) interface{} {
-	ttl := c.config.ttl
+	ttl := c.defaultTTL()
 
-	c.set(key, value, ttl)
-	v := c.get(key)
-
-	return v
+	return c.setGet(key, value, ttl)
 }
 
 // SetGetWithTTL works similar to SetGet method, but with an opportunity
 // to adjust  ttl for that particular key manually.
 func (c *Cache) SetGetWithTTL(key string, value interface{}, ttl time.Duration) interface{} {
-	c.set(key, value, ttl)
-	v := c.get(key)
-
-	return v
+	return c.setGet(key, value, ttl)
 }
 
 // Get returns the value of key.
 // If the key doesn't exist, nil value will be returned.
+// If a loader is configured, a missing value is loaded first, see GetContext.
 func (c *Cache) Get(key string) interface{} {
 	return c.get(key)
 }
 
 // GetMultiple returns the values of all specified keys.
 // For every specified key that doesn't exist, nil value will be returned.
+//
+// Every key is read separately, so the returned values don't represent
+// a single point-in-time view of the cache.
 func (c *Cache) GetMultiple(keys []string) []interface{} {
 	values := make([]interface{}, len(keys))
 
@@ -129,126 +349,267 @@ func (c *Cache) GetMultiple(keys []string) []interface{} {
 
 // GetSet returns the old value stored by key and set the new one for that key.
 // If the key doesn't exist, nil value will be returned.
+// The read and the write are performed atomically.
 func (c *Cache) GetSet(key string, value interface{}) interface{} {
-	ttl := c.config.ttl
-
-	v := c.get(key)
-	c.set(key, value, ttl)
+	ttl := c.defaultTTL()
 
-	return v
+	return c.getSet(key, value, ttl)
 }
 
 // GetSetWithTTL works similar to GetSet method, but with an opportunity to
 // adjust a ttl for that particular key manually.
 func (c *Cache) GetSetWithTTL(key string, value interface{}, ttl time.Duration) interface{} {
-	v := c.get(key)
-	c.set(key, value, ttl)
-
-	return v
+	return c.getSet(key, value, ttl)
 }
 
 // GetDelete returns the value of key and delete it.
 // If the key doesn't exist, nil value will be returned.
+// The read and the deletion are performed atomically, so when several
+// goroutines call it for the same key, only one of them receives the value.
 func (c *Cache) GetDelete(key string) interface{} {
-	value := c.get(key)
-	if value != nil {
-		c.evict(key)
-	}
+	c.hooks.beforeGet(key)
+
+	value := c.getDelete(key)
+	c.hooks.afterGet(key, value)
 
 	return value
 }
 
-// Delete deletes the value of key.
-// If the key doesn't exist, nothing will happen.
-func (c *Cache) Delete(key string) {
+func (c *Cache) getDelete(key string) interface{} {
 	c.mu.Lock()
-	_, ok := c.items[key]
-	c.mu.Unlock()
+	defer c.unlock()
 
-	if ok {
-		c.evict(key)
+	value := c.getLocked(key)
+	if value != nil {
+		c.evictLocked(key, reasonDeleted)
 	}
+
+	return value
+}
+
+// Delete deletes the value of key and reports whether it existed.
+// Expired values that weren't removed yet are deleted, but don't count.
+// If the key doesn't exist, nothing will happen.
+func (c *Cache) Delete(key string) bool {
+	return c.delete(key)
 }
 
 // DeleteAll deletes all values stored in the cache.
+// It's an alias of FlushAll.
 func (c *Cache) DeleteAll() {
+	c.FlushAll()
+}
+
+// FlushAll removes all items from the cache at once. Every removed item
+// is recorded as an eviction in metrics.
+//
+// The items are detached from the cache rather than deleted one by one, so
+// the lock is held for the same short time no matter how many items are
+// stored, and the memory is released by the GC afterwards.
+//
+// Eviction events and OnEvict hooks are only triggered when the cache
+// is created with WithFlushEvents, after the lock is released. Removed
+// items aren't deleted from the write-behind backend.
+func (c *Cache) FlushAll() {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.unlock()
 
+	c.flushAllLocked()
+	c.logDeleteLocked(logFlush, "")
+}
+
+// flushAllLocked must be called with the write lock held.
+func (c *Cache) flushAllLocked() {
+	if c.config.flushEvents && len(c.items) > 0 {
+		c.flushed = append(c.flushed, c.items)
+	}
+
+	c.metrics.addEvictions(uint64(len(c.items)))
+
+	if c.config.enableDebug {
+		c.config.debugf("[flush] %d items", len(c.items))
+	}
+
+	// Recreate the maps instead of deleting items, so flushing doesn't
+	// depend on the number of items.
 	c.items = make(map[string]Item)
+	c.expirationsQueue = make(map[string]time.Time)
+	c.peakLen = 0
+
+	for _, index := range c.indexes {
+		index.reset()
+	}
+
+	for _, limit := range c.namespaceLimits {
+		limit.queue.reset()
+	}
+
+	if c.evictionQueue != nil {
+		c.evictionQueue.reset()
+	}
+
+	if c.readIndex != nil {
+		c.readIndex.reset()
+	}
 }
 
-// DeleteExpired deletes all expired items from the cache.
+// DeleteExpired deletes all expired items from the cache and returns
+// the number of deleted items.
 // You don't need to perform it manually unless cleanupInterval is <= 0.
-func (c *Cache) DeleteExpired() {
+func (c *Cache) DeleteExpired() int {
+	return c.DeleteExpiredN(0)
+}
+
+// DeleteExpiredN works similar to DeleteExpired, but deletes at most max
+// items, so the time the cache is locked for can be bounded. The remaining
+// expired items are deleted by the next calls. max <= 0 means no limit.
+func (c *Cache) DeleteExpiredN(max int) int {
 	c.mu.Lock()
+	defer c.unlock()
 
 	timeNow := time.Now()
-	expiredKeys := make([]string, 0, len(c.expirationsQueue))
+	deleted := 0
+
+	for key, expiresAt := range c.expirationsQueue {
+		if max > 0 && deleted >= max {
+			break
+		}
+
+		if timeNow.Before(expiresAt) {
+			continue
+		}
+
+		// The queue is only a hint, the decision is made by the item itself.
+		item, ok := c.items[key]
+		if !ok || !item.CanExpire() {
+			delete(c.expirationsQueue, key)
+			continue
+		}
 
-	for key, time := range c.expirationsQueue {
-		if timeNow.Before(time) {
+		if timeNow.Before(item.ExpiresAt) {
+			c.expirationsQueue[key] = item.ExpiresAt
 			continue
 		}
 
-		expiredKeys = append(expiredKeys, key)
+		c.evictLocked(key, reasonExpired)
+		deleted++
 	}
 
-	c.mu.Unlock()
+	return deleted
+}
+
+// PauseCleanup temporarily stops the automatic removal of expired items,
+// e.g. during latency-sensitive bulk imports. Expired items are still
+// never returned. Use ResumeCleanup to continue.
+func (c *Cache) PauseCleanup() {
+	if cleaner := c.getCleaner(); cleaner != nil {
+		cleaner.pause()
+	}
+}
 
-	for _, key := range expiredKeys {
-		c.evict(key)
+// ResumeCleanup resumes the automatic removal of expired items paused
+// by PauseCleanup.
+func (c *Cache) ResumeCleanup() {
+	if cleaner := c.getCleaner(); cleaner != nil {
+		cleaner.resume()
 	}
 }
 
 // Keys returns slice of all existing keys in the cache.
+// Keys of expired items that weren't removed yet aren't included.
 func (c *Cache) Keys() []string {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 
-	keys := make([]string, len(c.items))
+	keys := make([]string, 0, len(c.items))
 
-	i := 0
-	for key := range c.items {
-		keys[i] = key
-		i++
+	timeNow := time.Now()
+	for key, item := range c.items {
+		if item.expiredAt(timeNow) {
+			continue
+		}
+
+		keys = append(keys, key)
 	}
 
 	return keys
 }
 
 // Len returns the number of stored elements in the cache.
+// Expired items that weren't removed yet aren't counted.
 func (c *Cache) Len() int {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
+	count := len(c.items)
+
+	// Only items in the expirations queue can be expired.
+	timeNow := time.Now()
+	for key := range c.expirationsQueue {
+		if c.items[key].expiredAt(timeNow) {
+			count--
+		}
+	}
+
+	return count
+}
+
+// LenIncludingExpired returns the number of stored elements in the cache,
+// including expired items that weren't removed yet.
+func (c *Cache) LenIncludingExpired() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	count := len(c.items)
 	return count
 }
 
-// Has checks if the key exists in the cache.
+// Has checks if the key exists in the cache and isn't expired.
 func (c *Cache) Has(key string) bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	_, ok := c.items[key]
-	return ok
+	item, ok := c.items[key]
+	return ok && !item.Expired()
 }
 
-// Metrics returns collected cache metrics.
-func (c *Cache) Metrics() metrics {
+// MemoryUsage returns an approximate number of bytes occupied by keys and
+// values stored in the cache.
+//
+// The estimation iterates over all items, so it isn't supposed to be called
+// on a hot path.
+func (c *Cache) MemoryUsage() uint64 {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
+	var size uint64
+	for key, item := range c.items {
+		size += uint64(len(key)) + estimateSize(item.Value) + itemOverhead
+	}
+
+	return size
+}
+
+// Metrics returns collected cache metrics.
+func (c *Cache) Metrics() metrics {
 	return c.metrics
 }
 
 // ResetMetrics resets cache metrics.
 func (c *Cache) ResetMetrics() {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
 	c.metrics.reset()
+
+	if c.topKeys != nil {
+		c.topKeys.reset()
+	}
+
+	if c.keyGroups != nil {
+		c.keyGroups.reset()
+	}
+
+	if c.namespaces != nil {
+		c.namespaces.reset()
+	}
 }
 
 func (c *Cache) OnInsertion(fn func(key string, value interface{})) {
@@ -259,62 +620,449 @@ func (c *Cache) OnEviction(fn func(key string, value interface{})) {
 	c.eventHandlers.OnEviction(fn)
 }
 
+// OnUpdate registers the handler of overwrites of existing keys.
+// Until it's registered, overwrites are reported to the OnInsertion handler.
+func (c *Cache) OnUpdate(fn func(key string, oldValue, newValue interface{})) {
+	c.eventHandlers.OnUpdate(fn)
+}
+
+// OnExpiration registers the handler of items removed because their TTL
+// has passed. Until it's registered, expirations are reported to
+// the OnEviction handler.
+func (c *Cache) OnExpiration(fn func(key string, value interface{})) {
+	c.eventHandlers.OnExpiration(fn)
+}
+
 func (c *Cache) set(key string, value interface{}, ttl time.Duration) {
+	c.hooks.beforeSet(key, value, ttl)
+
+	c.store(key, value, ttl)
+	c.hooks.afterSet(key, value, ttl)
+}
+
+func (c *Cache) get(key string) interface{} {
+	value, _ := c.GetContext(context.Background(), key)
+
+	return value
+}
+
+func (c *Cache) setGet(key string, value interface{}, ttl time.Duration) interface{} {
+	c.hooks.beforeSet(key, value, ttl)
+	c.hooks.beforeGet(key)
+
+	v := c.storeLookup(key, value, ttl)
+
+	c.hooks.afterGet(key, v)
+	c.hooks.afterSet(key, value, ttl)
+
+	return v
+}
+
+func (c *Cache) getSet(key string, value interface{}, ttl time.Duration) interface{} {
+	c.hooks.beforeGet(key)
+	c.hooks.beforeSet(key, value, ttl)
+
+	v := c.lookupStore(key, value, ttl)
+
+	c.hooks.afterSet(key, value, ttl)
+	c.hooks.afterGet(key, v)
+
+	return v
+}
+
+func (c *Cache) setWithPriority(key string, value interface{}, ttl time.Duration, priority int) {
+	item := newItem(value, c.jitter(ttl))
+	item.priority = priority
+
+	c.hooks.beforeSet(key, value, ttl)
+
+	c.storeItem(key, item)
+	c.hooks.afterSet(key, value, ttl)
+}
+
+func (c *Cache) store(key string, value interface{}, ttl time.Duration) {
+	c.storeItem(key, newItem(value, c.jitter(ttl)))
+}
+
+// storeItem, lookup and delete are on the hot path and don't defer, defer
+// is a measurable share of their cost on older versions of Go.
+func (c *Cache) storeItem(key string, item Item) {
+	if c.config.enableDetailedMetrics {
+		defer observeLatency(time.Now(), c.metrics.observeSet)
+	}
+
+	c.mu.Lock()
+	c.setItemLocked(key, item)
+	c.unlock()
+}
+
+func (c *Cache) lookup(key string) interface{} {
+	if c.config.enableDetailedMetrics {
+		defer observeLatency(time.Now(), c.metrics.observeGet)
+	}
+
+	if c.readIndex != nil {
+		return c.lookupLockFree(key)
+	}
+
+	c.mu.RLock()
+	value := c.getLocked(key)
+	c.mu.RUnlock()
+
+	return value
+}
+
+func (c *Cache) storeLookup(key string, value interface{}, ttl time.Duration) interface{} {
+	c.mu.Lock()
+	defer c.unlock()
+
+	c.setLocked(key, value, ttl)
+
+	return c.getLocked(key)
+}
+
+func (c *Cache) lookupStore(key string, value interface{}, ttl time.Duration) interface{} {
 	c.mu.Lock()
-	defer c.mu.Unlock()
+	defer c.unlock()
+
+	v := c.getLocked(key)
+	c.setLocked(key, value, ttl)
+
+	return v
+}
+
+// unlock compacts the maps if needed, releases the write lock and notifies
+// hooks about items that were evicted while it was held. Event handlers of
+// the changes made under the lock are called here too, so a handler waiting
+// for a free space in the queue of the event workers doesn't hold the lock.
+// Events of batched evictions, see evictionReason.batched, are emitted here
+// as well, so evicting many items doesn't call handlers under the lock.
+//
+// Operations of write-behind mode are pushed to its queue before the lock
+// is released, so they keep the order of the changes, but the writer waits
+// for room in a full queue only after releasing it.
+func (c *Cache) unlock() {
+	c.compactLocked()
+
+	evicted, rejected, flushed := c.evicted, c.rejected, c.flushed
+	c.evicted, c.rejected, c.flushed = nil, nil, nil
+
+	events := c.handlerEvents
+	c.handlerEvents = nil
+
+	wroteBehind := len(c.pendingWrites) > 0
+	if wroteBehind {
+		c.writeBehind.push(c.pendingWrites)
+		c.pendingWrites = c.pendingWrites[:0]
+	}
+
+	c.mu.Unlock()
+
+	if wroteBehind {
+		c.writeBehind.waitForRoom()
+	}
+
+	for _, event := range events {
+		c.eventHandlers.dispatch(event)
+	}
+
+	for _, item := range evicted {
+		if item.emit {
+			c.emitEviction(item.key, item.value, item.version, item.reason)
+		}
+
+		c.hooks.onEvict(item.key, item.value)
+	}
+
+	for _, items := range flushed {
+		for key, item := range items {
+			value := c.value(item.Value)
+
+			c.emitEviction(key, value, item.version, reasonFlushed)
+			c.hooks.onEvict(key, value)
+		}
+	}
+
+	for _, item := range rejected {
+		c.config.rejectionHandler(item.key, item.value, item.err)
+	}
+}
+
+// delete deletes the value of key and reports whether it existed.
+func (c *Cache) delete(key string) bool {
+	if c.config.enableDetailedMetrics {
+		defer observeLatency(time.Now(), c.metrics.observeDelete)
+	}
+
+	c.mu.Lock()
+
+	item, ok := c.items[key]
+	if ok {
+		c.evictLocked(key, reasonDeleted)
+	}
+
+	c.unlock()
+
+	return ok && !item.Expired()
+}
+
+func observeLatency(start time.Time, observe func(d time.Duration)) {
+	observe(time.Since(start))
+}
+
+// setLocked must be called with the write lock held.
+func (c *Cache) setLocked(key string, value interface{}, ttl time.Duration) {
+	c.setItemLocked(key, newItem(value, c.jitter(ttl)))
+}
 
-	c.eventHandlers.onInsertion(key, value)
+// setItemLocked must be called with the write lock held.
+// Items are stored by value, so overwriting a key reuses its slot in the
+// map and doesn't allocate.
+func (c *Cache) setItemLocked(key string, item Item) {
+	if err := c.validate(key, item.Value); err != nil {
+		c.rejectLocked(key, item.Value, err)
+		return
+	}
+
+	item.Value = c.copyValue(item.Value)
+
+	old, exists := c.items[key]
+	if exists && old.Expired() {
+		// The old item has to be reported as expired rather than updated.
+		c.evictLocked(key, reasonExpired)
+		exists = false
+	}
+
+	c.lastVersion++
+	item.version = c.lastVersion
+
+	if c.eventHandlers.hasHandlers() {
+		event := handlerEvent{typ: EventInsertion, key: key, value: item.Value}
+		if exists {
+			event.typ = EventUpdate
+			event.oldValue = c.value(old.Value)
+		}
+
+		c.handlerEvents = append(c.handlerEvents, event)
+	}
+
+	if c.notifying() {
+		event := Event{Type: EventInsertion, Key: key, Value: item.Value, Version: item.version, Time: time.Now()}
+		if exists {
+			event.Type = EventUpdate
+			event.OldValue = c.value(old.Value)
+		}
 
-	item := newItem(value, ttl)
+		c.notify(event)
+	}
+
+	if !exists && c.evictionQueue != nil {
+		c.makeRoomLocked()
+	}
+
+	if !exists && len(c.namespaceLimits) > 0 {
+		c.makeNamespaceRoomLocked(key)
+	}
+
+	item.storedAt = c.now().UnixNano()
+
+	if c.config.accessTracking {
+		item.access = c.accessStatsLocked(old, exists)
+	} else {
+		item.access = nil
+	}
+
+	if c.writeBehind != nil {
+		c.writeBehindLocked(BackendOp{Key: key, Value: item.Value, TTL: item.remainingTTL()})
+	}
+
+	c.indexLocked(key, item.Value)
+
+	item.Value = c.compress(item.Value)
 	c.items[key] = item
+	c.mirrorLocked(key, item)
+	c.trackPeakLocked()
 
 	if item.CanExpire() {
 		c.expirationsQueue[key] = item.ExpiresAt
+
+		if c.cleaner != nil {
+			c.cleaner.wake()
+		}
+	} else {
+		delete(c.expirationsQueue, key)
+	}
+
+	if c.evictionQueue != nil {
+		c.evictionQueue.push(key, item.priority)
 	}
 
-	c.config.debugf("[set] key: '%s', item: %+v", key, item)
+	c.pushNamespaceLimitsLocked(key, item.priority)
+
+	// Arguments of debugf escape to the heap, so the hot paths check
+	// enableDebug first to stay allocation-free.
+	if c.config.enableDebug {
+		c.config.debugf("[set] key: '%s', item: %+v", key, item)
+	}
 
-	c.metrics.incrementInsertions()
+	c.logSetLocked(key, item)
+	c.countInsertion(key)
 }
 
-func (c *Cache) get(key string) interface{} {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+// makeRoomLocked evicts items until there is room for a new one.
+// It must be called with the write lock held.
+func (c *Cache) makeRoomLocked() {
+	c.shrinkLocked(c.maxEntries - 1)
+}
 
-	item := c.items[key]
-	value := item.Value
+// shrinkLocked evicts items until there are at most n of them.
+// It must be called with the write lock held.
+func (c *Cache) shrinkLocked(n int) {
+	for len(c.items) > n {
+		key, ok := c.evictionQueue.next()
+		if !ok {
+			return
+		}
+
+		c.evictLocked(key, reasonCapacity)
+	}
+}
+
+// getLocked must be called with at least the read lock held.
+func (c *Cache) getLocked(key string) interface{} {
+	return c.readItem(key, c.items[key])
+}
+
+// readItem returns the value of the item read by key, taking expiration
+// into account. It doesn't access the items map, so it's safe to call
+// without the lock.
+func (c *Cache) readItem(key string, item Item) interface{} {
+	if item.Value == nil {
+		if c.config.enableDebug {
+			c.config.debugf("[get] no value was found for the key: '%s'", key)
+		}
+
+		c.countMiss(key)
+		return nil
+	}
 
-	if value == nil {
-		c.config.debugf("[get] no value was found for the key: '%s'", key)
+	if item.expiredAt(c.now()) {
+		if c.config.enableDebug {
+			c.config.debugf("[get] received value for the key: '%s' is expired", key)
+		}
 
-		c.metrics.incrementMisses()
+		c.countMiss(key)
 		return nil
 	}
 
-	if item.Expired() {
-		c.config.debugf("[get] received value for the key: '%s' is expired", key)
+	if reclaimed(item.Value) {
+		if c.config.enableDebug {
+			c.config.debugf("[get] received value for the key: '%s' is reclaimed", key)
+		}
 
-		c.metrics.incrementMisses()
+		c.countMiss(key)
 		return nil
 	}
 
-	c.metrics.incrementHits()
+	value := c.copyValue(c.value(item.Value))
 
-	c.config.debugf("[get] key: '%s', value: %+v", key, value)
+	c.countHit(key)
+
+	if item.access != nil {
+		item.access.record(c.now())
+	}
+
+	if c.topKeys != nil {
+		c.topKeys.record(key)
+	}
+
+	if c.config.enableDebug {
+		c.config.debugf("[get] key: '%s', value: %+v", key, value)
+	}
 
 	return value
 }
 
-func (c *Cache) evict(key string) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+// emitEviction calls event handlers and notifies subscribers about
+// the eviction of the item. It must be called without the lock held.
+func (c *Cache) emitEviction(key string, value interface{}, version uint64, reason evictionReason) {
+	event := evictionEvent(key, value, reason)
+	c.eventHandlers.dispatch(event)
+
+	if c.notifying() {
+		c.notify(Event{Type: event.typ, Key: key, Value: value, Reason: reason.String(), Version: version, Time: time.Now()})
+	}
+}
 
-	value := c.items[key]
-	c.eventHandlers.onEviction(key, value)
+// emitEvictionLocked works like emitEviction, but the handlers are called
+// by unlock. It must be called with the write lock held.
+func (c *Cache) emitEvictionLocked(key string, value interface{}, version uint64, reason evictionReason) {
+	event := evictionEvent(key, value, reason)
+	if c.eventHandlers.hasHandlers() {
+		c.handlerEvents = append(c.handlerEvents, event)
+	}
+
+	if c.notifying() {
+		c.notify(Event{Type: event.typ, Key: key, Value: value, Reason: reason.String(), Version: version, Time: time.Now()})
+	}
+}
+
+func evictionEvent(key string, value interface{}, reason evictionReason) handlerEvent {
+	if reason == reasonExpired {
+		return handlerEvent{typ: EventExpiration, key: key, value: value}
+	}
+
+	return handlerEvent{typ: EventEviction, key: key, value: value}
+}
+
+// evictLocked must be called with the write lock held.
+func (c *Cache) evictLocked(key string, reason evictionReason) {
+	item := c.items[key]
+
+	item.Value = c.value(item.Value)
+
+	switch {
+	case reason.batched():
+		c.evicted = append(c.evicted, evictedItem{key: key, value: item.Value, version: item.version, emit: true, reason: reason})
+	case len(c.hooks) > 0:
+		c.emitEvictionLocked(key, item.Value, item.version, reason)
+		c.evicted = append(c.evicted, evictedItem{key: key, value: item.Value})
+	default:
+		c.emitEvictionLocked(key, item.Value, item.version, reason)
+	}
 
 	delete(c.items, key)
 	delete(c.expirationsQueue, key)
+	c.unmirrorLocked(key)
+	c.unindexLocked(key)
+
+	if c.evictionQueue != nil {
+		c.evictionQueue.remove(key)
+	}
 
-	c.config.debugf("[evict] key: '%s'", key)
-	c.metrics.incrementEvictions()
+	c.removeNamespaceLimitsLocked(key)
+
+	// Expired items are skipped on replay anyway.
+	if reason != reasonExpired {
+		c.logDeleteLocked(logDelete, key)
+	}
+
+	if reason == reasonDeleted {
+		c.trace(TraceDelete, key, false)
+
+		if c.writeBehind != nil {
+			c.writeBehindLocked(BackendOp{Key: key, Delete: true})
+		}
+	}
+
+	if c.config.enableDebug {
+		c.config.debugf("[evict] key: '%s', reason: %s", key, reason)
+	}
+
+	switch reason {
+	case reasonExpired:
+		c.metrics.incrementExpired()
+	default:
+		c.metrics.incrementEvictions()
+	}
 }
@@ -54,3 +54,85 @@ func BenchmarkDeleteExpired(b *testing.B) {
 		}
 	})
 }
+
+func BenchmarkGetWithMetrics(b *testing.B) {
+	cache := New(WithMetrics())
+	defer cache.Close()
+
+	cache.Set("key0", "value")
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			cache.Get("key0")
+		}
+	})
+}
+
+func BenchmarkGetReadOptimized(b *testing.B) {
+	cache := New(WithReadOptimizedStorage())
+	defer cache.Close()
+
+	cache.Set("key0", "value")
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			cache.Get("key0")
+		}
+	})
+}
+
+func BenchmarkSetReadOptimized(b *testing.B) {
+	cache := New(WithReadOptimizedStorage())
+	defer cache.Close()
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			cache.Set("key", "value")
+		}
+	})
+}
+
+func BenchmarkMostlyReads(b *testing.B) {
+	benchmarkMostlyReads(b, New())
+}
+
+func BenchmarkMostlyReadsReadOptimized(b *testing.B) {
+	benchmarkMostlyReads(b, New(WithReadOptimizedStorage()))
+}
+
+// benchmarkMostlyReads performs one write per 20 reads.
+func benchmarkMostlyReads(b *testing.B, cache *Cache) {
+	defer cache.Close()
+
+	keys := make([]string, 100)
+	for i := range keys {
+		keys[i] = fmt.Sprint("key", i)
+		cache.Set(keys[i], i)
+	}
+
+	b.ResetTimer()
+	b.ReportAllocs()
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := keys[i%len(keys)]
+			if i%20 == 0 {
+				cache.Set(key, i)
+			} else {
+				cache.Get(key)
+			}
+
+			i++
+		}
+	})
+}
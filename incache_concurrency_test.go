@@ -0,0 +1,315 @@
+package incache
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	stressGoroutines = 1000
+	stressOperations = 100
+	stressKeys       = 50
+)
+
+func TestConcurrentMixedOperations(t *testing.T) {
+	cache := New(WithTTL(time.Millisecond), WithCleanupInterval(time.Millisecond), WithMetrics())
+	defer cache.Close()
+
+	cache.OnInsertion(func(_ string, _ interface{}) {})
+	cache.OnEviction(func(_ string, _ interface{}) {})
+
+	var wg sync.WaitGroup
+
+	for g := 0; g < stressGoroutines; g++ {
+		wg.Add(1)
+
+		go func(seed int64) {
+			defer wg.Done()
+
+			r := rand.New(rand.NewSource(seed))
+
+			for i := 0; i < stressOperations; i++ {
+				key := fmt.Sprint("key", r.Intn(stressKeys))
+
+				switch r.Intn(12) {
+				case 0:
+					cache.Set(key, i)
+				case 1:
+					cache.SetWithTTL(key, i, 0)
+				case 2:
+					cache.SetGet(key, i)
+				case 3:
+					cache.GetSet(key, i)
+				case 4:
+					cache.GetDelete(key)
+				case 5:
+					cache.Delete(key)
+				case 6:
+					cache.GetMultiple([]string{key, "nokey"})
+				case 7:
+					cache.Keys()
+				case 8:
+					cache.Len()
+				case 9:
+					cache.Has(key)
+				case 10:
+					cache.DeleteExpired()
+				default:
+					cache.Get(key)
+				}
+			}
+		}(int64(g))
+	}
+
+	wg.Wait()
+
+	metrics := cache.Metrics()
+	assert.NotZero(t, metrics.Insertions())
+	assert.NotZero(t, metrics.Hits()+metrics.Misses())
+}
+
+func TestConcurrentGetSetIsAtomic(t *testing.T) {
+	cache := New(WithTTL(0))
+	defer cache.Close()
+
+	// Every goroutine swaps in its own unique values. If GetSet is atomic,
+	// every stored value is returned as an old value exactly once, except
+	// for the one that stays in the cache at the end.
+	var wg sync.WaitGroup
+	olds := make(chan interface{}, stressGoroutines*stressOperations)
+
+	for g := 0; g < stressGoroutines; g++ {
+		wg.Add(1)
+
+		go func(g int) {
+			defer wg.Done()
+
+			for i := 0; i < stressOperations; i++ {
+				olds <- cache.GetSet("key", g*stressOperations+i)
+			}
+		}(g)
+	}
+
+	wg.Wait()
+	close(olds)
+
+	seen := make(map[interface{}]int, stressGoroutines*stressOperations)
+	for old := range olds {
+		seen[old]++
+	}
+	seen[cache.Get("key")]++
+
+	assert.Len(t, seen, stressGoroutines*stressOperations+1)
+	assert.Equal(t, 1, seen[nil])
+
+	for value, count := range seen {
+		require.Equal(t, 1, count, "value %v was observed %d times", value, count)
+	}
+}
+
+func TestConcurrentGetDeleteIsAtomic(t *testing.T) {
+	cache := New(WithTTL(0))
+	defer cache.Close()
+
+	for round := 0; round < stressOperations; round++ {
+		cache.Set("key", round)
+
+		var (
+			wg       sync.WaitGroup
+			received int32
+		)
+
+		for g := 0; g < 100; g++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				if cache.GetDelete("key") != nil {
+					atomic.AddInt32(&received, 1)
+				}
+			}()
+		}
+
+		wg.Wait()
+
+		require.EqualValues(t, 1, received)
+		require.False(t, cache.Has("key"))
+	}
+}
+
+func TestConcurrentDeleteEvictsOnce(t *testing.T) {
+	cache := New(WithTTL(0), WithMetrics())
+	defer cache.Close()
+
+	var evictions int32
+	cache.OnEviction(func(_ string, _ interface{}) {
+		atomic.AddInt32(&evictions, 1)
+	})
+
+	for round := 0; round < stressOperations; round++ {
+		cache.Set("key", round)
+
+		var wg sync.WaitGroup
+
+		for g := 0; g < 100; g++ {
+			wg.Add(1)
+
+			go func(g int) {
+				defer wg.Done()
+
+				if g%2 == 0 {
+					cache.Delete("key")
+				} else {
+					cache.GetDelete("key")
+				}
+			}(g)
+		}
+
+		wg.Wait()
+	}
+
+	cache.Close()
+
+	assert.EqualValues(t, stressOperations, atomic.LoadInt32(&evictions))
+	assert.EqualValues(t, stressOperations, cache.Metrics().Evictions())
+}
+
+func TestConcurrentSetGetReturnsOwnValue(t *testing.T) {
+	cache := New(WithTTL(0))
+	defer cache.Close()
+
+	var wg sync.WaitGroup
+
+	for g := 0; g < stressGoroutines; g++ {
+		wg.Add(1)
+
+		go func(g int) {
+			defer wg.Done()
+
+			for i := 0; i < stressOperations; i++ {
+				value := g*stressOperations + i
+				if v := cache.SetGet("key", value); v != value {
+					t.Errorf("SetGet returned %v, expected %v", v, value)
+					return
+				}
+			}
+		}(g)
+	}
+
+	wg.Wait()
+}
+
+func TestConcurrentMetricsAreExact(t *testing.T) {
+	cache := New(WithMetrics())
+	defer cache.Close()
+
+	cache.Set("key", "value")
+
+	var wg sync.WaitGroup
+
+	for g := 0; g < stressGoroutines; g++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < stressOperations; i++ {
+				cache.Get("key")
+				cache.Get("nokey")
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	metrics := cache.Metrics()
+	assert.EqualValues(t, stressGoroutines*stressOperations, metrics.Hits())
+	assert.EqualValues(t, stressGoroutines*stressOperations, metrics.Misses())
+}
+
+func TestConcurrentResetMetrics(t *testing.T) {
+	cache := New(WithMetrics())
+	defer cache.Close()
+
+	var wg sync.WaitGroup
+
+	for g := 0; g < 100; g++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < stressOperations; i++ {
+				cache.Set("key", i)
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < stressOperations; i++ {
+				cache.ResetMetrics()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	assert.LessOrEqual(t, cache.Metrics().Insertions(), uint64(100*stressOperations))
+}
+
+func TestConcurrentEventHandlersRegistration(t *testing.T) {
+	cache := New()
+	defer cache.Close()
+
+	var wg sync.WaitGroup
+
+	for g := 0; g < 100; g++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			cache.OnInsertion(func(_ string, _ interface{}) {})
+			cache.OnEviction(func(_ string, _ interface{}) {})
+		}()
+
+		go func(g int) {
+			defer wg.Done()
+
+			key := fmt.Sprint("key", g)
+			cache.Set(key, g)
+			cache.Delete(key)
+		}(g)
+	}
+
+	wg.Wait()
+}
+
+func TestSetWithoutTTLOverridesExpiration(t *testing.T) {
+	cache := New(WithTTL(0))
+	defer cache.Close()
+
+	cache.SetWithTTL("key1", "value1", time.Millisecond)
+	cache.Set("key1", "value2")
+	time.Sleep(2 * time.Millisecond)
+
+	cache.DeleteExpired()
+
+	assert.Equal(t, "value2", cache.Get("key1"))
+}
+
+func TestCloseIsIdempotent(t *testing.T) {
+	cache := New(WithCleanupInterval(time.Millisecond))
+
+	cache.Close()
+	cache.Close()
+}
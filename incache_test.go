@@ -1,6 +1,9 @@
 package incache
 
 import (
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -57,6 +60,61 @@ func TestNewCustomConfigWithoutCleanup(t *testing.T) {
 	assert.EqualValues(t, 0, cache.config.cleanupInterval)
 }
 
+func TestCleanerStartsLazily(t *testing.T) {
+	cache := New(WithTTL(0), WithCleanupInterval(time.Millisecond))
+	defer cache.Close()
+
+	cache.Set("key1", "value1")
+	assert.False(t, cache.cleaner.isRunning())
+
+	cache.SetWithTTL("key2", "value2", time.Millisecond)
+	assert.True(t, cache.cleaner.isRunning())
+
+	assert.Eventually(t, func() bool {
+		return !cache.Has("key2") && cache.LenIncludingExpired() == 1 && !cache.cleaner.isRunning()
+	}, time.Second, time.Millisecond)
+
+	cache.SetWithTTL("key3", "value3", time.Millisecond)
+	assert.True(t, cache.cleaner.isRunning())
+
+	assert.Eventually(t, func() bool {
+		return cache.LenIncludingExpired() == 1
+	}, time.Second, time.Millisecond)
+}
+
+func TestCleanerDoesNotStartAfterClose(t *testing.T) {
+	cache := New(WithTTL(time.Minute), WithCleanupInterval(time.Millisecond))
+	cache.Close()
+
+	cache.Set("key1", "value1")
+	assert.False(t, cache.cleaner.isRunning())
+}
+
+func TestPauseCleanup(t *testing.T) {
+	cache := New(WithTTL(time.Millisecond), WithCleanupInterval(time.Millisecond))
+	defer cache.Close()
+
+	cache.PauseCleanup()
+	cache.Set("key1", "value1")
+	time.Sleep(10 * time.Millisecond)
+
+	assert.Equal(t, 1, cache.LenIncludingExpired())
+	assert.Nil(t, cache.Get("key1"))
+
+	cache.ResumeCleanup()
+
+	assert.Eventually(t, func() bool {
+		return cache.LenIncludingExpired() == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestPauseCleanupWithoutCleaner(t *testing.T) {
+	cache := New(WithCleanupInterval(0))
+
+	cache.PauseCleanup()
+	cache.ResumeCleanup()
+}
+
 func TestSet(t *testing.T) {
 	cache := New()
 
@@ -65,6 +123,20 @@ func TestSet(t *testing.T) {
 	assert.Equal(t, "value1", cache.items["key1"].Value)
 }
 
+func TestSetDoesNotAllocate(t *testing.T) {
+	cache := New()
+	defer cache.Close()
+
+	cache.Set("key1", "value1")
+
+	allocs := testing.AllocsPerRun(100, func() {
+		cache.Set("key1", "value1")
+		cache.Get("key1")
+	})
+
+	assert.Zero(t, allocs)
+}
+
 func TestSetWithTTL(t *testing.T) {
 	cache := New()
 
@@ -74,6 +146,28 @@ func TestSetWithTTL(t *testing.T) {
 	assert.Nil(t, cache.Get("key1"))
 }
 
+func TestSetWithExpiresAt(t *testing.T) {
+	cache := New(WithTTL(0))
+
+	at := time.Now().Add(time.Hour).Round(0)
+	cache.SetWithExpiresAt("key1", "value1", at)
+
+	info, ok := cache.Inspect("key1")
+	assert.True(t, ok)
+	assert.True(t, at.Equal(info.ExpiresAt))
+	assert.True(t, at.Equal(cache.expirationsQueue["key1"]))
+	assert.Equal(t, "value1", cache.Get("key1"))
+
+	cache.SetWithExpiresAt("key1", "value2", time.Time{})
+
+	info, _ = cache.Inspect("key1")
+	assert.True(t, info.ExpiresAt.IsZero())
+	assert.Equal(t, "value2", cache.Get("key1"))
+
+	cache.SetWithExpiresAt("key1", "value3", time.Now().Add(-time.Second))
+	assert.False(t, cache.Has("key1"))
+}
+
 func TestSetGet(t *testing.T) {
 	cache := New()
 
@@ -157,6 +251,38 @@ func TestDelete(t *testing.T) {
 	assert.Len(t, cache.items, 0)
 }
 
+func TestDeleteReportsExistence(t *testing.T) {
+	cache := New(WithTTL(0))
+
+	cache.Set("key1", "value1")
+	cache.SetWithTTL("key2", "value2", time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+
+	assert.True(t, cache.Delete("key1"))
+	assert.False(t, cache.Delete("key1"))
+	assert.False(t, cache.Delete("key2"))
+	assert.Len(t, cache.items, 0)
+
+	// Only one of concurrent deletions of a key reports it.
+	cache.Set("key1", "value1")
+
+	var deleted int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if cache.Delete("key1") {
+				atomic.AddInt32(&deleted, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), deleted)
+}
+
 func TestDeleteAll(t *testing.T) {
 	cache := New()
 
@@ -168,6 +294,79 @@ func TestDeleteAll(t *testing.T) {
 	assert.Empty(t, cache.items)
 }
 
+func TestFlushAll(t *testing.T) {
+	cache := New(WithTTL(time.Minute), WithMetrics())
+
+	var evictions int32
+	cache.OnEviction(func(_ string, _ interface{}) {
+		atomic.AddInt32(&evictions, 1)
+	})
+
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+
+	cache.FlushAll()
+	cache.Close()
+
+	assert.Empty(t, cache.items)
+	assert.Empty(t, cache.expirationsQueue)
+	assert.EqualValues(t, 2, cache.Metrics().Evictions())
+	assert.Zero(t, atomic.LoadInt32(&evictions))
+}
+
+func TestFlushAllWithEvents(t *testing.T) {
+	var calls []string
+	hook := &recordingHook{name: "hook", calls: &calls}
+	cache := New(WithTTL(time.Minute), WithFlushEvents(), WithHook(hook))
+
+	var evictions int32
+	cache.OnEviction(func(_ string, _ interface{}) {
+		atomic.AddInt32(&evictions, 1)
+	})
+
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+	calls = nil
+
+	cache.FlushAll()
+	cache.Close()
+
+	assert.Empty(t, cache.items)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&evictions))
+	assert.ElementsMatch(t, []string{"hook:OnEvict(key1, value1)", "hook:OnEvict(key2, value2)"}, calls)
+}
+
+func TestFlushAllEventsAfterUnlock(t *testing.T) {
+	cache := New(WithTTL(0), WithFlushEvents(), WithEventWorkers(1, 1, EventOverflowBlock))
+
+	for i := 0; i < 4; i++ {
+		cache.Set(fmt.Sprint("key", i), i)
+	}
+
+	// The handler reads the cache, so it would deadlock with the full queue
+	// if events were emitted while the lock is held.
+	var evictions int32
+	cache.OnEviction(func(key string, _ interface{}) {
+		cache.Has(key)
+		atomic.AddInt32(&evictions, 1)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		cache.FlushAll()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("FlushAll is blocked by event handlers")
+	}
+
+	cache.Close()
+	assert.EqualValues(t, 4, atomic.LoadInt32(&evictions))
+}
+
 func TestDeleteExpired(t *testing.T) {
 	cache := New(WithTTL(1 * time.Millisecond))
 
@@ -175,11 +374,46 @@ func TestDeleteExpired(t *testing.T) {
 	cache.Set("key2", "value2")
 	time.Sleep(1 * time.Millisecond)
 
-	cache.DeleteExpired()
+	assert.Equal(t, 2, cache.DeleteExpired())
 
 	assert.Empty(t, cache.items)
 }
 
+func TestDeleteExpiredN(t *testing.T) {
+	cache := New(WithTTL(time.Millisecond))
+
+	for i := 0; i < 5; i++ {
+		cache.Set(fmt.Sprint("key", i), i)
+	}
+	cache.SetWithTTL("key", "value", 0)
+	time.Sleep(2 * time.Millisecond)
+
+	assert.Equal(t, 2, cache.DeleteExpiredN(2))
+	assert.Len(t, cache.items, 4)
+
+	assert.Equal(t, 3, cache.DeleteExpiredN(10))
+	assert.Equal(t, 0, cache.DeleteExpiredN(10))
+	assert.Len(t, cache.items, 1)
+}
+
+func TestDeleteExpiredRechecksItems(t *testing.T) {
+	cache := New(WithTTL(0), WithMetrics())
+
+	cache.SetWithTTL("key1", "value1", time.Hour)
+	cache.SetWithTTL("key2", "value2", time.Hour)
+
+	// Simulate stale entries of the queue.
+	cache.expirationsQueue["key1"] = time.Now().Add(-time.Hour)
+	cache.expirationsQueue["key3"] = time.Now().Add(-time.Hour)
+
+	cache.DeleteExpired()
+
+	assert.Len(t, cache.items, 2)
+	assert.Equal(t, cache.items["key1"].ExpiresAt, cache.expirationsQueue["key1"])
+	assert.NotContains(t, cache.expirationsQueue, "key3")
+	assert.Zero(t, cache.Metrics().Expired())
+}
+
 func TestKeys(t *testing.T) {
 	cache := New()
 
@@ -208,6 +442,22 @@ func TestHas(t *testing.T) {
 	assert.False(t, cache.Has("nokey"))
 }
 
+func TestReadsIgnoreExpiredItems(t *testing.T) {
+	cache := New(WithTTL(0))
+
+	cache.Set("key1", "value1")
+	cache.SetWithTTL("key2", "value2", time.Millisecond)
+	cache.SetWithTTL("key3", "value3", time.Hour)
+	time.Sleep(2 * time.Millisecond)
+
+	assert.True(t, cache.Has("key1"))
+	assert.False(t, cache.Has("key2"))
+	assert.True(t, cache.Has("key3"))
+	assert.Equal(t, 2, cache.Len())
+	assert.Equal(t, 3, cache.LenIncludingExpired())
+	assert.ElementsMatch(t, []string{"key1", "key3"}, cache.Keys())
+}
+
 func TestMetrics(t *testing.T) {
 	cache := New(WithMetrics())
 
@@ -223,6 +473,28 @@ func TestMetrics(t *testing.T) {
 	assert.EqualValues(t, 1, metrics.Hits())
 	assert.EqualValues(t, 1, metrics.Misses())
 	assert.EqualValues(t, 1, metrics.Evictions())
+	assert.EqualValues(t, 0, metrics.Expired())
+}
+
+func TestMetricsExpired(t *testing.T) {
+	cache := New(WithMetrics(), WithCleanupInterval(0))
+
+	cache.SetWithTTL("key1", "value1", 1*time.Millisecond)
+	cache.SetWithTTL("key2", "value2", 1*time.Millisecond)
+	cache.Set("key3", "value3")
+	time.Sleep(2 * time.Millisecond)
+
+	cache.DeleteExpired()
+	cache.Delete("key3")
+
+	metrics := cache.Metrics()
+
+	assert.EqualValues(t, 2, metrics.Expired())
+	assert.EqualValues(t, 1, metrics.Evictions())
+
+	cache.ResetMetrics()
+
+	assert.EqualValues(t, 0, metrics.Expired())
 }
 
 func TestNoMetricsType(t *testing.T) {
@@ -282,3 +554,163 @@ func TestOnEviction(t *testing.T) {
 		return len(checkCh) == 1
 	}, time.Millisecond*500, time.Millisecond*250)
 }
+
+func TestOnUpdate(t *testing.T) {
+	cache := New(WithTTL(0))
+
+	var (
+		mu         sync.Mutex
+		insertions []string
+		updates    []string
+	)
+
+	cache.OnInsertion(func(key string, value interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		insertions = append(insertions, fmt.Sprintf("%s=%v", key, value))
+	})
+
+	// Overwrites are reported as insertions until OnUpdate is registered.
+	cache.Set("key1", "value1")
+	cache.Set("key1", "value2")
+	cache.Close()
+
+	cache.OnUpdate(func(key string, oldValue, newValue interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		updates = append(updates, fmt.Sprintf("%s=%v->%v", key, oldValue, newValue))
+	})
+
+	cache.Set("key1", "value3")
+	cache.Set("key2", "value1")
+	cache.Close()
+
+	assert.ElementsMatch(t, []string{"key1=value1", "key1=value2", "key2=value1"}, insertions)
+	assert.Equal(t, []string{"key1=value2->value3"}, updates)
+}
+
+func TestOnExpiration(t *testing.T) {
+	cache := New(WithTTL(time.Millisecond))
+
+	var evictions, expirations int32
+	cache.OnEviction(func(_ string, _ interface{}) {
+		atomic.AddInt32(&evictions, 1)
+	})
+	cache.OnExpiration(func(_ string, _ interface{}) {
+		atomic.AddInt32(&expirations, 1)
+	})
+
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+	cache.Delete("key1")
+	time.Sleep(2 * time.Millisecond)
+	cache.DeleteExpired()
+	cache.Close()
+
+	assert.EqualValues(t, 1, atomic.LoadInt32(&evictions))
+	assert.EqualValues(t, 1, atomic.LoadInt32(&expirations))
+}
+
+func TestSetOverExpiredItemReportsExpiration(t *testing.T) {
+	cache := New(WithTTL(time.Millisecond), WithMetrics())
+
+	var insertions, updates, expirations int32
+	cache.OnInsertion(func(_ string, _ interface{}) {
+		atomic.AddInt32(&insertions, 1)
+	})
+	cache.OnUpdate(func(_ string, _, _ interface{}) {
+		atomic.AddInt32(&updates, 1)
+	})
+	cache.OnExpiration(func(_ string, _ interface{}) {
+		atomic.AddInt32(&expirations, 1)
+	})
+
+	cache.Set("key1", "value1")
+	time.Sleep(2 * time.Millisecond)
+	cache.Set("key1", "value2")
+	cache.Close()
+
+	assert.EqualValues(t, 2, atomic.LoadInt32(&insertions))
+	assert.Zero(t, atomic.LoadInt32(&updates))
+	assert.EqualValues(t, 1, atomic.LoadInt32(&expirations))
+	assert.EqualValues(t, 1, cache.Metrics().Expired())
+}
+
+func TestOnEvictionReceivesValue(t *testing.T) {
+	cache := New(WithTTL(time.Millisecond))
+	values := make(chan interface{}, 3)
+
+	cache.OnEviction(func(_ string, value interface{}) {
+		values <- value
+	})
+
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+	cache.SetWithTTL("key3", "value3", 0)
+
+	cache.Delete("key1")
+	cache.GetDelete("key3")
+	time.Sleep(2 * time.Millisecond)
+	cache.DeleteExpired()
+	cache.Close()
+	close(values)
+
+	var received []interface{}
+	for value := range values {
+		received = append(received, value)
+	}
+
+	assert.ElementsMatch(t, []interface{}{"value1", "value2", "value3"}, received)
+}
+
+func TestMemoryUsage(t *testing.T) {
+	cache := New()
+
+	assert.Zero(t, cache.MemoryUsage())
+
+	cache.Set("key1", "value1")
+	cache.Set("key2", []byte("value2"))
+
+	expected := 2*(uint64(len("key1"))+itemOverhead) + estimateSize("value1") + estimateSize([]byte("value2"))
+	assert.Equal(t, expected, cache.MemoryUsage())
+
+	cache.Delete("key1")
+	cache.Delete("key2")
+
+	assert.Zero(t, cache.MemoryUsage())
+}
+
+func TestDetailedMetrics(t *testing.T) {
+	cache := New(WithDetailedMetrics())
+
+	cache.Set("key1", "value1")
+	cache.Get("key1")
+	cache.Get("key2")
+	cache.Delete("key1")
+
+	metrics := cache.Metrics()
+
+	assert.EqualValues(t, 1, metrics.Insertions())
+	assert.EqualValues(t, 1, metrics.SetLatency().Count)
+	assert.EqualValues(t, 2, metrics.GetLatency().Count)
+	assert.EqualValues(t, 1, metrics.DeleteLatency().Count)
+
+	cache.ResetMetrics()
+
+	assert.Zero(t, metrics.GetLatency().Count)
+}
+
+func TestLatenciesWithoutDetailedMetrics(t *testing.T) {
+	cache := New(WithMetrics())
+
+	cache.Set("key1", "value1")
+	cache.Get("key1")
+
+	metrics := cache.Metrics()
+
+	assert.Zero(t, metrics.SetLatency().Count)
+	assert.Zero(t, metrics.GetLatency().Count)
+	assert.Empty(t, metrics.GetLatency().Buckets)
+}
@@ -0,0 +1,247 @@
+// Package incachebolt provides an incache.Backend that stores values in a
+// bbolt database, which gives durable local storage with per-key
+// granularity instead of whole-file snapshots:
+//
+//	backend, err := incachebolt.Open("cache.db", incachebolt.Options{})
+//	if err != nil {
+//		return err
+//	}
+//	defer backend.Close()
+//
+//	tiered := incache.Tiered(incache.New(), backend)
+package incachebolt
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/wittyjudge/incache"
+)
+
+// DefaultBucket is the name of the bucket the values are stored in by default.
+const DefaultBucket = "incache"
+
+// Options configures a Backend.
+type Options struct {
+	// Bucket the values are stored in, DefaultBucket is used if it's empty.
+	Bucket string
+	// Codec encodes the values, incache.GobValueCodec is used if it's nil.
+	Codec incache.Codec
+	// Timeout of acquiring the lock of the database file, which is held by
+	// one process at a time. Zero means waiting indefinitely.
+	Timeout time.Duration
+}
+
+// Backend stores values in a bbolt database. Every value is stored along
+// with its expiration time, expired values are reported as missing and are
+// removed by DeleteExpired.
+type Backend struct {
+	db     *bolt.DB
+	bucket []byte
+	codec  incache.Codec
+}
+
+var (
+	_ incache.Backend      = (*Backend)(nil)
+	_ incache.BatchBackend = (*Backend)(nil)
+)
+
+// Open opens the database at path, creating it if it doesn't exist.
+func Open(path string, opts Options) (*Backend, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: opts.Timeout})
+	if err != nil {
+		return nil, err
+	}
+
+	backend, err := New(db, opts)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return backend, nil
+}
+
+// New returns a backend that stores values in the open database.
+func New(db *bolt.DB, opts Options) (*Backend, error) {
+	if opts.Bucket == "" {
+		opts.Bucket = DefaultBucket
+	}
+
+	if opts.Codec == nil {
+		opts.Codec = incache.GobValueCodec
+	}
+
+	b := &Backend{db: db, bucket: []byte(opts.Bucket), codec: opts.Codec}
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(b.bucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Close closes the database.
+func (b *Backend) Close() error {
+	return b.db.Close()
+}
+
+// Get returns the value of key along with its remaining TTL.
+// incache.ErrNotFound is returned if the key doesn't exist or has expired.
+func (b *Backend) Get(ctx context.Context, key string) (interface{}, time.Duration, error) {
+	var (
+		value interface{}
+		ttl   time.Duration
+	)
+
+	err := b.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(b.bucket).Get([]byte(key))
+		if data == nil {
+			return incache.ErrNotFound
+		}
+
+		expiresAt, encoded, err := decodeRecord(data)
+		if err != nil {
+			return err
+		}
+
+		if expiresAt != 0 {
+			ttl = time.Until(time.Unix(0, expiresAt))
+			if ttl <= 0 {
+				return incache.ErrNotFound
+			}
+		}
+
+		// The data is only valid during the transaction.
+		value, err = b.codec.Decode(encoded)
+
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return value, ttl, nil
+}
+
+// Set stores the value of key for ttl. Zero TTL means that the value never
+// expires.
+func (b *Backend) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	return b.WriteBatch(ctx, []incache.BackendOp{{Key: key, Value: value, TTL: ttl}})
+}
+
+// Delete deletes the value of key.
+func (b *Backend) Delete(ctx context.Context, key string) error {
+	return b.WriteBatch(ctx, []incache.BackendOp{{Key: key, Delete: true}})
+}
+
+// WriteBatch applies all operations in a single transaction.
+func (b *Backend) WriteBatch(ctx context.Context, ops []incache.BackendOp) error {
+	records := make([][]byte, len(ops))
+
+	// Values are encoded before the transaction, so it's held for less time.
+	for i, op := range ops {
+		if op.Delete {
+			continue
+		}
+
+		data, err := b.codec.Encode(op.Value)
+		if err != nil {
+			return err
+		}
+
+		records[i] = encodeRecord(op.TTL, data)
+	}
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.bucket)
+
+		for i, op := range ops {
+			var err error
+
+			if op.Delete {
+				err = bucket.Delete([]byte(op.Key))
+			} else {
+				err = bucket.Put([]byte(op.Key), records[i])
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// DeleteExpired removes the expired values and returns their number.
+func (b *Backend) DeleteExpired() (int, error) {
+	now := time.Now().UnixNano()
+
+	var n int
+
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.bucket)
+
+		// Deleting while iterating makes the cursor skip items, so the keys
+		// are collected first.
+		var expired [][]byte
+
+		err := bucket.ForEach(func(key, data []byte) error {
+			if expiresAt, _, err := decodeRecord(data); err == nil && expiresAt != 0 && expiresAt <= now {
+				expired = append(expired, key)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, key := range expired {
+			if err := bucket.Delete(key); err != nil {
+				return err
+			}
+		}
+
+		n = len(expired)
+
+		return nil
+	})
+
+	return n, err
+}
+
+// errInvalidRecord is returned for records that weren't written by Backend.
+var errInvalidRecord = errors.New("incachebolt: invalid record")
+
+// A record is the expiration time in Unix nanoseconds, zero if the value
+// never expires, followed by the encoded value.
+func encodeRecord(ttl time.Duration, data []byte) []byte {
+	var expiresAt int64
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl).UnixNano()
+	}
+
+	record := make([]byte, 8+len(data))
+	binary.BigEndian.PutUint64(record, uint64(expiresAt))
+	copy(record[8:], data)
+
+	return record
+}
+
+func decodeRecord(record []byte) (int64, []byte, error) {
+	if len(record) < 8 {
+		return 0, nil, errInvalidRecord
+	}
+
+	return int64(binary.BigEndian.Uint64(record)), record[8:], nil
+}
@@ -0,0 +1,135 @@
+package incachebolt
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/wittyjudge/incache"
+)
+
+func openBackend(t *testing.T, path string) *Backend {
+	t.Helper()
+
+	backend, err := Open(path, Options{})
+	assert.NoError(t, err)
+
+	return backend
+}
+
+func TestBackend(t *testing.T) {
+	ctx := context.Background()
+	backend := openBackend(t, filepath.Join(t.TempDir(), "cache.db"))
+	defer backend.Close()
+
+	assert.NoError(t, backend.Set(ctx, "key1", "value1", 0))
+	assert.NoError(t, backend.Set(ctx, "key2", 42, time.Minute))
+
+	value, ttl, err := backend.Get(ctx, "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "value1", value)
+	assert.Zero(t, ttl)
+
+	value, ttl, err = backend.Get(ctx, "key2")
+	assert.NoError(t, err)
+	assert.Equal(t, 42, value)
+	assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+
+	assert.NoError(t, backend.Delete(ctx, "key1"))
+	assert.NoError(t, backend.Delete(ctx, "missing"))
+
+	_, _, err = backend.Get(ctx, "key1")
+	assert.ErrorIs(t, err, incache.ErrNotFound)
+}
+
+func TestBackendIsDurable(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache.db")
+
+	backend := openBackend(t, path)
+	assert.NoError(t, backend.Set(ctx, "key1", "value1", 0))
+	assert.NoError(t, backend.Close())
+
+	backend = openBackend(t, path)
+	defer backend.Close()
+
+	value, _, err := backend.Get(ctx, "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "value1", value)
+}
+
+func TestBackendExpiration(t *testing.T) {
+	ctx := context.Background()
+	backend := openBackend(t, filepath.Join(t.TempDir(), "cache.db"))
+	defer backend.Close()
+
+	assert.NoError(t, backend.WriteBatch(ctx, []incache.BackendOp{
+		{Key: "key1", Value: "value1", TTL: time.Millisecond},
+		{Key: "key2", Value: "value2", TTL: time.Millisecond},
+		{Key: "key3", Value: "value3"},
+	}))
+
+	time.Sleep(5 * time.Millisecond)
+
+	_, _, err := backend.Get(ctx, "key1")
+	assert.ErrorIs(t, err, incache.ErrNotFound)
+
+	n, err := backend.DeleteExpired()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	value, _, err := backend.Get(ctx, "key3")
+	assert.NoError(t, err)
+	assert.Equal(t, "value3", value)
+}
+
+func TestBackendWithWriteBehind(t *testing.T) {
+	ctx := context.Background()
+	backend := openBackend(t, filepath.Join(t.TempDir(), "cache.db"))
+	defer backend.Close()
+
+	cache := incache.New(incache.WithWriteBehind(backend, time.Hour, 100))
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+	cache.Delete("key2")
+
+	assert.NoError(t, cache.Flush(ctx))
+	cache.Close()
+
+	value, ttl, err := backend.Get(ctx, "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "value1", value)
+	assert.Greater(t, ttl, time.Duration(0))
+
+	_, _, err = backend.Get(ctx, "key2")
+	assert.ErrorIs(t, err, incache.ErrNotFound)
+}
+
+func TestBackendCustomBucketAndCodec(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "cache.db"), 0o600, nil)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	backend, err := New(db, Options{Bucket: "users", Codec: incache.JSONValueCodec})
+	assert.NoError(t, err)
+
+	assert.NoError(t, backend.Set(ctx, "user:1", map[string]interface{}{"name": "alice"}, 0))
+
+	assert.NoError(t, db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket([]byte("users")).Get([]byte("user:1"))
+		assert.Equal(t, `{"name":"alice"}`, string(data[8:]))
+
+		return nil
+	}))
+}
+
+func TestDecodeRecord(t *testing.T) {
+	_, _, err := decodeRecord([]byte{1, 2})
+	assert.ErrorIs(t, err, errInvalidRecord)
+}
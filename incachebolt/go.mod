@@ -0,0 +1,16 @@
+module github.com/wittyjudge/incache/incachebolt
+
+go 1.23
+
+require (
+	github.com/stretchr/testify v1.11.1
+	github.com/wittyjudge/incache v0.1.0
+	go.etcd.io/bbolt v1.4.3
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/sys v0.29.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,56 @@
+// Package incachemsgpack provides a MessagePack snapshot codec for incache.
+//
+// Importing the package registers the codec, so snapshots encoded with it
+// can be loaded by incache.Cache.LoadSnapshot:
+//
+//	import _ "github.com/wittyjudge/incache/incachemsgpack"
+package incachemsgpack
+
+import (
+	"io"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/wittyjudge/incache"
+)
+
+// Codec encodes snapshots with MessagePack, which is usually more compact
+// and faster than gob.
+//
+// Values are decoded into generic types, e.g. structs become
+// map[string]interface{}. Use msgpack.RegisterExt to preserve concrete
+// types of stored values.
+var Codec incache.SnapshotCodec = codec{}
+
+func init() {
+	incache.RegisterSnapshotCodec(Codec)
+}
+
+type codec struct{}
+
+func (codec) Name() string {
+	return "msgpack"
+}
+
+func (codec) NewEncoder(w io.Writer) incache.SnapshotEncoder {
+	return &encoder{enc: msgpack.NewEncoder(w)}
+}
+
+func (codec) NewDecoder(r io.Reader) incache.SnapshotDecoder {
+	return &decoder{dec: msgpack.NewDecoder(r)}
+}
+
+type encoder struct {
+	enc *msgpack.Encoder
+}
+
+func (e *encoder) Encode(entry incache.SnapshotEntry) error {
+	return e.enc.Encode(&entry)
+}
+
+type decoder struct {
+	dec *msgpack.Decoder
+}
+
+func (d *decoder) Decode(entry *incache.SnapshotEntry) error {
+	return d.dec.Decode(entry)
+}
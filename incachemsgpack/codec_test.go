@@ -0,0 +1,70 @@
+package incachemsgpack
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/wittyjudge/incache"
+)
+
+type point struct {
+	X, Y int
+}
+
+func (p *point) MarshalMsgpack() ([]byte, error) {
+	return msgpack.Marshal([]int{p.X, p.Y})
+}
+
+func (p *point) UnmarshalMsgpack(data []byte) error {
+	var coords []int
+	if err := msgpack.Unmarshal(data, &coords); err != nil {
+		return err
+	}
+
+	p.X, p.Y = coords[0], coords[1]
+
+	return nil
+}
+
+func init() {
+	msgpack.RegisterExt(1, (*point)(nil))
+}
+
+func TestCodecIsRegistered(t *testing.T) {
+	codec, ok := incache.SnapshotCodecByName("msgpack")
+	require.True(t, ok)
+	assert.Equal(t, Codec, codec)
+}
+
+func TestCodec(t *testing.T) {
+	source := incache.New()
+	defer source.Close()
+
+	source.SetWithTTL("key1", "value1", time.Minute)
+	source.SetWithTTL("key2", []byte("value2"), 0)
+	source.SetWithTTL("key3", &point{X: 1, Y: 2}, 0)
+
+	var buf bytes.Buffer
+	require.NoError(t, source.SaveSnapshot(&buf, Codec))
+	assert.True(t, strings.HasPrefix(buf.String(), "incache/2:msgpack\n"))
+
+	cache := incache.New()
+	defer cache.Close()
+
+	require.NoError(t, cache.LoadSnapshot(&buf))
+
+	assert.Equal(t, 3, cache.Len())
+	assert.Equal(t, "value1", cache.Get("key1"))
+	assert.Equal(t, []byte("value2"), cache.Get("key2"))
+	assert.Equal(t, &point{X: 1, Y: 2}, cache.Get("key3"))
+
+	info, ok := cache.Inspect("key1")
+	require.True(t, ok)
+	assert.Equal(t, time.Minute, info.TTL)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), info.ExpiresAt, time.Second)
+}
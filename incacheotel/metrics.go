@@ -0,0 +1,94 @@
+// Package incacheotel integrates incache with OpenTelemetry.
+package incacheotel
+
+import (
+	"context"
+
+	"github.com/wittyjudge/incache"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const instrumentationName = "github.com/wittyjudge/incache/incacheotel"
+
+// MetricsOpts allows to adjust the registered instruments.
+type MetricsOpts struct {
+	// Attributes are attached to all observed values. It's useful
+	// to distinguish several caches reporting to the same provider.
+	Attributes []attribute.KeyValue
+}
+
+// RegisterMetrics registers cache metrics as asynchronous instruments
+// of a meter created by the provider.
+//
+// The following instruments are registered:
+//   - incache.hits, incache.misses, incache.insertions, incache.evictions
+//     and incache.expired counters;
+//   - incache.entries gauge with the number of stored items;
+//   - incache.memory.usage gauge with the estimated memory usage in bytes.
+//
+// Counters are read from the cache on every collection, so the cache has
+// to be created with incache.WithMetrics() to get non zero values.
+// Call Unregister on the returned registration to stop reporting.
+func RegisterMetrics(cache *incache.Cache, provider metric.MeterProvider, opts MetricsOpts) (metric.Registration, error) {
+	meter := provider.Meter(instrumentationName)
+
+	hits, err := meter.Int64ObservableCounter("incache.hits",
+		metric.WithDescription("Number of times items were successfully retrieved"))
+	if err != nil {
+		return nil, err
+	}
+
+	misses, err := meter.Int64ObservableCounter("incache.misses",
+		metric.WithDescription("Number of times items weren't retrieved"))
+	if err != nil {
+		return nil, err
+	}
+
+	insertions, err := meter.Int64ObservableCounter("incache.insertions",
+		metric.WithDescription("Number of times items were inserted"))
+	if err != nil {
+		return nil, err
+	}
+
+	evictions, err := meter.Int64ObservableCounter("incache.evictions",
+		metric.WithDescription("Number of times items were released from the cache"))
+	if err != nil {
+		return nil, err
+	}
+
+	expired, err := meter.Int64ObservableCounter("incache.expired",
+		metric.WithDescription("Number of times items were removed because their TTL has passed"))
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := meter.Int64ObservableGauge("incache.entries",
+		metric.WithDescription("Number of items currently stored in the cache"))
+	if err != nil {
+		return nil, err
+	}
+
+	memory, err := meter.Int64ObservableGauge("incache.memory.usage",
+		metric.WithDescription("Estimated memory occupied by stored items"),
+		metric.WithUnit("By"))
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := metric.WithAttributes(opts.Attributes...)
+
+	return meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		metrics := cache.Metrics()
+
+		o.ObserveInt64(hits, int64(metrics.Hits()), attrs)
+		o.ObserveInt64(misses, int64(metrics.Misses()), attrs)
+		o.ObserveInt64(insertions, int64(metrics.Insertions()), attrs)
+		o.ObserveInt64(evictions, int64(metrics.Evictions()), attrs)
+		o.ObserveInt64(expired, int64(metrics.Expired()), attrs)
+		o.ObserveInt64(entries, int64(cache.Len()), attrs)
+		o.ObserveInt64(memory, int64(cache.MemoryUsage()), attrs)
+
+		return nil
+	}, hits, misses, insertions, evictions, expired, entries, memory)
+}
@@ -0,0 +1,76 @@
+package incacheotel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wittyjudge/incache"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func collect(t *testing.T, reader sdkmetric.Reader) map[string]int64 {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	values := make(map[string]int64)
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			switch data := m.Data.(type) {
+			case metricdata.Sum[int64]:
+				values[m.Name] = data.DataPoints[0].Value
+			case metricdata.Gauge[int64]:
+				values[m.Name] = data.DataPoints[0].Value
+			}
+		}
+	}
+
+	return values
+}
+
+func TestRegisterMetrics(t *testing.T) {
+	cache := incache.New(incache.WithMetrics())
+	defer cache.Close()
+
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	_, err := RegisterMetrics(cache, provider, MetricsOpts{
+		Attributes: []attribute.KeyValue{attribute.String("cache", "users")},
+	})
+	require.NoError(t, err)
+
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+	cache.Get("key1")
+	cache.Get("key3")
+	cache.Delete("key2")
+
+	values := collect(t, reader)
+
+	assert.EqualValues(t, 1, values["incache.hits"])
+	assert.EqualValues(t, 1, values["incache.misses"])
+	assert.EqualValues(t, 2, values["incache.insertions"])
+	assert.EqualValues(t, 1, values["incache.evictions"])
+	assert.EqualValues(t, 0, values["incache.expired"])
+	assert.EqualValues(t, 1, values["incache.entries"])
+	assert.EqualValues(t, cache.MemoryUsage(), values["incache.memory.usage"])
+}
+
+func TestRegisterMetricsUnregister(t *testing.T) {
+	cache := incache.New(incache.WithMetrics())
+	defer cache.Close()
+
+	reader := sdkmetric.NewManualReader()
+	provider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	registration, err := RegisterMetrics(cache, provider, MetricsOpts{})
+	require.NoError(t, err)
+	require.NoError(t, registration.Unregister())
+
+	assert.Empty(t, collect(t, reader))
+}
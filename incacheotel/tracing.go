@@ -0,0 +1,114 @@
+package incacheotel
+
+import (
+	"context"
+	"time"
+
+	"github.com/wittyjudge/incache"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	keyAttribute = attribute.Key("incache.key")
+	hitAttribute = attribute.Key("incache.hit")
+	ttlAttribute = attribute.Key("incache.ttl")
+)
+
+// TracingOpts allows to adjust how cache operations are traced.
+type TracingOpts struct {
+	// SpanEvents makes the cache add events to the span found in the context
+	// instead of starting a new span for every operation. It's cheaper,
+	// but operations aren't timed.
+	SpanEvents bool
+
+	// RecordKeys adds the key of every operation to its attributes.
+	// Keep it disabled if keys may contain sensitive data.
+	RecordKeys bool
+}
+
+// TracedCache wraps the cache and records every Get, Set and Delete
+// operation in the trace propagated through the context, so the cache
+// behavior shows up in distributed traces of request handlers.
+type TracedCache struct {
+	cache  *incache.Cache
+	tracer trace.Tracer
+	opts   TracingOpts
+}
+
+// NewTracedCache creates new traced wrapper of the cache, which uses tracer
+// created by the provider.
+func NewTracedCache(cache *incache.Cache, provider trace.TracerProvider, opts TracingOpts) *TracedCache {
+	return &TracedCache{
+		cache:  cache,
+		tracer: provider.Tracer(instrumentationName),
+		opts:   opts,
+	}
+}
+
+// Cache returns the wrapped cache.
+func (t *TracedCache) Cache() *incache.Cache {
+	return t.cache
+}
+
+// Get works similar to incache.Cache.Get. The recorded span or event
+// has an incache.hit attribute that reports whether the value was found.
+func (t *TracedCache) Get(ctx context.Context, key string) interface{} {
+	finish := t.start(ctx, "incache.Get", key)
+
+	value := t.cache.Get(key)
+	finish(hitAttribute.Bool(value != nil))
+
+	return value
+}
+
+// Set works similar to incache.Cache.Set.
+func (t *TracedCache) Set(ctx context.Context, key string, value interface{}) {
+	finish := t.start(ctx, "incache.Set", key)
+
+	t.cache.Set(key, value)
+	finish()
+}
+
+// SetWithTTL works similar to incache.Cache.SetWithTTL.
+func (t *TracedCache) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) {
+	finish := t.start(ctx, "incache.Set", key)
+
+	t.cache.SetWithTTL(key, value, ttl)
+	finish(ttlAttribute.String(ttl.String()))
+}
+
+// Delete works similar to incache.Cache.Delete.
+func (t *TracedCache) Delete(ctx context.Context, key string) {
+	finish := t.start(ctx, "incache.Delete", key)
+
+	t.cache.Delete(key)
+	finish()
+}
+
+// start begins recording of the operation and returns a function that
+// finishes it with additional attributes.
+func (t *TracedCache) start(ctx context.Context, name, key string) func(attrs ...attribute.KeyValue) {
+	var base []attribute.KeyValue
+	if t.opts.RecordKeys {
+		base = append(base, keyAttribute.String(key))
+	}
+
+	if t.opts.SpanEvents {
+		span := trace.SpanFromContext(ctx)
+
+		return func(attrs ...attribute.KeyValue) {
+			span.AddEvent(name, trace.WithAttributes(append(base, attrs...)...))
+		}
+	}
+
+	_, span := t.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(base...),
+	)
+
+	return func(attrs ...attribute.KeyValue) {
+		span.SetAttributes(attrs...)
+		span.End()
+	}
+}
@@ -0,0 +1,91 @@
+package incacheotel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wittyjudge/incache"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newTracerProvider() (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	return provider, recorder
+}
+
+func attributes(kvs []attribute.KeyValue) map[attribute.Key]attribute.Value {
+	m := make(map[attribute.Key]attribute.Value, len(kvs))
+	for _, kv := range kvs {
+		m[kv.Key] = kv.Value
+	}
+
+	return m
+}
+
+func TestTracedCacheSpans(t *testing.T) {
+	cache := incache.New()
+	defer cache.Close()
+
+	provider, recorder := newTracerProvider()
+	traced := NewTracedCache(cache, provider, TracingOpts{RecordKeys: true})
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+
+	traced.SetWithTTL(ctx, "key1", "value1", time.Minute)
+	assert.Equal(t, "value1", traced.Get(ctx, "key1"))
+	assert.Nil(t, traced.Get(ctx, "key2"))
+	traced.Delete(ctx, "key1")
+
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 5)
+
+	names := make([]string, 0, len(spans))
+	for _, span := range spans[:4] {
+		names = append(names, span.Name())
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+	}
+	assert.Equal(t, []string{"incache.Set", "incache.Get", "incache.Get", "incache.Delete"}, names)
+
+	hit := attributes(spans[1].Attributes())
+	assert.Equal(t, "key1", hit[keyAttribute].AsString())
+	assert.True(t, hit[hitAttribute].AsBool())
+
+	miss := attributes(spans[2].Attributes())
+	assert.False(t, miss[hitAttribute].AsBool())
+}
+
+func TestTracedCacheSpanEvents(t *testing.T) {
+	cache := incache.New()
+	defer cache.Close()
+
+	provider, recorder := newTracerProvider()
+	traced := NewTracedCache(cache, provider, TracingOpts{SpanEvents: true})
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "request")
+
+	traced.Set(ctx, "key1", "value1")
+	traced.Get(ctx, "key1")
+
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+
+	events := spans[0].Events()
+	require.Len(t, events, 2)
+	assert.Equal(t, "incache.Set", events[0].Name)
+	assert.Equal(t, "incache.Get", events[1].Name)
+
+	attrs := attributes(events[1].Attributes)
+	assert.True(t, attrs[hitAttribute].AsBool())
+	assert.NotContains(t, attrs, keyAttribute)
+}
@@ -0,0 +1,114 @@
+// Package incacheprom provides a Prometheus collector that exposes
+// incache metrics.
+//
+// Example:
+//
+//	cache := incache.New(incache.WithMetrics())
+//	prometheus.MustRegister(incacheprom.NewCollector(cache, incacheprom.Opts{}))
+package incacheprom
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/wittyjudge/incache"
+)
+
+const defaultNamespace = "incache"
+
+// Opts allows to adjust the names and labels of exposed metrics.
+type Opts struct {
+	// Namespace is used as a prefix of all metric names.
+	// Default value is "incache".
+	Namespace string
+
+	// ConstLabels are attached to all exposed metrics. It's useful
+	// to distinguish several caches registered in the same registry.
+	ConstLabels prometheus.Labels
+}
+
+// Collector implements prometheus.Collector and exposes cache metrics.
+//
+// Metrics are read from the cache on every scrape, so the cache has to be
+// created with incache.WithMetrics() to get non zero counters.
+//
+// Besides the counters, the collector exposes the histogram of remaining
+// TTLs of the items and the number of items without TTL, which are
+// computed from all items on every scrape, see
+// incache.Cache.ExpirationSummary.
+type Collector struct {
+	cache *incache.Cache
+
+	insertions   *prometheus.Desc
+	hits         *prometheus.Desc
+	misses       *prometheus.Desc
+	evictions    *prometheus.Desc
+	expired      *prometheus.Desc
+	items        *prometheus.Desc
+	remainingTTL *prometheus.Desc
+	withoutTTL   *prometheus.Desc
+}
+
+var _ prometheus.Collector = (*Collector)(nil)
+
+// NewCollector creates new collector for the cache.
+func NewCollector(cache *incache.Cache, opts Opts) *Collector {
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+
+	newDesc := func(name, help string) *prometheus.Desc {
+		fqName := prometheus.BuildFQName(namespace, "items", name)
+		return prometheus.NewDesc(fqName, help, nil, opts.ConstLabels)
+	}
+
+	return &Collector{
+		cache: cache,
+
+		insertions: newDesc("inserted_total", "Number of items inserted"),
+		hits:       newDesc("hitted_total", "Number of items hitted"),
+		misses:     newDesc("missed_total", "Number of items missed"),
+		evictions:  newDesc("evicted_total", "Number of items evicted"),
+		expired:    newDesc("expired_total", "Number of items expired"),
+		items:      newDesc("count_current", "Number of items currently stored in cache"),
+
+		remainingTTL: newDesc("ttl_remaining_seconds", "Remaining TTL of items that can expire"),
+		withoutTTL:   newDesc("without_ttl_current", "Number of items that never expire"),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.insertions
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.evictions
+	ch <- c.expired
+	ch <- c.items
+	ch <- c.remainingTTL
+	ch <- c.withoutTTL
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	metrics := c.cache.Metrics()
+
+	ch <- prometheus.MustNewConstMetric(c.insertions, prometheus.CounterValue, float64(metrics.Insertions()))
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(metrics.Hits()))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(metrics.Misses()))
+	ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(metrics.Evictions()))
+	ch <- prometheus.MustNewConstMetric(c.expired, prometheus.CounterValue, float64(metrics.Expired()))
+	ch <- prometheus.MustNewConstMetric(c.items, prometheus.GaugeValue, float64(c.cache.Len()))
+
+	summary := c.cache.ExpirationSummary()
+
+	// Buckets of the summary aren't cumulative, the last one is +Inf.
+	buckets := make(map[float64]uint64, len(summary.Buckets)-1)
+	count := uint64(0)
+	for _, bucket := range summary.Buckets[:len(summary.Buckets)-1] {
+		count += uint64(bucket.Count)
+		buckets[bucket.UpperBound.Seconds()] = count
+	}
+
+	ch <- prometheus.MustNewConstHistogram(c.remainingTTL, uint64(summary.Expiring), summary.RemainingTTL.Seconds(), buckets)
+	ch <- prometheus.MustNewConstMetric(c.withoutTTL, prometheus.GaugeValue, float64(summary.NeverExpiring))
+}
@@ -0,0 +1,129 @@
+package incacheprom
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wittyjudge/incache"
+)
+
+func TestCollector(t *testing.T) {
+	cache := incache.New(incache.WithMetrics())
+	defer cache.Close()
+
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+	cache.Get("key1")
+	cache.Get("key3")
+	cache.Delete("key2")
+
+	collector := NewCollector(cache, Opts{})
+
+	expected := `
+# HELP incache_items_count_current Number of items currently stored in cache
+# TYPE incache_items_count_current gauge
+incache_items_count_current 1
+# HELP incache_items_evicted_total Number of items evicted
+# TYPE incache_items_evicted_total counter
+incache_items_evicted_total 1
+# HELP incache_items_expired_total Number of items expired
+# TYPE incache_items_expired_total counter
+incache_items_expired_total 0
+# HELP incache_items_hitted_total Number of items hitted
+# TYPE incache_items_hitted_total counter
+incache_items_hitted_total 1
+# HELP incache_items_inserted_total Number of items inserted
+# TYPE incache_items_inserted_total counter
+incache_items_inserted_total 2
+# HELP incache_items_missed_total Number of items missed
+# TYPE incache_items_missed_total counter
+incache_items_missed_total 1
+`
+
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"incache_items_count_current",
+		"incache_items_evicted_total",
+		"incache_items_expired_total",
+		"incache_items_hitted_total",
+		"incache_items_inserted_total",
+		"incache_items_missed_total",
+	)
+	assert.NoError(t, err)
+}
+
+func TestCollectorExpiration(t *testing.T) {
+	cache := incache.New(incache.WithTTL(0))
+	defer cache.Close()
+
+	cache.SetWithTTL("key1", "value1", 30*time.Second)
+	cache.SetWithTTL("key2", "value2", 2*time.Hour)
+	cache.Set("key3", "value3")
+	cache.Set("key4", "value4")
+	cache.Set("key5", "value5")
+
+	collector := NewCollector(cache, Opts{})
+
+	expected := `
+# HELP incache_items_without_ttl_current Number of items that never expire
+# TYPE incache_items_without_ttl_current gauge
+incache_items_without_ttl_current 3
+`
+
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "incache_items_without_ttl_current")
+	assert.NoError(t, err)
+
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(collector))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	var histogram *dto.Histogram
+	for _, family := range families {
+		if family.GetName() == "incache_items_ttl_remaining_seconds" {
+			histogram = family.GetMetric()[0].GetHistogram()
+		}
+	}
+	require.NotNil(t, histogram)
+
+	assert.EqualValues(t, 2, histogram.GetSampleCount())
+	assert.InDelta(t, 7230, histogram.GetSampleSum(), 1)
+
+	cumulative := map[float64]uint64{}
+	for _, bucket := range histogram.GetBucket() {
+		cumulative[bucket.GetUpperBound()] = bucket.GetCumulativeCount()
+	}
+	assert.EqualValues(t, 0, cumulative[10])
+	assert.EqualValues(t, 1, cumulative[60])
+	assert.EqualValues(t, 1, cumulative[3600])
+	assert.EqualValues(t, 2, cumulative[6*3600])
+}
+
+func TestCollectorOpts(t *testing.T) {
+	cache := incache.New()
+	defer cache.Close()
+
+	collector := NewCollector(cache, Opts{
+		Namespace:   "custom",
+		ConstLabels: prometheus.Labels{"cache": "users"},
+	})
+
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(collector))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 8)
+
+	for _, family := range families {
+		assert.True(t, strings.HasPrefix(family.GetName(), "custom_items_"))
+		require.Len(t, family.GetMetric(), 1)
+		assert.Equal(t, "users", family.GetMetric()[0].GetLabel()[0].GetValue())
+	}
+}
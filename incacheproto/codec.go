@@ -0,0 +1,51 @@
+// Package incacheproto provides a protobuf value codec for incache.
+//
+// Register it for every message type stored in the cache, so
+// incache.RegisteredCodec encodes the messages with protobuf:
+//
+//	incacheproto.Register(&pb.User{})
+//
+//	cache := incache.New(incache.WithValueCodec(incache.RegisteredCodec))
+package incacheproto
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/wittyjudge/incache"
+)
+
+type codec struct {
+	prototype proto.Message
+}
+
+// NewCodec returns a codec that encodes messages of the same type as
+// prototype with protobuf.
+func NewCodec(prototype proto.Message) incache.Codec {
+	return codec{prototype: prototype}
+}
+
+// Register registers the codec for messages of the same type as prototype
+// with incache.RegisterCodec.
+func Register(prototype proto.Message) {
+	incache.RegisterCodec(prototype, NewCodec(prototype))
+}
+
+func (c codec) Encode(value interface{}) ([]byte, error) {
+	msg, ok := value.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("incacheproto: %T isn't a protobuf message", value)
+	}
+
+	return proto.Marshal(msg)
+}
+
+func (c codec) Decode(data []byte) (interface{}, error) {
+	msg := c.prototype.ProtoReflect().New().Interface()
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return nil, err
+	}
+
+	return msg, nil
+}
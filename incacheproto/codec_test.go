@@ -0,0 +1,47 @@
+package incacheproto
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/wittyjudge/incache"
+)
+
+func TestCodec(t *testing.T) {
+	codec := NewCodec(&wrapperspb.StringValue{})
+
+	data, err := codec.Encode(wrapperspb.String("value1"))
+	assert.NoError(t, err)
+
+	value, err := codec.Decode(data)
+	assert.NoError(t, err)
+	assert.True(t, proto.Equal(wrapperspb.String("value1"), value.(proto.Message)))
+
+	_, err = codec.Encode("value1")
+	assert.EqualError(t, err, "incacheproto: string isn't a protobuf message")
+
+	_, err = codec.Decode([]byte{0xff})
+	assert.Error(t, err)
+}
+
+func TestRegister(t *testing.T) {
+	Register(&structpb.Struct{})
+
+	cache := incache.New(incache.WithCompression(1, nil), incache.WithValueCodec(incache.RegisteredCodec))
+
+	user, err := structpb.NewStruct(map[string]interface{}{"name": "alice", "bio": strings.Repeat("A long biography of alice. ", 20)})
+	assert.NoError(t, err)
+
+	cache.Set("user", user)
+
+	info, _ := cache.Inspect("user")
+	assert.True(t, info.Compressed)
+
+	value := cache.Get("user")
+	assert.True(t, proto.Equal(user, value.(proto.Message)))
+}
@@ -0,0 +1,16 @@
+module github.com/wittyjudge/incache/incacheproto
+
+go 1.23
+
+require (
+	github.com/stretchr/testify v1.11.1
+	github.com/wittyjudge/incache v0.1.0
+	google.golang.org/protobuf v1.36.9
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/google/go-cmp v0.7.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
@@ -0,0 +1,156 @@
+package incache
+
+import (
+	"sort"
+	"time"
+)
+
+// secondaryIndex maps the values extracted from stored values to the keys
+// they were extracted from, see WithIndex.
+type secondaryIndex struct {
+	extract func(key string, value interface{}) (string, bool)
+	keys    map[string]map[string]struct{}
+	// Value each key is currently indexed under.
+	values map[string]string
+}
+
+func newSecondaryIndex(extract func(key string, value interface{}) (string, bool)) *secondaryIndex {
+	return &secondaryIndex{
+		extract: extract,
+		keys:    make(map[string]map[string]struct{}),
+		values:  make(map[string]string),
+	}
+}
+
+func (i *secondaryIndex) add(key string, value interface{}) {
+	i.remove(key)
+
+	indexValue, ok := i.extract(key, value)
+	if !ok {
+		return
+	}
+
+	keys, ok := i.keys[indexValue]
+	if !ok {
+		keys = make(map[string]struct{})
+		i.keys[indexValue] = keys
+	}
+
+	keys[key] = struct{}{}
+	i.values[key] = indexValue
+}
+
+func (i *secondaryIndex) remove(key string) {
+	indexValue, ok := i.values[key]
+	if !ok {
+		return
+	}
+
+	delete(i.values, key)
+
+	keys := i.keys[indexValue]
+	delete(keys, key)
+
+	if len(keys) == 0 {
+		delete(i.keys, indexValue)
+	}
+}
+
+func (i *secondaryIndex) reset() {
+	i.keys = make(map[string]map[string]struct{})
+	i.values = make(map[string]string)
+}
+
+func newSecondaryIndexes(extractors map[string]func(key string, value interface{}) (string, bool)) map[string]*secondaryIndex {
+	if len(extractors) == 0 {
+		return nil
+	}
+
+	indexes := make(map[string]*secondaryIndex, len(extractors))
+	for name, extract := range extractors {
+		indexes[name] = newSecondaryIndex(extract)
+	}
+
+	return indexes
+}
+
+// indexLocked adds the value stored under key to all secondary indexes.
+// It must be called with the write lock held.
+func (c *Cache) indexLocked(key string, value interface{}) {
+	for _, index := range c.indexes {
+		index.add(key, value)
+	}
+}
+
+// unindexLocked removes key from all secondary indexes.
+// It must be called with the write lock held.
+func (c *Cache) unindexLocked(key string) {
+	for _, index := range c.indexes {
+		index.remove(key)
+	}
+}
+
+// FindBy returns the entries whose values are indexed under value in the
+// index registered with WithIndex, sorted by key. Expired items are skipped.
+// It returns nil when there is no index with the given name.
+func (c *Cache) FindBy(index, value string) []Entry {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	i, ok := c.indexes[index]
+	if !ok {
+		return nil
+	}
+
+	keys := i.keys[value]
+	if len(keys) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+
+	entries := make([]Entry, 0, len(keys))
+	for key := range keys {
+		if c.items[key].expiredAt(now) {
+			continue
+		}
+
+		entries = append(entries, c.entryLocked(key))
+	}
+
+	sortEntries(entries)
+
+	return entries
+}
+
+// Find returns the entries for which predicate returns true, sorted by key.
+// Expired items are skipped. It scans all items while holding the read lock,
+// so predicate must not use the cache. Use WithIndex to look values up
+// without scanning.
+func (c *Cache) Find(predicate func(key string, value interface{}) bool) []Entry {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+
+	var entries []Entry
+	for key, item := range c.items {
+		if item.expiredAt(now) {
+			continue
+		}
+
+		if predicate(key, c.value(item.Value)) {
+			entries = append(entries, c.entryLocked(key))
+		}
+	}
+
+	sortEntries(entries)
+
+	return entries
+}
+
+func sortEntries(entries []Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+}
@@ -0,0 +1,101 @@
+package incache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct {
+	Name  string
+	Email string
+	Team  string
+}
+
+func newIndexedCache(conf ...configFunc) *Cache {
+	conf = append(conf, WithIndex("team", func(key string, value interface{}) (string, bool) {
+		u, ok := value.(user)
+		if !ok || u.Team == "" {
+			return "", false
+		}
+
+		return u.Team, true
+	}))
+
+	return New(conf...)
+}
+
+func TestFindBy(t *testing.T) {
+	cache := newIndexedCache()
+
+	cache.Set("bob", user{Name: "bob", Team: "core"})
+	cache.Set("alice", user{Name: "alice", Team: "core"})
+	cache.Set("carol", user{Name: "carol", Team: "web"})
+	cache.Set("dave", user{Name: "dave"})
+	cache.Set("key1", "value1")
+
+	entries := cache.FindBy("team", "core")
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "alice", entries[0].Key)
+	assert.Equal(t, user{Name: "alice", Team: "core"}, entries[0].Value)
+	assert.Equal(t, "bob", entries[1].Key)
+
+	assert.Len(t, cache.FindBy("team", "web"), 1)
+	assert.Nil(t, cache.FindBy("team", "unknown"))
+	assert.Nil(t, cache.FindBy("unknown", "core"))
+}
+
+func TestFindByUpdatesIndex(t *testing.T) {
+	cache := newIndexedCache()
+
+	cache.Set("bob", user{Name: "bob", Team: "core"})
+	cache.Set("bob", user{Name: "bob", Team: "web"})
+
+	assert.Nil(t, cache.FindBy("team", "core"))
+	assert.Len(t, cache.FindBy("team", "web"), 1)
+
+	cache.Delete("bob")
+	assert.Nil(t, cache.FindBy("team", "web"))
+	assert.Empty(t, cache.indexes["team"].keys)
+	assert.Empty(t, cache.indexes["team"].values)
+
+	cache.Set("alice", user{Name: "alice", Team: "core"})
+	cache.FlushAll()
+	assert.Nil(t, cache.FindBy("team", "core"))
+}
+
+func TestFindBySkipsExpired(t *testing.T) {
+	cache := newIndexedCache(WithCleanupInterval(0))
+
+	cache.SetWithTTL("bob", user{Name: "bob", Team: "core"}, time.Millisecond)
+	cache.Set("alice", user{Name: "alice", Team: "core"})
+
+	time.Sleep(5 * time.Millisecond)
+
+	entries := cache.FindBy("team", "core")
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "alice", entries[0].Key)
+}
+
+func TestFind(t *testing.T) {
+	cache := New(WithCleanupInterval(0))
+
+	cache.Set("key1", 1)
+	cache.Set("key3", 3)
+	cache.Set("key2", 2)
+	cache.SetWithTTL("key4", 4, time.Millisecond)
+
+	time.Sleep(5 * time.Millisecond)
+
+	entries := cache.Find(func(key string, value interface{}) bool {
+		return value.(int) > 1
+	})
+
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "key2", entries[0].Key)
+	assert.Equal(t, 2, entries[0].Value)
+	assert.Equal(t, "key3", entries[1].Key)
+
+	assert.Empty(t, cache.Find(func(key string, value interface{}) bool { return false }))
+}
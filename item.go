@@ -7,6 +7,17 @@ type Item struct {
 	Value     interface{}
 	TTL       time.Duration
 	ExpiresAt time.Time
+
+	// version is assigned when the item is stored, see Cache.GetVersioned.
+	version uint64
+	// priority defines the order of eviction, see Cache.SetWithPriority.
+	priority int
+	// storedAt is the time the item was stored in Unix nanoseconds,
+	// see Result.Age.
+	storedAt int64
+	// Only used with WithAccessTracking. It's shared by copies of the item,
+	// e.g. in the read index, so reads update it under the read lock.
+	access *accessStats
 }
 
 func newItem(value interface{}, ttl time.Duration) Item {
@@ -22,11 +33,16 @@ func newItem(value interface{}, ttl time.Duration) Item {
 
 // Expired checks whether the item has expired.
 func (i Item) Expired() bool {
+	return i.expiredAt(time.Now())
+}
+
+// expiredAt checks whether the item has expired by the given time.
+func (i Item) expiredAt(t time.Time) bool {
 	if !i.CanExpire() {
 		return false
 	}
 
-	return time.Now().After(i.ExpiresAt)
+	return t.After(i.ExpiresAt)
 }
 
 // CanExpire checks whether the item can expire.
@@ -34,6 +50,37 @@ func (i Item) CanExpire() bool {
 	return !i.ExpiresAt.IsZero()
 }
 
+// remainingTTL returns the time left until the item expires.
+// Zero means that the item never expires.
+func (i Item) remainingTTL() time.Duration {
+	if !i.CanExpire() {
+		return 0
+	}
+
+	ttl := time.Until(i.ExpiresAt)
+	if ttl <= 0 {
+		// The item is already expired, but zero would mean the opposite.
+		return time.Nanosecond
+	}
+
+	return ttl
+}
+
+// monotonic returns t with a reading of the monotonic clock, so comparing
+// it with time.Now isn't affected by jumps of the wall clock, e.g. by NTP,
+// made afterwards. Expiration times given as wall clock time, e.g. restored
+// from snapshots, must be converted by it before they are stored.
+func monotonic(t time.Time) time.Time {
+	if t.IsZero() || t != t.Round(0) {
+		// Round(0) strips the monotonic clock reading, so t already has it.
+		return t
+	}
+
+	now := time.Now()
+
+	return now.Add(t.Sub(now))
+}
+
 func (i *Item) setExpiration() {
 	if i.TTL <= 0 {
 		return
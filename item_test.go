@@ -52,3 +52,16 @@ func TestItemNeverExpires(t *testing.T) {
 	assert.True(t, time.Now().After(item.ExpiresAt))
 	assert.True(t, item.ExpiresAt.IsZero())
 }
+
+func TestMonotonic(t *testing.T) {
+	wall := time.Now().Add(time.Hour).Round(0)
+
+	converted := monotonic(wall)
+	assert.True(t, wall.Equal(converted))
+	assert.NotEqual(t, converted, converted.Round(0), "monotonic clock reading is expected")
+
+	now := time.Now()
+	assert.Equal(t, now, monotonic(now))
+
+	assert.True(t, monotonic(time.Time{}).IsZero())
+}
@@ -0,0 +1,24 @@
+package incache
+
+import (
+	"math/rand"
+	"time"
+)
+
+// jitter randomizes the TTL by the fraction configured with WithTTLJitter.
+func (c *Cache) jitter(ttl time.Duration) time.Duration {
+	if ttl <= 0 || c.config.ttlJitter <= 0 {
+		return ttl
+	}
+
+	// A random factor in the [-fraction, +fraction) range.
+	factor := (rand.Float64()*2 - 1) * c.config.ttlJitter
+
+	jittered := ttl + time.Duration(float64(ttl)*factor)
+	if jittered <= 0 {
+		// Zero TTL would mean that the item never expires.
+		return time.Nanosecond
+	}
+
+	return jittered
+}
@@ -0,0 +1,46 @@
+package incache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTTLJitter(t *testing.T) {
+	cache := New(WithTTL(10*time.Minute), WithTTLJitter(0.1))
+
+	ttls := make(map[time.Duration]struct{})
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprint("key", i)
+		cache.Set(key, i)
+
+		info, _ := cache.Inspect(key)
+		assert.GreaterOrEqual(t, info.TTL, 9*time.Minute)
+		assert.LessOrEqual(t, info.TTL, 11*time.Minute)
+
+		ttls[info.TTL] = struct{}{}
+	}
+
+	assert.Greater(t, len(ttls), 1)
+}
+
+func TestTTLJitterDoesNotAffectItemsWithoutTTL(t *testing.T) {
+	cache := New(WithTTL(0), WithTTLJitter(0.5))
+
+	cache.Set("key1", "value1")
+
+	info, _ := cache.Inspect("key1")
+	assert.Zero(t, info.TTL)
+	assert.True(t, info.ExpiresAt.IsZero())
+}
+
+func TestTTLJitterKeepsExpiration(t *testing.T) {
+	cache := New(WithTTLJitter(2))
+
+	for i := 0; i < 100; i++ {
+		assert.Positive(t, cache.jitter(time.Second))
+	}
+}
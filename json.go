@@ -0,0 +1,124 @@
+package incache
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+)
+
+var errNotInitialized = errors.New("incache: cache must be created with New")
+
+type jsonCache struct {
+	Items []jsonItem `json:"items"`
+}
+
+type jsonItem struct {
+	Key       string      `json:"key"`
+	Value     interface{} `json:"value"`
+	TTL       string      `json:"ttl,omitempty"`
+	ExpiresAt *time.Time  `json:"expires_at,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. It encodes all items that haven't
+// expired yet along with their TTL and expiration time, sorted by key.
+//
+// Example of the output:
+//
+//	{"items":[{"key":"key1","value":"value1","ttl":"5m0s","expires_at":"2024-01-01T12:05:00Z"}]}
+func (c *Cache) MarshalJSON() ([]byte, error) {
+	c.mu.RLock()
+
+	items := make([]jsonItem, 0, len(c.items))
+	for key, item := range c.items {
+		if item.Expired() {
+			continue
+		}
+
+		item.Value = c.value(item.Value)
+		items = append(items, newJSONItem(key, item))
+	}
+
+	c.mu.RUnlock()
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Key < items[j].Key
+	})
+
+	return json.Marshal(jsonCache{Items: items})
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It stores items encoded
+// by MarshalJSON into the cache, overwriting the existing items with the
+// same keys. Items keep their original expiration time, already expired
+// items are skipped.
+//
+// The cache must be created with New beforehand. Values are decoded
+// into generic JSON types, e.g. numbers become float64.
+func (c *Cache) UnmarshalJSON(data []byte) error {
+	if c.items == nil {
+		return errNotInitialized
+	}
+
+	var decoded jsonCache
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	items := make(map[string]Item, len(decoded.Items))
+	for _, ji := range decoded.Items {
+		item, err := ji.item()
+		if err != nil {
+			return err
+		}
+
+		if item.Expired() {
+			continue
+		}
+
+		items[ji.Key] = item
+	}
+
+	c.mu.Lock()
+	defer c.unlock()
+
+	for key, item := range items {
+		c.setItemLocked(key, item)
+	}
+
+	return nil
+}
+
+func newJSONItem(key string, item Item) jsonItem {
+	ji := jsonItem{
+		Key:   key,
+		Value: item.Value,
+	}
+
+	if item.CanExpire() {
+		expiresAt := item.ExpiresAt
+		ji.TTL = item.TTL.String()
+		ji.ExpiresAt = &expiresAt
+	}
+
+	return ji
+}
+
+func (ji jsonItem) item() (Item, error) {
+	item := Item{Value: ji.Value}
+
+	if ji.TTL != "" {
+		ttl, err := time.ParseDuration(ji.TTL)
+		if err != nil {
+			return Item{}, err
+		}
+
+		item.TTL = ttl
+	}
+
+	if ji.ExpiresAt != nil {
+		item.ExpiresAt = monotonic(*ji.ExpiresAt)
+	}
+
+	return item, nil
+}
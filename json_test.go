@@ -0,0 +1,79 @@
+package incache
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalJSON(t *testing.T) {
+	cache := New(WithCleanupInterval(0))
+
+	cache.SetWithTTL("key2", "value2", 0)
+	cache.SetWithTTL("key1", 1, time.Minute)
+	cache.SetWithTTL("key3", "expired", time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+
+	data, err := json.Marshal(cache)
+	require.NoError(t, err)
+
+	var decoded jsonCache
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Len(t, decoded.Items, 2)
+
+	assert.Equal(t, "key1", decoded.Items[0].Key)
+	assert.EqualValues(t, 1, decoded.Items[0].Value)
+	assert.Equal(t, "1m0s", decoded.Items[0].TTL)
+	require.NotNil(t, decoded.Items[0].ExpiresAt)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), *decoded.Items[0].ExpiresAt, time.Second)
+
+	assert.Equal(t, "key2", decoded.Items[1].Key)
+	assert.Equal(t, "value2", decoded.Items[1].Value)
+	assert.Empty(t, decoded.Items[1].TTL)
+	assert.Nil(t, decoded.Items[1].ExpiresAt)
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	source := New()
+	source.SetWithTTL("key1", "value1", time.Minute)
+	source.SetWithTTL("key2", "value2", 0)
+
+	data, err := json.Marshal(source)
+	require.NoError(t, err)
+
+	cache := New()
+	cache.Set("key3", "value3")
+	require.NoError(t, json.Unmarshal(data, cache))
+
+	assert.Equal(t, 3, cache.Len())
+	assert.Equal(t, "value1", cache.Get("key1"))
+	assert.Equal(t, "value2", cache.Get("key2"))
+	assert.Equal(t, "value3", cache.Get("key3"))
+
+	assert.Equal(t, source.items["key1"].ExpiresAt.Unix(), cache.items["key1"].ExpiresAt.Unix())
+	assert.Equal(t, time.Minute, cache.items["key1"].TTL)
+	assert.False(t, cache.items["key2"].CanExpire())
+	assert.Contains(t, cache.expirationsQueue, "key1")
+}
+
+func TestUnmarshalJSONSkipsExpired(t *testing.T) {
+	cache := New()
+
+	data := `{"items":[{"key":"key1","value":"value1","ttl":"1s","expires_at":"2000-01-01T00:00:00Z"}]}`
+	require.NoError(t, json.Unmarshal([]byte(data), cache))
+
+	assert.Zero(t, cache.Len())
+}
+
+func TestUnmarshalJSONErrors(t *testing.T) {
+	cache := New()
+
+	assert.Error(t, json.Unmarshal([]byte(`{"items":[{"key":"key1","ttl":"invalid"}]}`), cache))
+	assert.Error(t, json.Unmarshal([]byte(`{"items":{}}`), cache))
+
+	var zero Cache
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"items":[]}`), &zero), errNotInitialized)
+}
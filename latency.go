@@ -0,0 +1,135 @@
+package incache
+
+import (
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// The upper bound of the first bucket is 2^minLatencyBucketPower ns (64ns),
+	// the upper bound of every next bucket is twice bigger than the previous one.
+	minLatencyBucketPower = 6
+	// The upper bound of the last bucket is 2^maxLatencyBucketPower ns (~1s),
+	// all slower operations fall into the overflow bucket.
+	maxLatencyBucketPower = 30
+
+	latencyBucketsCount = maxLatencyBucketPower - minLatencyBucketPower + 2
+)
+
+// LatencyBucket holds the number of operations whose latency was less than or
+// equal to UpperBound and bigger than the upper bound of the previous bucket.
+type LatencyBucket struct {
+	// UpperBound of the last bucket is math.MaxInt64, it collects everything
+	// that doesn't fit into other buckets.
+	UpperBound time.Duration
+	Count      uint64
+}
+
+// LatencyHistogram is a snapshot of the latency distribution of a single
+// cache operation.
+type LatencyHistogram struct {
+	Count   uint64
+	Sum     time.Duration
+	Buckets []LatencyBucket
+}
+
+// Mean returns the average latency of the operation.
+func (h LatencyHistogram) Mean() time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+
+	return h.Sum / time.Duration(h.Count)
+}
+
+// Quantile returns an upper bound of the latency of q fraction of operations,
+// where q is a number between 0 and 1. For example, Quantile(0.99) returns
+// the upper bound of the bucket containing the 99th percentile.
+func (h LatencyHistogram) Quantile(q float64) time.Duration {
+	if h.Count == 0 {
+		return 0
+	}
+
+	rank := uint64(q * float64(h.Count))
+	if rank == 0 {
+		rank = 1
+	}
+
+	var seen uint64
+	for _, bucket := range h.Buckets {
+		seen += bucket.Count
+		if seen >= rank {
+			return bucket.UpperBound
+		}
+	}
+
+	return h.Buckets[len(h.Buckets)-1].UpperBound
+}
+
+// latencyHistogram collects latencies into exponential buckets.
+// All methods are safe for concurrent use.
+type latencyHistogram struct {
+	count   uint64
+	sum     uint64
+	buckets [latencyBucketsCount]uint64
+}
+
+func (h *latencyHistogram) observe(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+
+	atomic.AddUint64(&h.buckets[latencyBucketIndex(d)], 1)
+	atomic.AddUint64(&h.sum, uint64(d))
+	atomic.AddUint64(&h.count, 1)
+}
+
+func (h *latencyHistogram) snapshot() LatencyHistogram {
+	snapshot := LatencyHistogram{
+		Count:   atomic.LoadUint64(&h.count),
+		Sum:     time.Duration(atomic.LoadUint64(&h.sum)),
+		Buckets: make([]LatencyBucket, latencyBucketsCount),
+	}
+
+	for i := range h.buckets {
+		snapshot.Buckets[i] = LatencyBucket{
+			UpperBound: latencyBucketUpperBound(i),
+			Count:      atomic.LoadUint64(&h.buckets[i]),
+		}
+	}
+
+	return snapshot
+}
+
+func (h *latencyHistogram) reset() {
+	atomic.StoreUint64(&h.count, 0)
+	atomic.StoreUint64(&h.sum, 0)
+
+	for i := range h.buckets {
+		atomic.StoreUint64(&h.buckets[i], 0)
+	}
+}
+
+func latencyBucketIndex(d time.Duration) int {
+	if d <= 1<<minLatencyBucketPower {
+		return 0
+	}
+
+	// The number of bits needed to represent d-1 is the power of two
+	// of the smallest bucket upper bound that is >= d.
+	power := bits.Len64(uint64(d - 1))
+	if power > maxLatencyBucketPower {
+		return latencyBucketsCount - 1
+	}
+
+	return power - minLatencyBucketPower
+}
+
+func latencyBucketUpperBound(i int) time.Duration {
+	if i == latencyBucketsCount-1 {
+		return time.Duration(1<<63 - 1)
+	}
+
+	return time.Duration(1) << (minLatencyBucketPower + i)
+}
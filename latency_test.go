@@ -0,0 +1,46 @@
+package incache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatencyBucketIndex(t *testing.T) {
+	assert.Equal(t, 0, latencyBucketIndex(0))
+	assert.Equal(t, 0, latencyBucketIndex(64))
+	assert.Equal(t, 1, latencyBucketIndex(65))
+	assert.Equal(t, 1, latencyBucketIndex(128))
+	assert.Equal(t, latencyBucketsCount-2, latencyBucketIndex(1<<maxLatencyBucketPower))
+	assert.Equal(t, latencyBucketsCount-1, latencyBucketIndex(time.Hour))
+}
+
+func TestLatencyHistogram(t *testing.T) {
+	h := &latencyHistogram{}
+
+	for i := 0; i < 98; i++ {
+		h.observe(50 * time.Nanosecond)
+	}
+	h.observe(time.Microsecond)
+	h.observe(time.Hour)
+
+	snapshot := h.snapshot()
+
+	assert.EqualValues(t, 100, snapshot.Count)
+	assert.Equal(t, 98*50*time.Nanosecond+time.Microsecond+time.Hour, snapshot.Sum)
+	assert.Len(t, snapshot.Buckets, latencyBucketsCount)
+	assert.EqualValues(t, 98, snapshot.Buckets[0].Count)
+
+	assert.Equal(t, 64*time.Nanosecond, snapshot.Quantile(0.5))
+	assert.Equal(t, 1024*time.Nanosecond, snapshot.Quantile(0.99))
+	assert.Equal(t, time.Duration(1<<63-1), snapshot.Quantile(1))
+	assert.Equal(t, snapshot.Sum/100, snapshot.Mean())
+
+	h.reset()
+
+	snapshot = h.snapshot()
+	assert.Zero(t, snapshot.Count)
+	assert.Zero(t, snapshot.Quantile(0.5))
+	assert.Zero(t, snapshot.Mean())
+}
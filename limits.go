@@ -0,0 +1,48 @@
+package incache
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrKeyTooLong is passed to the rejection handler when the key is
+	// longer than the limit set by WithMaxKeyLength.
+	ErrKeyTooLong = errors.New("incache: key is too long")
+	// ErrValueTooLarge is passed to the rejection handler when the value is
+	// larger than the limit set by WithMaxValueSize.
+	ErrValueTooLarge = errors.New("incache: value is too large")
+)
+
+// rejectedItem holds an item rejected while the cache lock was held,
+// so the rejection handler can be called after the lock is released.
+type rejectedItem struct {
+	key   string
+	value interface{}
+	err   error
+}
+
+// validate checks the key and the value against the configured limits.
+func (c *Cache) validate(key string, value interface{}) error {
+	if c.config.maxKeyLength > 0 && len(key) > c.config.maxKeyLength {
+		return fmt.Errorf("%w: %d bytes, limit is %d", ErrKeyTooLong, len(key), c.config.maxKeyLength)
+	}
+
+	if c.config.maxValueSize > 0 {
+		if size := estimateSize(value); size > c.config.maxValueSize {
+			return fmt.Errorf("%w: %d bytes, limit is %d", ErrValueTooLarge, size, c.config.maxValueSize)
+		}
+	}
+
+	return nil
+}
+
+// rejectLocked records the rejected item for the rejection handler.
+// It must be called with the write lock held.
+func (c *Cache) rejectLocked(key string, value interface{}, err error) {
+	c.config.debugf("[reject] key: '%s': %v", key, err)
+
+	if c.config.rejectionHandler != nil {
+		c.rejected = append(c.rejected, rejectedItem{key: key, value: value, err: err})
+	}
+}
@@ -0,0 +1,57 @@
+package incache
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxKeyLength(t *testing.T) {
+	var rejected []error
+
+	cache := New(WithMaxKeyLength(4), WithRejectionHandler(func(key string, value interface{}, err error) {
+		rejected = append(rejected, err)
+	}))
+
+	cache.Set("key1", "value1")
+	cache.Set("key10", "value10")
+
+	assert.Equal(t, "value1", cache.Get("key1"))
+	assert.False(t, cache.Has("key10"))
+
+	assert.Len(t, rejected, 1)
+	assert.ErrorIs(t, rejected[0], ErrKeyTooLong)
+}
+
+func TestMaxValueSize(t *testing.T) {
+	var (
+		cache        *Cache
+		rejectedKeys []string
+	)
+
+	cache = New(WithMaxValueSize(8), WithRejectionHandler(func(key string, value interface{}, err error) {
+		assert.ErrorIs(t, err, ErrValueTooLarge)
+
+		// The handler is called without the lock held.
+		cache.Has(key)
+		rejectedKeys = append(rejectedKeys, key)
+	}))
+
+	cache.Set("key1", "value1")
+	cache.Set("key1", strings.Repeat("a", 9))
+	cache.Set("key2", []byte(strings.Repeat("a", 9)))
+
+	assert.Equal(t, "value1", cache.Get("key1"))
+	assert.False(t, cache.Has("key2"))
+	assert.Equal(t, []string{"key1", "key2"}, rejectedKeys)
+}
+
+func TestMaxValueSizeWithoutRejectionHandler(t *testing.T) {
+	cache := New(WithMaxValueSize(1))
+
+	cache.Set("key1", "value1")
+
+	assert.False(t, cache.Has("key1"))
+	assert.Empty(t, cache.rejected)
+}
@@ -0,0 +1,311 @@
+package incache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when the key doesn't exist in the cache and
+// it can't be loaded.
+var ErrNotFound = errors.New("incache: key not found")
+
+// LoaderFunc loads the value of key from the origin on a cache miss.
+//
+// The returned value is stored in the cache for ttl. Zero ttl means the
+// default TTL of the cache, negative ttl means that the value never expires.
+// If the value doesn't exist in the origin, nil value and nil error
+// should be returned.
+type LoaderFunc func(ctx context.Context, key string) (value interface{}, ttl time.Duration, err error)
+
+type loader struct {
+	fn LoaderFunc
+	// How long errors returned by fn are cached. Zero disables caching.
+	errorTTL time.Duration
+	// The TTL of errors doubles for consecutive failures up to maxErrorTTL.
+	// Zero disables the growth.
+	maxErrorTTL time.Duration
+
+	inflight callGroup
+
+	// Nil when the circuit breaker isn't enabled.
+	breaker *circuitBreaker
+
+	mu     sync.Mutex
+	errors map[string]loaderError
+}
+
+type loaderError struct {
+	err       error
+	expiresAt time.Time
+	ttl       time.Duration
+}
+
+func newLoader(fn LoaderFunc, errorTTL, maxErrorTTL time.Duration) *loader {
+	return &loader{
+		fn:          fn,
+		errorTTL:    errorTTL,
+		maxErrorTTL: maxErrorTTL,
+		errors:      make(map[string]loaderError),
+	}
+}
+
+// call calls the loader function. A panic of the function is returned as
+// an error, so it's recorded as a failed load and doesn't leave the circuit
+// breaker probing forever or waiters of the load without a result.
+func (l *loader) call(ctx context.Context, key string) (value interface{}, ttl time.Duration, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			value, ttl, err = nil, 0, fmt.Errorf("incache: loader panicked: %v", r)
+		}
+	}()
+
+	return l.fn(ctx, key)
+}
+
+// cachedError returns the error of the previous load of key if it's still cached.
+func (l *loader) cachedError(key string) error {
+	if l.errorTTL <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	cached, ok := l.errors[key]
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+	if now.After(cached.expiresAt) {
+		// With backoff, the error is remembered for another TTL after it
+		// expires, so the next failure is cached longer.
+		if l.maxErrorTTL <= 0 || now.After(cached.expiresAt.Add(cached.ttl)) {
+			delete(l.errors, key)
+		}
+
+		return nil
+	}
+
+	return cached.err
+}
+
+func (l *loader) cacheError(key string, err error) {
+	if l.errorTTL <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ttl := l.errorTTL
+	if previous, ok := l.errors[key]; ok && l.maxErrorTTL > 0 {
+		ttl = previous.ttl * 2
+		if ttl > l.maxErrorTTL {
+			ttl = l.maxErrorTTL
+		}
+	}
+
+	l.errors[key] = loaderError{err: err, expiresAt: time.Now().Add(ttl), ttl: ttl}
+}
+
+// cacheable reports whether the error of a load is caused by the origin.
+// Canceled and timed out contexts say nothing about it, so caching them
+// would fail the key for every other caller.
+func cacheable(err error) bool {
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// forgetError resets the backoff of key after a successful load.
+func (l *loader) forgetError(key string) {
+	if l.errorTTL <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.errors, key)
+}
+
+// GetContext returns the value of key.
+// If the key doesn't exist and a loader is configured with WithLoader,
+// the value is loaded, stored in the cache and returned. Concurrent calls
+// for the same missing key share a single load. The loader receives the
+// values of ctx but not its cancellation, the load is only limited by the
+// timeout set with WithLoadTimeout. GetContext returns ctx.Err() as soon
+// as ctx is done, while the load goes on for the other callers.
+//
+// ErrNotFound is returned if the value can't be found nor loaded.
+func (c *Cache) GetContext(ctx context.Context, key string) (interface{}, error) {
+	c.hooks.beforeGet(key)
+
+	value, err := c.getOrLoad(ctx, key)
+	c.hooks.afterGet(key, value)
+
+	return value, err
+}
+
+func (c *Cache) getOrLoad(ctx context.Context, key string) (interface{}, error) {
+	if value := c.lookup(key); value != nil {
+		return value, nil
+	}
+
+	if c.loader == nil {
+		return nil, ErrNotFound
+	}
+
+	return c.load(ctx, key)
+}
+
+func (c *Cache) load(ctx context.Context, key string) (interface{}, error) {
+	if err := c.loader.cachedError(key); err != nil {
+		return nil, err
+	}
+
+	// The shared load doesn't depend on the context of the caller that
+	// started it, otherwise a caller with a short deadline would fail the
+	// load for the others. Every caller stops waiting on its own context.
+	return c.loader.inflight.doDetached(ctx, key, func() (interface{}, error) {
+		if c.loader.breaker != nil && !c.loader.breaker.allow() {
+			return c.shortCircuit(key)
+		}
+
+		ctx := context.Context(detachedContext{ctx})
+
+		if c.config.loadTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, c.config.loadTimeout)
+			defer cancel()
+		}
+
+		value, ttl, err := c.loader.call(ctx, key)
+		c.recordLoad(err)
+
+		if err != nil {
+			c.config.debugf("[load] failed to load the key: '%s': %v", key, err)
+
+			if cacheable(err) {
+				c.loader.cacheError(key, err)
+			}
+
+			return nil, err
+		}
+
+		c.loader.forgetError(key)
+
+		if value == nil {
+			return nil, ErrNotFound
+		}
+
+		if ttl == 0 {
+			ttl = c.defaultTTL()
+		}
+
+		c.set(key, value, ttl)
+
+		return value, nil
+	})
+}
+
+// callGroup deduplicates concurrent calls with the same key, so only
+// the first caller executes the function and others wait for its result.
+type callGroup struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+type call struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// do executes fn, unless there is already a call in flight for the key,
+// in which case it waits for that call to finish and returns its result.
+// Waiting is interrupted if ctx is done.
+func (g *callGroup) do(ctx context.Context, key string, fn func() (interface{}, error)) (interface{}, error) {
+	cl, leader := g.join(key)
+	if !leader {
+		return cl.wait(ctx)
+	}
+
+	defer g.finish(key, cl)
+
+	cl.value, cl.err = fn()
+
+	return cl.value, cl.err
+}
+
+// doDetached works like do, but executes fn in a separate goroutine, so
+// the caller that started the call stops waiting when its ctx is done,
+// just like the others, while fn goes on.
+func (g *callGroup) doDetached(ctx context.Context, key string, fn func() (interface{}, error)) (interface{}, error) {
+	cl, leader := g.join(key)
+	if leader {
+		go func() {
+			defer g.finish(key, cl)
+
+			cl.value, cl.err = fn()
+		}()
+	}
+
+	return cl.wait(ctx)
+}
+
+// join returns the call in flight for the key, or registers a new one and
+// reports that the caller has to execute it.
+func (g *callGroup) join(key string) (*call, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+
+	if cl, ok := g.calls[key]; ok {
+		return cl, false
+	}
+
+	cl := &call{done: make(chan struct{})}
+	g.calls[key] = cl
+
+	return cl, true
+}
+
+// finish unregisters the call and wakes up its waiters.
+func (g *callGroup) finish(key string, cl *call) {
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+
+	close(cl.done)
+}
+
+// wait returns the result of the call once it's finished, or the error of
+// ctx if it's done first.
+func (cl *call) wait(ctx context.Context) (interface{}, error) {
+	select {
+	case <-cl.done:
+		return cl.value, cl.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// detachedContext keeps the values of its parent, but is never canceled
+// and has no deadline.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
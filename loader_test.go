@@ -0,0 +1,343 @@
+package incache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoader(t *testing.T) {
+	var calls int32
+
+	cache := New(WithTTL(time.Minute), WithLoader(func(_ context.Context, key string) (interface{}, time.Duration, error) {
+		atomic.AddInt32(&calls, 1)
+
+		switch key {
+		case "missing":
+			return nil, 0, nil
+		case "forever":
+			return "value", -1, nil
+		}
+
+		return "loaded:" + key, 0, nil
+	}))
+
+	assert.Equal(t, "loaded:key1", cache.Get("key1"))
+	assert.Equal(t, "loaded:key1", cache.Get("key1"))
+	assert.EqualValues(t, 1, calls)
+
+	info, ok := cache.Inspect("key1")
+	require.True(t, ok)
+	assert.Equal(t, time.Minute, info.TTL)
+
+	assert.Nil(t, cache.Get("missing"))
+	assert.False(t, cache.Has("missing"))
+
+	value, err := cache.GetContext(context.Background(), "missing")
+	assert.Nil(t, value)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	cache.Get("forever")
+	info, _ = cache.Inspect("forever")
+	assert.True(t, info.ExpiresAt.IsZero())
+}
+
+func TestGetContextWithoutLoader(t *testing.T) {
+	cache := New()
+
+	cache.Set("key1", "value1")
+
+	value, err := cache.GetContext(context.Background(), "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "value1", value)
+
+	value, err = cache.GetContext(context.Background(), "key2")
+	assert.Nil(t, value)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestLoaderError(t *testing.T) {
+	errOrigin := errors.New("origin is down")
+	var calls int32
+
+	cache := New(WithLoader(func(_ context.Context, key string) (interface{}, time.Duration, error) {
+		atomic.AddInt32(&calls, 1)
+		return nil, 0, errOrigin
+	}))
+
+	_, err := cache.GetContext(context.Background(), "key1")
+	assert.ErrorIs(t, err, errOrigin)
+	assert.Nil(t, cache.Get("key1"))
+	assert.EqualValues(t, 2, calls)
+}
+
+func TestLoaderErrorTTL(t *testing.T) {
+	errOrigin := errors.New("origin is down")
+	var calls int32
+
+	cache := New(
+		WithLoaderErrorTTL(20*time.Millisecond),
+		WithLoader(func(_ context.Context, key string) (interface{}, time.Duration, error) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				return nil, 0, errOrigin
+			}
+
+			return "value", 0, nil
+		}),
+	)
+
+	_, err := cache.GetContext(context.Background(), "key1")
+	assert.ErrorIs(t, err, errOrigin)
+
+	_, err = cache.GetContext(context.Background(), "key1")
+	assert.ErrorIs(t, err, errOrigin)
+	assert.EqualValues(t, 1, calls)
+
+	time.Sleep(25 * time.Millisecond)
+
+	value, err := cache.GetContext(context.Background(), "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+	assert.EqualValues(t, 2, calls)
+}
+
+func TestLoaderErrorTTLIgnoresContextErrors(t *testing.T) {
+	var calls int32
+
+	cache := New(
+		WithLoaderErrorTTL(time.Minute),
+		WithLoader(func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+			switch atomic.AddInt32(&calls, 1) {
+			case 1:
+				return nil, 0, context.Canceled
+			case 2:
+				return nil, 0, context.DeadlineExceeded
+			default:
+				return "value", 0, nil
+			}
+		}),
+	)
+
+	_, err := cache.GetContext(context.Background(), "key1")
+	assert.ErrorIs(t, err, context.Canceled)
+
+	_, err = cache.GetContext(context.Background(), "key1")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	value, err := cache.GetContext(context.Background(), "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+	assert.EqualValues(t, 3, calls)
+}
+
+func TestLoaderErrorCachingBackoff(t *testing.T) {
+	errOrigin := errors.New("origin is down")
+	var fail int32 = 1
+
+	cache := New(
+		WithErrorCaching(20*time.Millisecond, 50*time.Millisecond),
+		WithLoader(func(_ context.Context, key string) (interface{}, time.Duration, error) {
+			if atomic.LoadInt32(&fail) == 1 {
+				return nil, 0, errOrigin
+			}
+
+			return "value", time.Nanosecond, nil
+		}),
+	)
+
+	ttls := func() time.Duration {
+		cache.loader.mu.Lock()
+		defer cache.loader.mu.Unlock()
+
+		return cache.loader.errors["key1"].ttl
+	}
+
+	_, err := cache.GetContext(context.Background(), "key1")
+	assert.ErrorIs(t, err, errOrigin)
+	assert.Equal(t, 20*time.Millisecond, ttls())
+
+	time.Sleep(25 * time.Millisecond)
+
+	_, err = cache.GetContext(context.Background(), "key1")
+	assert.ErrorIs(t, err, errOrigin)
+	assert.Equal(t, 40*time.Millisecond, ttls())
+
+	time.Sleep(45 * time.Millisecond)
+
+	_, err = cache.GetContext(context.Background(), "key1")
+	assert.ErrorIs(t, err, errOrigin)
+	assert.Equal(t, 50*time.Millisecond, ttls())
+
+	time.Sleep(55 * time.Millisecond)
+	atomic.StoreInt32(&fail, 0)
+
+	value, err := cache.GetContext(context.Background(), "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+
+	// A successful load resets the backoff.
+	atomic.StoreInt32(&fail, 1)
+	time.Sleep(time.Millisecond)
+
+	_, err = cache.GetContext(context.Background(), "key1")
+	assert.ErrorIs(t, err, errOrigin)
+	assert.Equal(t, 20*time.Millisecond, ttls())
+}
+
+func TestLoaderErrorCachingLastOptionWins(t *testing.T) {
+	config := newConfig([]configFunc{WithErrorCaching(time.Second, time.Minute), WithLoaderErrorTTL(time.Hour)})
+	assert.Equal(t, time.Hour, config.loaderErrorTTL)
+	assert.Zero(t, config.loaderErrorMaxTTL)
+
+	config = newConfig([]configFunc{WithLoaderErrorTTL(time.Hour), WithErrorCaching(time.Second, time.Minute)})
+	assert.Equal(t, time.Second, config.loaderErrorTTL)
+	assert.Equal(t, time.Minute, config.loaderErrorMaxTTL)
+}
+
+func TestLoadTimeout(t *testing.T) {
+	var deadline bool
+
+	cache := New(
+		WithLoadTimeout(10*time.Millisecond),
+		WithLoader(func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+			_, deadline = ctx.Deadline()
+
+			select {
+			case <-ctx.Done():
+				return nil, 0, ctx.Err()
+			case <-time.After(time.Second):
+				return "value", 0, nil
+			}
+		}),
+	)
+
+	start := time.Now()
+	_, err := cache.GetContext(context.Background(), "key1")
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, deadline)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestLoadTimeoutKeepsCallerContext(t *testing.T) {
+	type ctxKey struct{}
+
+	cache := New(
+		WithLoadTimeout(time.Minute),
+		WithLoader(func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+			return ctx.Value(ctxKey{}), 0, nil
+		}),
+	)
+
+	ctx := context.WithValue(context.Background(), ctxKey{}, "value")
+
+	value, err := cache.GetContext(ctx, "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+}
+
+func TestLoaderSharedLoadOutlivesCallerContext(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+
+	cache := New(
+		WithLoader(func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+			atomic.AddInt32(&calls, 1)
+
+			select {
+			case <-ctx.Done():
+				return nil, 0, ctx.Err()
+			case <-release:
+				return "value", 0, nil
+			}
+		}),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// The first caller starts the load and gives up when its deadline passes.
+	_, err := cache.GetContext(ctx, "key1")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	result := make(chan interface{})
+	go func() {
+		value, _ := cache.GetContext(context.Background(), "key1")
+		result <- value
+	}()
+
+	close(release)
+	assert.Equal(t, "value", <-result)
+
+	// The second caller joined the load started by the first one.
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+}
+
+func TestLoaderDeduplicatesConcurrentLoads(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+
+	cache := New(WithLoader(func(_ context.Context, key string) (interface{}, time.Duration, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+
+		return "value", 0, nil
+	}))
+
+	var wg sync.WaitGroup
+	results := make(chan interface{}, 100)
+
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			results <- cache.Get("key1")
+		}()
+	}
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&calls) == 1
+	}, time.Second, time.Millisecond)
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	for result := range results {
+		assert.Equal(t, "value", result)
+	}
+	assert.EqualValues(t, 1, calls)
+}
+
+func TestLoaderWaiterContextCanceled(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	cache := New(WithLoader(func(_ context.Context, key string) (interface{}, time.Duration, error) {
+		<-release
+		return "value", 0, nil
+	}))
+
+	go cache.Get("key1")
+
+	assert.Eventually(t, func() bool {
+		cache.loader.inflight.mu.Lock()
+		defer cache.loader.inflight.mu.Unlock()
+
+		return len(cache.loader.inflight.calls) == 1
+	}, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := cache.GetContext(ctx, "key1")
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,178 @@
+// Package loadgen runs synthetic or recorded workloads against a cache and
+// reports the achieved hit rate. It helps to choose the size and the
+// settings of a cache with data instead of guesses:
+//
+//	for _, size := range []int{1000, 10000, 100000} {
+//		cache := incache.New(incache.WithMaxEntries(size))
+//		result := loadgen.Run(cache, loadgen.Requests(loadgen.Zipf(1_000_000, 1.1, 1), 1_000_000), loadgen.Options{Fill: true})
+//		fmt.Printf("%d: %.2f%%\n", size, result.HitRate()*100)
+//	}
+package loadgen
+
+import (
+	"math/rand"
+	"strconv"
+
+	"github.com/wittyjudge/incache"
+)
+
+// Op is a cache operation.
+type Op uint8
+
+// Operations of an access.
+const (
+	OpGet Op = iota
+	OpSet
+	OpDelete
+)
+
+func (op Op) String() string {
+	switch op {
+	case OpGet:
+		return "get"
+	case OpSet:
+		return "set"
+	case OpDelete:
+		return "delete"
+	default:
+		return "unknown"
+	}
+}
+
+// Access is a single operation on a key.
+type Access struct {
+	Op  Op
+	Key string
+}
+
+// Source produces accesses until it returns false.
+type Source interface {
+	Next() (Access, bool)
+}
+
+// Generator produces keys following a distribution.
+type Generator interface {
+	Next() string
+}
+
+type uniform struct {
+	rand *rand.Rand
+	keys int
+}
+
+// Uniform returns a generator of keys picked from keys distinct keys with
+// equal probability.
+func Uniform(keys int, seed int64) Generator {
+	return &uniform{rand: rand.New(rand.NewSource(seed)), keys: keys}
+}
+
+func (g *uniform) Next() string {
+	return key(g.rand.Intn(g.keys))
+}
+
+type zipf struct {
+	zipf *rand.Zipf
+}
+
+// Zipf returns a generator of keys picked from keys distinct keys following
+// the Zipf distribution with the exponent s, which must be greater than 1.
+// The bigger s is, the more accesses go to the few most popular keys.
+func Zipf(keys int, s float64, seed int64) Generator {
+	r := rand.New(rand.NewSource(seed))
+	return &zipf{zipf: rand.NewZipf(r, s, 1, uint64(keys-1))}
+}
+
+func (g *zipf) Next() string {
+	return key(int(g.zipf.Uint64()))
+}
+
+func key(i int) string {
+	return "key:" + strconv.Itoa(i)
+}
+
+type requests struct {
+	gen Generator
+	n   int
+}
+
+// Requests returns a source of n reads of keys produced by the generator.
+func Requests(gen Generator, n int) Source {
+	return &requests{gen: gen, n: n}
+}
+
+func (r *requests) Next() (Access, bool) {
+	if r.n <= 0 {
+		return Access{}, false
+	}
+	r.n--
+
+	return Access{Op: OpGet, Key: r.gen.Next()}, true
+}
+
+// Options configures Run.
+type Options struct {
+	// Fill stores the key after a miss, like an application that loads
+	// missing values would do.
+	Fill bool
+	// Value returns the value stored for the key. The key itself is stored
+	// if it's nil.
+	Value func(key string) interface{}
+}
+
+// Result is the outcome of Run.
+type Result struct {
+	Gets    uint64
+	Hits    uint64
+	Misses  uint64
+	Sets    uint64
+	Deletes uint64
+}
+
+// HitRate returns the fraction of reads that hit the cache.
+func (r Result) HitRate() float64 {
+	if r.Gets == 0 {
+		return 0
+	}
+
+	return float64(r.Hits) / float64(r.Gets)
+}
+
+// Run applies all accesses of the source to the cache.
+func Run(cache *incache.Cache, source Source, opts Options) Result {
+	var result Result
+
+	value := opts.Value
+	if value == nil {
+		value = func(key string) interface{} { return key }
+	}
+
+	for {
+		access, ok := source.Next()
+		if !ok {
+			return result
+		}
+
+		switch access.Op {
+		case OpGet:
+			result.Gets++
+
+			if cache.Get(access.Key) != nil {
+				result.Hits++
+				continue
+			}
+
+			result.Misses++
+
+			if opts.Fill {
+				cache.Set(access.Key, value(access.Key))
+				result.Sets++
+			}
+		case OpSet:
+			cache.Set(access.Key, value(access.Key))
+			result.Sets++
+		case OpDelete:
+			cache.Delete(access.Key)
+			result.Deletes++
+		}
+	}
+}
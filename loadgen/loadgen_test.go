@@ -0,0 +1,71 @@
+package loadgen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wittyjudge/incache"
+)
+
+func TestRequests(t *testing.T) {
+	source := Requests(Uniform(10, 1), 3)
+
+	for i := 0; i < 3; i++ {
+		access, ok := source.Next()
+		assert.True(t, ok)
+		assert.Equal(t, OpGet, access.Op)
+		assert.Contains(t, access.Key, "key:")
+	}
+
+	_, ok := source.Next()
+	assert.False(t, ok)
+}
+
+func TestZipf(t *testing.T) {
+	gen := Zipf(100, 1.1, 1)
+
+	counts := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		counts[gen.Next()]++
+	}
+
+	for key, count := range counts {
+		assert.LessOrEqual(t, count, counts["key:0"], key)
+	}
+}
+
+func TestRun(t *testing.T) {
+	cache := incache.New(incache.WithTTL(0))
+
+	result := Run(cache, Requests(Uniform(10, 1), 1000), Options{Fill: true})
+
+	assert.Equal(t, uint64(1000), result.Gets)
+	assert.Equal(t, uint64(10), result.Misses)
+	assert.Equal(t, uint64(990), result.Hits)
+	assert.Equal(t, uint64(10), result.Sets)
+	assert.InDelta(t, 0.99, result.HitRate(), 0.001)
+}
+
+func TestRunBoundedCache(t *testing.T) {
+	source := func() Source { return Requests(Zipf(1000, 1.1, 1), 10000) }
+
+	small := Run(incache.New(incache.WithTTL(0), incache.WithMaxEntries(10)), source(), Options{Fill: true})
+	large := Run(incache.New(incache.WithTTL(0), incache.WithMaxEntries(500)), source(), Options{Fill: true})
+
+	assert.Less(t, small.HitRate(), large.HitRate())
+}
+
+func TestRunWithoutFill(t *testing.T) {
+	cache := incache.New(incache.WithTTL(0))
+
+	result := Run(cache, Requests(Uniform(10, 1), 100), Options{})
+
+	assert.Equal(t, uint64(100), result.Misses)
+	assert.Zero(t, result.HitRate())
+	assert.Zero(t, cache.Len())
+}
+
+func TestResultHitRateWithoutReads(t *testing.T) {
+	assert.Zero(t, Result{}.HitRate())
+}
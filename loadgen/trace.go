@@ -0,0 +1,110 @@
+package loadgen
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/wittyjudge/incache"
+)
+
+// Trace is a recorded sequence of accesses.
+type Trace []Access
+
+// ReadTrace reads a trace in the text format: one access per line, the
+// operation ("get", "set" or "delete") followed by the key and separated by
+// a space. Empty lines and lines starting with # are skipped.
+func ReadTrace(r io.Reader) (Trace, error) {
+	var trace Trace
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		name, key, ok := strings.Cut(text, " ")
+		if !ok {
+			return nil, fmt.Errorf("line %d: missing key", line)
+		}
+
+		op, err := parseOp(name)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		trace = append(trace, Access{Op: op, Key: strings.TrimSpace(key)})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return trace, nil
+}
+
+func parseOp(name string) (Op, error) {
+	switch strings.ToLower(name) {
+	case "get":
+		return OpGet, nil
+	case "set":
+		return OpSet, nil
+	case "delete", "del":
+		return OpDelete, nil
+	default:
+		return 0, fmt.Errorf("unknown operation %q", name)
+	}
+}
+
+// Source returns a source that replays the trace.
+func (t Trace) Source() Source {
+	return &replay{trace: t}
+}
+
+type replay struct {
+	trace Trace
+	pos   int
+}
+
+func (r *replay) Next() (Access, bool) {
+	if r.pos >= len(r.trace) {
+		return Access{}, false
+	}
+
+	access := r.trace[r.pos]
+	r.pos++
+
+	return access, true
+}
+
+// ReadRecordedTrace reads a trace recorded with incache.WithTraceRecorder.
+// Keys are only recorded as hashes, so they are replayed as the hashes
+// formatted in hex.
+func ReadRecordedTrace(r io.Reader) (Trace, error) {
+	var trace Trace
+
+	reader := incache.NewTraceReader(r)
+	for {
+		record, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return trace, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		op := OpGet
+		switch record.Op {
+		case incache.TraceSet:
+			op = OpSet
+		case incache.TraceDelete:
+			op = OpDelete
+		}
+
+		trace = append(trace, Access{Op: op, Key: strconv.FormatUint(record.KeyHash, 16)})
+	}
+}
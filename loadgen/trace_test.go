@@ -0,0 +1,67 @@
+package loadgen
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wittyjudge/incache"
+)
+
+func TestReadTrace(t *testing.T) {
+	trace, err := ReadTrace(strings.NewReader(`
+# recorded traffic
+get user:1
+set user:1
+GET user:1
+delete user:1
+get user:1
+`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, Trace{
+		{Op: OpGet, Key: "user:1"},
+		{Op: OpSet, Key: "user:1"},
+		{Op: OpGet, Key: "user:1"},
+		{Op: OpDelete, Key: "user:1"},
+		{Op: OpGet, Key: "user:1"},
+	}, trace)
+
+	result := Run(incache.New(incache.WithTTL(0)), trace.Source(), Options{
+		Value: func(key string) interface{} { return 1 },
+	})
+
+	assert.Equal(t, Result{Gets: 3, Hits: 1, Misses: 2, Sets: 1, Deletes: 1}, result)
+}
+
+func TestReadTraceErrors(t *testing.T) {
+	_, err := ReadTrace(strings.NewReader("get key\nget"))
+	assert.EqualError(t, err, "line 2: missing key")
+
+	_, err = ReadTrace(strings.NewReader("put key"))
+	assert.EqualError(t, err, `line 1: unknown operation "put"`)
+}
+
+func TestReadRecordedTrace(t *testing.T) {
+	var buf bytes.Buffer
+
+	recorded := incache.New(incache.WithTTL(0), incache.WithTraceRecorder(&buf))
+	recorded.Get("key1")
+	recorded.Set("key1", "value1")
+	recorded.Get("key1")
+	recorded.Delete("key1")
+	recorded.Close()
+
+	trace, err := ReadRecordedTrace(&buf)
+	assert.NoError(t, err)
+	assert.Len(t, trace, 4)
+	assert.Equal(t, []Op{OpGet, OpSet, OpGet, OpDelete}, []Op{trace[0].Op, trace[1].Op, trace[2].Op, trace[3].Op})
+
+	result := Run(incache.New(incache.WithTTL(0)), trace.Source(), Options{})
+	assert.Equal(t, Result{Gets: 2, Hits: 1, Misses: 1, Sets: 1, Deletes: 1}, result)
+
+	_, err = ReadRecordedTrace(strings.NewReader("get key1"))
+	assert.ErrorIs(t, err, incache.ErrInvalidTrace)
+}
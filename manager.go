@@ -0,0 +1,170 @@
+package incache
+
+import (
+	"sort"
+	"sync"
+)
+
+// Manager creates and retrieves named caches. Expired items of all of them
+// are removed by a single shared cleanup goroutine, instead of one goroutine
+// per cache, which suits applications with many small caches.
+//
+// Example:
+//
+//	manager := incache.NewManager(
+//		incache.WithTTL(5*time.Minute),
+//		incache.WithCleanupInterval(time.Minute),
+//	)
+//	defer manager.Close()
+//
+//	users := manager.Cache("users")
+type Manager struct {
+	mu     sync.RWMutex
+	caches map[string]*Cache
+
+	// Options applied to every created cache.
+	defaults []configFunc
+	cleaner  *cleaner
+}
+
+// NewManager creates new manager of caches.
+//
+// The options are applied to every created cache. The cleanup interval
+// configures the shared cleaner.
+func NewManager(conf ...configFunc) *Manager {
+	config := defaultConfig()
+	for _, fn := range conf {
+		fn(&config)
+	}
+
+	m := &Manager{
+		caches:   make(map[string]*Cache),
+		defaults: conf,
+	}
+
+	if config.cleanupInterval > 0 {
+		m.cleaner = newCleaner(config.cleanupInterval, func() expiredDeleter { return m })
+		m.cleaner.wake()
+	}
+
+	return m
+}
+
+// Cache returns the cache with the given name, creating it if it doesn't
+// exist yet. The options are applied on top of the manager's ones when
+// the cache is created, and ignored otherwise.
+//
+// Caches are cleaned up by the shared cleaner, so the cleanup interval
+// option doesn't take effect.
+func (m *Manager) Cache(name string, conf ...configFunc) *Cache {
+	m.mu.RLock()
+	cache, ok := m.caches[name]
+	m.mu.RUnlock()
+
+	if ok {
+		return cache
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if cache, ok := m.caches[name]; ok {
+		return cache
+	}
+
+	options := make([]configFunc, 0, len(m.defaults)+len(conf)+1)
+	options = append(options, m.defaults...)
+	options = append(options, conf...)
+	options = append(options, WithCleanupInterval(0))
+
+	cache = New(options...)
+	m.caches[name] = cache
+
+	return cache
+}
+
+// Lookup returns the cache with the given name, if it exists.
+func (m *Manager) Lookup(name string) (*Cache, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	cache, ok := m.caches[name]
+	return cache, ok
+}
+
+// Names returns sorted names of all caches.
+func (m *Manager) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.caches))
+	for name := range m.caches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Remove closes the cache with the given name and removes it from the manager.
+// If the cache doesn't exist, nothing will happen.
+func (m *Manager) Remove(name string) {
+	m.mu.Lock()
+	cache, ok := m.caches[name]
+	delete(m.caches, name)
+	m.mu.Unlock()
+
+	if ok {
+		cache.Close()
+	}
+}
+
+// DeleteExpired deletes expired items from all caches and returns
+// the number of deleted items.
+// You don't need to perform it manually unless cleanupInterval is <= 0.
+func (m *Manager) DeleteExpired() int {
+	deleted := 0
+	for _, cache := range m.snapshot() {
+		deleted += cache.DeleteExpired()
+	}
+
+	return deleted
+}
+
+func (m *Manager) cleanup() {
+	for _, cache := range m.snapshot() {
+		cache.cleanup()
+	}
+}
+
+// hasExpiring always reports true, so the shared cleaner keeps running
+// for caches created later.
+func (m *Manager) hasExpiring() bool {
+	return true
+}
+
+// Close stops the shared cleaner and closes all caches.
+// It's safe to call Close multiple times.
+func (m *Manager) Close() {
+	if m.cleaner != nil {
+		m.cleaner.close()
+	}
+
+	for _, cache := range m.snapshot() {
+		cache.Close()
+	}
+}
+
+// snapshot returns all caches, so they can be processed without holding
+// the lock.
+func (m *Manager) snapshot() []*Cache {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	caches := make([]*Cache, 0, len(m.caches))
+	for _, cache := range m.caches {
+		caches = append(caches, cache)
+	}
+
+	return caches
+}
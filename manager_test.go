@@ -0,0 +1,87 @@
+package incache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManagerCache(t *testing.T) {
+	manager := NewManager(WithTTL(time.Hour))
+	defer manager.Close()
+
+	users := manager.Cache("users")
+	sessions := manager.Cache("sessions", WithTTL(time.Minute))
+
+	assert.Same(t, users, manager.Cache("users"))
+	assert.NotSame(t, users, sessions)
+
+	users.Set("1", "user1")
+	sessions.Set("1", "session1")
+
+	assert.Equal(t, "user1", users.Get("1"))
+	assert.Equal(t, "session1", sessions.Get("1"))
+
+	info, _ := users.Inspect("1")
+	assert.Equal(t, time.Hour, info.TTL)
+
+	info, _ = sessions.Inspect("1")
+	assert.Equal(t, time.Minute, info.TTL)
+
+	assert.Equal(t, []string{"sessions", "users"}, manager.Names())
+
+	cache, ok := manager.Lookup("users")
+	assert.True(t, ok)
+	assert.Same(t, users, cache)
+
+	manager.Remove("users")
+
+	_, ok = manager.Lookup("users")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"sessions"}, manager.Names())
+}
+
+func TestManagerSharedCleaner(t *testing.T) {
+	manager := NewManager(WithTTL(time.Millisecond), WithCleanupInterval(time.Millisecond))
+	defer manager.Close()
+
+	first := manager.Cache("first")
+	second := manager.Cache("second", WithCleanupInterval(time.Hour))
+
+	assert.Nil(t, first.cleaner)
+	assert.Nil(t, second.cleaner)
+
+	first.Set("key", "value")
+	second.Set("key", "value")
+
+	assert.Eventually(t, func() bool {
+		return first.Len() == 0 && second.Len() == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestManagerConcurrentCache(t *testing.T) {
+	manager := NewManager()
+	defer manager.Close()
+
+	caches := make([]*Cache, 100)
+
+	var wg sync.WaitGroup
+
+	for i := range caches {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			caches[i] = manager.Cache("cache")
+		}(i)
+	}
+
+	wg.Wait()
+
+	for _, cache := range caches {
+		assert.Same(t, caches[0], cache)
+	}
+}
@@ -0,0 +1,50 @@
+package incache
+
+import (
+	"sort"
+	"time"
+)
+
+// NewFromMap creates a new cache and stores the items of the map in it with
+// the default TTL, e.g. to seed the cache from a configuration file.
+// Items are stored in the order of their keys, which matters when the map
+// has more items than allowed by WithMaxEntries.
+func NewFromMap(items map[string]interface{}, conf ...configFunc) *Cache {
+	cache := New(conf...)
+
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	cache.mu.Lock()
+	defer cache.unlock()
+
+	for _, key := range keys {
+		cache.setLocked(key, items[key], cache.defaultTTL())
+	}
+
+	return cache
+}
+
+// ToMap returns a map with the values of all items that haven't expired.
+// Changes to the map don't affect the cache.
+func (c *Cache) ToMap() map[string]interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := time.Now()
+
+	items := make(map[string]interface{}, len(c.items))
+	for key, item := range c.items {
+		if item.expiredAt(now) {
+			continue
+		}
+
+		items[key] = c.copyValue(c.value(item.Value))
+	}
+
+	return items
+}
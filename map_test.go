@@ -0,0 +1,47 @@
+package incache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFromMap(t *testing.T) {
+	cache := NewFromMap(map[string]interface{}{
+		"key1": "value1",
+		"key2": 2,
+	}, WithTTL(time.Minute))
+	defer cache.Close()
+
+	assert.Equal(t, "value1", cache.Get("key1"))
+	assert.Equal(t, 2, cache.Get("key2"))
+	assert.Equal(t, time.Minute, cache.items["key1"].TTL)
+}
+
+func TestNewFromMapWithMaxEntries(t *testing.T) {
+	cache := NewFromMap(map[string]interface{}{
+		"key3": 3,
+		"key1": 1,
+		"key2": 2,
+	}, WithMaxEntries(2))
+	defer cache.Close()
+
+	assert.ElementsMatch(t, []string{"key2", "key3"}, cache.Keys())
+}
+
+func TestToMap(t *testing.T) {
+	cache := New(WithCleanupInterval(0))
+
+	cache.Set("key1", "value1")
+	cache.Set("key2", 2)
+	cache.SetWithTTL("key3", 3, time.Millisecond)
+
+	time.Sleep(5 * time.Millisecond)
+
+	items := cache.ToMap()
+	assert.Equal(t, map[string]interface{}{"key1": "value1", "key2": 2}, items)
+
+	items["key4"] = 4
+	assert.False(t, cache.Has("key4"))
+}
@@ -0,0 +1,9 @@
+//go:build !go1.19
+
+package incache
+
+// memoryLimit returns zero before Go 1.19, since the memory limit
+// isn't available.
+func memoryLimit() uint64 {
+	return 0
+}
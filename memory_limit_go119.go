@@ -0,0 +1,19 @@
+//go:build go1.19
+
+package incache
+
+import (
+	"math"
+	"runtime/debug"
+)
+
+// memoryLimit returns the memory limit of the process set by GOMEMLIMIT or
+// debug.SetMemoryLimit, or zero if there is no limit.
+func memoryLimit() uint64 {
+	limit := debug.SetMemoryLimit(-1)
+	if limit <= 0 || limit == math.MaxInt64 {
+		return 0
+	}
+
+	return uint64(limit)
+}
@@ -0,0 +1,132 @@
+package incache
+
+import (
+	rtmetrics "runtime/metrics"
+	"sync"
+	"time"
+)
+
+// memoryCheckInterval is how often the memory usage of the process is
+// checked, see WithMemoryWatermark.
+const memoryCheckInterval = time.Second
+
+// memoryWatcher periodically checks the memory usage of the process and
+// sheds items of the cache when it's above the high watermark.
+type memoryWatcher struct {
+	high, low uint64
+	interval  time.Duration
+	// readMemory returns the memory used by the process.
+	readMemory func() uint64
+	// target returns the cache, or nil once it's garbage-collected.
+	target func() *Cache
+
+	closeCh   chan struct{}
+	closeOnce sync.Once
+}
+
+func newMemoryWatcher(high, low uint64, target func() *Cache) *memoryWatcher {
+	return &memoryWatcher{
+		high:       high,
+		low:        low,
+		interval:   memoryCheckInterval,
+		readMemory: readProcessMemory,
+		target:     target,
+
+		closeCh: make(chan struct{}),
+	}
+}
+
+func (w *memoryWatcher) start() {
+	go w.run()
+}
+
+func (w *memoryWatcher) run() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c := w.target()
+			if c == nil {
+				return
+			}
+
+			w.check(c)
+		case <-w.closeCh:
+			return
+		}
+	}
+}
+
+// check sheds items of the cache when the memory usage is above the high
+// watermark. The memory is only released by the next GC, so the amount of
+// items to shed is estimated from their size, see MemoryUsage.
+func (w *memoryWatcher) check(c *Cache) {
+	used := w.readMemory()
+	if used < w.high {
+		return
+	}
+
+	c.config.debugf("[memory] usage %d is above the watermark %d", used, w.high)
+
+	c.shed(used - w.low)
+}
+
+// close stops the watcher. It's safe to call it multiple times.
+func (w *memoryWatcher) close() {
+	w.closeOnce.Do(func() {
+		close(w.closeCh)
+	})
+}
+
+// shed evicts items in the order of EvictN until the estimated size of
+// the evicted items reaches size. It returns the number of evicted items.
+func (c *Cache) shed(size uint64) int {
+	c.mu.Lock()
+	defer c.unlock()
+
+	var keys []string
+	if c.evictionQueue == nil {
+		keys = c.evictionOrderLocked()
+	}
+
+	freed, evicted := uint64(0), 0
+	for freed < size {
+		var key string
+		if c.evictionQueue != nil {
+			next, ok := c.evictionQueue.next()
+			if !ok {
+				break
+			}
+
+			key = next
+		} else {
+			if evicted == len(keys) {
+				break
+			}
+
+			key = keys[evicted]
+		}
+
+		freed += uint64(len(key)) + estimateSize(c.items[key].Value) + itemOverhead
+		c.evictLocked(key, reasonMemory)
+		evicted++
+	}
+
+	return evicted
+}
+
+var memoryMetrics = []string{
+	"/memory/classes/total:bytes",
+	"/memory/classes/heap/released:bytes",
+}
+
+// readProcessMemory returns the memory used by the Go runtime, the same
+// way it's accounted for the memory limit, see debug.SetMemoryLimit.
+func readProcessMemory() uint64 {
+	samples := []rtmetrics.Sample{{Name: memoryMetrics[0]}, {Name: memoryMetrics[1]}}
+	rtmetrics.Read(samples)
+
+	return samples[0].Value.Uint64() - samples[1].Value.Uint64()
+}
@@ -0,0 +1,68 @@
+package incache
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryWatcherSheds(t *testing.T) {
+	cache := New(WithTTL(0), WithCleanupInterval(0))
+
+	value := strings.Repeat("a", 1000)
+	for i := 0; i < 10; i++ {
+		cache.Set(fmt.Sprint("key", i), value)
+	}
+
+	used := uint64(10000)
+
+	watcher := newMemoryWatcher(12000, 5000, func() *Cache { return cache })
+	watcher.readMemory = func() uint64 { return used }
+
+	watcher.check(cache)
+	assert.Equal(t, 10, cache.Len())
+
+	used = 13000
+	watcher.check(cache)
+
+	// 8000 bytes have to be shed, every item takes a bit more than 1000.
+	assert.ElementsMatch(t, []string{"key8", "key9"}, cache.Keys())
+}
+
+func TestShedWithMaxEntries(t *testing.T) {
+	cache := New(WithTTL(0), WithCleanupInterval(0), WithMaxEntries(10))
+
+	for i := 0; i < 10; i++ {
+		cache.Set(fmt.Sprint("key", i), strings.Repeat("a", 100))
+	}
+
+	assert.Equal(t, 2, cache.shed(105+itemOverhead))
+	assert.NotContains(t, cache.Keys(), "key0")
+	assert.NotContains(t, cache.Keys(), "key1")
+
+	assert.Equal(t, 8, cache.shed(1<<40))
+	assert.Zero(t, cache.Len())
+}
+
+func TestWithMemoryWatermark(t *testing.T) {
+	// The process always uses more than a byte.
+	cache := New(WithTTL(0), WithMemoryWatermark(1, 0))
+	defer cache.Close()
+
+	cache.Set("key1", "value1")
+
+	assert.Eventually(t, func() bool {
+		return cache.Len() == 0
+	}, 3*time.Second, 10*time.Millisecond)
+}
+
+func TestWithMemoryWatermarkDefaults(t *testing.T) {
+	cache := New(WithMemoryWatermark(1000, 0))
+	assert.EqualValues(t, 900, cache.config.memoryLowWatermark)
+
+	_, err := NewWithError(WithMemoryWatermark(1000, 2000))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
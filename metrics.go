@@ -1,12 +1,42 @@
 package incache
 
-import "sync/atomic"
+import (
+	"encoding/json"
+	"sync/atomic"
+	"time"
+)
 
 type metrics interface {
 	Insertions() uint64
 	Hits() uint64
 	Misses() uint64
 	Evictions() uint64
+	Expired() uint64
+	// Number of times the circuit breaker of the loader opened,
+	// see WithLoaderCircuitBreaker.
+	CircuitOpens() uint64
+	// Number of loads short-circuited by the circuit breaker.
+	ShortCircuits() uint64
+
+	// Reset* reset the counter and return its value before the reset.
+	// The value is swapped atomically, so increments that happen
+	// concurrently are never lost, which allows to compute deltas.
+	ResetInsertions() uint64
+	ResetHits() uint64
+	ResetMisses() uint64
+	ResetEvictions() uint64
+	ResetExpired() uint64
+	ResetCircuitOpens() uint64
+	ResetShortCircuits() uint64
+
+	// AsMap returns the counters by their snake_case names, e.g. "hits".
+	AsMap() map[string]uint64
+	// MarshalJSON encodes the counters as a JSON object, see AsMap.
+	MarshalJSON() ([]byte, error)
+
+	GetLatency() LatencyHistogram
+	SetLatency() LatencyHistogram
+	DeleteLatency() LatencyHistogram
 
 	reset()
 
@@ -14,68 +44,269 @@ type metrics interface {
 	incrementHits()
 	incrementMisses()
 	incrementEvictions()
+	addEvictions(n uint64)
+	incrementExpired()
+	incrementCircuitOpens()
+	incrementShortCircuits()
+
+	observeGet(d time.Duration)
+	observeSet(d time.Duration)
+	observeDelete(d time.Duration)
+}
+
+// counter is an atomic uint64 counter padded to the size of a cache line,
+// so increments of different counters that happen on different CPU cores
+// don't invalidate each other's cache lines (false sharing).
+type counter struct {
+	value uint64
+	_     [56]byte
+}
+
+func (c *counter) increment() {
+	atomic.AddUint64(&c.value, 1)
+}
+
+func (c *counter) add(n uint64) {
+	atomic.AddUint64(&c.value, n)
 }
 
-// Metrics stores cache statistics
+func (c *counter) load() uint64 {
+	return atomic.LoadUint64(&c.value)
+}
+
+func (c *counter) reset() {
+	atomic.StoreUint64(&c.value, 0)
+}
+
+// swap resets the counter and returns its previous value.
+func (c *counter) swap() uint64 {
+	return atomic.SwapUint64(&c.value, 0)
+}
+
+// Metrics stores cache statistics.
+// All counters are updated atomically, so it's safe to read and update them
+// concurrently.
 type realMetrics struct {
 	// Shows how many times items were inserted into cache.
-	insertions uint64
+	insertions counter
 
 	// Shows how many times items were successfully retrieved by key.
-	hits uint64
+	hits counter
 
 	// Shows how many times items weren't retrieved by key.
-	misses uint64
+	misses counter
 
 	// Shows how many items were released from the cache.
-	evictions uint64
+	evictions counter
+
+	// Shows how many items were removed from the cache because
+	// their TTL has passed. These removals aren't counted as evictions.
+	expired counter
+
+	// Shows how many times the circuit breaker of the loader opened and
+	// how many loads it short-circuited.
+	circuitOpens  counter
+	shortCircuits counter
+
+	// Latency distributions of operations.
+	// They are only collected when detailed metrics are enabled.
+	getLatency    *latencyHistogram
+	setLatency    *latencyHistogram
+	deleteLatency *latencyHistogram
 }
 
-func newRealMetrics() *realMetrics {
-	return &realMetrics{}
+func newRealMetrics(detailed bool) *realMetrics {
+	m := &realMetrics{}
+
+	if detailed {
+		m.getLatency = &latencyHistogram{}
+		m.setLatency = &latencyHistogram{}
+		m.deleteLatency = &latencyHistogram{}
+	}
+
+	return m
 }
 
 // Get collected insertions.
 func (m *realMetrics) Insertions() uint64 {
-	return atomic.LoadUint64(&m.insertions)
+	return m.insertions.load()
 }
 
 // Get collected hits.
 func (m *realMetrics) Hits() uint64 {
-	return atomic.LoadUint64(&m.hits)
+	return m.hits.load()
 }
 
 // Get collected misses.
 func (m *realMetrics) Misses() uint64 {
-	return atomic.LoadUint64(&m.misses)
+	return m.misses.load()
 }
 
 // Get collected evictions.
 func (m *realMetrics) Evictions() uint64 {
-	return atomic.LoadUint64(&m.evictions)
+	return m.evictions.load()
+}
+
+// Get collected expirations.
+func (m *realMetrics) Expired() uint64 {
+	return m.expired.load()
+}
+
+// Get the number of times the loader circuit opened.
+func (m *realMetrics) CircuitOpens() uint64 {
+	return m.circuitOpens.load()
+}
+
+// Get the number of short-circuited loads.
+func (m *realMetrics) ShortCircuits() uint64 {
+	return m.shortCircuits.load()
+}
+
+// Reset collected insertions.
+func (m *realMetrics) ResetInsertions() uint64 {
+	return m.insertions.swap()
+}
+
+// Reset collected hits.
+func (m *realMetrics) ResetHits() uint64 {
+	return m.hits.swap()
+}
+
+// Reset collected misses.
+func (m *realMetrics) ResetMisses() uint64 {
+	return m.misses.swap()
+}
+
+// Reset collected evictions.
+func (m *realMetrics) ResetEvictions() uint64 {
+	return m.evictions.swap()
+}
+
+// Reset collected expirations.
+func (m *realMetrics) ResetExpired() uint64 {
+	return m.expired.swap()
+}
+
+// Reset the number of times the loader circuit opened.
+func (m *realMetrics) ResetCircuitOpens() uint64 {
+	return m.circuitOpens.swap()
+}
+
+// Reset the number of short-circuited loads.
+func (m *realMetrics) ResetShortCircuits() uint64 {
+	return m.shortCircuits.swap()
+}
+
+// AsMap returns the counters by their names.
+func (m *realMetrics) AsMap() map[string]uint64 {
+	return metricsMap(m)
+}
+
+// MarshalJSON implements json.Marshaler.
+func (m *realMetrics) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.AsMap())
+}
+
+// Get latency distribution of Get operations.
+func (m *realMetrics) GetLatency() LatencyHistogram {
+	return snapshotLatency(m.getLatency)
+}
+
+// Get latency distribution of Set operations.
+func (m *realMetrics) SetLatency() LatencyHistogram {
+	return snapshotLatency(m.setLatency)
+}
+
+// Get latency distribution of Delete operations.
+func (m *realMetrics) DeleteLatency() LatencyHistogram {
+	return snapshotLatency(m.deleteLatency)
 }
 
 func (m *realMetrics) reset() {
-	m.insertions = 0
-	m.hits = 0
-	m.misses = 0
-	m.evictions = 0
+	m.insertions.reset()
+	m.hits.reset()
+	m.misses.reset()
+	m.evictions.reset()
+	m.expired.reset()
+	m.circuitOpens.reset()
+	m.shortCircuits.reset()
+
+	if m.getLatency != nil {
+		m.getLatency.reset()
+		m.setLatency.reset()
+		m.deleteLatency.reset()
+	}
 }
 
 func (m *realMetrics) incrementInsertions() {
-	m.insertions += 1
+	m.insertions.increment()
 }
 
 func (m *realMetrics) incrementHits() {
-	m.hits += 1
+	m.hits.increment()
 }
 
 func (m *realMetrics) incrementMisses() {
-	m.misses += 1
+	m.misses.increment()
 }
 
 func (m *realMetrics) incrementEvictions() {
-	m.evictions += 1
+	m.evictions.increment()
+}
+
+func (m *realMetrics) addEvictions(n uint64) {
+	m.evictions.add(n)
+}
+
+func (m *realMetrics) incrementExpired() {
+	m.expired.increment()
+}
+
+func (m *realMetrics) incrementCircuitOpens() {
+	m.circuitOpens.increment()
+}
+
+func (m *realMetrics) incrementShortCircuits() {
+	m.shortCircuits.increment()
+}
+
+func (m *realMetrics) observeGet(d time.Duration) {
+	if m.getLatency != nil {
+		m.getLatency.observe(d)
+	}
+}
+
+func (m *realMetrics) observeSet(d time.Duration) {
+	if m.setLatency != nil {
+		m.setLatency.observe(d)
+	}
+}
+
+func (m *realMetrics) observeDelete(d time.Duration) {
+	if m.deleteLatency != nil {
+		m.deleteLatency.observe(d)
+	}
+}
+
+// metricsMap returns the counters of m by their names.
+func metricsMap(m metrics) map[string]uint64 {
+	return map[string]uint64{
+		"insertions":     m.Insertions(),
+		"hits":           m.Hits(),
+		"misses":         m.Misses(),
+		"evictions":      m.Evictions(),
+		"expired":        m.Expired(),
+		"circuit_opens":  m.CircuitOpens(),
+		"short_circuits": m.ShortCircuits(),
+	}
+}
+
+func snapshotLatency(h *latencyHistogram) LatencyHistogram {
+	if h == nil {
+		return LatencyHistogram{}
+	}
+
+	return h.snapshot()
 }
 
 // Dummy metrics implementation that is used if metrics is disabled.
@@ -87,6 +318,25 @@ func (m *noMetrics) Insertions() uint64 { return 0 }
 func (m *noMetrics) Hits() uint64       { return 0 }
 func (m *noMetrics) Misses() uint64     { return 0 }
 func (m *noMetrics) Evictions() uint64  { return 0 }
+func (m *noMetrics) Expired() uint64    { return 0 }
+
+func (m *noMetrics) CircuitOpens() uint64  { return 0 }
+func (m *noMetrics) ShortCircuits() uint64 { return 0 }
+
+func (m *noMetrics) ResetInsertions() uint64    { return 0 }
+func (m *noMetrics) ResetHits() uint64          { return 0 }
+func (m *noMetrics) ResetMisses() uint64        { return 0 }
+func (m *noMetrics) ResetEvictions() uint64     { return 0 }
+func (m *noMetrics) ResetExpired() uint64       { return 0 }
+func (m *noMetrics) ResetCircuitOpens() uint64  { return 0 }
+func (m *noMetrics) ResetShortCircuits() uint64 { return 0 }
+
+func (m *noMetrics) AsMap() map[string]uint64     { return metricsMap(m) }
+func (m *noMetrics) MarshalJSON() ([]byte, error) { return json.Marshal(m.AsMap()) }
+
+func (m *noMetrics) GetLatency() LatencyHistogram    { return LatencyHistogram{} }
+func (m *noMetrics) SetLatency() LatencyHistogram    { return LatencyHistogram{} }
+func (m *noMetrics) DeleteLatency() LatencyHistogram { return LatencyHistogram{} }
 
 func (m *noMetrics) reset() {}
 
@@ -94,3 +344,12 @@ func (m *noMetrics) incrementInsertions() {}
 func (m *noMetrics) incrementHits()       {}
 func (m *noMetrics) incrementMisses()     {}
 func (m *noMetrics) incrementEvictions()  {}
+func (m *noMetrics) addEvictions(uint64)  {}
+func (m *noMetrics) incrementExpired()    {}
+
+func (m *noMetrics) incrementCircuitOpens()  {}
+func (m *noMetrics) incrementShortCircuits() {}
+
+func (m *noMetrics) observeGet(d time.Duration)    {}
+func (m *noMetrics) observeSet(d time.Duration)    {}
+func (m *noMetrics) observeDelete(d time.Duration) {}
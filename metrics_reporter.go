@@ -0,0 +1,108 @@
+package incache
+
+import (
+	"sync"
+	"time"
+)
+
+// MetricsReport is a snapshot of the cache statistics passed to
+// MetricsReporter.
+type MetricsReport struct {
+	Time        time.Time
+	Len         int
+	MemoryUsage uint64
+	// Counters by name, see Metrics().AsMap.
+	Counters map[string]uint64
+	// Increase of the counters since the previous report. Counters that
+	// were reset in the meantime are reported as increased by their
+	// current value.
+	Deltas map[string]uint64
+}
+
+// MetricsReporter pushes cache statistics to a telemetry system,
+// see WithMetricsReporter and StatsDReporter.
+type MetricsReporter interface {
+	Report(report MetricsReport) error
+}
+
+// metricsReporter periodically passes reports of the cache to a reporter.
+type metricsReporter struct {
+	reporter MetricsReporter
+	interval time.Duration
+	// target returns the cache, or nil once it's garbage-collected.
+	target func() *Cache
+	// Counters of the previous report, used to compute deltas.
+	previous map[string]uint64
+
+	closeCh   chan struct{}
+	closeOnce sync.Once
+}
+
+func newMetricsReporter(reporter MetricsReporter, interval time.Duration, target func() *Cache) *metricsReporter {
+	return &metricsReporter{
+		reporter: reporter,
+		interval: interval,
+		target:   target,
+		previous: make(map[string]uint64),
+
+		closeCh: make(chan struct{}),
+	}
+}
+
+func (r *metricsReporter) start() {
+	go r.run()
+}
+
+func (r *metricsReporter) run() {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c := r.target()
+			if c == nil {
+				return
+			}
+
+			if err := r.reporter.Report(r.report(c)); err != nil {
+				c.config.debugf("[metrics] failed to report metrics: %v", err)
+			}
+		case <-r.closeCh:
+			return
+		}
+	}
+}
+
+// report builds the next report of the cache.
+func (r *metricsReporter) report(c *Cache) MetricsReport {
+	counters := c.Metrics().AsMap()
+
+	deltas := make(map[string]uint64, len(counters))
+	for name, value := range counters {
+		previous := r.previous[name]
+		if value < previous {
+			// The counter was reset.
+			previous = 0
+		}
+
+		deltas[name] = value - previous
+	}
+
+	r.previous = counters
+
+	return MetricsReport{
+		Time:        time.Now(),
+		Len:         c.Len(),
+		MemoryUsage: c.MemoryUsage(),
+		Counters:    counters,
+		Deltas:      deltas,
+	}
+}
+
+// close stops the reporter. It's safe to call it multiple times.
+func (r *metricsReporter) close() {
+	r.closeOnce.Do(func() {
+		close(r.closeCh)
+	})
+}
@@ -0,0 +1,75 @@
+package incache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type reporterFunc func(report MetricsReport) error
+
+func (f reporterFunc) Report(report MetricsReport) error {
+	return f(report)
+}
+
+func TestMetricsReporter(t *testing.T) {
+	reports := make(chan MetricsReport, 10)
+
+	cache := New(WithMetrics(), WithMetricsReporter(reporterFunc(func(report MetricsReport) error {
+		reports <- report
+		return nil
+	}), 10*time.Millisecond))
+	defer cache.Close()
+
+	cache.Set("key1", "value1")
+	cache.Get("key1")
+
+	report := <-reports
+	assert.Equal(t, 1, report.Len)
+	assert.EqualValues(t, 1, report.Counters["hits"])
+	assert.EqualValues(t, 1, report.Deltas["hits"])
+	assert.NotZero(t, report.MemoryUsage)
+
+	cache.Get("key1")
+
+	report = <-reports
+	assert.EqualValues(t, 2, report.Counters["hits"])
+	assert.EqualValues(t, 1, report.Deltas["hits"])
+	assert.EqualValues(t, 0, report.Deltas["insertions"])
+}
+
+func TestMetricsReporterDeltasAfterReset(t *testing.T) {
+	cache := New(WithMetrics())
+	reporter := newMetricsReporter(nil, time.Minute, weakRef(cache))
+
+	cache.Get("key1")
+	cache.Get("key1")
+	reporter.report(cache)
+
+	cache.ResetMetrics()
+	cache.Get("key1")
+
+	report := reporter.report(cache)
+	assert.EqualValues(t, 1, report.Deltas["misses"])
+}
+
+func TestMetricsReporterStopsOnClose(t *testing.T) {
+	reports := make(chan MetricsReport, 100)
+
+	cache := New(WithMetricsReporter(reporterFunc(func(report MetricsReport) error {
+		reports <- report
+		return nil
+	}), time.Millisecond))
+
+	<-reports
+	cache.Close()
+
+	time.Sleep(5 * time.Millisecond)
+	for len(reports) > 0 {
+		<-reports
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	assert.Empty(t, reports)
+}
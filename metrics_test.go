@@ -0,0 +1,127 @@
+package incache
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounterIsPadded(t *testing.T) {
+	assert.EqualValues(t, 64, unsafe.Sizeof(counter{}))
+}
+
+func TestRealMetricsConcurrentIncrements(t *testing.T) {
+	metrics := newRealMetrics(true)
+
+	var wg sync.WaitGroup
+
+	for g := 0; g < 100; g++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < 1000; i++ {
+				metrics.incrementInsertions()
+				metrics.incrementHits()
+				metrics.incrementMisses()
+				metrics.incrementEvictions()
+				metrics.incrementExpired()
+				metrics.observeGet(0)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	assert.EqualValues(t, 100000, metrics.Insertions())
+	assert.EqualValues(t, 100000, metrics.Hits())
+	assert.EqualValues(t, 100000, metrics.Misses())
+	assert.EqualValues(t, 100000, metrics.Evictions())
+	assert.EqualValues(t, 100000, metrics.Expired())
+	assert.EqualValues(t, 100000, metrics.GetLatency().Count)
+
+	metrics.reset()
+
+	assert.Zero(t, metrics.Insertions())
+	assert.Zero(t, metrics.Hits())
+	assert.Zero(t, metrics.GetLatency().Count)
+}
+
+func TestMetricsAsMap(t *testing.T) {
+	cache := New(WithMetrics())
+
+	cache.Set("key1", "value1")
+	cache.Get("key1")
+	cache.Get("key2")
+
+	assert.Equal(t, map[string]uint64{
+		"insertions":     1,
+		"hits":           1,
+		"misses":         1,
+		"evictions":      0,
+		"expired":        0,
+		"circuit_opens":  0,
+		"short_circuits": 0,
+	}, cache.Metrics().AsMap())
+}
+
+func TestMetricsMarshalJSON(t *testing.T) {
+	cache := New(WithMetrics())
+	cache.Set("key1", "value1")
+
+	data, err := json.Marshal(cache.Metrics())
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"insertions":1,"hits":0,"misses":0,"evictions":0,"expired":0,"circuit_opens":0,"short_circuits":0}`, string(data))
+
+	data, err = json.Marshal(New().Metrics())
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"hits":0`)
+}
+
+func TestMetricsResetCounter(t *testing.T) {
+	metrics := newRealMetrics(false)
+
+	var wg sync.WaitGroup
+	var total uint64
+	var mu sync.Mutex
+
+	for g := 0; g < 10; g++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < 1000; i++ {
+				metrics.incrementHits()
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < 100; i++ {
+				delta := metrics.ResetHits()
+
+				mu.Lock()
+				total += delta
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// No increments are lost between the resets.
+	assert.EqualValues(t, 10000, total+metrics.ResetHits())
+	assert.Zero(t, metrics.Hits())
+
+	metrics.incrementMisses()
+	metrics.incrementInsertions()
+	assert.EqualValues(t, 1, metrics.ResetMisses())
+	assert.EqualValues(t, 1, metrics.Insertions())
+	assert.Zero(t, newNoMetrics().ResetHits())
+}
@@ -0,0 +1,181 @@
+package incache
+
+import (
+	"strings"
+	"time"
+)
+
+// namespaceSeparator separates the name of a namespace from keys.
+const namespaceSeparator = ":"
+
+// Namespace is a view of the cache that prefixes all keys with its name,
+// so one cache can safely be shared by several modules.
+//
+// Example:
+//
+//	users := cache.Namespace("users").WithTTL(time.Minute)
+//	users.Set("1", user) // stored as "users:1"
+type Namespace struct {
+	cache  *Cache
+	name   string
+	prefix string
+	ttl    time.Duration
+	// Set when the namespace has its own default TTL, otherwise the
+	// default TTL of the cache is used.
+	hasTTL bool
+	// Priority of eviction of the items stored through the namespace.
+	priority int
+}
+
+// Namespace returns a view of the cache with keys prefixed by "name:".
+// It uses the default TTL of the cache.
+func (c *Cache) Namespace(name string) *Namespace {
+	n := &Namespace{
+		cache:  c,
+		name:   name,
+		prefix: name + namespaceSeparator,
+	}
+	n.track()
+
+	return n
+}
+
+// Name returns the full name of the namespace.
+func (n *Namespace) Name() string {
+	return n.name
+}
+
+// WithTTL returns a copy of the namespace with its own default TTL.
+func (n *Namespace) WithTTL(ttl time.Duration) *Namespace {
+	ns := *n
+	ns.ttl = ttl
+	ns.hasTTL = true
+
+	return &ns
+}
+
+// WithPriority returns a copy of the namespace that stores items with the
+// given priority of eviction, see Cache.SetWithPriority. E.g. long-lived
+// reference data can be given higher priority than short-lived tokens
+// stored in the same cache, so the tokens are evicted first.
+func (n *Namespace) WithPriority(priority int) *Namespace {
+	ns := *n
+	ns.priority = priority
+
+	return &ns
+}
+
+// Namespace returns a nested namespace, e.g. "users:sessions".
+// The nested namespace inherits the default TTL and the priority.
+func (n *Namespace) Namespace(name string) *Namespace {
+	nested := &Namespace{
+		cache:    n.cache,
+		name:     n.prefix + name,
+		prefix:   n.prefix + name + namespaceSeparator,
+		ttl:      n.ttl,
+		hasTTL:   n.hasTTL,
+		priority: n.priority,
+	}
+	nested.track()
+
+	return nested
+}
+
+// track makes the cache count metrics of the namespace when metrics are
+// enabled, see Cache.NamespaceMetrics.
+func (n *Namespace) track() {
+	if n.cache.namespaces != nil {
+		n.cache.namespaces.track(n.name, n.prefix)
+	}
+}
+
+// defaultTTL returns the default TTL of the namespace.
+func (n *Namespace) defaultTTL() time.Duration {
+	if n.hasTTL {
+		return n.ttl
+	}
+
+	return n.cache.defaultTTL()
+}
+
+// Set sets the key to hold a value with the default TTL of the namespace.
+func (n *Namespace) Set(key string, value interface{}) {
+	n.cache.setWithPriority(n.prefix+key, value, n.defaultTTL(), n.priority)
+}
+
+// SetWithTTL sets the key to hold a value for ttl.
+func (n *Namespace) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	n.cache.setWithPriority(n.prefix+key, value, ttl, n.priority)
+}
+
+// Get returns the value of key.
+// If the key doesn't exist, nil value will be returned.
+func (n *Namespace) Get(key string) interface{} {
+	return n.cache.get(n.prefix + key)
+}
+
+// GetDelete returns the value of key and delete it.
+// If the key doesn't exist, nil value will be returned.
+func (n *Namespace) GetDelete(key string) interface{} {
+	return n.cache.GetDelete(n.prefix + key)
+}
+
+// Delete deletes the value of key.
+// If the key doesn't exist, nothing will happen.
+func (n *Namespace) Delete(key string) {
+	n.cache.delete(n.prefix + key)
+}
+
+// Has checks if the key exists in the namespace.
+func (n *Namespace) Has(key string) bool {
+	return n.cache.Has(n.prefix + key)
+}
+
+// Keys returns slice of all keys in the namespace, without the prefix.
+// Keys of expired items that weren't removed yet aren't included.
+func (n *Namespace) Keys() []string {
+	n.cache.mu.RLock()
+	defer n.cache.mu.RUnlock()
+
+	keys := []string{}
+
+	timeNow := time.Now()
+	for key, item := range n.cache.items {
+		if strings.HasPrefix(key, n.prefix) && !item.expiredAt(timeNow) {
+			keys = append(keys, strings.TrimPrefix(key, n.prefix))
+		}
+	}
+
+	return keys
+}
+
+// Len returns the number of elements stored in the namespace.
+// Expired items that weren't removed yet aren't counted.
+func (n *Namespace) Len() int {
+	n.cache.mu.RLock()
+	defer n.cache.mu.RUnlock()
+
+	count := 0
+
+	timeNow := time.Now()
+	for key, item := range n.cache.items {
+		if strings.HasPrefix(key, n.prefix) && !item.expiredAt(timeNow) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// DeleteAll deletes all values stored in the namespace, leaving
+// the rest of the cache untouched.
+func (n *Namespace) DeleteAll() {
+	n.cache.mu.Lock()
+	defer n.cache.unlock()
+
+	for key := range n.cache.items {
+		if strings.HasPrefix(key, n.prefix) {
+			n.cache.evictLocked(key, reasonDeleted)
+		}
+	}
+}
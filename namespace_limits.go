@@ -0,0 +1,99 @@
+package incache
+
+import "strings"
+
+// namespaceLimit limits the number of items in a namespace,
+// see Namespace.SetMaxEntries.
+type namespaceLimit struct {
+	maxEntries int
+	// Items of the namespace in the order of eviction.
+	queue *evictionQueue
+}
+
+// SetMaxEntries limits the number of items in the namespace. When the limit
+// is reached, storing a new key evicts an item of the same namespace in the
+// same order as when the whole cache is full, see WithMaxEntries. Items
+// above the new limit are evicted immediately. Zero removes the limit.
+//
+// The limit belongs to the cache rather than to the view, so it applies to
+// all keys with the prefix of the namespace, including the ones of nested
+// namespaces, no matter how they're stored.
+func (n *Namespace) SetMaxEntries(maxEntries int) {
+	c := n.cache
+
+	c.mu.Lock()
+	defer c.unlock()
+
+	if maxEntries <= 0 {
+		delete(c.namespaceLimits, n.prefix)
+		return
+	}
+
+	limit, ok := c.namespaceLimits[n.prefix]
+	if !ok {
+		limit = &namespaceLimit{queue: newEvictionQueue()}
+
+		for _, key := range c.evictionOrderLocked() {
+			if strings.HasPrefix(key, n.prefix) {
+				limit.queue.push(key, c.items[key].priority)
+			}
+		}
+
+		if c.namespaceLimits == nil {
+			c.namespaceLimits = make(map[string]*namespaceLimit)
+		}
+
+		c.namespaceLimits[n.prefix] = limit
+	}
+
+	limit.maxEntries = maxEntries
+	limit.shrinkLocked(c, maxEntries)
+
+	c.config.debugf("[namespace] %s max entries: %d", n.name, maxEntries)
+}
+
+// shrinkLocked evicts items of the namespace until at most n are left.
+// It must be called with the write lock held.
+func (l *namespaceLimit) shrinkLocked(c *Cache, n int) {
+	for len(l.queue.index) > n {
+		key, ok := l.queue.next()
+		if !ok {
+			return
+		}
+
+		c.evictLocked(key, reasonCapacity)
+	}
+}
+
+// makeNamespaceRoomLocked evicts items of the limited namespaces the new
+// key belongs to, so it can be stored without exceeding their limits.
+// It must be called with the write lock held.
+func (c *Cache) makeNamespaceRoomLocked(key string) {
+	for prefix, limit := range c.namespaceLimits {
+		if strings.HasPrefix(key, prefix) {
+			limit.shrinkLocked(c, limit.maxEntries-1)
+		}
+	}
+}
+
+// pushNamespaceLimitsLocked adds the key to the eviction queues of the
+// limited namespaces it belongs to.
+// It must be called with the write lock held.
+func (c *Cache) pushNamespaceLimitsLocked(key string, priority int) {
+	for prefix, limit := range c.namespaceLimits {
+		if strings.HasPrefix(key, prefix) {
+			limit.queue.push(key, priority)
+		}
+	}
+}
+
+// removeNamespaceLimitsLocked removes the key from the eviction queues of
+// the limited namespaces.
+// It must be called with the write lock held.
+func (c *Cache) removeNamespaceLimitsLocked(key string) {
+	for prefix, limit := range c.namespaceLimits {
+		if strings.HasPrefix(key, prefix) {
+			limit.queue.remove(key)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package incache
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// NamespaceMetrics holds the metrics of a namespace, see Namespace.Metrics.
+type NamespaceMetrics struct {
+	Insertions uint64
+	Hits       uint64
+	Misses     uint64
+	// Len is the number of items in the namespace. Expired items that
+	// weren't removed yet aren't counted.
+	Len int
+}
+
+// NamespaceMetrics returns the metrics of the namespaces created with
+// Cache.Namespace, by full name, which shows whether a few namespaces
+// dominate the traffic or the size of a shared cache. Operations are
+// counted from the moment a namespace was first created, no matter whether
+// the keys are accessed through the namespace or the cache. Nil is returned
+// when metrics aren't enabled.
+func (c *Cache) NamespaceMetrics() map[string]NamespaceMetrics {
+	if c.namespaces == nil {
+		return nil
+	}
+
+	tracked := c.namespaces.load()
+
+	namespaces := make(map[string]NamespaceMetrics, len(tracked))
+	lens := make([]int, len(tracked))
+
+	c.mu.RLock()
+
+	timeNow := time.Now()
+	for key, item := range c.items {
+		if item.expiredAt(timeNow) {
+			continue
+		}
+
+		for i, ns := range tracked {
+			if strings.HasPrefix(key, ns.prefix) {
+				lens[i]++
+			}
+		}
+	}
+
+	c.mu.RUnlock()
+
+	for i, ns := range tracked {
+		namespaces[ns.name] = ns.metrics(lens[i])
+	}
+
+	return namespaces
+}
+
+// Metrics returns the metrics of the namespace, see Cache.NamespaceMetrics.
+// Only the number of items is reported when metrics aren't enabled.
+func (n *Namespace) Metrics() NamespaceMetrics {
+	length := n.Len()
+
+	if n.cache.namespaces != nil {
+		for _, ns := range n.cache.namespaces.load() {
+			if ns.prefix == n.prefix {
+				return ns.metrics(length)
+			}
+		}
+	}
+
+	return NamespaceMetrics{Len: length}
+}
+
+// namespaceCounters tallies metrics of the namespaces by prefix.
+type namespaceCounters struct {
+	mu sync.Mutex
+	// []*trackedNamespace, which is replaced rather than modified when
+	// a namespace is added, so counting doesn't take the lock.
+	tracked atomic.Value
+}
+
+type trackedNamespace struct {
+	name     string
+	prefix   string
+	counters groupCounters
+}
+
+func newNamespaceCounters() *namespaceCounters {
+	n := &namespaceCounters{}
+	n.tracked.Store([]*trackedNamespace(nil))
+
+	return n
+}
+
+func (n *namespaceCounters) load() []*trackedNamespace {
+	return n.tracked.Load().([]*trackedNamespace)
+}
+
+// track starts counting operations on the keys of the namespace, unless
+// they're already counted.
+func (n *namespaceCounters) track(name, prefix string) {
+	for _, ns := range n.load() {
+		if ns.prefix == prefix {
+			return
+		}
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	tracked := n.load()
+	for _, ns := range tracked {
+		if ns.prefix == prefix {
+			return
+		}
+	}
+
+	updated := make([]*trackedNamespace, len(tracked), len(tracked)+1)
+	copy(updated, tracked)
+	updated = append(updated, &trackedNamespace{name: name, prefix: prefix})
+
+	n.tracked.Store(updated)
+}
+
+// each calls fn with the counters of every namespace the key belongs to.
+func (n *namespaceCounters) each(key string, fn func(counters *groupCounters)) {
+	for _, ns := range n.load() {
+		if strings.HasPrefix(key, ns.prefix) {
+			fn(&ns.counters)
+		}
+	}
+}
+
+func (n *namespaceCounters) reset() {
+	for _, ns := range n.load() {
+		ns.counters.insertions.reset()
+		ns.counters.hits.reset()
+		ns.counters.misses.reset()
+	}
+}
+
+func (ns *trackedNamespace) metrics(length int) NamespaceMetrics {
+	return NamespaceMetrics{
+		Insertions: ns.counters.insertions.load(),
+		Hits:       ns.counters.hits.load(),
+		Misses:     ns.counters.misses.load(),
+		Len:        length,
+	}
+}
@@ -0,0 +1,54 @@
+package incache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespaceMetrics(t *testing.T) {
+	cache := New(WithMetrics(), WithTTL(0))
+
+	users := cache.Namespace("users")
+	admins := users.Namespace("admins")
+	orders := cache.Namespace("orders")
+
+	users.Set("1", "user1")
+	admins.Set("1", "admin1")
+	orders.SetWithTTL("1", "order1", time.Millisecond)
+	cache.Set("users:2", "user2")
+	cache.Set("config", "value")
+
+	users.Get("1")
+	users.Get("3")
+	admins.Get("1")
+	cache.Get("orders:2")
+
+	time.Sleep(2 * time.Millisecond)
+
+	assert.Equal(t, map[string]NamespaceMetrics{
+		"users":        {Insertions: 3, Hits: 2, Misses: 1, Len: 3},
+		"users:admins": {Insertions: 1, Hits: 1, Len: 1},
+		"orders":       {Insertions: 1, Misses: 1},
+	}, cache.NamespaceMetrics())
+
+	assert.Equal(t, NamespaceMetrics{Insertions: 1, Hits: 1, Len: 1}, admins.Metrics())
+
+	// Views of the same namespace share its metrics.
+	assert.Equal(t, users.Metrics(), cache.Namespace("users").WithTTL(time.Minute).Metrics())
+
+	cache.ResetMetrics()
+	assert.Equal(t, NamespaceMetrics{Len: 3}, users.Metrics())
+}
+
+func TestNamespaceMetricsWithoutMetrics(t *testing.T) {
+	cache := New()
+
+	users := cache.Namespace("users")
+	users.Set("1", "user1")
+	users.Get("1")
+
+	assert.Nil(t, cache.NamespaceMetrics())
+	assert.Equal(t, NamespaceMetrics{Len: 1}, users.Metrics())
+}
@@ -0,0 +1,162 @@
+package incache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespace(t *testing.T) {
+	cache := New(WithTTL(0))
+	defer cache.Close()
+
+	users := cache.Namespace("users")
+	orders := cache.Namespace("orders")
+
+	users.Set("1", "user1")
+	users.Set("2", "user2")
+	orders.Set("1", "order1")
+
+	assert.Equal(t, "users", users.Name())
+	assert.Equal(t, "user1", users.Get("1"))
+	assert.Equal(t, "order1", orders.Get("1"))
+	assert.Equal(t, "user1", cache.Get("users:1"))
+	assert.True(t, users.Has("2"))
+	assert.False(t, orders.Has("2"))
+
+	assert.Equal(t, 2, users.Len())
+	assert.Equal(t, 1, orders.Len())
+	assert.ElementsMatch(t, []string{"1", "2"}, users.Keys())
+
+	assert.Equal(t, "user2", users.GetDelete("2"))
+	users.Delete("1")
+	assert.Zero(t, users.Len())
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestNamespaceDeleteAll(t *testing.T) {
+	cache := New(WithTTL(0))
+	defer cache.Close()
+
+	var (
+		mu      sync.Mutex
+		evicted []string
+	)
+	cache.OnEviction(func(key string, _ interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		evicted = append(evicted, key)
+	})
+
+	users := cache.Namespace("users")
+	users.Set("1", "user1")
+	users.Set("2", "user2")
+	cache.Set("usersettings", "value")
+
+	users.DeleteAll()
+	cache.Close()
+
+	assert.Zero(t, users.Len())
+	assert.True(t, cache.Has("usersettings"))
+	assert.ElementsMatch(t, []string{"users:1", "users:2"}, evicted)
+}
+
+func TestNamespaceTTL(t *testing.T) {
+	cache := New(WithTTL(time.Hour))
+	defer cache.Close()
+
+	sessions := cache.Namespace("sessions").WithTTL(time.Minute)
+	sessions.Set("1", "session1")
+
+	info, ok := cache.Inspect("sessions:1")
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, info.TTL)
+
+	cache.Namespace("users").Set("1", "user1")
+
+	info, ok = cache.Inspect("users:1")
+	assert.True(t, ok)
+	assert.Equal(t, time.Hour, info.TTL)
+}
+
+func TestNestedNamespace(t *testing.T) {
+	cache := New(WithTTL(0))
+	defer cache.Close()
+
+	tokens := cache.Namespace("users").WithTTL(time.Minute).Namespace("tokens")
+	tokens.Set("1", "token1")
+
+	assert.Equal(t, "users:tokens", tokens.Name())
+	assert.Equal(t, "token1", cache.Get("users:tokens:1"))
+	assert.Equal(t, []string{"tokens:1"}, cache.Namespace("users").Keys())
+
+	info, _ := cache.Inspect("users:tokens:1")
+	assert.Equal(t, time.Minute, info.TTL)
+}
+
+func TestNamespacePriority(t *testing.T) {
+	cache := New(WithMaxEntries(2))
+
+	tokens := cache.Namespace("tokens")
+	reference := cache.Namespace("reference").WithPriority(10)
+
+	reference.Set("1", "value1")
+	tokens.Set("1", "token1")
+	tokens.Set("2", "token2")
+
+	assert.True(t, reference.Has("1"))
+	assert.False(t, tokens.Has("1"))
+	assert.True(t, tokens.Has("2"))
+	assert.Equal(t, 10, reference.Namespace("nested").priority)
+}
+
+func TestNamespaceMaxEntries(t *testing.T) {
+	cache := New()
+
+	tokens := cache.Namespace("tokens")
+	tokens.Set("1", "token1")
+	tokens.Set("2", "token2")
+	tokens.Set("3", "token3")
+	cache.Set("other", "value")
+
+	tokens.SetMaxEntries(2)
+	assert.ElementsMatch(t, []string{"2", "3"}, tokens.Keys())
+
+	// The limit applies to keys stored directly in the cache as well.
+	cache.Set("tokens:4", "token4")
+	assert.ElementsMatch(t, []string{"3", "4"}, tokens.Keys())
+
+	// Overwriting an existing key doesn't evict anything.
+	tokens.Set("3", "updated")
+	assert.ElementsMatch(t, []string{"3", "4"}, tokens.Keys())
+
+	tokens.Set("5", "token5")
+	assert.ElementsMatch(t, []string{"3", "5"}, tokens.Keys())
+	assert.True(t, cache.Has("other"))
+
+	tokens.Delete("3")
+	tokens.Set("6", "token6")
+	assert.ElementsMatch(t, []string{"5", "6"}, tokens.Keys())
+
+	tokens.SetMaxEntries(0)
+	tokens.Set("7", "token7")
+	assert.Equal(t, 3, tokens.Len())
+	assert.Empty(t, cache.namespaceLimits)
+}
+
+func TestNamespaceMaxEntriesFlushAll(t *testing.T) {
+	cache := New()
+
+	tokens := cache.Namespace("tokens")
+	tokens.SetMaxEntries(1)
+	tokens.Set("1", "token1")
+
+	cache.FlushAll()
+	assert.Empty(t, cache.namespaceLimits["tokens:"].queue.index)
+
+	tokens.Set("2", "token2")
+	assert.Equal(t, 1, tokens.Len())
+}
@@ -0,0 +1,235 @@
+// Package peers lets a fleet of processes share the work of filling their
+// caches, similar to groupcache. Every key is owned by one peer of the pool.
+// On a miss, the value is requested from the owner over HTTP, so the origin
+// is called once per key for the whole fleet instead of once per process.
+// The loader is only called locally when the process owns the key or the
+// owner can't be reached.
+//
+//	pool := peers.NewPool("http://10.0.0.1:8080", peers.Options{})
+//	pool.Set("http://10.0.0.1:8080", "http://10.0.0.2:8080", "http://10.0.0.3:8080")
+//
+//	cache := incache.New(incache.WithLoader(pool.Loader(loadFromDatabase)))
+//	http.Handle(peers.DefaultPath, pool.Handler(cache))
+package peers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wittyjudge/incache"
+)
+
+// DefaultPath is the path the peers are served on by default.
+const DefaultPath = "/_incache/"
+
+// ttlHeader holds the remaining TTL of the returned value in milliseconds,
+// -1 means that the value never expires.
+const ttlHeader = "X-Incache-TTL"
+
+// Options configures a Pool.
+type Options struct {
+	// Path the peers are served on, DefaultPath is used if it's empty.
+	Path string
+	// Client sends requests to the peers, http.DefaultClient is used if
+	// it's nil. Set a timeout, so a slow peer doesn't block the misses.
+	Client *http.Client
+	// Codec encodes the values, incache.GobValueCodec is used if it's nil.
+	Codec incache.Codec
+}
+
+// Pool is a set of peers, addressed by their base URLs, e.g.
+// "http://10.0.0.1:8080". It's safe for concurrent use.
+type Pool struct {
+	self string
+	opts Options
+
+	mu    sync.RWMutex
+	peers []string
+}
+
+// NewPool returns a pool of peers for the process reachable at self.
+func NewPool(self string, opts Options) *Pool {
+	if opts.Path == "" {
+		opts.Path = DefaultPath
+	}
+
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+
+	if opts.Codec == nil {
+		opts.Codec = incache.GobValueCodec
+	}
+
+	return &Pool{self: strings.TrimSuffix(self, "/"), opts: opts}
+}
+
+// Set replaces the peers of the pool. The list should contain self, and
+// has to be the same on all peers, otherwise they disagree on owners of
+// the keys.
+func (p *Pool) Set(peers ...string) {
+	trimmed := make([]string, len(peers))
+	for i, peer := range peers {
+		trimmed[i] = strings.TrimSuffix(peer, "/")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.peers = trimmed
+}
+
+// Owner returns the peer owning the key. Keys are assigned with rendezvous
+// hashing, so changing the list of peers only moves the keys of the added or
+// removed peers. Without peers, self owns all keys.
+func (p *Pool) Owner(key string) string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	owner := p.self
+
+	var best uint64
+	for _, peer := range p.peers {
+		h := fnv.New64a()
+		h.Write([]byte(peer))
+		h.Write([]byte{0})
+		h.Write([]byte(key))
+
+		if score := h.Sum64(); score >= best {
+			owner, best = peer, score
+		}
+	}
+
+	return owner
+}
+
+type peerRequestKey struct{}
+
+// Loader returns a loader that requests the value from the owner of the key
+// and calls fallback when the process owns the key itself, or the owner
+// fails to respond. Use it with incache.WithLoader.
+func (p *Pool) Loader(fallback incache.LoaderFunc) incache.LoaderFunc {
+	return func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		// Requests from other peers are always served locally, so peers that
+		// disagree on the owner don't send a request around in circles.
+		if owner := p.Owner(key); owner != p.self && ctx.Value(peerRequestKey{}) == nil {
+			value, ttl, err := p.fetch(ctx, owner, key)
+			if err == nil {
+				return value, ttl, nil
+			}
+
+			if ctx.Err() != nil {
+				return nil, 0, ctx.Err()
+			}
+		}
+
+		return fallback(ctx, key)
+	}
+}
+
+func (p *Pool) fetch(ctx context.Context, owner, key string) (interface{}, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, owner+p.opts.Path+url.PathEscape(key), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp, err := p.opts.Client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, 0, nil
+	default:
+		return nil, 0, fmt.Errorf("peers: %s responded with %s", owner, resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	value, err := p.opts.Codec.Decode(data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var ttl time.Duration
+	if ms, err := strconv.ParseInt(resp.Header.Get(ttlHeader), 10, 64); err == nil {
+		if ms < 0 {
+			ttl = -1
+		} else if ms > 0 {
+			ttl = time.Duration(ms) * time.Millisecond
+		}
+	}
+
+	return value, ttl, nil
+}
+
+// Handler returns a handler serving the values of the cache to other peers
+// on the path of the pool. Missing values are loaded with the loader of the
+// cache, which must be the one returned by Loader.
+func (p *Pool) Handler(cache *incache.Cache) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		key, err := url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), p.opts.Path))
+		if err != nil || key == "" {
+			http.Error(w, "invalid key", http.StatusBadRequest)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), peerRequestKey{}, true)
+
+		value, err := cache.GetContext(ctx, key)
+		if errors.Is(err, incache.ErrNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		data, err := p.opts.Codec.Encode(value)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if info, ok := cache.Inspect(key); ok {
+			w.Header().Set(ttlHeader, strconv.FormatInt(remainingTTL(info), 10))
+		}
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(data)
+	})
+}
+
+// remainingTTL returns the remaining TTL of the entry in milliseconds.
+func remainingTTL(info incache.EntryInfo) int64 {
+	if info.TTL <= 0 || info.ExpiresAt.IsZero() {
+		return -1
+	}
+
+	if ms := time.Until(info.ExpiresAt).Milliseconds(); ms > 0 {
+		return ms
+	}
+
+	return 0
+}
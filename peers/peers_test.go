@@ -0,0 +1,196 @@
+package peers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wittyjudge/incache"
+)
+
+type node struct {
+	pool   *Pool
+	cache  *incache.Cache
+	server *httptest.Server
+	loads  int64
+}
+
+// newFleet starts n peers sharing an origin that returns "value of <key>"
+// for every key except "missing".
+func newFleet(t *testing.T, n int) []*node {
+	t.Helper()
+
+	nodes := make([]*node, n)
+	urls := make([]string, n)
+
+	for i := range nodes {
+		node := &node{}
+		mux := http.NewServeMux()
+		node.server = httptest.NewServer(mux)
+		t.Cleanup(node.server.Close)
+
+		node.pool = NewPool(node.server.URL, Options{})
+		node.cache = incache.New(incache.WithLoader(node.pool.Loader(func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+			atomic.AddInt64(&node.loads, 1)
+
+			if key == "missing" {
+				return nil, 0, nil
+			}
+
+			return "value of " + key, time.Minute, nil
+		})))
+		t.Cleanup(node.cache.Close)
+
+		mux.Handle(DefaultPath, node.pool.Handler(node.cache))
+
+		nodes[i] = node
+		urls[i] = node.server.URL
+	}
+
+	for _, node := range nodes {
+		node.pool.Set(urls...)
+	}
+
+	return nodes
+}
+
+func TestPoolFillsFromOwner(t *testing.T) {
+	nodes := newFleet(t, 3)
+
+	keys := make([]string, 20)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key %d/%d", i, i)
+	}
+
+	for _, node := range nodes {
+		for _, key := range keys {
+			value, err := node.cache.GetContext(context.Background(), key)
+			assert.NoError(t, err)
+			assert.Equal(t, "value of "+key, value)
+		}
+	}
+
+	// Every key is loaded from the origin once, by its owner.
+	var loads int64
+	for _, node := range nodes {
+		loads += atomic.LoadInt64(&node.loads)
+	}
+	assert.Equal(t, int64(len(keys)), loads)
+
+	// The fetched values keep the TTL set by the owner.
+	for _, node := range nodes {
+		info, ok := node.cache.Inspect(keys[0])
+		assert.True(t, ok)
+		assert.InDelta(t, time.Minute, time.Until(info.ExpiresAt), float64(5*time.Second))
+	}
+}
+
+func TestPoolNotFound(t *testing.T) {
+	nodes := newFleet(t, 2)
+
+	for _, node := range nodes {
+		_, err := node.cache.GetContext(context.Background(), "missing")
+		assert.ErrorIs(t, err, incache.ErrNotFound)
+	}
+}
+
+func TestPoolFallsBackWhenOwnerIsDown(t *testing.T) {
+	nodes := newFleet(t, 2)
+
+	var key string
+	for i := 0; ; i++ {
+		key = fmt.Sprint("key", i)
+		if nodes[0].pool.Owner(key) == nodes[1].server.URL {
+			break
+		}
+	}
+
+	nodes[1].server.Close()
+
+	value, err := nodes[0].cache.GetContext(context.Background(), key)
+	assert.NoError(t, err)
+	assert.Equal(t, "value of "+key, value)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&nodes[0].loads))
+}
+
+func TestPoolServesPeerRequestsLocally(t *testing.T) {
+	nodes := newFleet(t, 2)
+
+	// The second peer thinks that the first one owns all keys, while the
+	// first one only knows about itself.
+	nodes[0].pool.Set(nodes[0].server.URL)
+	nodes[1].pool.Set(nodes[0].server.URL)
+
+	value, err := nodes[1].cache.GetContext(context.Background(), "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, "value of key1", value)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&nodes[0].loads))
+	assert.Zero(t, atomic.LoadInt64(&nodes[1].loads))
+}
+
+func TestPoolOwner(t *testing.T) {
+	pool := NewPool("http://a", Options{})
+	assert.Equal(t, "http://a", pool.Owner("key1"))
+
+	pool.Set("http://a/", "http://b", "http://c")
+
+	owners := map[string]string{}
+	counts := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprint("key", i)
+		owners[key] = pool.Owner(key)
+		counts[owners[key]]++
+	}
+
+	assert.Len(t, counts, 3)
+	assert.Contains(t, counts, "http://a")
+
+	// Removing a peer only moves its own keys.
+	pool.Set("http://a", "http://b")
+	for key, owner := range owners {
+		if owner != "http://c" {
+			assert.Equal(t, owner, pool.Owner(key))
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	cache := incache.New(incache.WithTTL(0))
+	cache.Set("key1", "value1")
+
+	handler := NewPool("http://a", Options{}).Handler(cache)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, DefaultPath+"key1", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "-1", rec.Header().Get(ttlHeader))
+
+	value, err := incache.GobValueCodec.Decode(rec.Body.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, "value1", value)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, DefaultPath+"key2", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, DefaultPath+"key1", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestHandlerLoaderError(t *testing.T) {
+	cache := incache.New(incache.WithLoader(func(ctx context.Context, key string) (interface{}, time.Duration, error) {
+		return nil, 0, errors.New("origin is down")
+	}))
+
+	rec := httptest.NewRecorder()
+	NewPool("http://a", Options{}).Handler(cache).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, DefaultPath+"key1", nil))
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+}
@@ -0,0 +1,329 @@
+package peers
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/wittyjudge/incache"
+)
+
+// DefaultGossipPath is the path the replication messages are served on by
+// default.
+const DefaultGossipPath = "/_incache_gossip/"
+
+// DefaultMaxMessageSize is the default limit of the size of a received
+// replication message.
+const DefaultMaxMessageSize = 16 << 20
+
+// ReplicationOptions configures a Replicator.
+type ReplicationOptions struct {
+	// Prefixes of the replicated keys. All keys are replicated if it's empty.
+	Prefixes []string
+	// Number of random peers every message is sent to, 3 by default.
+	Fanout int
+	// Number of times a message is forwarded further, 3 by default.
+	// Fanout and hops have to be large enough to reach all the peers.
+	Hops int
+	// Path the messages are served on, DefaultGossipPath is used if it's
+	// empty.
+	Path string
+	// Maximum size of a received message in bytes, DefaultMaxMessageSize is
+	// used if it's zero or negative. Larger messages are rejected.
+	MaxMessageSize int64
+}
+
+// message is a replicated operation.
+type message struct {
+	// ID identifies the message, so it's applied once by every peer.
+	ID     string
+	Key    string
+	Delete bool
+	Value  []byte
+	// Remaining TTL in milliseconds, -1 means that the value never expires.
+	// Values that expired on the way aren't applied.
+	TTL  int64
+	Hops int
+}
+
+// Replicator gossips Set and Delete operations of keys with the configured
+// prefixes to the peers of a pool, so their caches eventually contain the
+// same values. It's experimental: operations can be lost when a peer is
+// unreachable or events of the cache are dropped, and concurrent writes of
+// the same key on different peers may leave them with different values.
+//
+// Expired and evicted items aren't replicated, every peer expires and evicts
+// its items on its own.
+type Replicator struct {
+	cache *incache.Cache
+	pool  *Pool
+	opts  ReplicationOptions
+
+	seq int64
+
+	mu sync.Mutex
+	// IDs of applied messages. Two generations are kept, so the set doesn't
+	// grow forever and recent messages are still recognized.
+	seen, prevSeen map[string]struct{}
+	// Versions of the items stored or deleted by remote operations, whose
+	// events mustn't be replicated again. An entry is removed by the first
+	// event of the key with the same or a newer version, so a dropped event
+	// doesn't make the replicator skip later local writes of the key.
+	suppressed map[string]uint64
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// maxSeen is the size of a generation of applied message IDs.
+const maxSeen = 10000
+
+// NewReplicator starts replicating the operations of the cache to the peers
+// of the pool. The peers receive the messages with Handler. Stop it with
+// Close before closing the cache.
+func NewReplicator(cache *incache.Cache, pool *Pool, opts ReplicationOptions) *Replicator {
+	if opts.Fanout <= 0 {
+		opts.Fanout = 3
+	}
+
+	if opts.Hops <= 0 {
+		opts.Hops = 3
+	}
+
+	if opts.Path == "" {
+		opts.Path = DefaultGossipPath
+	}
+
+	if opts.MaxMessageSize <= 0 {
+		opts.MaxMessageSize = DefaultMaxMessageSize
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r := &Replicator{
+		cache:      cache,
+		pool:       pool,
+		opts:       opts,
+		seen:       make(map[string]struct{}),
+		suppressed: make(map[string]uint64),
+		cancel:     cancel,
+		done:       make(chan struct{}),
+	}
+
+	go r.run(cache.WatchPrefix(ctx, ""))
+
+	return r
+}
+
+// Close stops the replication.
+func (r *Replicator) Close() {
+	r.cancel()
+	<-r.done
+}
+
+func (r *Replicator) run(events <-chan incache.Event) {
+	defer close(r.done)
+
+	for event := range events {
+		if !r.replicated(event.Key) || r.unsuppress(event) {
+			continue
+		}
+
+		switch {
+		case event.Type == incache.EventInsertion || event.Type == incache.EventUpdate:
+			data, err := r.pool.opts.Codec.Encode(event.Value)
+			if err != nil {
+				continue
+			}
+
+			ttl := int64(-1)
+			if info, ok := r.cache.Inspect(event.Key); ok {
+				ttl = remainingTTL(info)
+			}
+
+			r.send(r.newMessage(message{Key: event.Key, Value: data, TTL: ttl}))
+		case event.Type == incache.EventEviction && event.Reason == "deleted":
+			r.send(r.newMessage(message{Key: event.Key, Delete: true}))
+		}
+	}
+}
+
+func (r *Replicator) replicated(key string) bool {
+	if len(r.opts.Prefixes) == 0 {
+		return true
+	}
+
+	for _, prefix := range r.opts.Prefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *Replicator) newMessage(msg message) message {
+	msg.ID = r.pool.self + "#" + strconv.FormatInt(atomic.AddInt64(&r.seq, 1), 10)
+	msg.Hops = r.opts.Hops
+
+	r.markSeen(msg.ID)
+
+	return msg
+}
+
+// markSeen records the ID of the message and reports whether it was seen
+// before.
+func (r *Replicator) markSeen(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.seen[id]; ok {
+		return true
+	}
+
+	if _, ok := r.prevSeen[id]; ok {
+		return true
+	}
+
+	if len(r.seen) >= maxSeen {
+		r.prevSeen, r.seen = r.seen, make(map[string]struct{})
+	}
+
+	r.seen[id] = struct{}{}
+
+	return false
+}
+
+// unsuppress reports whether the event is the one of a remote operation,
+// which has to be skipped.
+func (r *Replicator) unsuppress(event incache.Event) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	version, ok := r.suppressed[event.Key]
+	if !ok || event.Version < version {
+		return false
+	}
+
+	delete(r.suppressed, event.Key)
+
+	return event.Version == version
+}
+
+// send sends the message to random peers.
+func (r *Replicator) send(msg message) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(msg); err != nil {
+		return
+	}
+
+	for _, peer := range r.targets() {
+		req, err := http.NewRequest(http.MethodPost, peer+r.opts.Path, bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			continue
+		}
+
+		resp, err := r.pool.opts.Client.Do(req)
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+	}
+}
+
+// targets returns up to Fanout random peers other than self.
+func (r *Replicator) targets() []string {
+	r.pool.mu.RLock()
+	peers := make([]string, 0, len(r.pool.peers))
+	for _, peer := range r.pool.peers {
+		if peer != r.pool.self {
+			peers = append(peers, peer)
+		}
+	}
+	r.pool.mu.RUnlock()
+
+	rand.Shuffle(len(peers), func(i, j int) { peers[i], peers[j] = peers[j], peers[i] })
+
+	if len(peers) > r.opts.Fanout {
+		peers = peers[:r.opts.Fanout]
+	}
+
+	return peers
+}
+
+// Handler returns a handler receiving the messages of other peers on the
+// path of the replicator. Messages aren't authenticated, and every one of
+// them is applied to the cache, so the handler must only be reachable by
+// the peers, e.g. on a private network or behind a middleware that checks
+// their credentials.
+func (r *Replicator) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body := http.MaxBytesReader(w, req.Body, r.opts.MaxMessageSize)
+
+		var msg message
+		if err := gob.NewDecoder(body).Decode(&msg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := r.apply(msg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// apply applies the operation to the cache and forwards the message.
+func (r *Replicator) apply(msg message) error {
+	if !r.replicated(msg.Key) || r.markSeen(msg.ID) {
+		return nil
+	}
+
+	if msg.Delete {
+		// The lock is held until the version is recorded, so the event of
+		// the operation isn't handled by run before.
+		r.mu.Lock()
+		if info, ok := r.cache.Inspect(msg.Key); ok {
+			r.suppressed[msg.Key] = info.Version
+			r.cache.Delete(msg.Key)
+		}
+		r.mu.Unlock()
+	} else if msg.TTL != 0 {
+		value, err := r.pool.opts.Codec.Decode(msg.Value)
+		if err != nil {
+			return err
+		}
+
+		ttl := time.Duration(msg.TTL) * time.Millisecond
+		if msg.TTL < 0 {
+			ttl = 0
+		}
+
+		r.mu.Lock()
+		if version := r.cache.SetVersioned(msg.Key, value, ttl); version != 0 {
+			r.suppressed[msg.Key] = version
+		}
+		r.mu.Unlock()
+	}
+
+	if msg.Hops > 1 {
+		msg.Hops--
+		go r.send(msg)
+	}
+
+	return nil
+}
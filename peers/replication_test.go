@@ -0,0 +1,163 @@
+package peers
+
+import (
+	"bytes"
+	"encoding/gob"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wittyjudge/incache"
+)
+
+type replica struct {
+	cache      *incache.Cache
+	replicator *Replicator
+	server     *httptest.Server
+}
+
+func newReplicas(t *testing.T, n int, opts ReplicationOptions) []*replica {
+	t.Helper()
+
+	replicas := make([]*replica, n)
+	pools := make([]*Pool, n)
+	urls := make([]string, n)
+
+	for i := range replicas {
+		mux := http.NewServeMux()
+		server := httptest.NewServer(mux)
+		t.Cleanup(server.Close)
+
+		cache := incache.New(incache.WithTTL(0))
+		t.Cleanup(cache.Close)
+
+		pools[i] = NewPool(server.URL, Options{})
+		replicator := NewReplicator(cache, pools[i], opts)
+		t.Cleanup(replicator.Close)
+
+		mux.Handle(DefaultGossipPath, replicator.Handler())
+
+		replicas[i] = &replica{cache: cache, replicator: replicator, server: server}
+		urls[i] = server.URL
+	}
+
+	for _, pool := range pools {
+		pool.Set(urls...)
+	}
+
+	return replicas
+}
+
+func TestReplicator(t *testing.T) {
+	replicas := newReplicas(t, 5, ReplicationOptions{Prefixes: []string{"session:"}, Fanout: 4, Hops: 2})
+
+	replicas[0].cache.SetWithTTL("session:1", "alice", time.Minute)
+	replicas[0].cache.Set("local:1", "value1")
+
+	for _, r := range replicas[1:] {
+		assert.Eventually(t, func() bool {
+			return r.cache.Get("session:1") == "alice"
+		}, time.Second, 5*time.Millisecond)
+
+		info, _ := r.cache.Inspect("session:1")
+		assert.InDelta(t, time.Minute, time.Until(info.ExpiresAt), float64(5*time.Second))
+	}
+
+	replicas[2].cache.Delete("session:1")
+
+	for _, r := range replicas {
+		assert.Eventually(t, func() bool {
+			return r.cache.Get("session:1") == nil
+		}, time.Second, 5*time.Millisecond)
+	}
+
+	// Keys without the replicated prefixes stay local.
+	for _, r := range replicas[1:] {
+		assert.Nil(t, r.cache.Get("local:1"))
+	}
+}
+
+func TestReplicatorDoesNotEchoOperations(t *testing.T) {
+	replicas := newReplicas(t, 2, ReplicationOptions{})
+
+	events := replicas[0].cache.Events()
+
+	replicas[0].cache.Set("key1", "value1")
+
+	assert.Eventually(t, func() bool {
+		return replicas[1].cache.Get("key1") == "value1"
+	}, time.Second, 5*time.Millisecond)
+
+	// Give an echo the time to arrive.
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Len(t, events, 1)
+}
+
+func TestReplicatorDroppedEventDoesNotSuppressWrites(t *testing.T) {
+	replicas := newReplicas(t, 2, ReplicationOptions{Prefixes: []string{"session:"}})
+	r := replicas[1].replicator
+
+	// A remote operation whose event was dropped by the watch.
+	version := replicas[1].cache.SetVersioned("local:1", "value1", 0)
+	r.mu.Lock()
+	r.suppressed["session:1"] = version
+	r.mu.Unlock()
+
+	replicas[1].cache.Set("session:1", "bob")
+
+	assert.Eventually(t, func() bool {
+		return replicas[0].cache.Get("session:1") == "bob"
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestReplicatorIgnoresSeenMessages(t *testing.T) {
+	replicas := newReplicas(t, 1, ReplicationOptions{})
+	r := replicas[0].replicator
+
+	data, err := incache.GobValueCodec.Encode("value1")
+	assert.NoError(t, err)
+
+	assert.NoError(t, r.apply(message{ID: "a#1", Key: "key1", Value: data, TTL: -1}))
+	replicas[0].cache.Set("key1", "value2")
+	assert.NoError(t, r.apply(message{ID: "a#1", Key: "key1", Value: data, TTL: -1}))
+
+	assert.Equal(t, "value2", replicas[0].cache.Get("key1"))
+
+	// Values that expired on the way aren't applied.
+	assert.NoError(t, r.apply(message{ID: "a#2", Key: "key2", Value: data, TTL: 0}))
+	assert.Nil(t, replicas[0].cache.Get("key2"))
+}
+
+func TestReplicatorHandler(t *testing.T) {
+	replicas := newReplicas(t, 1, ReplicationOptions{})
+	handler := replicas[0].replicator.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, DefaultGossipPath, nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, DefaultGossipPath, nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestReplicatorHandlerMaxMessageSize(t *testing.T) {
+	replicas := newReplicas(t, 1, ReplicationOptions{MaxMessageSize: 64})
+	handler := replicas[0].replicator.Handler()
+
+	data, err := incache.GobValueCodec.Encode(strings.Repeat("a", 100))
+	assert.NoError(t, err)
+
+	var body bytes.Buffer
+	assert.NoError(t, gob.NewEncoder(&body).Encode(message{ID: "a#1", Key: "key1", Value: data, TTL: -1}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, DefaultGossipPath, &body))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Nil(t, replicas[0].cache.Get("key1"))
+}
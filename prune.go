@@ -0,0 +1,94 @@
+package incache
+
+import (
+	"math"
+	"sort"
+)
+
+// EvictN removes up to n items in the order they would be evicted when
+// the cache is full (see WithMaxEntries): items with lower priority first,
+// and among items with the same priority, the ones stored earlier first.
+// It returns the number of removed items.
+//
+// It allows to respond to memory pressure on demand. Eviction handlers
+// are called for the removed items.
+func (c *Cache) EvictN(n int) int {
+	c.mu.Lock()
+	defer c.unlock()
+
+	return c.evictNLocked(n)
+}
+
+// Prune removes the fraction of items, e.g. 0.25 removes a quarter of
+// them, in the same order as EvictN. It returns the number of removed items.
+func (c *Cache) Prune(fraction float64) int {
+	if fraction <= 0 {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.unlock()
+
+	n := int(math.Ceil(float64(len(c.items)) * fraction))
+
+	return c.evictNLocked(n)
+}
+
+// evictNLocked must be called with the write lock held.
+func (c *Cache) evictNLocked(n int) int {
+	if n <= 0 {
+		return 0
+	}
+
+	if c.evictionQueue == nil {
+		return c.evictOrderedLocked(n)
+	}
+
+	evicted := 0
+	for ; evicted < n; evicted++ {
+		key, ok := c.evictionQueue.next()
+		if !ok {
+			break
+		}
+
+		c.evictLocked(key, reasonPruned)
+	}
+
+	return evicted
+}
+
+// evictOrderedLocked evicts items in the order of the eviction queue when
+// the cache isn't limited and there is no queue to take it from.
+// It must be called with the write lock held.
+func (c *Cache) evictOrderedLocked(n int) int {
+	keys := c.evictionOrderLocked()
+	if n > len(keys) {
+		n = len(keys)
+	}
+
+	for _, key := range keys[:n] {
+		c.evictLocked(key, reasonPruned)
+	}
+
+	return n
+}
+
+// evictionOrderLocked returns all keys in the order of the eviction queue.
+// It must be called with at least the read lock held.
+func (c *Cache) evictionOrderLocked() []string {
+	keys := make([]string, 0, len(c.items))
+	for key := range c.items {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := c.items[keys[i]], c.items[keys[j]]
+		if a.priority != b.priority {
+			return a.priority < b.priority
+		}
+
+		return a.version < b.version
+	})
+
+	return keys
+}
@@ -0,0 +1,64 @@
+package incache
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvictN(t *testing.T) {
+	cache := New(WithTTL(0), WithCleanupInterval(0))
+
+	cache.SetWithPriority("important", 0, 1)
+	for i := 0; i < 4; i++ {
+		cache.Set(fmt.Sprint("key", i), i)
+	}
+
+	assert.Equal(t, 2, cache.EvictN(2))
+	assert.ElementsMatch(t, []string{"important", "key2", "key3"}, cache.Keys())
+
+	assert.Equal(t, 3, cache.EvictN(10))
+	assert.Zero(t, cache.Len())
+	assert.Zero(t, cache.EvictN(1))
+}
+
+func TestEvictNWithMaxEntries(t *testing.T) {
+	cache := New(WithTTL(0), WithCleanupInterval(0), WithMaxEntries(10), WithMetrics())
+
+	for i := 0; i < 4; i++ {
+		cache.Set(fmt.Sprint("key", i), i)
+	}
+
+	cache.Set("key0", 0)
+
+	assert.Equal(t, 2, cache.EvictN(2))
+	assert.ElementsMatch(t, []string{"key0", "key3"}, cache.Keys())
+	assert.EqualValues(t, 2, cache.Metrics().Evictions())
+}
+
+func TestPrune(t *testing.T) {
+	cache := New(WithTTL(0), WithCleanupInterval(0))
+
+	events := cache.Events()
+
+	for i := 0; i < 10; i++ {
+		cache.Set(fmt.Sprint("key", i), i)
+	}
+
+	assert.Equal(t, 3, cache.Prune(0.25))
+	assert.Equal(t, 7, cache.Len())
+	assert.Zero(t, cache.Prune(0))
+
+	cache.Close()
+
+	var pruned []string
+	for event := range events {
+		if event.Type == EventEviction {
+			assert.Equal(t, "pruned", event.Reason)
+			pruned = append(pruned, event.Key)
+		}
+	}
+
+	assert.Equal(t, []string{"key0", "key1", "key2"}, pruned)
+}
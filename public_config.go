@@ -0,0 +1,277 @@
+package incache
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Settings is a plain representation of the options that can be unmarshaled
+// from a configuration file or environment variables and passed to
+// NewFromSettings. Use DefaultSettings as the base, so omitted fields keep
+// their default values. See the corresponding With* options for details.
+//
+// Options that take functions or interfaces, e.g. WithLoader or WithHook,
+// can be passed to NewFromSettings separately.
+type Settings struct {
+	TTL               Duration `json:"ttl" yaml:"ttl"`
+	TTLJitter         float64  `json:"ttl_jitter" yaml:"ttl_jitter"`
+	CleanupInterval   Duration `json:"cleanup_interval" yaml:"cleanup_interval"`
+	CleanupSampleSize int      `json:"cleanup_sample_size" yaml:"cleanup_sample_size"`
+	MaxEntries        int      `json:"max_entries" yaml:"max_entries"`
+
+	EnableMetrics         bool `json:"enable_metrics" yaml:"enable_metrics"`
+	EnableDetailedMetrics bool `json:"enable_detailed_metrics" yaml:"enable_detailed_metrics"`
+	AccessTracking        bool `json:"access_tracking" yaml:"access_tracking"`
+	EnableDebug           bool `json:"enable_debug" yaml:"enable_debug"`
+
+	SnapshotPath     string   `json:"snapshot_path" yaml:"snapshot_path"`
+	SnapshotInterval Duration `json:"snapshot_interval" yaml:"snapshot_interval"`
+	// Name of a registered codec, see RegisterSnapshotCodec.
+	SnapshotCodec string `json:"snapshot_codec" yaml:"snapshot_codec"`
+
+	// See WithAppendOnlyLog.
+	AppendOnlyLogPath            string   `json:"append_only_log_path" yaml:"append_only_log_path"`
+	AppendOnlyLogCompactInterval Duration `json:"append_only_log_compact_interval" yaml:"append_only_log_compact_interval"`
+
+	LoaderErrorTTL Duration `json:"loader_error_ttl" yaml:"loader_error_ttl"`
+	// Maximum TTL of cached loader errors, see WithErrorCaching.
+	LoaderErrorMaxTTL Duration `json:"loader_error_max_ttl" yaml:"loader_error_max_ttl"`
+	LoadTimeout       Duration `json:"load_timeout" yaml:"load_timeout"`
+	// See WithLoaderCircuitBreaker.
+	CircuitBreakerFailures int      `json:"circuit_breaker_failures" yaml:"circuit_breaker_failures"`
+	CircuitBreakerCooldown Duration `json:"circuit_breaker_cooldown" yaml:"circuit_breaker_cooldown"`
+
+	FlushEvents         bool                `json:"flush_events" yaml:"flush_events"`
+	EventWorkers        int                 `json:"event_workers" yaml:"event_workers"`
+	EventQueueSize      int                 `json:"event_queue_size" yaml:"event_queue_size"`
+	EventOverflowPolicy EventOverflowPolicy `json:"event_overflow_policy" yaml:"event_overflow_policy"`
+	EventsBufferSize    int                 `json:"events_buffer_size" yaml:"events_buffer_size"`
+
+	CompressionThreshold int    `json:"compression_threshold" yaml:"compression_threshold"`
+	ReadOptimizedStorage bool   `json:"read_optimized_storage" yaml:"read_optimized_storage"`
+	MaxKeyLength         int    `json:"max_key_length" yaml:"max_key_length"`
+	MaxValueSize         uint64 `json:"max_value_size" yaml:"max_value_size"`
+
+	// See WithCoarseClock.
+	CoarseClockResolution Duration `json:"coarse_clock_resolution" yaml:"coarse_clock_resolution"`
+
+	// Unlike WithMemoryWatermark, zero high watermark disables shedding.
+	MemoryHighWatermark uint64 `json:"memory_high_watermark" yaml:"memory_high_watermark"`
+	MemoryLowWatermark  uint64 `json:"memory_low_watermark" yaml:"memory_low_watermark"`
+}
+
+// DefaultSettings returns the settings New uses when no options are passed.
+func DefaultSettings() Settings {
+	defaults := defaultConfig()
+
+	return Settings{
+		TTL:              Duration(defaults.ttl),
+		CleanupInterval:  Duration(defaults.cleanupInterval),
+		SnapshotCodec:    defaults.snapshotCodec.Name(),
+		EventsBufferSize: defaults.eventsBufferSize,
+	}
+}
+
+// NewFromSettings creates new instance of the cache configured by settings
+// and the options passed after it. The result is validated the same way as
+// by NewWithError.
+func NewFromSettings(settings Settings, conf ...configFunc) (*Cache, error) {
+	config := defaultConfig()
+
+	if err := settings.apply(&config); err != nil {
+		return nil, err
+	}
+
+	for _, fn := range conf {
+		fn(&config)
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	return newCache(config), nil
+}
+
+func (c Settings) apply(config *Config) error {
+	config.ttl = time.Duration(c.TTL)
+	config.ttlJitter = c.TTLJitter
+	config.cleanupInterval = time.Duration(c.CleanupInterval)
+	config.cleanupSampleSize = c.CleanupSampleSize
+	config.maxEntries = c.MaxEntries
+
+	config.enableMetrics = c.EnableMetrics || c.EnableDetailedMetrics
+	config.enableDetailedMetrics = c.EnableDetailedMetrics
+	config.accessTracking = c.AccessTracking
+	config.enableDebug = c.EnableDebug
+
+	config.snapshotPath = c.SnapshotPath
+	config.snapshotInterval = time.Duration(c.SnapshotInterval)
+	config.appendLogPath = c.AppendOnlyLogPath
+	config.appendLogCompactInterval = time.Duration(c.AppendOnlyLogCompactInterval)
+
+	if c.SnapshotCodec != "" {
+		codec, ok := SnapshotCodecByName(c.SnapshotCodec)
+		if !ok {
+			return invalidConfig("unknown snapshot codec %q", c.SnapshotCodec)
+		}
+
+		config.snapshotCodec = codec
+	}
+
+	config.loaderErrorTTL = time.Duration(c.LoaderErrorTTL)
+	config.loaderErrorMaxTTL = time.Duration(c.LoaderErrorMaxTTL)
+	config.loadTimeout = time.Duration(c.LoadTimeout)
+	config.circuitBreakerThreshold = c.CircuitBreakerFailures
+	config.circuitBreakerCooldown = time.Duration(c.CircuitBreakerCooldown)
+
+	config.flushEvents = c.FlushEvents
+	config.eventWorkers = c.EventWorkers
+	config.eventQueueSize = c.EventQueueSize
+	config.eventOverflowPolicy = c.EventOverflowPolicy
+	config.eventsBufferSize = c.EventsBufferSize
+
+	if c.CompressionThreshold > 0 {
+		WithCompression(c.CompressionThreshold, nil)(config)
+	}
+
+	config.readOptimized = c.ReadOptimizedStorage
+	config.maxKeyLength = c.MaxKeyLength
+	config.maxValueSize = c.MaxValueSize
+	config.coarseClockResolution = time.Duration(c.CoarseClockResolution)
+
+	if c.MemoryHighWatermark > 0 {
+		WithMemoryWatermark(c.MemoryHighWatermark, c.MemoryLowWatermark)(config)
+	}
+
+	return nil
+}
+
+// SettingsFromEnv returns the default settings overridden by the environment
+// variables with the prefix, see Settings.LoadEnv.
+func SettingsFromEnv(prefix string) (Settings, error) {
+	settings := DefaultSettings()
+	err := settings.LoadEnv(prefix)
+
+	return settings, err
+}
+
+// LoadEnv overrides the settings by the environment variables named after
+// the JSON names of the fields in upper case, joined to the prefix with '_',
+// e.g. APP_CACHE_TTL or APP_CACHE_MAX_ENTRIES for the prefix "APP_CACHE".
+// Unset variables are ignored. Durations are parsed by time.ParseDuration,
+// booleans by strconv.ParseBool and EventOverflowPolicy from its text form.
+func (c *Settings) LoadEnv(prefix string) error {
+	if prefix != "" && !strings.HasSuffix(prefix, "_") {
+		prefix += "_"
+	}
+
+	settings := reflect.ValueOf(c).Elem()
+	for i := 0; i < settings.NumField(); i++ {
+		name := prefix + strings.ToUpper(settings.Type().Field(i).Tag.Get("json"))
+
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+
+		if err := setFromText(settings.Field(i), value); err != nil {
+			return invalidConfig("%s: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+func setFromText(field reflect.Value, text string) error {
+	if unmarshaler, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return unmarshaler.UnmarshalText([]byte(text))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(text)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.ParseInt(text, 10, 0)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint64:
+		n, err := strconv.ParseUint(text, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+
+	return nil
+}
+
+// Duration is a time.Duration that is unmarshaled from the text form
+// accepted by time.ParseDuration, e.g. "1m30s", so durations in
+// configuration files and environment variables don't have to be written in
+// nanoseconds. JSON numbers are still decoded as nanoseconds.
+type Duration time.Duration
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(text []byte) error {
+	duration, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(duration)
+
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+
+		return d.UnmarshalText([]byte(text))
+	}
+
+	var nanoseconds int64
+	if err := json.Unmarshal(data, &nanoseconds); err != nil {
+		return fmt.Errorf("incache: invalid duration %s", data)
+	}
+
+	*d = Duration(nanoseconds)
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package incache
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFromSettings(t *testing.T) {
+	cfg := DefaultSettings()
+	require.NoError(t, json.Unmarshal([]byte(`{"ttl": "1m", "max_entries": 2, "enable_metrics": true}`), &cfg))
+
+	cache, err := NewFromSettings(cfg, WithMaxKeyLength(4))
+	require.NoError(t, err)
+	defer cache.Close()
+
+	assert.Equal(t, time.Minute, cache.config.ttl)
+	assert.Equal(t, 5*time.Minute, cache.config.cleanupInterval)
+	assert.Equal(t, 2, cache.config.maxEntries)
+	assert.True(t, cache.config.enableMetrics)
+	assert.Equal(t, GobCodec, cache.config.snapshotCodec)
+
+	cache.Set("key1", "value1")
+	cache.Set("key10", "value10")
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestDefaultSettings(t *testing.T) {
+	cache, err := NewFromSettings(DefaultSettings())
+	require.NoError(t, err)
+	defer cache.Close()
+
+	defaults := New()
+	defer defaults.Close()
+
+	assert.Equal(t, defaults.config.ttl, cache.config.ttl)
+	assert.Equal(t, defaults.config.cleanupInterval, cache.config.cleanupInterval)
+	assert.Equal(t, defaults.config.eventsBufferSize, cache.config.eventsBufferSize)
+}
+
+func TestNewFromSettingsInvalid(t *testing.T) {
+	cfg := DefaultSettings()
+	cfg.MaxEntries = -1
+
+	_, err := NewFromSettings(cfg)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+
+	cfg = DefaultSettings()
+	cfg.SnapshotCodec = "unknown"
+
+	_, err = NewFromSettings(cfg)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestSettingsJSON(t *testing.T) {
+	cfg := DefaultSettings()
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"cleanup_interval": 30000000000,
+		"load_timeout": "1.5s",
+		"event_overflow_policy": "drop"
+	}`), &cfg))
+
+	assert.Equal(t, Duration(30*time.Second), cfg.CleanupInterval)
+	assert.Equal(t, Duration(1500*time.Millisecond), cfg.LoadTimeout)
+	assert.Equal(t, EventOverflowDrop, cfg.EventOverflowPolicy)
+
+	data, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"ttl":"5m0s"`)
+	assert.Contains(t, string(data), `"event_overflow_policy":"drop"`)
+
+	var decoded Settings
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, cfg, decoded)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"ttl": "1 minute"}`), &cfg))
+	assert.Error(t, json.Unmarshal([]byte(`{"event_overflow_policy": "wait"}`), &cfg))
+}
+
+func TestSettingsFromEnv(t *testing.T) {
+	t.Setenv("APP_CACHE_TTL", "90s")
+	t.Setenv("APP_CACHE_MAX_ENTRIES", "100")
+	t.Setenv("APP_CACHE_ENABLE_METRICS", "true")
+	t.Setenv("APP_CACHE_TTL_JITTER", "0.1")
+	t.Setenv("APP_CACHE_MAX_VALUE_SIZE", "1024")
+	snapshotPath := filepath.Join(t.TempDir(), "cache")
+	t.Setenv("APP_CACHE_SNAPSHOT_PATH", snapshotPath)
+	t.Setenv("APP_CACHE_SNAPSHOT_INTERVAL", "1h")
+	t.Setenv("APP_CACHE_EVENT_OVERFLOW_POLICY", "log")
+	t.Setenv("MAX_KEY_LENGTH", "8")
+
+	cfg, err := SettingsFromEnv("APP_CACHE")
+	require.NoError(t, err)
+
+	assert.Equal(t, Duration(90*time.Second), cfg.TTL)
+	assert.Equal(t, Duration(5*time.Minute), cfg.CleanupInterval)
+	assert.Equal(t, 100, cfg.MaxEntries)
+	assert.True(t, cfg.EnableMetrics)
+	assert.Equal(t, 0.1, cfg.TTLJitter)
+	assert.Equal(t, uint64(1024), cfg.MaxValueSize)
+	assert.Equal(t, snapshotPath, cfg.SnapshotPath)
+	assert.Equal(t, Duration(time.Hour), cfg.SnapshotInterval)
+	assert.Equal(t, EventOverflowLog, cfg.EventOverflowPolicy)
+	assert.Zero(t, cfg.MaxKeyLength)
+
+	cache, err := NewFromSettings(cfg)
+	require.NoError(t, err)
+	defer cache.Close()
+
+	assert.Equal(t, 90*time.Second, cache.config.ttl)
+	assert.Equal(t, 100, cache.config.maxEntries)
+
+	t.Setenv("APP_CACHE_MAX_ENTRIES", "many")
+
+	_, err = SettingsFromEnv("APP_CACHE_")
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "APP_CACHE_MAX_ENTRIES")
+}
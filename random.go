@@ -0,0 +1,73 @@
+package incache
+
+import (
+	"math/rand"
+	"time"
+)
+
+// RandomKey returns a random key of an item that hasn't expired, e.g. to
+// sample the cache for diagnostics. It returns false when the cache is
+// empty. Picking the key takes time proportional to the number of items.
+func (c *Cache) RandomKey() (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.randomKeyLocked()
+}
+
+// PopRandom removes a random item that hasn't expired and returns its key
+// and value, for work-queue-like usage where any item will do. It returns
+// false when the cache is empty. Removed items are reported as deleted.
+func (c *Cache) PopRandom() (key string, value interface{}, ok bool) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	key, ok = c.randomKeyLocked()
+	if !ok {
+		return "", nil, false
+	}
+
+	value = c.copyValue(c.value(c.items[key].Value))
+	c.evictLocked(key, reasonDeleted)
+
+	return key, value, true
+}
+
+// randomKeyLocked picks the first live key after a random position in the
+// map iteration order, wrapping around to the beginning. The iteration
+// order alone isn't uniform enough, since it only randomizes the starting
+// bucket.
+// It must be called with at least the read lock held.
+func (c *Cache) randomKeyLocked() (string, bool) {
+	if len(c.items) == 0 {
+		return "", false
+	}
+
+	now := time.Now()
+	skip := rand.Intn(len(c.items))
+
+	var (
+		first string
+		found bool
+		i     int
+	)
+
+	for key, item := range c.items {
+		if item.expiredAt(now) {
+			i++
+			continue
+		}
+
+		if i >= skip {
+			return key, true
+		}
+
+		if !found {
+			first, found = key, true
+		}
+
+		i++
+	}
+
+	return first, found
+}
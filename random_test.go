@@ -0,0 +1,65 @@
+package incache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandomKey(t *testing.T) {
+	cache := New(WithCleanupInterval(0))
+
+	_, ok := cache.RandomKey()
+	assert.False(t, ok)
+
+	for i := 0; i < 10; i++ {
+		cache.Set("key"+strconv.Itoa(i), i)
+	}
+
+	cache.SetWithTTL("expired", "value", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		key, ok := cache.RandomKey()
+		assert.True(t, ok)
+		assert.NotEqual(t, "expired", key)
+
+		seen[key] = true
+	}
+
+	assert.Len(t, seen, 10)
+}
+
+func TestRandomKeyOnlyExpired(t *testing.T) {
+	cache := New(WithCleanupInterval(0))
+
+	cache.SetWithTTL("expired", "value", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	_, ok := cache.RandomKey()
+	assert.False(t, ok)
+}
+
+func TestPopRandom(t *testing.T) {
+	cache := New()
+
+	cache.Set("key1", 1)
+	cache.Set("key2", 2)
+
+	popped := make(map[string]interface{})
+	for i := 0; i < 2; i++ {
+		key, value, ok := cache.PopRandom()
+		assert.True(t, ok)
+
+		popped[key] = value
+	}
+
+	assert.Equal(t, map[string]interface{}{"key1": 1, "key2": 2}, popped)
+	assert.Equal(t, 0, cache.Len())
+
+	_, _, ok := cache.PopRandom()
+	assert.False(t, ok)
+}
@@ -0,0 +1,67 @@
+package incache
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// readIndex mirrors items in a sync.Map, see WithReadOptimizedStorage.
+// The map is replaced atomically by FlushAll, so it doesn't have to be
+// cleared item by item while readers keep using it.
+type readIndex struct {
+	// Holds *sync.Map.
+	m atomic.Value
+}
+
+func newReadIndex() *readIndex {
+	index := &readIndex{}
+	index.reset()
+
+	return index
+}
+
+func (r *readIndex) current() *sync.Map {
+	return r.m.Load().(*sync.Map)
+}
+
+func (r *readIndex) Load(key string) (interface{}, bool) {
+	return r.current().Load(key)
+}
+
+func (r *readIndex) Store(key string, item Item) {
+	r.current().Store(key, item)
+}
+
+func (r *readIndex) Delete(key string) {
+	r.current().Delete(key)
+}
+
+// reset replaces the map with an empty one.
+func (r *readIndex) reset() {
+	r.m.Store(&sync.Map{})
+}
+
+// lookupLockFree reads the item from the read index, see
+// WithReadOptimizedStorage.
+func (c *Cache) lookupLockFree(key string) interface{} {
+	v, _ := c.readIndex.Load(key)
+	item, _ := v.(Item)
+
+	return c.readItem(key, item)
+}
+
+// mirrorLocked stores the item in the read index, if it's enabled.
+// It must be called with the write lock held.
+func (c *Cache) mirrorLocked(key string, item Item) {
+	if c.readIndex != nil {
+		c.readIndex.Store(key, item)
+	}
+}
+
+// unmirrorLocked deletes the item from the read index, if it's enabled.
+// It must be called with the write lock held.
+func (c *Cache) unmirrorLocked(key string) {
+	if c.readIndex != nil {
+		c.readIndex.Delete(key)
+	}
+}
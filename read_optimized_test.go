@@ -0,0 +1,77 @@
+package incache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadOptimizedStorage(t *testing.T) {
+	cache := New(WithReadOptimizedStorage(), WithTTL(0), WithCleanupInterval(0))
+
+	assert.Nil(t, cache.Get("key1"))
+
+	cache.Set("key1", "value1")
+	assert.Equal(t, "value1", cache.Get("key1"))
+
+	cache.Set("key1", "value2")
+	assert.Equal(t, "value2", cache.Get("key1"))
+
+	cache.Delete("key1")
+	assert.Nil(t, cache.Get("key1"))
+
+	cache.Set("key2", "value2")
+	cache.FlushAll()
+	assert.Nil(t, cache.Get("key2"))
+}
+
+func TestReadOptimizedStorageExpiration(t *testing.T) {
+	cache := New(WithReadOptimizedStorage(), WithTTL(0), WithCleanupInterval(0))
+
+	cache.SetWithTTL("key1", "value1", time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	assert.Nil(t, cache.Get("key1"))
+
+	cache.Set("key2", "value2")
+	cache.Expire("key2", time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	assert.Nil(t, cache.Get("key2"))
+
+	cache.DeleteExpired()
+
+	_, ok := cache.readIndex.Load("key1")
+	assert.False(t, ok)
+}
+
+func TestReadOptimizedStorageConcurrency(t *testing.T) {
+	cache := New(WithReadOptimizedStorage())
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				cache.Set(fmt.Sprint("key", j), i)
+			}
+		}(i)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				cache.Get(fmt.Sprint("key", j))
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, 100, cache.Len())
+}
@@ -0,0 +1,100 @@
+package incache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// SetDefaultTTL changes the default TTL used by Set and similar methods,
+// as if the cache was created with WithTTL(ttl). Items that are already
+// stored keep their expiration.
+func (c *Cache) SetDefaultTTL(ttl time.Duration) {
+	atomic.StoreInt64(&c.ttl, int64(ttl))
+
+	c.config.debugf("[reconfigure] default ttl: %s", ttl)
+}
+
+func (c *Cache) defaultTTL() time.Duration {
+	return time.Duration(atomic.LoadInt64(&c.ttl))
+}
+
+// SetCleanupInterval changes the interval between removing expired items,
+// as if the cache was created with WithCleanupInterval(interval).
+// If the interval is less than or equal to 0, the automatic cleanup stops.
+func (c *Cache) SetCleanupInterval(interval time.Duration) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	atomic.StoreInt64(&c.cleanupInterval, int64(interval))
+
+	switch {
+	case interval <= 0:
+		if c.cleaner != nil {
+			c.cleaner.close()
+			c.cleaner = nil
+		}
+	case c.cleaner != nil:
+		c.cleaner.setInterval(interval)
+	default:
+		c.cleaner = c.newCleaner(interval)
+		stopCleanerOnCollect(c, c.cleaner)
+
+		if len(c.expirationsQueue) > 0 {
+			c.cleaner.wake()
+		}
+	}
+
+	c.config.debugf("[reconfigure] cleanup interval: %s", interval)
+}
+
+// Resize changes the maximum number of items, as if the cache was created
+// with WithMaxEntries(maxEntries). If the cache holds more items, the extra
+// ones are evicted immediately in the usual order. Zero means no limit.
+func (c *Cache) Resize(maxEntries int) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	c.maxEntries = maxEntries
+	c.config.debugf("[reconfigure] max entries: %d", maxEntries)
+
+	if maxEntries <= 0 {
+		c.evictionQueue = nil
+		return
+	}
+
+	if c.evictionQueue == nil {
+		c.evictionQueue = newEvictionQueue()
+
+		for _, key := range c.evictionOrderLocked() {
+			c.evictionQueue.push(key, c.items[key].priority)
+		}
+	}
+
+	c.shrinkLocked(maxEntries)
+}
+
+// getCleaner returns the cleaner, which can be replaced by
+// SetCleanupInterval.
+func (c *Cache) getCleaner() *cleaner {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.cleaner
+}
+
+// newCleaner creates the cleaner that doesn't keep the cache reachable,
+// see weakRef.
+func (c *Cache) newCleaner(interval time.Duration) *cleaner {
+	ref := weakRef(c)
+
+	cleaner := newCleaner(interval, func() expiredDeleter {
+		if cache := ref(); cache != nil {
+			return cache
+		}
+
+		return nil
+	})
+	cleaner.clock = c.clock
+
+	return cleaner
+}
@@ -0,0 +1,120 @@
+package incache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetDefaultTTL(t *testing.T) {
+	cache := New(WithTTL(0), WithCleanupInterval(0))
+
+	cache.Set("key1", "value1")
+	ns := cache.Namespace("ns")
+	own := cache.Namespace("own").WithTTL(time.Hour)
+
+	cache.SetDefaultTTL(time.Minute)
+
+	cache.Set("key2", "value2")
+	ns.Set("key3", "value3")
+	own.Set("key4", "value4")
+
+	info, _ := cache.Inspect("key1")
+	assert.Zero(t, info.TTL)
+
+	info, _ = cache.Inspect("key2")
+	assert.Equal(t, time.Minute, info.TTL)
+
+	info, _ = cache.Inspect("ns:key3")
+	assert.Equal(t, time.Minute, info.TTL)
+
+	info, _ = cache.Inspect("own:key4")
+	assert.Equal(t, time.Hour, info.TTL)
+}
+
+func TestSetCleanupInterval(t *testing.T) {
+	cache := New(WithTTL(0), WithCleanupInterval(0))
+	defer cache.Close()
+
+	cache.SetWithTTL("key1", "value1", time.Millisecond)
+	assert.Nil(t, cache.getCleaner())
+
+	cache.SetCleanupInterval(time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		return cache.LenIncludingExpired() == 0
+	}, time.Second, time.Millisecond)
+
+	cache.SetCleanupInterval(time.Hour)
+	assert.Equal(t, time.Hour, cache.getCleaner().interval())
+
+	cache.SetCleanupInterval(0)
+	assert.Nil(t, cache.getCleaner())
+
+	cache.SetWithTTL("key2", "value2", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, 1, cache.LenIncludingExpired())
+}
+
+func TestCleanerPicksUpNewInterval(t *testing.T) {
+	cache := New(WithTTL(0), WithCleanupInterval(time.Hour))
+	defer cache.Close()
+
+	cache.SetWithTTL("key1", "value1", time.Millisecond)
+	cache.SetCleanupInterval(time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		return cache.LenIncludingExpired() == 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestResize(t *testing.T) {
+	cache := New(WithTTL(0), WithCleanupInterval(0))
+
+	for i := 0; i < 5; i++ {
+		cache.Set(fmt.Sprint("key", i), i)
+	}
+
+	cache.Resize(3)
+	assert.ElementsMatch(t, []string{"key2", "key3", "key4"}, cache.Keys())
+
+	cache.Set("key5", 5)
+	assert.ElementsMatch(t, []string{"key3", "key4", "key5"}, cache.Keys())
+
+	cache.Resize(0)
+	cache.Set("key6", 6)
+	assert.Equal(t, 4, cache.Len())
+	assert.Nil(t, cache.evictionQueue)
+
+	cache.Resize(1)
+	assert.Equal(t, []string{"key6"}, cache.Keys())
+}
+
+func TestResizeDuringConcurrentSets(t *testing.T) {
+	cache := New(WithTTL(0), WithCleanupInterval(0), WithMaxEntries(10))
+	defer cache.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 1000; j++ {
+				cache.Set(fmt.Sprint("key", i, "-", j), j)
+			}
+		}(i)
+	}
+
+	for i := 0; i < 100; i++ {
+		cache.Resize(i%20 + 1)
+	}
+
+	wg.Wait()
+
+	cache.Resize(5)
+	assert.Equal(t, 5, cache.Len())
+}
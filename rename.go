@@ -0,0 +1,67 @@
+package incache
+
+import "time"
+
+// Rename moves the item stored by oldKey to newKey, keeping its TTL,
+// expiration time and priority, and reports whether the item existed.
+// An item stored by newKey is overwritten, like in Redis RENAME.
+// The move is performed atomically.
+//
+// Events and hooks see the move as the deletion of oldKey followed by
+// the insertion of newKey. If newKey is rejected because of the limits set
+// by WithMaxKeyLength, nothing is moved.
+func (c *Cache) Rename(oldKey, newKey string) bool {
+	c.mu.Lock()
+	defer c.unlock()
+
+	item, ok := c.liveItemLocked(oldKey)
+	if !ok {
+		return false
+	}
+
+	if oldKey == newKey {
+		return true
+	}
+
+	item.Value = c.value(item.Value)
+
+	if err := c.validate(newKey, item.Value); err != nil {
+		c.rejectLocked(newKey, item.Value, err)
+		return false
+	}
+
+	c.evictLocked(oldKey, reasonDeleted)
+	c.setItemLocked(newKey, item)
+
+	return true
+}
+
+// Swap sets the key to hold the new value with the default TTL and returns
+// the old value along with whether the key existed, which tells a missing
+// key apart from a nil value. The read and the write are performed
+// atomically, see GetSet.
+func (c *Cache) Swap(key string, value interface{}) (old interface{}, ok bool) {
+	ttl := c.defaultTTL()
+
+	c.hooks.beforeGet(key)
+	c.hooks.beforeSet(key, value, ttl)
+
+	old, ok = c.swap(key, value, ttl)
+
+	c.hooks.afterSet(key, value, ttl)
+	c.hooks.afterGet(key, old)
+
+	return old, ok
+}
+
+func (c *Cache) swap(key string, value interface{}, ttl time.Duration) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	_, ok := c.liveItemLocked(key)
+	old := c.getLocked(key)
+
+	c.setLocked(key, value, ttl)
+
+	return old, ok
+}
@@ -0,0 +1,67 @@
+package incache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRename(t *testing.T) {
+	cache := New()
+
+	cache.SetWithTTL("key1", "value1", time.Minute)
+	expiresAt := cache.items["key1"].ExpiresAt
+
+	assert.True(t, cache.Rename("key1", "key2"))
+	assert.False(t, cache.Has("key1"))
+	assert.Equal(t, "value1", cache.Get("key2"))
+	assert.Equal(t, expiresAt, cache.items["key2"].ExpiresAt)
+	assert.Equal(t, time.Minute, cache.items["key2"].TTL)
+
+	assert.True(t, cache.Rename("key2", "key2"))
+	assert.Equal(t, "value1", cache.Get("key2"))
+
+	assert.False(t, cache.Rename("missing", "key3"))
+	assert.False(t, cache.Has("key3"))
+}
+
+func TestRenameOverwrites(t *testing.T) {
+	cache := New(WithMaxEntries(2))
+
+	cache.SetWithPriority("key1", "value1", 5)
+	cache.Set("key2", "value2")
+
+	assert.True(t, cache.Rename("key1", "key2"))
+	assert.Equal(t, 1, cache.Len())
+	assert.Equal(t, "value1", cache.Get("key2"))
+	assert.Equal(t, 5, cache.items["key2"].priority)
+}
+
+func TestRenameRejected(t *testing.T) {
+	cache := New(WithMaxKeyLength(4))
+
+	cache.Set("key1", "value1")
+
+	assert.False(t, cache.Rename("key1", "too long"))
+	assert.Equal(t, "value1", cache.Get("key1"))
+}
+
+func TestSwap(t *testing.T) {
+	cache := New()
+
+	old, ok := cache.Swap("key1", "value1")
+	assert.False(t, ok)
+	assert.Nil(t, old)
+
+	old, ok = cache.Swap("key1", "value2")
+	assert.True(t, ok)
+	assert.Equal(t, "value1", old)
+	assert.Equal(t, "value2", cache.Get("key1"))
+
+	cache.Set("key2", nil)
+
+	old, ok = cache.Swap("key2", "value2")
+	assert.True(t, ok)
+	assert.Nil(t, old)
+}
@@ -0,0 +1,173 @@
+package incache
+
+import (
+	"sync"
+	"time"
+)
+
+// RequestCache is a short-lived layer over a cache for the duration of one
+// request or transaction. Reads from the parent cache are memoized, so the
+// request sees consistent values, and writes are buffered until Commit,
+// which applies them to the parent atomically, or Rollback discards them.
+//
+// Example:
+//
+//	rc := incache.NewRequestCache(cache)
+//	rc.Set("balance:1", balance-amount)
+//	if err := process(); err != nil {
+//		rc.Rollback()
+//		return err
+//	}
+//	rc.Commit()
+type RequestCache struct {
+	parent *Cache
+
+	mu sync.Mutex
+	// Values read from the parent, nil for missing keys.
+	reads  map[string]interface{}
+	writes map[string]requestWrite
+	// Keys in the order of their last write, so they're applied in the
+	// same order on Commit.
+	order []string
+}
+
+type requestWrite struct {
+	value interface{}
+	ttl   time.Duration
+	// Set when the parent's default TTL at the moment of Commit is used.
+	defaultTTL bool
+	deleted    bool
+	seq        int
+}
+
+// NewRequestCache creates a request cache over the parent cache.
+func NewRequestCache(parent *Cache) *RequestCache {
+	return &RequestCache{
+		parent: parent,
+		reads:  make(map[string]interface{}),
+		writes: make(map[string]requestWrite),
+	}
+}
+
+// Get returns the value of key written in the request, or the value of the
+// parent cache. The value read from the parent is memoized, so subsequent
+// calls return it even if the parent changes.
+// If the key doesn't exist, nil value will be returned.
+func (r *RequestCache) Get(key string) interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if w, ok := r.writes[key]; ok {
+		return w.value
+	}
+
+	if value, ok := r.reads[key]; ok {
+		return value
+	}
+
+	value := r.parent.Get(key)
+	r.reads[key] = value
+
+	return value
+}
+
+// Has checks if the key exists in the request.
+func (r *RequestCache) Has(key string) bool {
+	return r.Get(key) != nil
+}
+
+// Set buffers the value of key, which is stored with the default TTL of
+// the parent on Commit.
+func (r *RequestCache) Set(key string, value interface{}) {
+	r.write(key, requestWrite{value: value, defaultTTL: true})
+}
+
+// SetWithTTL buffers the value of key, which is stored for ttl on Commit.
+func (r *RequestCache) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	r.write(key, requestWrite{value: value, ttl: ttl})
+}
+
+// Delete buffers the deletion of key, which is applied on Commit.
+func (r *RequestCache) Delete(key string) {
+	r.write(key, requestWrite{deleted: true})
+}
+
+func (r *RequestCache) write(key string, w requestWrite) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w.seq = len(r.order)
+	r.writes[key] = w
+	r.order = append(r.order, key)
+}
+
+// Commit applies the buffered writes to the parent cache atomically, in the
+// order they were made, and resets the request cache. Hooks of the parent
+// are called for the stored values.
+func (r *RequestCache) Commit() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c := r.parent
+
+	writes := make([]keyedWrite, 0, len(r.writes))
+	for seq, key := range r.order {
+		// Only the last write of a key is applied.
+		if w := r.writes[key]; w.seq == seq {
+			if w.defaultTTL {
+				w.ttl = c.defaultTTL()
+			}
+
+			writes = append(writes, keyedWrite{key: key, requestWrite: w})
+		}
+	}
+
+	for _, w := range writes {
+		if !w.deleted {
+			c.hooks.beforeSet(w.key, w.value, w.ttl)
+		}
+	}
+
+	c.applyWrites(writes)
+
+	for _, w := range writes {
+		if !w.deleted {
+			c.hooks.afterSet(w.key, w.value, w.ttl)
+		}
+	}
+
+	r.resetLocked()
+}
+
+type keyedWrite struct {
+	key string
+	requestWrite
+}
+
+// applyWrites applies the writes of a request cache atomically.
+func (c *Cache) applyWrites(writes []keyedWrite) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	for _, w := range writes {
+		if !w.deleted {
+			c.setLocked(w.key, w.value, w.ttl)
+		} else if _, ok := c.items[w.key]; ok {
+			c.evictLocked(w.key, reasonDeleted)
+		}
+	}
+}
+
+// Rollback discards the buffered writes and the memoized reads.
+func (r *RequestCache) Rollback() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.resetLocked()
+}
+
+func (r *RequestCache) resetLocked() {
+	r.reads = make(map[string]interface{})
+	r.writes = make(map[string]requestWrite)
+	r.order = nil
+}
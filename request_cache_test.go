@@ -0,0 +1,84 @@
+package incache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestCacheMemoizesReads(t *testing.T) {
+	cache := New()
+	cache.Set("key1", "value1")
+
+	rc := NewRequestCache(cache)
+
+	assert.Equal(t, "value1", rc.Get("key1"))
+	assert.Nil(t, rc.Get("key2"))
+
+	cache.Set("key1", "changed")
+	cache.Set("key2", "value2")
+
+	assert.Equal(t, "value1", rc.Get("key1"))
+	assert.False(t, rc.Has("key2"))
+}
+
+func TestRequestCacheCommit(t *testing.T) {
+	cache := New(WithTTL(time.Minute))
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+
+	rc := NewRequestCache(cache)
+
+	rc.Set("key1", "updated")
+	rc.SetWithTTL("key3", "value3", time.Hour)
+	rc.Delete("key2")
+	rc.Set("key4", "value4")
+	rc.Delete("key4")
+
+	assert.Equal(t, "updated", rc.Get("key1"))
+	assert.Nil(t, rc.Get("key2"))
+	assert.Equal(t, "value2", cache.Get("key2"))
+
+	rc.Commit()
+
+	assert.Equal(t, "updated", cache.Get("key1"))
+	assert.False(t, cache.Has("key2"))
+	assert.Equal(t, "value3", cache.Get("key3"))
+	assert.False(t, cache.Has("key4"))
+	assert.Equal(t, time.Minute, cache.items["key1"].TTL)
+	assert.Equal(t, time.Hour, cache.items["key3"].TTL)
+
+	// The request cache is reset after Commit.
+	cache.Set("key1", "changed")
+	assert.Equal(t, "changed", rc.Get("key1"))
+}
+
+func TestRequestCacheCommitOrder(t *testing.T) {
+	cache := New(WithMaxEntries(2))
+
+	rc := NewRequestCache(cache)
+	rc.Set("key1", "value1")
+	rc.Set("key2", "value2")
+	rc.Set("key3", "value3")
+	rc.Set("key1", "updated")
+	rc.Commit()
+
+	assert.ElementsMatch(t, []string{"key3", "key1"}, cache.Keys())
+}
+
+func TestRequestCacheRollback(t *testing.T) {
+	cache := New()
+	cache.Set("key1", "value1")
+
+	rc := NewRequestCache(cache)
+	rc.Set("key1", "updated")
+	rc.Delete("key1")
+	rc.Rollback()
+
+	assert.Equal(t, "value1", cache.Get("key1"))
+	assert.Equal(t, "value1", rc.Get("key1"))
+
+	rc.Commit()
+	assert.Equal(t, "value1", cache.Get("key1"))
+}
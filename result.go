@@ -0,0 +1,76 @@
+package incache
+
+import "time"
+
+// Result is the value of a key together with its metadata, see GetResult.
+type Result[V any] struct {
+	Value V
+	// Found is set when the key exists, including an expired value that
+	// wasn't removed by the cleaner yet.
+	Found bool
+	// Stale is set when the value has expired, see GetStale.
+	Stale bool
+	// ExpiresAt is zero when the value never expires.
+	ExpiresAt time.Time
+	// Age is the time since the value was stored.
+	Age time.Duration
+}
+
+// GetResult returns the value of key together with whether it was found,
+// whether it's stale, when it expires and how long ago it was stored, all
+// read at once instead of separate calls of GetStale, Inspect and others.
+//
+// Like with GetStale, an expired value that wasn't removed yet is returned
+// flagged as stale and counted as a miss in metrics.
+func (c *Cache) GetResult(key string) Result[interface{}] {
+	c.hooks.beforeGet(key)
+
+	result := c.lookupResult(key)
+	c.hooks.afterGet(key, result.Value)
+
+	return result
+}
+
+func (c *Cache) lookupResult(key string) Result[interface{}] {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	item, ok := c.items[key]
+	if !ok || reclaimed(item.Value) {
+		c.countMiss(key)
+		return Result[interface{}]{}
+	}
+
+	result := Result[interface{}]{
+		Value:     c.copyValue(c.value(item.Value)),
+		Found:     true,
+		Stale:     item.Expired(),
+		ExpiresAt: item.ExpiresAt,
+		Age:       time.Since(time.Unix(0, item.storedAt)),
+	}
+
+	if result.Stale {
+		c.countMiss(key)
+	} else {
+		c.countHit(key)
+	}
+
+	return result
+}
+
+// typedResult converts the result to the one of type V. A value of another
+// type isn't found.
+func typedResult[V any](result Result[interface{}]) Result[V] {
+	value, ok := typedValue[V](result.Value)
+	if !ok {
+		return Result[V]{}
+	}
+
+	return Result[V]{
+		Value:     value,
+		Found:     result.Found,
+		Stale:     result.Stale,
+		ExpiresAt: result.ExpiresAt,
+		Age:       result.Age,
+	}
+}
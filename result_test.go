@@ -0,0 +1,56 @@
+package incache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetResult(t *testing.T) {
+	cache := New(WithTTL(0), WithCleanupInterval(0), WithMetrics())
+	defer cache.Close()
+
+	cache.Set("key1", "value1")
+	cache.SetWithTTL("key2", "value2", time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	result := cache.GetResult("key1")
+	assert.Equal(t, "value1", result.Value)
+	assert.True(t, result.Found)
+	assert.False(t, result.Stale)
+	assert.True(t, result.ExpiresAt.IsZero())
+	assert.GreaterOrEqual(t, result.Age, 5*time.Millisecond)
+	assert.Less(t, result.Age, time.Minute)
+
+	result = cache.GetResult("key2")
+	assert.Equal(t, "value2", result.Value)
+	assert.True(t, result.Found)
+	assert.True(t, result.Stale)
+	assert.False(t, result.ExpiresAt.IsZero())
+
+	assert.Equal(t, Result[interface{}]{}, cache.GetResult("key3"))
+
+	assert.EqualValues(t, 1, cache.Metrics().Hits())
+	assert.EqualValues(t, 2, cache.Metrics().Misses())
+}
+
+func TestTypedGetResult(t *testing.T) {
+	cache := New(WithTTL(0))
+	defer cache.Close()
+
+	counters := View[int](cache, "counters:")
+	counters.SetWithTTL("1", 10, time.Hour)
+	cache.Set("counters:2", "not a number")
+
+	result := counters.GetResult("1")
+	assert.Equal(t, 10, result.Value)
+	assert.True(t, result.Found)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), result.ExpiresAt, time.Minute)
+
+	assert.False(t, counters.GetResult("2").Found)
+
+	ids := NewTyped[int64, int](cache)
+	ids.Set(1, 20)
+	assert.Equal(t, 20, ids.GetResult(1).Value)
+}
@@ -0,0 +1,85 @@
+package incache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// The sampled cleanup continues while the ratio of expired keys
+	// in a sample is above this value.
+	sampledCleanupThreshold = 0.25
+	// The fraction of cleanupInterval the sampled cleanup may spend
+	// per tick.
+	sampledCleanupBudget = 4
+)
+
+// cleanup is called by the cleaner on every tick.
+func (c *Cache) cleanup() {
+	if c.config.cleanupSampleSize <= 0 {
+		c.DeleteExpired()
+		return
+	}
+
+	interval := time.Duration(atomic.LoadInt64(&c.cleanupInterval))
+	c.deleteExpiredSampled(c.config.cleanupSampleSize, interval/sampledCleanupBudget)
+}
+
+func (c *Cache) hasExpiring() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return len(c.expirationsQueue) > 0
+}
+
+// deleteExpiredSampled deletes expired items among random samples of keys
+// with TTL, taken until the ratio of expired keys drops below the threshold
+// or the time budget is spent. It returns the number of deleted items.
+func (c *Cache) deleteExpiredSampled(sampleSize int, budget time.Duration) int {
+	start := time.Now()
+	deleted := 0
+
+	for {
+		sampled, expired := c.deleteExpiredSample(sampleSize)
+		deleted += expired
+
+		if sampled == 0 || float64(expired) <= float64(sampled)*sampledCleanupThreshold {
+			return deleted
+		}
+
+		if budget > 0 && time.Since(start) >= budget {
+			return deleted
+		}
+	}
+}
+
+// deleteExpiredSample checks up to sampleSize keys with TTL and deletes
+// the expired ones. Map iteration order is random, so the checked keys
+// are a random sample.
+func (c *Cache) deleteExpiredSample(sampleSize int) (sampled, expired int) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	timeNow := time.Now()
+
+	for key := range c.expirationsQueue {
+		if sampled >= sampleSize {
+			break
+		}
+
+		sampled++
+
+		item, ok := c.items[key]
+		if !ok || !item.CanExpire() {
+			delete(c.expirationsQueue, key)
+			continue
+		}
+
+		if item.expiredAt(timeNow) {
+			c.evictLocked(key, reasonExpired)
+			expired++
+		}
+	}
+
+	return sampled, expired
+}
@@ -0,0 +1,63 @@
+package incache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteExpiredSample(t *testing.T) {
+	cache := New(WithTTL(time.Millisecond), WithCleanupInterval(0))
+
+	for i := 0; i < 100; i++ {
+		cache.Set(fmt.Sprint("key", i), i)
+	}
+	time.Sleep(2 * time.Millisecond)
+
+	sampled, expired := cache.deleteExpiredSample(10)
+	assert.Equal(t, 10, sampled)
+	assert.Equal(t, 10, expired)
+	assert.Equal(t, 90, cache.LenIncludingExpired())
+}
+
+func TestDeleteExpiredSampledAdaptsPace(t *testing.T) {
+	cache := New(WithTTL(0), WithCleanupInterval(0))
+
+	// While most of the sampled keys are expired, sampling continues.
+	for i := 0; i < 100; i++ {
+		cache.SetWithTTL(fmt.Sprint("expired", i), i, time.Millisecond)
+	}
+	time.Sleep(2 * time.Millisecond)
+
+	assert.Equal(t, 100, cache.deleteExpiredSampled(10, 0))
+	assert.Zero(t, cache.LenIncludingExpired())
+
+	// When few of the sampled keys are expired, it stops after one round.
+	for i := 0; i < 100; i++ {
+		cache.SetWithTTL(fmt.Sprint("live", i), i, time.Hour)
+	}
+
+	sampled, expired := cache.deleteExpiredSample(100)
+	assert.Equal(t, 100, sampled)
+	assert.Zero(t, expired)
+	assert.Zero(t, cache.deleteExpiredSampled(10, 0))
+}
+
+func TestSampledCleanup(t *testing.T) {
+	cache := New(
+		WithTTL(time.Millisecond),
+		WithCleanupInterval(time.Millisecond),
+		WithSampledCleanup(5),
+	)
+	defer cache.Close()
+
+	for i := 0; i < 100; i++ {
+		cache.Set(fmt.Sprint("key", i), i)
+	}
+
+	assert.Eventually(t, func() bool {
+		return cache.LenIncludingExpired() == 0
+	}, time.Second, time.Millisecond)
+}
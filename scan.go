@@ -0,0 +1,93 @@
+package incache
+
+import (
+	"container/heap"
+	"strings"
+	"time"
+)
+
+// defaultScanCount is the number of keys Scan returns when count isn't
+// positive, the same as in Redis.
+const defaultScanCount = 10
+
+// Scan iterates over the keys incrementally, similar to SCAN of Redis.
+// It returns up to count keys starting with matchPrefix and the cursor to
+// pass to the next call. Iteration starts with zero cursor and is complete
+// when zero cursor is returned.
+//
+// Keys are iterated in the order they were last stored, so a key stored
+// during the iteration can be returned again, but a key that exists during
+// the whole iteration is returned at least once. Expired keys are skipped.
+//
+// Every call scans all items without copying them, so the memory used by
+// the iteration doesn't depend on the size of the cache.
+func (c *Cache) Scan(cursor uint64, count int, matchPrefix string) (keys []string, nextCursor uint64) {
+	if count <= 0 {
+		count = defaultScanCount
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	// The newest of the selected items is on top, so it's replaced first
+	// when an older one is found.
+	selected := make(scanHeap, 0, count)
+	more := false
+
+	timeNow := time.Now()
+	for key, item := range c.items {
+		if item.version <= cursor || !strings.HasPrefix(key, matchPrefix) || item.expiredAt(timeNow) {
+			continue
+		}
+
+		switch {
+		case len(selected) < count:
+			heap.Push(&selected, scanEntry{key: key, version: item.version})
+		case item.version < selected[0].version:
+			selected[0] = scanEntry{key: key, version: item.version}
+			heap.Fix(&selected, 0)
+
+			more = true
+		default:
+			more = true
+		}
+	}
+
+	keys = make([]string, len(selected))
+	for i := len(selected) - 1; i >= 0; i-- {
+		entry := heap.Pop(&selected).(scanEntry)
+		keys[i] = entry.key
+
+		if i == len(keys)-1 && more {
+			nextCursor = entry.version
+		}
+	}
+
+	return keys, nextCursor
+}
+
+type scanEntry struct {
+	key     string
+	version uint64
+}
+
+// scanHeap is a max-heap of entries by version, it implements
+// heap.Interface.
+type scanHeap []scanEntry
+
+func (h scanHeap) Len() int           { return len(h) }
+func (h scanHeap) Less(i, j int) bool { return h[i].version > h[j].version }
+func (h scanHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *scanHeap) Push(x any) {
+	*h = append(*h, x.(scanEntry))
+}
+
+func (h *scanHeap) Pop() any {
+	old := *h
+	n := len(old)
+	entry := old[n-1]
+	*h = old[:n-1]
+
+	return entry
+}
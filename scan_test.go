@@ -0,0 +1,90 @@
+package incache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScan(t *testing.T) {
+	cache := New(WithTTL(0))
+	defer cache.Close()
+
+	for i := 0; i < 25; i++ {
+		cache.Set(fmt.Sprintf("user:%02d", i), i)
+	}
+	cache.Set("order:1", 1)
+
+	var keys []string
+
+	cursor := uint64(0)
+	for calls := 0; ; calls++ {
+		var page []string
+		page, cursor = cache.Scan(cursor, 10, "user:")
+
+		assert.LessOrEqual(t, len(page), 10)
+		keys = append(keys, page...)
+
+		if cursor == 0 {
+			assert.Equal(t, 2, calls)
+			break
+		}
+	}
+
+	assert.Len(t, keys, 25)
+	assert.Equal(t, "user:00", keys[0])
+	assert.Equal(t, "user:24", keys[24])
+}
+
+func TestScanDefaultCount(t *testing.T) {
+	cache := New(WithTTL(0))
+	defer cache.Close()
+
+	for i := 0; i < 15; i++ {
+		cache.Set(fmt.Sprint("key", i), i)
+	}
+
+	keys, cursor := cache.Scan(0, 0, "")
+	assert.Len(t, keys, defaultScanCount)
+	assert.NotZero(t, cursor)
+
+	keys, cursor = cache.Scan(cursor, 0, "")
+	assert.Len(t, keys, 5)
+	assert.Zero(t, cursor)
+}
+
+func TestScanWithWrites(t *testing.T) {
+	cache := New(WithTTL(0), WithCleanupInterval(0))
+	defer cache.Close()
+
+	for i := 0; i < 10; i++ {
+		cache.Set(fmt.Sprint("key", i), i)
+	}
+	cache.SetWithTTL("expired", 1, time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+
+	seen := map[string]int{}
+
+	keys, cursor := cache.Scan(0, 5, "")
+	for _, key := range keys {
+		seen[key]++
+	}
+
+	// Updating a returned key returns it again, deleting a key that wasn't
+	// returned yet skips it.
+	cache.Set(keys[0], 0)
+	cache.Delete("key9")
+
+	for cursor != 0 {
+		keys, cursor = cache.Scan(cursor, 5, "")
+		for _, key := range keys {
+			seen[key]++
+		}
+	}
+
+	assert.Len(t, seen, 9)
+	assert.Equal(t, 2, seen["key0"])
+	assert.NotContains(t, seen, "expired")
+}
@@ -0,0 +1,104 @@
+package server
+
+// matchGlob reports whether s matches the glob pattern with the semantics
+// of Redis KEYS: '*' matches any sequence of bytes, '?' matches a single
+// byte, '[...]' matches a byte of the set ('^' negates it, 'a-z' is a
+// range) and '\' escapes the next byte. Unlike path.Match, '/' isn't
+// treated as a separator, and malformed patterns don't cause errors.
+func matchGlob(pattern, s string) bool {
+	// Position to resume from when the last '*' has to consume one more
+	// byte of s.
+	starPattern, starS := -1, 0
+
+	p, i := 0, 0
+	for i < len(s) {
+		if p < len(pattern) {
+			switch pattern[p] {
+			case '*':
+				starPattern, starS = p, i
+				p++
+				continue
+			case '?':
+				p++
+				i++
+				continue
+			case '[':
+				if next, ok := matchClass(pattern, p, s[i]); ok {
+					p = next
+					i++
+					continue
+				}
+			case '\\':
+				if p+1 < len(pattern) && pattern[p+1] == s[i] {
+					p += 2
+					i++
+					continue
+				}
+			default:
+				if pattern[p] == s[i] {
+					p++
+					i++
+					continue
+				}
+			}
+		}
+
+		if starPattern < 0 {
+			return false
+		}
+
+		starS++
+		p, i = starPattern+1, starS
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+
+	return p == len(pattern)
+}
+
+// matchClass matches b against the class starting at pattern[start] == '['.
+// It returns the position after the class and whether b belongs to it.
+// An unterminated class extends to the end of the pattern, as in Redis.
+func matchClass(pattern string, start int, b byte) (int, bool) {
+	p := start + 1
+
+	negate := p < len(pattern) && pattern[p] == '^'
+	if negate {
+		p++
+	}
+
+	matched := false
+	for p < len(pattern) && pattern[p] != ']' {
+		switch {
+		case pattern[p] == '\\' && p+1 < len(pattern):
+			p++
+			if pattern[p] == b {
+				matched = true
+			}
+		case p+2 < len(pattern) && pattern[p+1] == '-' && pattern[p+2] != ']':
+			lo, hi := pattern[p], pattern[p+2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			if b >= lo && b <= hi {
+				matched = true
+			}
+			p += 2
+		default:
+			if pattern[p] == b {
+				matched = true
+			}
+		}
+
+		p++
+	}
+
+	if p < len(pattern) {
+		// Skip the closing ']'.
+		p++
+	}
+
+	return p, matched != negate
+}
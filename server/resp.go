@@ -0,0 +1,120 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Limits protecting the server from malformed or malicious requests.
+const (
+	maxArgs      = 1024
+	maxBulkBytes = 512 * 1024 * 1024
+)
+
+var errProtocol = errors.New("protocol error")
+
+// readCommand reads a single command, either in the RESP array format sent
+// by Redis clients, or in the inline format typed in telnet sessions.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(line) == 0 || line[0] != '*' {
+		return strings.Fields(line), nil
+	}
+
+	count, err := strconv.Atoi(line[1:])
+	if err != nil || count < 1 || count > maxArgs {
+		return nil, errProtocol
+	}
+
+	args := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		arg, err := readBulkString(r)
+		if err != nil {
+			return nil, err
+		}
+
+		args = append(args, arg)
+	}
+
+	return args, nil
+}
+
+func readBulkString(r *bufio.Reader) (string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return "", err
+	}
+
+	if len(line) == 0 || line[0] != '$' {
+		return "", errProtocol
+	}
+
+	size, err := strconv.Atoi(line[1:])
+	if err != nil || size < 0 || size > maxBulkBytes {
+		return "", errProtocol
+	}
+
+	buf := make([]byte, size+2)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+
+	if buf[size] != '\r' || buf[size+1] != '\n' {
+		return "", errProtocol
+	}
+
+	return string(buf[:size]), nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// writer encodes replies in the RESP format.
+type writer struct {
+	w *bufio.Writer
+}
+
+func (w writer) simpleString(s string) {
+	fmt.Fprintf(w.w, "+%s\r\n", s)
+}
+
+func (w writer) error(msg string) {
+	fmt.Fprintf(w.w, "-%s\r\n", msg)
+}
+
+func (w writer) integer(n int64) {
+	fmt.Fprintf(w.w, ":%d\r\n", n)
+}
+
+func (w writer) bulkString(s string) {
+	fmt.Fprintf(w.w, "$%d\r\n%s\r\n", len(s), s)
+}
+
+func (w writer) null() {
+	w.w.WriteString("$-1\r\n")
+}
+
+func (w writer) array(items []string) {
+	fmt.Fprintf(w.w, "*%d\r\n", len(items))
+	for _, item := range items {
+		w.bulkString(item)
+	}
+}
+
+func (w writer) flush() error {
+	return w.w.Flush()
+}
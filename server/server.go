@@ -0,0 +1,384 @@
+// Package server serves a subset of the Redis protocol (RESP) backed by
+// a cache, so existing Redis clients and redis-cli can be used to inspect
+// and manipulate the in-process cache during development.
+//
+// Supported commands: PING, GET, SET (with EX and PX options), DEL, EXPIRE,
+// TTL, KEYS and QUIT.
+//
+// The server doesn't perform any authentication, don't expose it publicly.
+package server
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wittyjudge/incache"
+)
+
+// ErrServerClosed is returned by Serve and ListenAndServe after Close.
+var ErrServerClosed = errors.New("server: closed")
+
+// Server serves Redis protocol requests backed by the cache.
+type Server struct {
+	cache *incache.Cache
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+	wg        sync.WaitGroup
+}
+
+// New creates new server for the cache.
+func New(cache *incache.Cache) *Server {
+	return &Server{
+		cache:     cache,
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[net.Conn]struct{}),
+	}
+}
+
+// ListenAndServe listens on the TCP network address addr and serves
+// connections. It blocks until the server is closed.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(l)
+}
+
+// Serve accepts connections on the listener and serves each of them
+// in a separate goroutine. It blocks until the server is closed.
+func (s *Server) Serve(l net.Listener) error {
+	if !s.trackListener(l) {
+		l.Close()
+		return ErrServerClosed
+	}
+	defer s.untrackListener(l)
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+
+			return err
+		}
+
+		if !s.trackConn(conn) {
+			conn.Close()
+			return ErrServerClosed
+		}
+
+		s.wg.Add(1)
+
+		go func() {
+			defer s.wg.Done()
+			defer s.untrackConn(conn)
+
+			s.serveConn(conn)
+		}()
+	}
+}
+
+// Close stops all listeners, closes active connections and waits until
+// their processing is finished.
+func (s *Server) Close() error {
+	s.mu.Lock()
+
+	s.closed = true
+
+	var err error
+	for l := range s.listeners {
+		if cerr := l.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+
+	for conn := range s.conns {
+		conn.Close()
+	}
+
+	s.mu.Unlock()
+
+	s.wg.Wait()
+
+	return err
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	w := writer{w: bufio.NewWriter(conn)}
+
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !s.isClosed() {
+				w.error("ERR " + err.Error())
+				_ = w.flush()
+			}
+
+			return
+		}
+
+		if len(args) == 0 {
+			continue
+		}
+
+		quit := s.execute(w, args)
+
+		if err := w.flush(); err != nil || quit {
+			return
+		}
+	}
+}
+
+// execute runs the command and writes its reply. It reports whether
+// the connection has to be closed.
+func (s *Server) execute(w writer, args []string) bool {
+	command := args[0]
+	args = args[1:]
+
+	switch strings.ToUpper(command) {
+	case "PING":
+		s.ping(w, args)
+	case "GET":
+		s.get(w, args)
+	case "SET":
+		s.set(w, args)
+	case "DEL":
+		s.del(w, args)
+	case "EXPIRE":
+		s.expire(w, args)
+	case "TTL":
+		s.ttl(w, args)
+	case "KEYS":
+		s.keys(w, args)
+	case "COMMAND":
+		// Sent by redis-cli on start to fetch commands documentation.
+		w.array(nil)
+	case "QUIT":
+		w.simpleString("OK")
+		return true
+	default:
+		w.error(fmt.Sprintf("ERR unknown command '%s'", command))
+	}
+
+	return false
+}
+
+func (s *Server) ping(w writer, args []string) {
+	switch len(args) {
+	case 0:
+		w.simpleString("PONG")
+	case 1:
+		w.bulkString(args[0])
+	default:
+		wrongArgs(w, "ping")
+	}
+}
+
+func (s *Server) get(w writer, args []string) {
+	if len(args) != 1 {
+		wrongArgs(w, "get")
+		return
+	}
+
+	value := s.cache.Get(args[0])
+	if value == nil {
+		w.null()
+		return
+	}
+
+	w.bulkString(formatValue(value))
+}
+
+func (s *Server) set(w writer, args []string) {
+	if len(args) != 2 && len(args) != 4 {
+		wrongArgs(w, "set")
+		return
+	}
+
+	key, value := args[0], args[1]
+
+	if len(args) == 2 {
+		s.cache.Set(key, value)
+		w.simpleString("OK")
+		return
+	}
+
+	ttl, err := parseTTL(strings.ToUpper(args[2]), args[3])
+	if err != nil {
+		w.error(err.Error())
+		return
+	}
+
+	s.cache.SetWithTTL(key, value, ttl)
+	w.simpleString("OK")
+}
+
+func (s *Server) del(w writer, args []string) {
+	if len(args) == 0 {
+		wrongArgs(w, "del")
+		return
+	}
+
+	var deleted int64
+	for _, key := range args {
+		if s.cache.Delete(key) {
+			deleted++
+		}
+	}
+
+	w.integer(deleted)
+}
+
+func (s *Server) expire(w writer, args []string) {
+	if len(args) != 2 {
+		wrongArgs(w, "expire")
+		return
+	}
+
+	seconds, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		w.error("ERR value is not an integer or out of range")
+		return
+	}
+
+	if s.cache.Expire(args[0], time.Duration(seconds)*time.Second) {
+		w.integer(1)
+	} else {
+		w.integer(0)
+	}
+}
+
+func (s *Server) ttl(w writer, args []string) {
+	if len(args) != 1 {
+		wrongArgs(w, "ttl")
+		return
+	}
+
+	info, ok := s.cache.Inspect(args[0])
+	if !ok || info.Expired {
+		w.integer(-2)
+		return
+	}
+
+	if info.ExpiresAt.IsZero() {
+		w.integer(-1)
+		return
+	}
+
+	remaining := time.Until(info.ExpiresAt)
+	w.integer(int64((remaining + time.Second/2) / time.Second))
+}
+
+func (s *Server) keys(w writer, args []string) {
+	if len(args) != 1 {
+		wrongArgs(w, "keys")
+		return
+	}
+
+	pattern := args[0]
+
+	keys := []string{}
+	for _, key := range s.cache.Keys() {
+		if matchGlob(pattern, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	w.array(keys)
+}
+
+func (s *Server) trackListener(l net.Listener) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return false
+	}
+
+	s.listeners[l] = struct{}{}
+
+	return true
+}
+
+func (s *Server) untrackListener(l net.Listener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.listeners, l)
+}
+
+func (s *Server) trackConn(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return false
+	}
+
+	s.conns[conn] = struct{}{}
+
+	return true
+}
+
+func (s *Server) untrackConn(conn net.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.conns, conn)
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.closed
+}
+
+func parseTTL(unit, amount string) (time.Duration, error) {
+	n, err := strconv.ParseInt(amount, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, errors.New("ERR invalid expire time in 'set' command")
+	}
+
+	switch unit {
+	case "EX":
+		return time.Duration(n) * time.Second, nil
+	case "PX":
+		return time.Duration(n) * time.Millisecond, nil
+	default:
+		return 0, errors.New("ERR syntax error")
+	}
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func wrongArgs(w writer, command string) {
+	w.error(fmt.Sprintf("ERR wrong number of arguments for '%s' command", command))
+}
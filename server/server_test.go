@@ -0,0 +1,227 @@
+package server
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wittyjudge/incache"
+)
+
+type client struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func newTestServer(t *testing.T, cache *incache.Cache) *client {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := New(cache)
+	go srv.Serve(l)
+	t.Cleanup(func() { srv.Close() })
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return &client{conn: conn, r: bufio.NewReader(conn)}
+}
+
+// do sends the command in the RESP format and returns the raw reply.
+func (c *client) do(t *testing.T, args ...string) string {
+	t.Helper()
+
+	w := writer{w: bufio.NewWriter(c.conn)}
+	w.array(args)
+	require.NoError(t, w.flush())
+
+	return c.readReply(t)
+}
+
+func (c *client) readReply(t *testing.T) string {
+	t.Helper()
+
+	line, err := c.r.ReadString('\n')
+	require.NoError(t, err)
+
+	switch line[0] {
+	case '$':
+		if line == "$-1\r\n" {
+			return line
+		}
+
+		next, err := c.r.ReadString('\n')
+		require.NoError(t, err)
+
+		return line + next
+	case '*':
+		reply := line
+		for i := 0; i < int(line[1]-'0'); i++ {
+			reply += c.readReply(t)
+		}
+
+		return reply
+	default:
+		return line
+	}
+}
+
+func TestServerGetSetDel(t *testing.T) {
+	cache := incache.New(incache.WithTTL(0))
+	defer cache.Close()
+
+	c := newTestServer(t, cache)
+
+	assert.Equal(t, "+PONG\r\n", c.do(t, "PING"))
+	assert.Equal(t, "$-1\r\n", c.do(t, "GET", "key1"))
+	assert.Equal(t, "+OK\r\n", c.do(t, "SET", "key1", "value1"))
+	assert.Equal(t, "$6\r\nvalue1\r\n", c.do(t, "GET", "key1"))
+	assert.Equal(t, "value1", cache.Get("key1"))
+
+	cache.Set("key2", 42)
+	assert.Equal(t, "$2\r\n42\r\n", c.do(t, "get", "key2"))
+
+	assert.Equal(t, ":2\r\n", c.do(t, "DEL", "key1", "key2", "key3"))
+	assert.Zero(t, cache.Len())
+}
+
+func TestServerExpiration(t *testing.T) {
+	cache := incache.New(incache.WithTTL(0))
+	defer cache.Close()
+
+	c := newTestServer(t, cache)
+
+	assert.Equal(t, ":-2\r\n", c.do(t, "TTL", "key1"))
+
+	c.do(t, "SET", "key1", "value1")
+	assert.Equal(t, ":-1\r\n", c.do(t, "TTL", "key1"))
+
+	assert.Equal(t, ":1\r\n", c.do(t, "EXPIRE", "key1", "100"))
+	assert.Equal(t, ":100\r\n", c.do(t, "TTL", "key1"))
+	assert.Equal(t, ":0\r\n", c.do(t, "EXPIRE", "key2", "100"))
+
+	assert.Equal(t, "+OK\r\n", c.do(t, "SET", "key2", "value2", "PX", "1"))
+	time.Sleep(2 * time.Millisecond)
+	assert.Equal(t, "$-1\r\n", c.do(t, "GET", "key2"))
+
+	assert.Equal(t, ":1\r\n", c.do(t, "EXPIRE", "key1", "0"))
+	assert.False(t, cache.Has("key1"))
+}
+
+func TestServerKeys(t *testing.T) {
+	cache := incache.New(incache.WithTTL(0))
+	defer cache.Close()
+
+	cache.Set("user:1", 1)
+	cache.Set("user:2", 2)
+	cache.Set("order:1", 1)
+
+	c := newTestServer(t, cache)
+
+	assert.Equal(t, "*2\r\n$6\r\nuser:1\r\n$6\r\nuser:2\r\n", c.do(t, "KEYS", "user:*"))
+	assert.Equal(t, "*0\r\n", c.do(t, "KEYS", "none*"))
+}
+
+func TestServerKeysWithSlashes(t *testing.T) {
+	cache := incache.New(incache.WithTTL(0))
+	defer cache.Close()
+
+	cache.Set("a/b", 1)
+
+	c := newTestServer(t, cache)
+
+	assert.Equal(t, "*1\r\n$3\r\na/b\r\n", c.do(t, "KEYS", "*"))
+}
+
+func TestServerDelDoesNotRecordReads(t *testing.T) {
+	cache := incache.New(incache.WithTTL(0), incache.WithMetrics())
+	defer cache.Close()
+
+	cache.Set("key1", "value1")
+
+	c := newTestServer(t, cache)
+
+	assert.Equal(t, ":1\r\n", c.do(t, "DEL", "key1", "key2"))
+	assert.False(t, cache.Has("key1"))
+	assert.Zero(t, cache.Metrics().Hits())
+	assert.Zero(t, cache.Metrics().Misses())
+}
+
+func TestServerMalformedLengths(t *testing.T) {
+	for _, request := range []string{
+		"*-1\r\n",
+		"*0\r\n",
+		"*1\r\n$-1\r\n",
+		"*1\r\n$-5\r\n",
+	} {
+		cache := incache.New()
+		c := newTestServer(t, cache)
+
+		_, err := c.conn.Write([]byte(request))
+		require.NoError(t, err)
+
+		assert.Equal(t, "-ERR protocol error\r\n", c.readReply(t), "%q", request)
+
+		cache.Close()
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	cache := incache.New()
+	defer cache.Close()
+
+	c := newTestServer(t, cache)
+
+	assert.Equal(t, "-ERR unknown command 'FOO'\r\n", c.do(t, "FOO"))
+	assert.Equal(t, "-ERR wrong number of arguments for 'get' command\r\n", c.do(t, "GET"))
+	assert.Equal(t, "-ERR syntax error\r\n", c.do(t, "SET", "key", "value", "XX", "1"))
+	assert.Equal(t, "-ERR value is not an integer or out of range\r\n", c.do(t, "EXPIRE", "key", "abc"))
+}
+
+func TestServerInlineCommands(t *testing.T) {
+	cache := incache.New()
+	defer cache.Close()
+
+	c := newTestServer(t, cache)
+
+	_, err := c.conn.Write([]byte("SET key1 value1\r\nGET key1\r\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "+OK\r\n", c.readReply(t))
+	assert.Equal(t, "$6\r\nvalue1\r\n", c.readReply(t))
+}
+
+func TestServerClose(t *testing.T) {
+	cache := incache.New()
+	defer cache.Close()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := New(cache)
+
+	done := make(chan error, 1)
+	go func() { done <- srv.Serve(l) }()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("PING\r\n"))
+	require.NoError(t, err)
+
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "+PONG\r\n", reply)
+
+	require.NoError(t, srv.Close())
+	assert.ErrorIs(t, <-done, ErrServerClosed)
+	assert.ErrorIs(t, srv.Serve(l), ErrServerClosed)
+}
@@ -0,0 +1,31 @@
+package incache
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// itemOverhead is an approximate number of bytes the cache spends on every
+// stored item besides its key and value: the Item struct itself, the map
+// entries and the expiration queue record.
+const itemOverhead = uint64(unsafe.Sizeof(Item{})) + 48
+
+// estimateSize returns an approximate number of bytes occupied by the value.
+// Only the memory directly referenced by strings and byte slices is taken
+// into account, other values are measured shallowly.
+func estimateSize(value interface{}) uint64 {
+	switch v := value.(type) {
+	case nil:
+		return 0
+	case string:
+		return uint64(len(v))
+	case []byte:
+		return uint64(cap(v))
+	case compressedValue:
+		return uint64(cap(v))
+	case encodedValue:
+		return uint64(cap(v.data))
+	}
+
+	return uint64(reflect.TypeOf(value).Size())
+}
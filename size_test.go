@@ -0,0 +1,15 @@
+package incache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateSize(t *testing.T) {
+	assert.EqualValues(t, 0, estimateSize(nil))
+	assert.EqualValues(t, 5, estimateSize("value"))
+	assert.EqualValues(t, 16, estimateSize(make([]byte, 10, 16)))
+	assert.EqualValues(t, 8, estimateSize(int64(1)))
+	assert.EqualValues(t, 1, estimateSize(true))
+}
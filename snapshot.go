@@ -0,0 +1,370 @@
+package incache
+
+import (
+	"bufio"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// snapshotHeaderPrefix starts the first line of every snapshot. It's
+// followed by the format version, a colon and the name of the codec the
+// snapshot was encoded with, e.g. "incache/2:gob".
+//
+// Snapshots written before the format was versioned start with
+// "incache:<codec>" and are read as version 1.
+const snapshotHeaderPrefix = "incache"
+
+// snapshotVersion is the version of the snapshot format written by
+// SaveSnapshot.
+const snapshotVersion = 2
+
+// snapshotMigrations upgrade entries decoded from a snapshot of the version
+// they're keyed by to the next version. ReadSnapshot applies them one after
+// another until entries are in the current format, so every format change
+// has to bump snapshotVersion and add a migration here.
+var snapshotMigrations = map[int]func(entry *SnapshotEntry) error{
+	// Version 2 only added the version to the header.
+	1: func(entry *SnapshotEntry) error { return nil },
+}
+
+var (
+	// ErrInvalidSnapshot is returned when the snapshot can't be recognized.
+	ErrInvalidSnapshot = errors.New("incache: invalid snapshot")
+	// ErrIncompatibleSnapshot is returned when the snapshot was written in a
+	// format version this version of the library can't read, usually by a
+	// newer one.
+	ErrIncompatibleSnapshot = errors.New("incache: incompatible snapshot version")
+	// ErrUnknownSnapshotCodec is returned when the snapshot was encoded with
+	// a codec that isn't registered.
+	ErrUnknownSnapshotCodec = errors.New("incache: unknown snapshot codec")
+)
+
+// SnapshotEntry is a single item of a cache snapshot.
+type SnapshotEntry struct {
+	Key       string
+	Value     interface{}
+	TTL       time.Duration
+	ExpiresAt time.Time
+}
+
+// SnapshotCodec encodes and decodes cache snapshots as a stream of entries.
+//
+// Implementations have to be registered with RegisterSnapshotCodec to be
+// able to load snapshots they have encoded.
+type SnapshotCodec interface {
+	// Name identifies the codec in the snapshot header.
+	Name() string
+	NewEncoder(w io.Writer) SnapshotEncoder
+	NewDecoder(r io.Reader) SnapshotDecoder
+}
+
+// SnapshotEncoder writes snapshot entries one by one.
+type SnapshotEncoder interface {
+	Encode(entry SnapshotEntry) error
+}
+
+// SnapshotDecoder reads snapshot entries one by one.
+// Decode returns io.EOF when there are no more entries.
+type SnapshotDecoder interface {
+	Decode(entry *SnapshotEntry) error
+}
+
+// GobCodec encodes snapshots with encoding/gob. It's registered by default.
+//
+// Concrete types of stored values, except the basic ones, have to be
+// registered with gob.Register before saving or loading snapshots.
+var GobCodec SnapshotCodec = gobCodec{}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]SnapshotCodec{}
+)
+
+func init() {
+	RegisterSnapshotCodec(GobCodec)
+}
+
+// RegisterSnapshotCodec makes the codec available to LoadSnapshot by its name.
+// If a codec with the same name is already registered, it's replaced.
+func RegisterSnapshotCodec(codec SnapshotCodec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	codecs[codec.Name()] = codec
+}
+
+// SnapshotCodecByName returns the registered codec with the given name.
+func SnapshotCodecByName(name string) (SnapshotCodec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	codec, ok := codecs[name]
+	return codec, ok
+}
+
+// snapshotBatchSize is the number of items SaveSnapshot copies at once.
+const snapshotBatchSize = 1024
+
+// SaveSnapshot writes all items that haven't expired yet to w using codec.
+// The snapshot starts with a header that identifies the codec, so it can be
+// loaded without knowing the codec in advance.
+//
+// Items are copied in small batches, and the lock is released while a batch
+// is encoded, so saving a large cache doesn't block writers for the whole
+// duration. As a result, the snapshot isn't a point-in-time view: an item
+// changed while the snapshot is saved may be written with either value, and
+// items added meanwhile are missing.
+func (c *Cache) SaveSnapshot(w io.Writer, codec SnapshotCodec) error {
+	keys := c.snapshotKeys()
+
+	if _, err := io.WriteString(w, snapshotHeader(snapshotVersion, codec.Name())); err != nil {
+		return err
+	}
+
+	encoder := codec.NewEncoder(w)
+
+	for len(keys) > 0 {
+		n := snapshotBatchSize
+		if n > len(keys) {
+			n = len(keys)
+		}
+
+		if err := c.encodeSnapshotEntries(encoder, c.snapshotEntries(keys[:n])); err != nil {
+			return err
+		}
+
+		keys = keys[n:]
+	}
+
+	return nil
+}
+
+func (c *Cache) encodeSnapshotEntries(encoder SnapshotEncoder, entries []SnapshotEntry) error {
+	for _, entry := range entries {
+		// Values are decompressed outside the lock.
+		entry.Value = c.value(entry.Value)
+
+		if c.config.valueCodec != nil {
+			data, err := c.config.valueCodec.Encode(entry.Value)
+			if err != nil {
+				return fmt.Errorf("incache: encode value of snapshot entry %q: %w", entry.Key, err)
+			}
+
+			entry.Value = data
+		}
+
+		if err := encoder.Encode(entry); err != nil {
+			return fmt.Errorf("incache: encode snapshot entry %q: %w", entry.Key, err)
+		}
+	}
+
+	return nil
+}
+
+// LoadSnapshot reads a snapshot written by SaveSnapshot and stores its items
+// into the cache, overwriting the existing items with the same keys.
+// Items keep their original expiration time, already expired items are
+// skipped. The codec is picked by the name written in the snapshot header.
+//
+// Nothing is stored if the snapshot can't be decoded completely.
+func (c *Cache) LoadSnapshot(r io.Reader) error {
+	entries, _, err := ReadSnapshot(r)
+	if err != nil {
+		return err
+	}
+
+	if c.config.valueCodec != nil {
+		for i, entry := range entries {
+			data, ok := entry.Value.([]byte)
+			if !ok {
+				return fmt.Errorf("incache: decode value of snapshot entry %q: not encoded", entry.Key)
+			}
+
+			if entries[i].Value, err = c.config.valueCodec.Decode(data); err != nil {
+				return fmt.Errorf("incache: decode value of snapshot entry %q: %w", entry.Key, err)
+			}
+		}
+	}
+
+	c.mu.Lock()
+	defer c.unlock()
+
+	for _, entry := range entries {
+		item := Item{
+			Value:     entry.Value,
+			TTL:       entry.TTL,
+			ExpiresAt: monotonic(entry.ExpiresAt),
+		}
+
+		if item.Expired() {
+			continue
+		}
+
+		c.setItemLocked(entry.Key, item)
+	}
+
+	return nil
+}
+
+// ReadSnapshot decodes all entries of a snapshot written by SaveSnapshot
+// without loading them into a cache, e.g. to inspect them. It returns the
+// entries, including the expired ones, along with the name of the codec.
+func ReadSnapshot(r io.Reader) ([]SnapshotEntry, string, error) {
+	br := bufio.NewReader(r)
+
+	header, err := br.ReadString('\n')
+	if err != nil {
+		return nil, "", ErrInvalidSnapshot
+	}
+
+	version, name, err := parseSnapshotHeader(header)
+	if err != nil {
+		return nil, name, err
+	}
+
+	codec, ok := SnapshotCodecByName(name)
+	if !ok {
+		return nil, name, fmt.Errorf("%w: %q", ErrUnknownSnapshotCodec, name)
+	}
+
+	var entries []SnapshotEntry
+
+	decoder := codec.NewDecoder(br)
+	for {
+		var entry SnapshotEntry
+
+		err := decoder.Decode(&entry)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, name, fmt.Errorf("incache: decode snapshot entry: %w", err)
+		}
+
+		for v := version; v < snapshotVersion; v++ {
+			if err := snapshotMigrations[v](&entry); err != nil {
+				return nil, name, fmt.Errorf("incache: migrate snapshot entry from version %d: %w", v, err)
+			}
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, name, nil
+}
+
+// snapshotHeader returns the first line of a snapshot.
+func snapshotHeader(version int, codec string) string {
+	return snapshotHeaderPrefix + "/" + strconv.Itoa(version) + ":" + codec + "\n"
+}
+
+// parseSnapshotHeader returns the format version and the codec name of the
+// snapshot with the given first line.
+func parseSnapshotHeader(header string) (int, string, error) {
+	rest := strings.TrimPrefix(header, snapshotHeaderPrefix)
+	if rest == header || !strings.HasSuffix(rest, "\n") {
+		return 0, "", ErrInvalidSnapshot
+	}
+	rest = strings.TrimSuffix(rest, "\n")
+
+	// Unversioned header of the first format.
+	if strings.HasPrefix(rest, ":") {
+		return 1, rest[1:], nil
+	}
+
+	rest = strings.TrimPrefix(rest, "/")
+	i := strings.IndexByte(rest, ':')
+	if i < 0 {
+		return 0, "", ErrInvalidSnapshot
+	}
+
+	version, err := strconv.Atoi(rest[:i])
+	if err != nil {
+		return 0, "", ErrInvalidSnapshot
+	}
+	name := rest[i+1:]
+
+	if version < 1 || version > snapshotVersion {
+		return version, name, fmt.Errorf("%w: %d, supported up to %d", ErrIncompatibleSnapshot, version, snapshotVersion)
+	}
+	for v := version; v < snapshotVersion; v++ {
+		if snapshotMigrations[v] == nil {
+			return version, name, fmt.Errorf("%w: no migration from version %d", ErrIncompatibleSnapshot, v)
+		}
+	}
+
+	return version, name, nil
+}
+
+// snapshotKeys returns the keys of all items.
+func (c *Cache) snapshotKeys() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	keys := make([]string, 0, len(c.items))
+	for key := range c.items {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// snapshotEntries returns the entries of the keys that still exist and
+// haven't expired yet. Values are returned as stored, possibly compressed.
+func (c *Cache) snapshotEntries(keys []string) []SnapshotEntry {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entries := make([]SnapshotEntry, 0, len(keys))
+	for _, key := range keys {
+		item, ok := c.items[key]
+		if !ok || item.Expired() {
+			continue
+		}
+
+		entries = append(entries, SnapshotEntry{
+			Key:       key,
+			Value:     item.Value,
+			TTL:       item.TTL,
+			ExpiresAt: item.ExpiresAt,
+		})
+	}
+
+	return entries
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Name() string {
+	return "gob"
+}
+
+func (gobCodec) NewEncoder(w io.Writer) SnapshotEncoder {
+	return &gobEncoder{enc: gob.NewEncoder(w)}
+}
+
+func (gobCodec) NewDecoder(r io.Reader) SnapshotDecoder {
+	return &gobDecoder{dec: gob.NewDecoder(r)}
+}
+
+type gobEncoder struct {
+	enc *gob.Encoder
+}
+
+func (e *gobEncoder) Encode(entry SnapshotEntry) error {
+	return e.enc.Encode(entry)
+}
+
+type gobDecoder struct {
+	dec *gob.Decoder
+}
+
+func (d *gobDecoder) Decode(entry *SnapshotEntry) error {
+	return d.dec.Decode(entry)
+}
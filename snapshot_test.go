@@ -0,0 +1,199 @@
+package incache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type snapshotValue struct {
+	Name string
+}
+
+func init() {
+	gob.Register(snapshotValue{})
+}
+
+func TestSnapshotGob(t *testing.T) {
+	source := New(WithCleanupInterval(0))
+
+	source.SetWithTTL("key1", "value1", time.Minute)
+	source.SetWithTTL("key2", 2, 0)
+	source.SetWithTTL("key3", snapshotValue{Name: "value3"}, 0)
+	source.SetWithTTL("key4", "expired", time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+
+	var buf bytes.Buffer
+	require.NoError(t, source.SaveSnapshot(&buf, GobCodec))
+	assert.True(t, strings.HasPrefix(buf.String(), "incache/2:gob\n"))
+
+	cache := New()
+	require.NoError(t, cache.LoadSnapshot(&buf))
+
+	assert.Equal(t, 3, cache.Len())
+	assert.Equal(t, "value1", cache.Get("key1"))
+	assert.Equal(t, 2, cache.Get("key2"))
+	assert.Equal(t, snapshotValue{Name: "value3"}, cache.Get("key3"))
+	assert.True(t, source.items["key1"].ExpiresAt.Round(0).Equal(cache.items["key1"].ExpiresAt))
+	assert.False(t, cache.items["key2"].CanExpire())
+}
+
+// jsonLinesCodec is a custom codec used to check codecs registration.
+type jsonLinesCodec struct{}
+
+func (jsonLinesCodec) Name() string { return "jsonlines" }
+
+func (jsonLinesCodec) NewEncoder(w io.Writer) SnapshotEncoder {
+	return jsonLinesEncoder{enc: json.NewEncoder(w)}
+}
+
+func (jsonLinesCodec) NewDecoder(r io.Reader) SnapshotDecoder {
+	return jsonLinesDecoder{dec: json.NewDecoder(r)}
+}
+
+type jsonLinesEncoder struct{ enc *json.Encoder }
+
+func (e jsonLinesEncoder) Encode(entry SnapshotEntry) error { return e.enc.Encode(entry) }
+
+type jsonLinesDecoder struct{ dec *json.Decoder }
+
+func (d jsonLinesDecoder) Decode(entry *SnapshotEntry) error { return d.dec.Decode(entry) }
+
+func TestSnapshotCustomCodec(t *testing.T) {
+	source := New()
+	source.Set("key1", "value1")
+
+	var buf bytes.Buffer
+	require.NoError(t, source.SaveSnapshot(&buf, jsonLinesCodec{}))
+
+	snapshot := buf.Bytes()
+
+	cache := New()
+	assert.ErrorIs(t, cache.LoadSnapshot(bytes.NewReader(snapshot)), ErrUnknownSnapshotCodec)
+
+	RegisterSnapshotCodec(jsonLinesCodec{})
+
+	codec, ok := SnapshotCodecByName("jsonlines")
+	require.True(t, ok)
+	assert.Equal(t, jsonLinesCodec{}, codec)
+
+	require.NoError(t, cache.LoadSnapshot(bytes.NewReader(snapshot)))
+	assert.Equal(t, "value1", cache.Get("key1"))
+}
+
+func TestLoadSnapshotErrors(t *testing.T) {
+	cache := New()
+
+	assert.ErrorIs(t, cache.LoadSnapshot(strings.NewReader("")), ErrInvalidSnapshot)
+	assert.ErrorIs(t, cache.LoadSnapshot(strings.NewReader("garbage\n")), ErrInvalidSnapshot)
+	assert.Error(t, cache.LoadSnapshot(strings.NewReader("incache/2:gob\ngarbage")))
+	assert.Zero(t, cache.Len())
+}
+
+func TestReadSnapshot(t *testing.T) {
+	cache := New()
+	cache.Set("key1", "value1")
+	cache.SetWithTTL("key2", 2, time.Millisecond)
+
+	var buf bytes.Buffer
+	require.NoError(t, cache.SaveSnapshot(&buf, GobCodec))
+
+	time.Sleep(5 * time.Millisecond)
+
+	entries, codec, err := ReadSnapshot(&buf)
+	require.NoError(t, err)
+
+	assert.Equal(t, "gob", codec)
+	assert.Len(t, entries, 2)
+
+	_, codec, err = ReadSnapshot(strings.NewReader("incache/2:unknown\n"))
+	assert.ErrorIs(t, err, ErrUnknownSnapshotCodec)
+	assert.Equal(t, "unknown", codec)
+}
+
+func TestLoadSnapshotVersion1(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString("incache:gob\n")
+
+	encoder := GobCodec.NewEncoder(&buf)
+	require.NoError(t, encoder.Encode(SnapshotEntry{Key: "key1", Value: "value1"}))
+	require.NoError(t, encoder.Encode(SnapshotEntry{Key: "key2", Value: 2, TTL: time.Hour, ExpiresAt: time.Now().Add(time.Hour)}))
+
+	cache := New()
+	require.NoError(t, cache.LoadSnapshot(&buf))
+
+	assert.Equal(t, "value1", cache.Get("key1"))
+	assert.Equal(t, 2, cache.Get("key2"))
+}
+
+func TestReadSnapshotIncompatibleVersion(t *testing.T) {
+	_, codec, err := ReadSnapshot(strings.NewReader("incache/3:gob\n"))
+	assert.ErrorIs(t, err, ErrIncompatibleSnapshot)
+	assert.Equal(t, "gob", codec)
+
+	_, _, err = ReadSnapshot(strings.NewReader("incache/0:gob\n"))
+	assert.ErrorIs(t, err, ErrIncompatibleSnapshot)
+
+	_, _, err = ReadSnapshot(strings.NewReader("incache/x:gob\n"))
+	assert.ErrorIs(t, err, ErrInvalidSnapshot)
+}
+
+// blockingWriter blocks the first write after the header until release is
+// closed.
+type blockingWriter struct {
+	bytes.Buffer
+	writes  int
+	blocked chan struct{}
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	if w.writes == 2 {
+		close(w.blocked)
+		<-w.release
+	}
+
+	return w.Buffer.Write(p)
+}
+
+func TestSaveSnapshotDoesNotBlockWriters(t *testing.T) {
+	cache := New(WithTTL(0))
+	for i := 0; i < 3*snapshotBatchSize; i++ {
+		cache.Set(fmt.Sprint("key", i), i)
+	}
+
+	w := &blockingWriter{blocked: make(chan struct{}), release: make(chan struct{})}
+
+	done := make(chan error)
+	go func() {
+		done <- cache.SaveSnapshot(w, GobCodec)
+	}()
+
+	<-w.blocked
+
+	// Writers aren't blocked while the first batch is encoded.
+	cache.FlushAll()
+	cache.Set("new", "value")
+
+	close(w.release)
+	require.NoError(t, <-done)
+
+	// Items deleted meanwhile aren't saved, added ones are missing.
+	entries, _, err := ReadSnapshot(&w.Buffer)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(entries), snapshotBatchSize)
+	assert.NotZero(t, len(entries))
+
+	for _, entry := range entries {
+		assert.NotEqual(t, "new", entry.Key)
+	}
+}
@@ -0,0 +1,102 @@
+package incache
+
+import (
+	"sort"
+	"time"
+)
+
+// SortOrder is the order of keys returned by Cache.KeysSorted.
+type SortOrder int
+
+const (
+	// SortByKey orders keys lexicographically.
+	SortByKey SortOrder = iota
+	// SortByExpiration orders keys by their expiration time, the ones that
+	// expire soonest first and the ones that never expire last.
+	SortByExpiration
+	// SortByInsertion orders keys by the time they were stored or last
+	// updated, the oldest first.
+	SortByInsertion
+	// SortByAccessCount orders keys by the number of their reads, the most
+	// read first. The reads are only counted with WithAccessTracking.
+	SortByAccessCount
+)
+
+// KeysSorted returns slice of all keys in the given order, e.g. for debug
+// views and eviction tooling. Keys that are equal in the order are sorted
+// lexicographically. Keys of expired items that weren't removed yet aren't
+// included.
+func (c *Cache) KeysSorted(by SortOrder) []string {
+	c.mu.RLock()
+
+	items := make([]keyedItem, 0, len(c.items))
+
+	timeNow := time.Now()
+	for key, item := range c.items {
+		if !item.expiredAt(timeNow) {
+			items = append(items, keyedItem{key: key, item: item})
+		}
+	}
+
+	c.mu.RUnlock()
+
+	less := sortLess(by)
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if less(a.item, b.item) {
+			return true
+		}
+
+		if less(b.item, a.item) {
+			return false
+		}
+
+		return a.key < b.key
+	})
+
+	keys := make([]string, len(items))
+	for i, ki := range items {
+		keys[i] = ki.key
+	}
+
+	return keys
+}
+
+// sortLess returns the function that reports whether a goes before b in
+// the order, ignoring keys.
+func sortLess(by SortOrder) func(a, b Item) bool {
+	switch by {
+	case SortByExpiration:
+		return func(a, b Item) bool {
+			if !a.CanExpire() || !b.CanExpire() {
+				return a.CanExpire() && !b.CanExpire()
+			}
+
+			return a.ExpiresAt.Before(b.ExpiresAt)
+		}
+	case SortByInsertion:
+		return func(a, b Item) bool {
+			return a.version < b.version
+		}
+	case SortByAccessCount:
+		return func(a, b Item) bool {
+			return accessCount(a) > accessCount(b)
+		}
+	default:
+		return func(a, b Item) bool {
+			return false
+		}
+	}
+}
+
+// accessCount returns the number of reads of the item, see
+// WithAccessTracking.
+func accessCount(item Item) uint64 {
+	if item.access == nil {
+		return 0
+	}
+
+	_, hits := item.access.load()
+
+	return hits
+}
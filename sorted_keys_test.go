@@ -0,0 +1,41 @@
+package incache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeysSorted(t *testing.T) {
+	cache := New(WithTTL(0), WithAccessTracking())
+	defer cache.Close()
+
+	cache.Set("c", 1)
+	cache.SetWithTTL("a", 2, time.Hour)
+	cache.SetWithTTL("d", 3, time.Minute)
+	cache.Set("b", 4)
+
+	cache.Get("b")
+	cache.Get("b")
+	cache.Get("d")
+
+	assert.Equal(t, []string{"a", "b", "c", "d"}, cache.KeysSorted(SortByKey))
+	assert.Equal(t, []string{"d", "a", "b", "c"}, cache.KeysSorted(SortByExpiration))
+	assert.Equal(t, []string{"c", "a", "d", "b"}, cache.KeysSorted(SortByInsertion))
+	assert.Equal(t, []string{"b", "d", "a", "c"}, cache.KeysSorted(SortByAccessCount))
+
+	cache.Set("c", 5)
+	assert.Equal(t, []string{"a", "d", "b", "c"}, cache.KeysSorted(SortByInsertion))
+}
+
+func TestKeysSortedSkipsExpired(t *testing.T) {
+	cache := New(WithTTL(0), WithCleanupInterval(0))
+	defer cache.Close()
+
+	cache.Set("a", 1)
+	cache.SetWithTTL("b", 2, time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+
+	assert.Equal(t, []string{"a"}, cache.KeysSorted(SortByExpiration))
+}
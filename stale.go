@@ -0,0 +1,36 @@
+package incache
+
+// GetStale returns the value of key, including an expired value that wasn't
+// removed by the cleaner yet, which is flagged as stale. It allows to serve
+// expired values when the origin is unavailable.
+//
+// Stale values are counted as misses in metrics.
+// If the key doesn't exist, nil value, false and false are returned.
+func (c *Cache) GetStale(key string) (value interface{}, stale bool, ok bool) {
+	c.hooks.beforeGet(key)
+
+	value, stale, ok = c.lookupStale(key)
+	c.hooks.afterGet(key, value)
+
+	return value, stale, ok
+}
+
+func (c *Cache) lookupStale(key string) (interface{}, bool, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	item, ok := c.items[key]
+	if !ok {
+		c.countMiss(key)
+		return nil, false, false
+	}
+
+	if item.Expired() {
+		c.countMiss(key)
+		return c.copyValue(c.value(item.Value)), true, true
+	}
+
+	c.countHit(key)
+
+	return c.copyValue(c.value(item.Value)), false, true
+}
@@ -0,0 +1,42 @@
+package incache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetStale(t *testing.T) {
+	cache := New(WithTTL(0), WithCleanupInterval(0), WithMetrics())
+
+	value, stale, ok := cache.GetStale("key1")
+	assert.Nil(t, value)
+	assert.False(t, stale)
+	assert.False(t, ok)
+
+	cache.Set("key1", "value1")
+
+	value, stale, ok = cache.GetStale("key1")
+	assert.Equal(t, "value1", value)
+	assert.False(t, stale)
+	assert.True(t, ok)
+
+	cache.SetWithTTL("key2", "value2", time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+
+	assert.Nil(t, cache.Get("key2"))
+
+	value, stale, ok = cache.GetStale("key2")
+	assert.Equal(t, "value2", value)
+	assert.True(t, stale)
+	assert.True(t, ok)
+
+	cache.DeleteExpired()
+
+	_, _, ok = cache.GetStale("key2")
+	assert.False(t, ok)
+
+	assert.EqualValues(t, 1, cache.Metrics().Hits())
+	assert.EqualValues(t, 4, cache.Metrics().Misses())
+}
@@ -0,0 +1,123 @@
+package incache
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// csvHeader lists the columns written by CSVReporter.
+var csvHeader = []string{"timestamp", "hits", "misses", "evictions", "len", "bytes"}
+
+// CSVReporter is a MetricsReporter that appends a line of statistics to
+// a writer on every report, e.g. to analyze the effectiveness of the cache
+// during a load test. The columns are listed in the header, which is written
+// before the first line:
+//
+//	timestamp,hits,misses,evictions,len,bytes
+//	2024-01-01T12:00:00Z,150,20,0,130,16640
+//
+// Counters are reported as totals rather than deltas.
+type CSVReporter struct {
+	mu            sync.Mutex
+	w             *csv.Writer
+	headerWritten bool
+}
+
+var _ MetricsReporter = (*CSVReporter)(nil)
+
+// NewCSVReporter creates a reporter that writes CSV lines to w.
+func NewCSVReporter(w io.Writer) *CSVReporter {
+	return &CSVReporter{w: csv.NewWriter(w)}
+}
+
+// Report implements MetricsReporter.
+func (r *CSVReporter) Report(report MetricsReport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.headerWritten {
+		if err := r.w.Write(csvHeader); err != nil {
+			return err
+		}
+
+		r.headerWritten = true
+	}
+
+	err := r.w.Write([]string{
+		report.Time.UTC().Format(time.RFC3339),
+		strconv.FormatUint(report.Counters["hits"], 10),
+		strconv.FormatUint(report.Counters["misses"], 10),
+		strconv.FormatUint(report.Counters["evictions"], 10),
+		strconv.Itoa(report.Len),
+		strconv.FormatUint(report.MemoryUsage, 10),
+	})
+	if err != nil {
+		return err
+	}
+
+	r.w.Flush()
+
+	return r.w.Error()
+}
+
+// GraphiteReporter is a MetricsReporter that writes the statistics to
+// a writer in the Graphite plaintext protocol, one metric per line, e.g.
+// "cache.hits 150 1704110400". The writer can be a connection to Carbon.
+type GraphiteReporter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	prefix string
+}
+
+var _ MetricsReporter = (*GraphiteReporter)(nil)
+
+// NewGraphiteReporter creates a reporter that writes metrics prefixed with
+// prefix and a dot to w.
+func NewGraphiteReporter(w io.Writer, prefix string) *GraphiteReporter {
+	if prefix != "" && !strings.HasSuffix(prefix, ".") {
+		prefix += "."
+	}
+
+	return &GraphiteReporter{w: w, prefix: prefix}
+}
+
+// Report implements MetricsReporter. Counters are reported as totals.
+func (r *GraphiteReporter) Report(report MetricsReport) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var b strings.Builder
+
+	timestamp := report.Time.Unix()
+	for _, column := range csvHeader[1:] {
+		var value uint64
+
+		switch column {
+		case "len":
+			value = uint64(report.Len)
+		case "bytes":
+			value = report.MemoryUsage
+		default:
+			value = report.Counters[column]
+		}
+
+		fmt.Fprintf(&b, "%s%s %d %d\n", r.prefix, column, value, timestamp)
+	}
+
+	_, err := io.WriteString(r.w, b.String())
+
+	return err
+}
+
+// WithStatsDump makes the cache append a CSV line of its statistics to w
+// every interval, see CSVReporter. To dump the statistics to a file, open
+// it with os.O_APPEND. Metrics have to be enabled with WithMetrics to get
+// non zero counters.
+func WithStatsDump(w io.Writer, interval time.Duration) configFunc {
+	return WithMetricsReporter(NewCSVReporter(w), interval)
+}
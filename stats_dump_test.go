@@ -0,0 +1,87 @@
+package incache
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSVReporter(t *testing.T) {
+	var buf bytes.Buffer
+	reporter := NewCSVReporter(&buf)
+
+	report := MetricsReport{
+		Time:        time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+		Len:         130,
+		MemoryUsage: 16640,
+		Counters:    map[string]uint64{"hits": 150, "misses": 20},
+	}
+
+	require.NoError(t, reporter.Report(report))
+	require.NoError(t, reporter.Report(report))
+
+	assert.Equal(t, "timestamp,hits,misses,evictions,len,bytes\n"+
+		"2024-01-01T12:00:00Z,150,20,0,130,16640\n"+
+		"2024-01-01T12:00:00Z,150,20,0,130,16640\n", buf.String())
+}
+
+func TestGraphiteReporter(t *testing.T) {
+	var buf bytes.Buffer
+	reporter := NewGraphiteReporter(&buf, "cache")
+
+	err := reporter.Report(MetricsReport{
+		Time:        time.Unix(1704110400, 0),
+		Len:         3,
+		MemoryUsage: 1024,
+		Counters:    map[string]uint64{"hits": 5},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "cache.hits 5 1704110400\n"+
+		"cache.misses 0 1704110400\n"+
+		"cache.evictions 0 1704110400\n"+
+		"cache.len 3 1704110400\n"+
+		"cache.bytes 1024 1704110400\n", buf.String())
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func TestWithStatsDump(t *testing.T) {
+	var buf syncBuffer
+
+	cache := New(WithMetrics(), WithStatsDump(&buf, time.Millisecond))
+	cache.Set("key1", "value1")
+	cache.Get("key1")
+
+	assert.Eventually(t, func() bool {
+		return strings.Count(buf.String(), "\n") >= 3
+	}, time.Second, time.Millisecond)
+
+	cache.Close()
+
+	lines := strings.Split(buf.String(), "\n")
+	assert.Equal(t, "timestamp,hits,misses,evictions,len,bytes", lines[0])
+	assert.Contains(t, lines[1], ",1,0,0,1,")
+}
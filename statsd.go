@@ -0,0 +1,83 @@
+package incache
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// StatsDReporter is a MetricsReporter that sends the statistics to a StatsD
+// server over UDP, e.g. the Datadog agent. Counters are sent as StatsD
+// counters by their increase since the previous report, the number of items
+// and the memory usage are sent as gauges.
+type StatsDReporter struct {
+	conn   net.Conn
+	prefix string
+	// Tags are attached to all metrics in the DogStatsD format,
+	// e.g. "env:prod".
+	Tags []string
+}
+
+var _ MetricsReporter = (*StatsDReporter)(nil)
+
+// NewStatsDReporter creates a reporter that sends metrics to the StatsD
+// server at addr, e.g. "127.0.0.1:8125". Names of the metrics are prefixed
+// with prefix and a dot, e.g. "cache.hits".
+func NewStatsDReporter(addr, prefix string) (*StatsDReporter, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("incache: dial statsd: %w", err)
+	}
+
+	if prefix != "" && !strings.HasSuffix(prefix, ".") {
+		prefix += "."
+	}
+
+	return &StatsDReporter{
+		conn:   conn,
+		prefix: prefix,
+	}, nil
+}
+
+// Report implements MetricsReporter. All metrics are sent in one packet.
+func (r *StatsDReporter) Report(report MetricsReport) error {
+	var buf bytes.Buffer
+
+	names := make([]string, 0, len(report.Deltas))
+	for name := range report.Deltas {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		r.write(&buf, name, report.Deltas[name], "c")
+	}
+
+	r.write(&buf, "items", uint64(report.Len), "g")
+	r.write(&buf, "memory_usage", report.MemoryUsage, "g")
+
+	_, err := r.conn.Write(buf.Bytes())
+
+	return err
+}
+
+func (r *StatsDReporter) write(buf *bytes.Buffer, name string, value uint64, kind string) {
+	if buf.Len() > 0 {
+		buf.WriteByte('\n')
+	}
+
+	fmt.Fprintf(buf, "%s%s:%d|%s", r.prefix, name, value, kind)
+
+	if len(r.Tags) > 0 {
+		buf.WriteString("|#")
+		buf.WriteString(strings.Join(r.Tags, ","))
+	}
+}
+
+// Close closes the connection to the server.
+func (r *StatsDReporter) Close() error {
+	return r.conn.Close()
+}
@@ -0,0 +1,40 @@
+package incache
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsDReporter(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	reporter, err := NewStatsDReporter(conn.LocalAddr().String(), "cache")
+	require.NoError(t, err)
+	defer reporter.Close()
+
+	reporter.Tags = []string{"env:test"}
+
+	err = reporter.Report(MetricsReport{
+		Len:         3,
+		MemoryUsage: 1024,
+		Deltas:      map[string]uint64{"misses": 1, "hits": 5},
+	})
+	require.NoError(t, err)
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+
+	assert.Equal(t, "cache.hits:5|c|#env:test\n"+
+		"cache.misses:1|c|#env:test\n"+
+		"cache.items:3|g|#env:test\n"+
+		"cache.memory_usage:1024|g|#env:test", string(buf[:n]))
+}
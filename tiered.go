@@ -0,0 +1,107 @@
+package incache
+
+import (
+	"context"
+	"time"
+)
+
+// TieredCache composes the in-memory cache (L1) with a backend (L2),
+// e.g. a Redis adapter.
+//
+// Misses in L1 fall through to L2, and values found in L2 are promoted
+// into L1. Writes populate both tiers.
+type TieredCache struct {
+	l1 *Cache
+	l2 Backend
+}
+
+// Tiered creates new two-tier cache.
+func Tiered(l1 *Cache, l2 Backend) *TieredCache {
+	return &TieredCache{
+		l1: l1,
+		l2: l2,
+	}
+}
+
+// L1 returns the in-memory tier.
+func (t *TieredCache) L1() *Cache {
+	return t.l1
+}
+
+// L2 returns the backend tier.
+func (t *TieredCache) L2() Backend {
+	return t.l2
+}
+
+// Get returns the value of key from L1, or from L2 if it's missing in L1.
+// A value found in L2 is stored in L1 for the remaining TTL reported by L2,
+// but not longer than the default TTL of L1.
+//
+// ErrNotFound is returned if the key doesn't exist in both tiers.
+func (t *TieredCache) Get(ctx context.Context, key string) (interface{}, error) {
+	if value := t.l1.Get(key); value != nil {
+		return value, nil
+	}
+
+	value, ttl, err := t.l2.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if value == nil {
+		return nil, ErrNotFound
+	}
+
+	t.l1.SetWithTTL(key, value, t.promotionTTL(ttl))
+
+	return value, nil
+}
+
+// Set stores the value of key in both tiers with the default TTL of L1.
+func (t *TieredCache) Set(ctx context.Context, key string, value interface{}) error {
+	return t.SetWithTTL(ctx, key, value, t.l1.defaultTTL())
+}
+
+// SetWithTTL stores the value of key in both tiers for ttl.
+// L2 is written first, so L1 never holds a value that L2 has rejected.
+func (t *TieredCache) SetWithTTL(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	if ttl < 0 {
+		ttl = 0
+	}
+
+	if err := t.l2.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+
+	t.l1.SetWithTTL(key, value, ttl)
+
+	return nil
+}
+
+// Delete deletes the value of key from both tiers.
+// L2 is deleted first, so if it fails, L1 keeps the value that is still
+// in L2 rather than promoting it again on the next miss.
+func (t *TieredCache) Delete(ctx context.Context, key string) error {
+	if err := t.l2.Delete(ctx, key); err != nil {
+		return err
+	}
+
+	t.l1.Delete(key)
+
+	return nil
+}
+
+// promotionTTL returns the TTL of a value promoted from L2 into L1.
+func (t *TieredCache) promotionTTL(l2TTL time.Duration) time.Duration {
+	l1TTL := t.l1.defaultTTL()
+
+	if l2TTL <= 0 {
+		return l1TTL
+	}
+
+	if l1TTL > 0 && l1TTL < l2TTL {
+		return l1TTL
+	}
+
+	return l2TTL
+}
@@ -0,0 +1,95 @@
+package incache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTieredGet(t *testing.T) {
+	ctx := context.Background()
+
+	l1 := New(WithTTL(time.Minute))
+	l2 := newMemoryBackend()
+	tiered := Tiered(l1, l2)
+
+	l1.Set("key1", "value1")
+	require.NoError(t, l2.Set(ctx, "key2", "value2", time.Hour))
+	require.NoError(t, l2.Set(ctx, "key3", "value3", time.Second))
+
+	value, err := tiered.Get(ctx, "key1")
+	require.NoError(t, err)
+	assert.Equal(t, "value1", value)
+
+	value, err = tiered.Get(ctx, "key2")
+	require.NoError(t, err)
+	assert.Equal(t, "value2", value)
+
+	info, ok := l1.Inspect("key2")
+	require.True(t, ok, "value must be promoted into L1")
+	assert.Equal(t, time.Minute, info.TTL)
+
+	_, err = tiered.Get(ctx, "key3")
+	require.NoError(t, err)
+
+	info, _ = l1.Inspect("key3")
+	assert.LessOrEqual(t, info.TTL, time.Second)
+
+	_, err = tiered.Get(ctx, "key4")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestTieredSetAndDelete(t *testing.T) {
+	ctx := context.Background()
+
+	l1 := New(WithTTL(time.Minute))
+	l2 := newMemoryBackend()
+	tiered := Tiered(l1, l2)
+
+	assert.Same(t, l1, tiered.L1())
+	assert.Equal(t, l2, tiered.L2())
+
+	require.NoError(t, tiered.Set(ctx, "key1", "value1"))
+	require.NoError(t, tiered.SetWithTTL(ctx, "key2", "value2", -1))
+
+	assert.Equal(t, "value1", l1.Get("key1"))
+	value, ttl, err := l2.Get(ctx, "key1")
+	require.NoError(t, err)
+	assert.Equal(t, "value1", value)
+	assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+
+	_, ttl, err = l2.Get(ctx, "key2")
+	require.NoError(t, err)
+	assert.Zero(t, ttl)
+
+	require.NoError(t, tiered.Delete(ctx, "key1"))
+	assert.Nil(t, l1.Get("key1"))
+	_, ok := l2.get("key1")
+	assert.False(t, ok)
+}
+
+func TestTieredBackendErrors(t *testing.T) {
+	ctx := context.Background()
+
+	l1 := New()
+	l2 := newMemoryBackend()
+	tiered := Tiered(l1, l2)
+
+	l2.setDown(true)
+
+	_, err := tiered.Get(ctx, "key1")
+	assert.ErrorIs(t, err, errBackendDown)
+
+	assert.ErrorIs(t, tiered.Set(ctx, "key1", "value1"), errBackendDown)
+	assert.False(t, l1.Has("key1"), "L1 must not be populated if L2 failed")
+
+	l2.setDown(false)
+	require.NoError(t, tiered.Set(ctx, "key1", "value1"))
+	l2.setDown(true)
+
+	assert.ErrorIs(t, tiered.Delete(ctx, "key1"), errBackendDown)
+	assert.True(t, l1.Has("key1"), "L1 must keep the value if L2 failed")
+}
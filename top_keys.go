@@ -0,0 +1,199 @@
+package incache
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+)
+
+const (
+	// Dimensions of the count-min sketch. With these values the counts
+	// are overestimated by at most 0.13% of all reads with 98% probability.
+	sketchDepth = 4
+	sketchWidth = 2048
+	// The maximum number of keys tracked by TopKeys.
+	topKeysCapacity = 100
+)
+
+// KeyCount is a key along with the approximate number of its reads,
+// see TopKeys.
+type KeyCount struct {
+	Key   string
+	Count uint64
+}
+
+// TopKeys returns up to n most read keys with approximate numbers of their
+// successful reads, sorted by the number of reads in descending order.
+// At most 100 keys are tracked. Keys are only tracked when metrics are
+// enabled, see WithMetrics, otherwise nil is returned.
+//
+// Keys that were deleted afterwards are still reported, since the numbers
+// of reads are kept until ResetMetrics.
+//
+// Without metrics, but with WithAccessTracking, the exact numbers of reads
+// of the stored items are reported instead.
+func (c *Cache) TopKeys(n int) []KeyCount {
+	if c.topKeys == nil {
+		if c.config.accessTracking {
+			return c.mostAccessed(n)
+		}
+
+		return nil
+	}
+
+	return c.topKeys.top(n)
+}
+
+// topKeys counts reads of keys with a count-min sketch and keeps the keys
+// with the highest counts in a min-heap.
+type topKeys struct {
+	mu     sync.Mutex
+	sketch [sketchDepth][sketchWidth]uint32
+	heap   topKeysHeap
+	index  map[string]*topKeysEntry
+}
+
+type topKeysEntry struct {
+	KeyCount
+	// Position of the entry in the heap.
+	pos int
+}
+
+func newTopKeys() *topKeys {
+	return &topKeys{
+		index: make(map[string]*topKeysEntry),
+	}
+}
+
+// record counts a read of the key.
+func (t *topKeys) record(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	count := t.increment(key)
+
+	if entry, ok := t.index[key]; ok {
+		entry.Count = count
+		heap.Fix(&t.heap, entry.pos)
+
+		return
+	}
+
+	if len(t.heap) < topKeysCapacity {
+		entry := &topKeysEntry{KeyCount: KeyCount{Key: key, Count: count}}
+		t.index[key] = entry
+		heap.Push(&t.heap, entry)
+
+		return
+	}
+
+	// The key with the lowest count is replaced, its entry is reused.
+	if min := t.heap[0]; count > min.Count {
+		delete(t.index, min.Key)
+
+		min.KeyCount = KeyCount{Key: key, Count: count}
+		t.index[key] = min
+		heap.Fix(&t.heap, 0)
+	}
+}
+
+// increment increments the counters of the key in the sketch and returns
+// the estimated count, which is the minimum of them.
+func (t *topKeys) increment(key string) uint64 {
+	h1, h2 := sketchHashes(key)
+
+	var estimate uint32
+	for i := 0; i < sketchDepth; i++ {
+		j := (h1 + uint32(i)*h2) % sketchWidth
+
+		if t.sketch[i][j] < ^uint32(0) {
+			t.sketch[i][j]++
+		}
+
+		if i == 0 || t.sketch[i][j] < estimate {
+			estimate = t.sketch[i][j]
+		}
+	}
+
+	return uint64(estimate)
+}
+
+func (t *topKeys) top(n int) []KeyCount {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	keys := make([]KeyCount, 0, len(t.heap))
+	for _, entry := range t.heap {
+		keys = append(keys, entry.KeyCount)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Count != keys[j].Count {
+			return keys[i].Count > keys[j].Count
+		}
+
+		return keys[i].Key < keys[j].Key
+	})
+
+	if n < len(keys) {
+		keys = keys[:n]
+	}
+
+	return keys
+}
+
+func (t *topKeys) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.sketch = [sketchDepth][sketchWidth]uint32{}
+	t.heap = nil
+	t.index = make(map[string]*topKeysEntry)
+}
+
+// sketchHashes returns two halves of the 64-bit FNV-1a hash of the key,
+// which are combined into the hashes of the sketch rows. The hash is
+// computed inline, so it doesn't allocate.
+func sketchHashes(key string) (uint32, uint32) {
+	const (
+		offset = 14695981039346656037
+		prime  = 1099511628211
+	)
+
+	h := uint64(offset)
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= prime
+	}
+
+	return uint32(h), uint32(h>>32) | 1
+}
+
+// topKeysHeap is a min-heap of keys by their counts.
+type topKeysHeap []*topKeysEntry
+
+func (h topKeysHeap) Len() int           { return len(h) }
+func (h topKeysHeap) Less(i, j int) bool { return h[i].Count < h[j].Count }
+
+func (h topKeysHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].pos = i
+	h[j].pos = j
+}
+
+func (h *topKeysHeap) Push(x any) {
+	entry := x.(*topKeysEntry)
+	entry.pos = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *topKeysHeap) Pop() any {
+	old := *h
+	n := len(old)
+
+	entry := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+
+	return entry
+}
@@ -0,0 +1,66 @@
+package incache
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopKeys(t *testing.T) {
+	cache := New(WithMetrics())
+
+	for i := 0; i < 200; i++ {
+		cache.Set(fmt.Sprint("key", i), i)
+	}
+
+	for i := 0; i < 200; i++ {
+		cache.Get(fmt.Sprint("key", i))
+	}
+
+	for i := 0; i < 10; i++ {
+		cache.Get("key5")
+	}
+
+	for i := 0; i < 5; i++ {
+		cache.Get("key150")
+	}
+
+	cache.Get("missing")
+
+	top := cache.TopKeys(2)
+	assert.Len(t, top, 2)
+	assert.Equal(t, "key5", top[0].Key)
+	assert.GreaterOrEqual(t, top[0].Count, uint64(11))
+	assert.Equal(t, "key150", top[1].Key)
+	assert.GreaterOrEqual(t, top[1].Count, uint64(6))
+
+	assert.Len(t, cache.TopKeys(1000), topKeysCapacity)
+
+	cache.ResetMetrics()
+	assert.Empty(t, cache.TopKeys(10))
+}
+
+func TestTopKeysWithoutMetrics(t *testing.T) {
+	cache := New()
+
+	cache.Set("key1", "value1")
+	cache.Get("key1")
+
+	assert.Nil(t, cache.TopKeys(10))
+}
+
+func TestCountMinSketch(t *testing.T) {
+	tk := newTopKeys()
+
+	for i := 0; i < 1000; i++ {
+		tk.record(fmt.Sprint("key", i%100))
+	}
+
+	for i := 0; i < 100; i++ {
+		count := tk.increment(fmt.Sprint("key", i))
+
+		// Counts are never underestimated.
+		assert.GreaterOrEqual(t, count, uint64(11))
+	}
+}
@@ -0,0 +1,202 @@
+package incache
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"io"
+	"sync"
+	"time"
+)
+
+// TraceOp is the operation of a trace record.
+type TraceOp uint8
+
+// Operations recorded by WithTraceRecorder.
+const (
+	TraceGet TraceOp = iota
+	TraceSet
+	TraceDelete
+)
+
+func (op TraceOp) String() string {
+	switch op {
+	case TraceGet:
+		return "get"
+	case TraceSet:
+		return "set"
+	case TraceDelete:
+		return "delete"
+	default:
+		return "unknown"
+	}
+}
+
+// TraceRecord is a single operation recorded by WithTraceRecorder.
+type TraceRecord struct {
+	Op TraceOp
+	// FNV-1a hash of the key, keys themselves aren't recorded.
+	KeyHash uint64
+	Time    time.Time
+	// Whether the read found the value, it's always false for writes.
+	Hit bool
+}
+
+// The trace starts with traceMagic followed by the version of the format.
+// Every record is encoded as:
+//
+//	op       1 byte, the highest bit is set for hits
+//	time     uvarint, nanoseconds since the previous record (since the Unix
+//	         epoch for the first one)
+//	key hash 8 bytes, little endian
+var traceMagic = []byte("ICTRACE")
+
+const (
+	traceVersion = 1
+	traceHitFlag = 0x80
+)
+
+// ErrInvalidTrace is returned by TraceReader for data that isn't a trace
+// recorded by WithTraceRecorder.
+var ErrInvalidTrace = errors.New("incache: invalid trace")
+
+// traceRecorder writes trace records. Writes are buffered, the first write
+// error stops the recording.
+type traceRecorder struct {
+	mu   sync.Mutex
+	w    *bufio.Writer
+	last int64
+	err  error
+	buf  [1 + binary.MaxVarintLen64 + 8]byte
+}
+
+func newTraceRecorder(w io.Writer) *traceRecorder {
+	r := &traceRecorder{w: bufio.NewWriter(w)}
+
+	r.w.Write(traceMagic)
+	r.w.WriteByte(traceVersion)
+
+	return r
+}
+
+func (r *traceRecorder) record(op TraceOp, key string, hit bool) {
+	now := time.Now().UnixNano()
+
+	h := fnv.New64a()
+	h.Write([]byte(key))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return
+	}
+
+	// The clock can go backwards, so the time of a record is never before
+	// the time of the previous one.
+	delta := now - r.last
+	if delta < 0 {
+		delta = 0
+	}
+	r.last += delta
+
+	r.buf[0] = byte(op)
+	if hit {
+		r.buf[0] |= traceHitFlag
+	}
+
+	n := 1 + binary.PutUvarint(r.buf[1:], uint64(delta))
+	binary.LittleEndian.PutUint64(r.buf[n:], h.Sum64())
+
+	_, r.err = r.w.Write(r.buf[:n+8])
+}
+
+func (r *traceRecorder) flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return r.err
+	}
+
+	r.err = r.w.Flush()
+
+	return r.err
+}
+
+// trace records the operation if WithTraceRecorder is used.
+func (c *Cache) trace(op TraceOp, key string, hit bool) {
+	if c.tracer != nil {
+		c.tracer.record(op, key, hit)
+	}
+}
+
+// WithTraceRecorder makes the cache record every read, write and deletion
+// of a key to w in a compact binary format, for offline analysis or replay
+// with the loadgen package. Keys are recorded as hashes. The trace is
+// buffered and flushed by Close, it's read with TraceReader.
+//
+// Recording stops after the first failed write to w.
+func WithTraceRecorder(w io.Writer) configFunc {
+	return func(c *Config) {
+		c.traceWriter = w
+	}
+}
+
+// TraceReader reads a trace recorded by WithTraceRecorder.
+type TraceReader struct {
+	r      *bufio.Reader
+	last   int64
+	header bool
+}
+
+// NewTraceReader returns a reader of the trace recorded to r.
+func NewTraceReader(r io.Reader) *TraceReader {
+	return &TraceReader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next record of the trace. It returns io.EOF when there
+// are no more records.
+func (t *TraceReader) Next() (TraceRecord, error) {
+	if !t.header {
+		header := make([]byte, len(traceMagic)+1)
+		if _, err := io.ReadFull(t.r, header); err != nil {
+			return TraceRecord{}, ErrInvalidTrace
+		}
+
+		if string(header[:len(traceMagic)]) != string(traceMagic) || header[len(traceMagic)] != traceVersion {
+			return TraceRecord{}, ErrInvalidTrace
+		}
+
+		t.header = true
+	}
+
+	op, err := t.r.ReadByte()
+	if err != nil {
+		return TraceRecord{}, err
+	}
+
+	delta, err := binary.ReadUvarint(t.r)
+	if err != nil {
+		return TraceRecord{}, ErrInvalidTrace
+	}
+
+	var hash [8]byte
+	if _, err := io.ReadFull(t.r, hash[:]); err != nil {
+		return TraceRecord{}, ErrInvalidTrace
+	}
+
+	if TraceOp(op&^traceHitFlag) > TraceDelete {
+		return TraceRecord{}, ErrInvalidTrace
+	}
+
+	t.last += int64(delta)
+
+	return TraceRecord{
+		Op:      TraceOp(op &^ traceHitFlag),
+		KeyHash: binary.LittleEndian.Uint64(hash[:]),
+		Time:    time.Unix(0, t.last),
+		Hit:     op&traceHitFlag != 0,
+	}, nil
+}
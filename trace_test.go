@@ -0,0 +1,120 @@
+package incache
+
+import (
+	"bytes"
+	"errors"
+	"hash/fnv"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func keyHash(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+
+	return h.Sum64()
+}
+
+func readTrace(t *testing.T, data []byte) []TraceRecord {
+	t.Helper()
+
+	var records []TraceRecord
+
+	reader := NewTraceReader(bytes.NewReader(data))
+	for {
+		record, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return records
+		}
+
+		assert.NoError(t, err)
+		records = append(records, record)
+	}
+}
+
+func TestTraceRecorder(t *testing.T) {
+	var buf bytes.Buffer
+
+	start := time.Now()
+	cache := New(WithTraceRecorder(&buf), WithCleanupInterval(0))
+
+	cache.Get("key1")
+	cache.Set("key1", "value1")
+	cache.Get("key1")
+	cache.Delete("key1")
+	cache.Close()
+
+	records := readTrace(t, buf.Bytes())
+	assert.Len(t, records, 4)
+
+	assert.Equal(t, TraceGet, records[0].Op)
+	assert.False(t, records[0].Hit)
+	assert.Equal(t, TraceSet, records[1].Op)
+	assert.Equal(t, TraceGet, records[2].Op)
+	assert.True(t, records[2].Hit)
+	assert.Equal(t, TraceDelete, records[3].Op)
+
+	for i, record := range records {
+		assert.Equal(t, keyHash("key1"), record.KeyHash)
+		assert.False(t, record.Time.Before(start.Truncate(time.Millisecond)))
+
+		if i > 0 {
+			assert.False(t, record.Time.Before(records[i-1].Time))
+		}
+	}
+
+	// The first record holds the full timestamp, the rest hold short deltas.
+	assert.Less(t, buf.Len(), 8+(1+9+8)+3*(1+4+8))
+}
+
+func TestTraceRecorderIsFlushedOnClose(t *testing.T) {
+	var buf bytes.Buffer
+
+	cache := New(WithTraceRecorder(&buf), WithCleanupInterval(0))
+	cache.Set("key1", "value1")
+
+	assert.Zero(t, buf.Len())
+
+	cache.Close()
+
+	assert.Len(t, readTrace(t, buf.Bytes()), 1)
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write")
+}
+
+func TestTraceRecorderStopsOnError(t *testing.T) {
+	recorder := newTraceRecorder(failingWriter{})
+
+	for i := 0; i < 1000; i++ {
+		recorder.record(TraceSet, "key", false)
+	}
+
+	assert.EqualError(t, recorder.flush(), "write")
+}
+
+func TestTraceReaderInvalidTrace(t *testing.T) {
+	_, err := NewTraceReader(bytes.NewReader([]byte("not a trace"))).Next()
+	assert.ErrorIs(t, err, ErrInvalidTrace)
+
+	_, err = NewTraceReader(bytes.NewReader(nil)).Next()
+	assert.ErrorIs(t, err, ErrInvalidTrace)
+
+	// Truncated record.
+	data := append(append([]byte{}, traceMagic...), traceVersion, byte(TraceGet), 1, 2)
+	_, err = NewTraceReader(bytes.NewReader(data)).Next()
+	assert.ErrorIs(t, err, ErrInvalidTrace)
+}
+
+func TestTraceOpString(t *testing.T) {
+	assert.Equal(t, "get", TraceGet.String())
+	assert.Equal(t, "set", TraceSet.String())
+	assert.Equal(t, "delete", TraceDelete.String())
+	assert.Equal(t, "unknown", TraceOp(10).String())
+}
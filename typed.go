@@ -0,0 +1,154 @@
+package incache
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// TypedCache is a type-safe cache with keys of any comparable type K and
+// values of type V, backed by a Cache.
+//
+// Keys are stored in the underlying cache as strings, so they appear in
+// debug output, events, snapshots and the append-only log in a readable
+// form. Strings, integers and fmt.Stringer implementations are converted
+// without fmt.Sprint, other keys, e.g. structs, should be given a
+// stringer with WithKeyStringer.
+//
+// Example:
+//
+//	users := incache.NewTyped[int64, User](cache)
+//	users.Set(42, user) // stored as "42"
+//	user, ok := users.Get(42)
+type TypedCache[K comparable, V any] struct {
+	cache     *Cache
+	keyString func(K) string
+}
+
+// TypedOption configures a TypedCache.
+type TypedOption[K comparable] func(*typedConfig[K])
+
+type typedConfig[K comparable] struct {
+	keyString func(K) string
+}
+
+// WithKeyStringer sets the function that converts keys to the strings they
+// are stored under. It has to return distinct strings for distinct keys.
+func WithKeyStringer[K comparable](fn func(K) string) TypedOption[K] {
+	return func(c *typedConfig[K]) {
+		c.keyString = fn
+	}
+}
+
+// NewTyped returns a typed cache backed by cache.
+func NewTyped[K comparable, V any](cache *Cache, options ...TypedOption[K]) *TypedCache[K, V] {
+	conf := typedConfig[K]{
+		keyString: defaultKeyString[K],
+	}
+
+	for _, option := range options {
+		option(&conf)
+	}
+
+	return &TypedCache[K, V]{
+		cache:     cache,
+		keyString: conf.keyString,
+	}
+}
+
+// Cache returns the underlying cache.
+func (t *TypedCache[K, V]) Cache() *Cache {
+	return t.cache
+}
+
+// Set sets the key to hold a value with the default TTL of the cache.
+func (t *TypedCache[K, V]) Set(key K, value V) {
+	t.cache.Set(t.keyString(key), value)
+}
+
+// SetWithTTL sets the key to hold a value for ttl.
+func (t *TypedCache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	t.cache.SetWithTTL(t.keyString(key), value, ttl)
+}
+
+// Get returns the value of key.
+// If the key doesn't exist or holds a value of another type, the zero value
+// and false will be returned.
+func (t *TypedCache[K, V]) Get(key K) (V, bool) {
+	return typedValue[V](t.cache.Get(t.keyString(key)))
+}
+
+// GetResult returns the value of key together with its metadata, see
+// Cache.GetResult. A value of another type isn't found.
+func (t *TypedCache[K, V]) GetResult(key K) Result[V] {
+	return typedResult[V](t.cache.GetResult(t.keyString(key)))
+}
+
+// GetDelete returns the value of key and delete it.
+// If the key doesn't exist or holds a value of another type, the zero value
+// and false will be returned, the key is deleted anyway.
+func (t *TypedCache[K, V]) GetDelete(key K) (V, bool) {
+	return typedValue[V](t.cache.GetDelete(t.keyString(key)))
+}
+
+// GetOrCompute returns the value of key. If the key doesn't exist, fn is
+// called to compute the value, which is stored with the default TTL of the
+// cache and returned.
+func (t *TypedCache[K, V]) GetOrCompute(key K, fn func() V) V {
+	value, _ := typedValue[V](t.cache.GetOrCompute(t.keyString(key), func() interface{} {
+		return fn()
+	}))
+
+	return value
+}
+
+// Delete deletes the value of key.
+// If the key doesn't exist, nothing will happen.
+func (t *TypedCache[K, V]) Delete(key K) {
+	t.cache.Delete(t.keyString(key))
+}
+
+// Has checks if the key exists and holds a value of type V.
+func (t *TypedCache[K, V]) Has(key K) bool {
+	_, ok := typedValue[V](t.cache.peek(t.keyString(key)))
+
+	return ok
+}
+
+// Len returns the number of elements stored in the underlying cache.
+func (t *TypedCache[K, V]) Len() int {
+	return t.cache.Len()
+}
+
+// defaultKeyString converts keys of common types to strings without
+// fmt.Sprint, falling back to it for the rest.
+func defaultKeyString[K comparable](key K) string {
+	switch k := any(key).(type) {
+	case string:
+		return k
+	case int:
+		return strconv.Itoa(k)
+	case int8:
+		return strconv.FormatInt(int64(k), 10)
+	case int16:
+		return strconv.FormatInt(int64(k), 10)
+	case int32:
+		return strconv.FormatInt(int64(k), 10)
+	case int64:
+		return strconv.FormatInt(k, 10)
+	case uint:
+		return strconv.FormatUint(uint64(k), 10)
+	case uint8:
+		return strconv.FormatUint(uint64(k), 10)
+	case uint16:
+		return strconv.FormatUint(uint64(k), 10)
+	case uint32:
+		return strconv.FormatUint(uint64(k), 10)
+	case uint64:
+		return strconv.FormatUint(k, 10)
+	case fmt.Stringer:
+		return k.String()
+	default:
+		return fmt.Sprint(key)
+	}
+}
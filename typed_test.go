@@ -0,0 +1,64 @@
+package incache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type typedKey struct {
+	Tenant string
+	ID     int
+}
+
+func TestTypedCache(t *testing.T) {
+	cache := New(WithTTL(0))
+	defer cache.Close()
+
+	users := NewTyped[int64, string](cache)
+	users.Set(42, "user42")
+
+	user, ok := users.Get(42)
+	assert.True(t, ok)
+	assert.Equal(t, "user42", user)
+	assert.Equal(t, "user42", cache.Get("42"))
+	assert.True(t, users.Has(42))
+	assert.False(t, users.Has(43))
+	assert.Equal(t, 1, users.Len())
+	assert.Same(t, cache, users.Cache())
+
+	user, ok = users.GetDelete(42)
+	assert.True(t, ok)
+	assert.Equal(t, "user42", user)
+
+	_, ok = users.Get(42)
+	assert.False(t, ok)
+}
+
+func TestTypedCacheKeyStringer(t *testing.T) {
+	cache := New(WithTTL(0))
+	defer cache.Close()
+
+	items := NewTyped[typedKey, int](cache, WithKeyStringer(func(key typedKey) string {
+		return key.Tenant + ":" + strconv.Itoa(key.ID)
+	}))
+
+	items.Set(typedKey{Tenant: "acme", ID: 1}, 10)
+
+	assert.Equal(t, 10, cache.Get("acme:1"))
+	assert.Equal(t, 11, items.GetOrCompute(typedKey{Tenant: "acme", ID: 2}, func() int { return 11 }))
+
+	items.Delete(typedKey{Tenant: "acme", ID: 1})
+	assert.False(t, cache.Has("acme:1"))
+}
+
+func TestDefaultKeyString(t *testing.T) {
+	assert.Equal(t, "key", defaultKeyString("key"))
+	assert.Equal(t, "-1", defaultKeyString(-1))
+	assert.Equal(t, "42", defaultKeyString(int64(42)))
+	assert.Equal(t, "42", defaultKeyString(uint8(42)))
+	assert.Equal(t, "1s", defaultKeyString(time.Second))
+	assert.Equal(t, "{acme 1}", defaultKeyString(typedKey{Tenant: "acme", ID: 1}))
+}
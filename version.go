@@ -0,0 +1,98 @@
+package incache
+
+import "time"
+
+// GetVersioned returns the value of key together with its version.
+// Every write of a key assigns it a new version, which is greater than
+// all versions assigned before, so the version can be used for lost-update-free
+// read-modify-write with SetIfVersion.
+//
+// If the key doesn't exist, nil value, zero version and false are returned.
+func (c *Cache) GetVersioned(key string) (interface{}, uint64, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	value := c.getLocked(key)
+	if value == nil {
+		return nil, 0, false
+	}
+
+	return value, c.items[key].version, true
+}
+
+// SetVersioned works like SetWithTTL, but also returns the version assigned
+// to the value, so the events of the write can be told apart from the events
+// of other writes of the key, see Event.Version. Zero is returned if
+// the value was rejected.
+func (c *Cache) SetVersioned(key string, value interface{}, ttl time.Duration) uint64 {
+	c.hooks.beforeSet(key, value, ttl)
+
+	version := c.storeVersioned(key, value, ttl)
+	c.hooks.afterSet(key, value, ttl)
+
+	return version
+}
+
+func (c *Cache) storeVersioned(key string, value interface{}, ttl time.Duration) uint64 {
+	c.mu.Lock()
+	defer c.unlock()
+
+	last := c.lastVersion
+	c.setLocked(key, value, ttl)
+
+	if c.lastVersion == last {
+		return 0
+	}
+
+	return c.lastVersion
+}
+
+// SetIfVersion sets the key to hold a value with the default TTL, if the
+// current version of the key is equal to expectedVersion, and reports
+// whether the value was stored. Zero expectedVersion means that the key
+// must not exist.
+func (c *Cache) SetIfVersion(key string, value interface{}, expectedVersion uint64) bool {
+	return c.setIfVersion(key, value, c.defaultTTL(), expectedVersion)
+}
+
+// SetIfVersionWithTTL works similar to SetIfVersion method, but with
+// an opportunity to adjust a ttl for that particular key manually.
+func (c *Cache) SetIfVersionWithTTL(key string, value interface{}, ttl time.Duration, expectedVersion uint64) bool {
+	return c.setIfVersion(key, value, ttl, expectedVersion)
+}
+
+func (c *Cache) setIfVersion(key string, value interface{}, ttl time.Duration, expectedVersion uint64) bool {
+	c.hooks.beforeSet(key, value, ttl)
+
+	if !c.storeIfVersion(key, value, ttl, expectedVersion) {
+		return false
+	}
+
+	c.hooks.afterSet(key, value, ttl)
+
+	return true
+}
+
+func (c *Cache) storeIfVersion(key string, value interface{}, ttl time.Duration, expectedVersion uint64) bool {
+	c.mu.Lock()
+	defer c.unlock()
+
+	if c.versionLocked(key) != expectedVersion {
+		return false
+	}
+
+	c.setLocked(key, value, ttl)
+
+	return true
+}
+
+// versionLocked returns the version of key, or zero if it doesn't exist.
+// It must be called with at least the read lock held.
+func (c *Cache) versionLocked(key string) uint64 {
+	item, ok := c.items[key]
+	if !ok || item.Expired() {
+		return 0
+	}
+
+	return item.version
+}
@@ -0,0 +1,116 @@
+package incache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetVersioned(t *testing.T) {
+	cache := New(WithTTL(0))
+
+	value, version, ok := cache.GetVersioned("key1")
+	assert.Nil(t, value)
+	assert.Zero(t, version)
+	assert.False(t, ok)
+
+	cache.Set("key1", "value1")
+	_, v1, ok := cache.GetVersioned("key1")
+	assert.True(t, ok)
+	assert.NotZero(t, v1)
+
+	cache.Set("key2", "value2")
+	cache.Set("key1", "value3")
+
+	value, v2, ok := cache.GetVersioned("key1")
+	assert.True(t, ok)
+	assert.Equal(t, "value3", value)
+	assert.Greater(t, v2, v1)
+}
+
+func TestSetVersioned(t *testing.T) {
+	cache := New(WithTTL(0), WithMaxKeyLength(4))
+	defer cache.Close()
+
+	events := cache.Watch(context.Background(), "key1")
+
+	version := cache.SetVersioned("key1", "value1", time.Minute)
+	assert.NotZero(t, version)
+
+	_, current, _ := cache.GetVersioned("key1")
+	assert.Equal(t, current, version)
+
+	event := <-events
+	assert.Equal(t, EventInsertion, event.Type)
+	assert.Equal(t, version, event.Version)
+
+	cache.Delete("key1")
+
+	event = <-events
+	assert.Equal(t, EventEviction, event.Type)
+	assert.Equal(t, version, event.Version)
+
+	assert.Zero(t, cache.SetVersioned("key10", "value10", 0))
+}
+
+func TestSetIfVersion(t *testing.T) {
+	cache := New(WithTTL(0))
+
+	assert.True(t, cache.SetIfVersion("key1", "value1", 0))
+	assert.False(t, cache.SetIfVersion("key1", "value2", 0))
+
+	_, version, _ := cache.GetVersioned("key1")
+
+	assert.True(t, cache.SetIfVersion("key1", "value2", version))
+	assert.False(t, cache.SetIfVersion("key1", "value3", version))
+	assert.Equal(t, "value2", cache.Get("key1"))
+
+	_, version, _ = cache.GetVersioned("key1")
+	assert.True(t, cache.SetIfVersionWithTTL("key1", "value3", time.Minute, version))
+
+	info, _ := cache.Inspect("key1")
+	assert.Equal(t, time.Minute, info.TTL)
+}
+
+func TestSetIfVersionAfterDelete(t *testing.T) {
+	cache := New(WithTTL(0))
+
+	cache.Set("key1", "value1")
+	_, version, _ := cache.GetVersioned("key1")
+
+	cache.Delete("key1")
+	cache.Set("key1", "value2")
+
+	assert.False(t, cache.SetIfVersion("key1", "value3", version))
+}
+
+func TestSetIfVersionConcurrentIncrements(t *testing.T) {
+	cache := New(WithTTL(0))
+	cache.Set("counter", 0)
+
+	var wg sync.WaitGroup
+
+	for g := 0; g < 50; g++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < 100; i++ {
+				for {
+					value, version, _ := cache.GetVersioned("counter")
+					if cache.SetIfVersion("counter", value.(int)+1, version) {
+						break
+					}
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, 5000, cache.Get("counter"))
+}
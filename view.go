@@ -0,0 +1,147 @@
+package incache
+
+import (
+	"strings"
+	"time"
+)
+
+// TypedView is a type-safe view of the cache that prefixes all keys with
+// its prefix and only holds values of type V. Several packages can share one
+// cache through views of their own types without type assertions.
+//
+// Example:
+//
+//	users := incache.View[User](cache, "users:")
+//	users.Set("1", user) // stored as "users:1"
+//	user, ok := users.Get("1")
+type TypedView[V any] struct {
+	cache  *Cache
+	prefix string
+}
+
+// View returns a view of the cache with keys prefixed by prefix and values
+// of type V.
+//
+// Values stored under the prefix by other means, e.g. through the cache
+// itself, that aren't of type V are treated as missing by the view.
+func View[V any](cache *Cache, prefix string) *TypedView[V] {
+	return &TypedView[V]{
+		cache:  cache,
+		prefix: prefix,
+	}
+}
+
+// Prefix returns the prefix of keys of the view.
+func (v *TypedView[V]) Prefix() string {
+	return v.prefix
+}
+
+// Set sets the key to hold a value with the default TTL of the cache.
+func (v *TypedView[V]) Set(key string, value V) {
+	v.cache.Set(v.prefix+key, value)
+}
+
+// SetWithTTL sets the key to hold a value for ttl.
+func (v *TypedView[V]) SetWithTTL(key string, value V, ttl time.Duration) {
+	v.cache.SetWithTTL(v.prefix+key, value, ttl)
+}
+
+// Get returns the value of key.
+// If the key doesn't exist or holds a value of another type, the zero value
+// and false will be returned.
+func (v *TypedView[V]) Get(key string) (V, bool) {
+	return typedValue[V](v.cache.Get(v.prefix + key))
+}
+
+// GetResult returns the value of key together with its metadata, see
+// Cache.GetResult. A value of another type isn't found.
+func (v *TypedView[V]) GetResult(key string) Result[V] {
+	return typedResult[V](v.cache.GetResult(v.prefix + key))
+}
+
+// GetDelete returns the value of key and delete it.
+// If the key doesn't exist or holds a value of another type, the zero value
+// and false will be returned, the key is deleted anyway.
+func (v *TypedView[V]) GetDelete(key string) (V, bool) {
+	return typedValue[V](v.cache.GetDelete(v.prefix + key))
+}
+
+// GetOrCompute returns the value of key. If the key doesn't exist, fn is
+// called to compute the value, which is stored with the default TTL of the
+// cache and returned.
+func (v *TypedView[V]) GetOrCompute(key string, fn func() V) V {
+	value, _ := typedValue[V](v.cache.GetOrCompute(v.prefix+key, func() interface{} {
+		return fn()
+	}))
+
+	return value
+}
+
+// Delete deletes the value of key.
+// If the key doesn't exist, nothing will happen.
+func (v *TypedView[V]) Delete(key string) {
+	v.cache.Delete(v.prefix + key)
+}
+
+// Has checks if the key exists in the view and holds a value of type V.
+func (v *TypedView[V]) Has(key string) bool {
+	_, ok := typedValue[V](v.cache.peek(v.prefix + key))
+
+	return ok
+}
+
+// Keys returns slice of all keys in the view, without the prefix.
+// Keys of expired items that weren't removed yet aren't included.
+func (v *TypedView[V]) Keys() []string {
+	v.cache.mu.RLock()
+	defer v.cache.mu.RUnlock()
+
+	keys := []string{}
+
+	timeNow := time.Now()
+	for key, item := range v.cache.items {
+		if strings.HasPrefix(key, v.prefix) && !item.expiredAt(timeNow) {
+			keys = append(keys, strings.TrimPrefix(key, v.prefix))
+		}
+	}
+
+	return keys
+}
+
+// Len returns the number of elements stored in the view.
+// Expired items that weren't removed yet aren't counted.
+func (v *TypedView[V]) Len() int {
+	v.cache.mu.RLock()
+	defer v.cache.mu.RUnlock()
+
+	count := 0
+
+	timeNow := time.Now()
+	for key, item := range v.cache.items {
+		if strings.HasPrefix(key, v.prefix) && !item.expiredAt(timeNow) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// DeleteAll deletes all values stored under the prefix of the view, leaving
+// the rest of the cache untouched.
+func (v *TypedView[V]) DeleteAll() {
+	v.cache.mu.Lock()
+	defer v.cache.unlock()
+
+	for key := range v.cache.items {
+		if strings.HasPrefix(key, v.prefix) {
+			v.cache.evictLocked(key, reasonDeleted)
+		}
+	}
+}
+
+// typedValue asserts that value is of type V.
+func typedValue[V any](value interface{}) (V, bool) {
+	typed, ok := value.(V)
+
+	return typed, ok
+}
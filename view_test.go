@@ -0,0 +1,97 @@
+package incache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type viewUser struct {
+	Name string
+}
+
+func TestView(t *testing.T) {
+	cache := New(WithTTL(0))
+	defer cache.Close()
+
+	users := View[viewUser](cache, "users:")
+	counters := View[int](cache, "counters:")
+
+	users.Set("1", viewUser{Name: "user1"})
+	users.SetWithTTL("2", viewUser{Name: "user2"}, time.Hour)
+	counters.Set("1", 10)
+
+	assert.Equal(t, "users:", users.Prefix())
+
+	user, ok := users.Get("1")
+	assert.True(t, ok)
+	assert.Equal(t, viewUser{Name: "user1"}, user)
+	assert.Equal(t, viewUser{Name: "user1"}, cache.Get("users:1"))
+
+	counter, ok := counters.Get("1")
+	assert.True(t, ok)
+	assert.Equal(t, 10, counter)
+
+	_, ok = users.Get("3")
+	assert.False(t, ok)
+
+	assert.True(t, users.Has("2"))
+	assert.False(t, counters.Has("2"))
+	assert.Equal(t, 2, users.Len())
+	assert.ElementsMatch(t, []string{"1", "2"}, users.Keys())
+
+	user, ok = users.GetDelete("2")
+	assert.True(t, ok)
+	assert.Equal(t, "user2", user.Name)
+
+	users.Delete("1")
+	assert.Zero(t, users.Len())
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestViewOtherType(t *testing.T) {
+	cache := New(WithTTL(0))
+	defer cache.Close()
+
+	cache.Set("counters:1", "not a number")
+
+	counters := View[int](cache, "counters:")
+
+	counter, ok := counters.Get("1")
+	assert.False(t, ok)
+	assert.Zero(t, counter)
+	assert.False(t, counters.Has("1"))
+}
+
+func TestViewGetOrCompute(t *testing.T) {
+	cache := New(WithTTL(0))
+	defer cache.Close()
+
+	counters := View[int](cache, "counters:")
+
+	calls := 0
+	compute := func() int {
+		calls++
+		return 42
+	}
+
+	assert.Equal(t, 42, counters.GetOrCompute("1", compute))
+	assert.Equal(t, 42, counters.GetOrCompute("1", compute))
+	assert.Equal(t, 1, calls)
+}
+
+func TestViewDeleteAll(t *testing.T) {
+	cache := New(WithTTL(0))
+	defer cache.Close()
+
+	users := View[string](cache, "users:")
+	users.Set("1", "user1")
+	users.Set("2", "user2")
+	cache.Set("orders:1", "order1")
+
+	users.DeleteAll()
+
+	assert.Zero(t, users.Len())
+	assert.True(t, cache.Has("orders:1"))
+}
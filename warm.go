@@ -0,0 +1,125 @@
+package incache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// WarmProgress reports the progress of WarmWithProgress after every key.
+type WarmProgress struct {
+	// Key that was just processed and the error returned by the loader.
+	Key string
+	Err error
+
+	Total int
+	// Number of keys whose values were stored.
+	Loaded int
+	// Number of keys that were already stored or had nil values.
+	Skipped int
+	Failed  int
+}
+
+// Done returns the number of processed keys.
+func (p WarmProgress) Done() int {
+	return p.Loaded + p.Skipped + p.Failed
+}
+
+// Warm pre-populates the cache with the values of keys returned by loader,
+// so a service can fill the cache before accepting traffic. The values are
+// loaded by up to concurrency goroutines and stored with the default TTL.
+// Keys that are already stored are skipped, so are nil values.
+//
+// Keys that fail to load don't stop warming, Warm returns the first error
+// returned by loader once all keys are processed. If ctx is done, the
+// remaining keys are skipped and ctx.Err() is returned.
+func (c *Cache) Warm(ctx context.Context, keys []string, loader func(ctx context.Context, key string) (interface{}, error), concurrency int) error {
+	return c.WarmWithProgress(ctx, keys, loader, concurrency, nil)
+}
+
+// WarmWithProgress works similar to Warm, but calls progress after every
+// processed key. Calls of progress are serialized.
+func (c *Cache) WarmWithProgress(ctx context.Context, keys []string, loader func(ctx context.Context, key string) (interface{}, error), concurrency int, progress func(WarmProgress)) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		mu       sync.Mutex
+		state    = WarmProgress{Total: len(keys)}
+		firstErr error
+	)
+
+	report := func(key string, loaded bool, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		state.Key, state.Err = key, err
+
+		switch {
+		case err != nil:
+			state.Failed++
+
+			if firstErr == nil {
+				firstErr = fmt.Errorf("incache: warm %q: %w", key, err)
+			}
+		case loaded:
+			state.Loaded++
+		default:
+			state.Skipped++
+		}
+
+		if progress != nil {
+			progress(state)
+		}
+	}
+
+	queue := make(chan string)
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for key := range queue {
+				if c.Has(key) {
+					report(key, false, nil)
+					continue
+				}
+
+				value, err := loader(ctx, key)
+				if err == nil && value != nil {
+					c.Set(key, value)
+				}
+
+				report(key, err == nil && value != nil, err)
+			}
+		}()
+	}
+
+feed:
+	for _, key := range keys {
+		if ctx.Err() != nil {
+			break
+		}
+
+		select {
+		case queue <- key:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+
+	close(queue)
+	wg.Wait()
+
+	c.config.debugf("[warm] loaded: %d, skipped: %d, failed: %d", state.Loaded, state.Skipped, state.Failed)
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return firstErr
+}
@@ -0,0 +1,82 @@
+package incache
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarm(t *testing.T) {
+	cache := New()
+	cache.Set("key1", "cached")
+
+	var calls int32
+	loader := func(ctx context.Context, key string) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+
+		if key == "missing" {
+			return nil, nil
+		}
+
+		return "loaded-" + key, nil
+	}
+
+	err := cache.Warm(context.Background(), []string{"key1", "key2", "key3", "missing"}, loader, 2)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	assert.Equal(t, "cached", cache.Get("key1"))
+	assert.Equal(t, "loaded-key2", cache.Get("key2"))
+	assert.Equal(t, "loaded-key3", cache.Get("key3"))
+	assert.False(t, cache.Has("missing"))
+}
+
+func TestWarmWithProgress(t *testing.T) {
+	cache := New()
+	cache.Set("key1", "cached")
+
+	errLoad := errors.New("load")
+	loader := func(ctx context.Context, key string) (interface{}, error) {
+		if key == "key3" {
+			return nil, errLoad
+		}
+
+		return key, nil
+	}
+
+	var reports []WarmProgress
+	err := cache.WarmWithProgress(context.Background(), []string{"key1", "key2", "key3"}, loader, 4, func(p WarmProgress) {
+		reports = append(reports, p)
+	})
+
+	assert.ErrorIs(t, err, errLoad)
+	assert.Len(t, reports, 3)
+
+	last := reports[len(reports)-1]
+	assert.Equal(t, 3, last.Total)
+	assert.Equal(t, 3, last.Done())
+	assert.Equal(t, 1, last.Loaded)
+	assert.Equal(t, 1, last.Skipped)
+	assert.Equal(t, 1, last.Failed)
+	assert.Equal(t, "key2", cache.Get("key2"))
+}
+
+func TestWarmCanceled(t *testing.T) {
+	cache := New()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	loader := func(ctx context.Context, key string) (interface{}, error) {
+		cancel()
+		return key, nil
+	}
+
+	err := cache.Warm(ctx, []string{"key1", "key2", "key3"}, loader, 1)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.True(t, cache.Has("key1"))
+	assert.False(t, cache.Has("key3"))
+}
@@ -0,0 +1,138 @@
+package incache
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Watch returns a channel that receives events about the key: when it's
+// set, updated, deleted or expires. Watching stops and the channel is closed
+// when ctx is done or the cache is closed.
+//
+// The channel is buffered (see WithEventsBuffer), and events are dropped
+// when the buffer is full, so operations of the cache never wait for
+// the consumer.
+func (c *Cache) Watch(ctx context.Context, key string) <-chan Event {
+	return c.watchers.add(ctx, key, false)
+}
+
+// WatchPrefix works similar to Watch method, but the channel receives
+// events about all keys with the prefix.
+func (c *Cache) WatchPrefix(ctx context.Context, prefix string) <-chan Event {
+	return c.watchers.add(ctx, prefix, true)
+}
+
+type watcher struct {
+	key    string
+	prefix bool
+	ch     chan Event
+	// Closed when the watcher is removed.
+	done chan struct{}
+}
+
+func (w *watcher) matches(key string) bool {
+	if w.prefix {
+		return strings.HasPrefix(key, w.key)
+	}
+
+	return key == w.key
+}
+
+type watchers struct {
+	// The number of watchers, so publishers don't need to take
+	// the lock when nobody is watching.
+	count int32
+
+	mu     sync.Mutex
+	set    map[*watcher]struct{}
+	size   int
+	closed bool
+}
+
+func newWatchers(size int) *watchers {
+	return &watchers{
+		set:  make(map[*watcher]struct{}),
+		size: size,
+	}
+}
+
+func (ws *watchers) add(ctx context.Context, key string, prefix bool) <-chan Event {
+	w := &watcher{key: key, prefix: prefix, ch: make(chan Event, ws.size), done: make(chan struct{})}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if ws.closed {
+		close(w.ch)
+		return w.ch
+	}
+
+	ws.set[w] = struct{}{}
+	atomic.AddInt32(&ws.count, 1)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			ws.remove(w)
+		case <-w.done:
+		}
+	}()
+
+	return w.ch
+}
+
+func (ws *watchers) remove(w *watcher) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if _, ok := ws.set[w]; !ok {
+		return
+	}
+
+	delete(ws.set, w)
+	atomic.AddInt32(&ws.count, -1)
+	close(w.ch)
+	close(w.done)
+}
+
+func (ws *watchers) enabled() bool {
+	return atomic.LoadInt32(&ws.count) > 0
+}
+
+func (ws *watchers) publish(event Event) {
+	if !ws.enabled() {
+		return
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	for w := range ws.set {
+		if !w.matches(event.Key) {
+			continue
+		}
+
+		select {
+		case w.ch <- event:
+		default:
+		}
+	}
+}
+
+// close closes channels of all watchers. It's safe to call it multiple times.
+func (ws *watchers) close() {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	ws.closed = true
+
+	for w := range ws.set {
+		delete(ws.set, w)
+		close(w.ch)
+		close(w.done)
+	}
+
+	atomic.StoreInt32(&ws.count, 0)
+}
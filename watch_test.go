@@ -0,0 +1,88 @@
+package incache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func drain(ch <-chan Event) []Event {
+	var events []Event
+	for event := range ch {
+		event.Time = time.Time{}
+		event.Version = 0
+		events = append(events, event)
+	}
+
+	return events
+}
+
+func TestWatch(t *testing.T) {
+	cache := New(WithTTL(0))
+
+	ch := cache.Watch(context.Background(), "key1")
+
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+	cache.Set("key1", "value3")
+	cache.SetWithTTL("key1", "value4", time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	cache.DeleteExpired()
+	cache.Set("key1", "value5")
+	cache.Delete("key1")
+	cache.Close()
+
+	assert.Equal(t, []Event{
+		{Type: EventInsertion, Key: "key1", Value: "value1"},
+		{Type: EventUpdate, Key: "key1", Value: "value3", OldValue: "value1"},
+		{Type: EventUpdate, Key: "key1", Value: "value4", OldValue: "value3"},
+		{Type: EventExpiration, Key: "key1", Value: "value4", Reason: "expired"},
+		{Type: EventInsertion, Key: "key1", Value: "value5"},
+		{Type: EventEviction, Key: "key1", Value: "value5", Reason: "deleted"},
+	}, drain(ch))
+}
+
+func TestWatchPrefix(t *testing.T) {
+	cache := New(WithTTL(0))
+
+	ch := cache.WatchPrefix(context.Background(), "users:")
+
+	cache.Set("users:1", "user1")
+	cache.Set("orders:1", "order1")
+	cache.Delete("users:1")
+	cache.Close()
+
+	assert.Equal(t, []Event{
+		{Type: EventInsertion, Key: "users:1", Value: "user1"},
+		{Type: EventEviction, Key: "users:1", Value: "user1", Reason: "deleted"},
+	}, drain(ch))
+}
+
+func TestWatchStopsWhenContextIsDone(t *testing.T) {
+	cache := New(WithTTL(0))
+	defer cache.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := cache.Watch(ctx, "key1")
+
+	cache.Set("key1", "value1")
+	cancel()
+
+	assert.Eventually(t, func() bool {
+		return !cache.watchers.enabled()
+	}, time.Second, time.Millisecond)
+
+	cache.Set("key1", "value2")
+
+	assert.Equal(t, []Event{{Type: EventInsertion, Key: "key1", Value: "value1"}}, drain(ch))
+}
+
+func TestWatchAfterClose(t *testing.T) {
+	cache := New()
+	cache.Close()
+
+	_, ok := <-cache.Watch(context.Background(), "key1")
+	assert.False(t, ok)
+}
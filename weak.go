@@ -0,0 +1,46 @@
+package incache
+
+// weakValue is stored instead of values set by SetWeak, so the GC can
+// reclaim them when nothing else references them.
+type weakValue interface {
+	// value returns the referenced value, or nil once it's reclaimed.
+	value() interface{}
+}
+
+// reclaimed reports whether the stored value is a weak reference to
+// a value reclaimed by the GC.
+func reclaimed(stored interface{}) bool {
+	w, ok := stored.(weakValue)
+
+	return ok && w.value() == nil
+}
+
+// storeWeak stores the item and replaces its value by the weak reference,
+// so events, indexes and persistence see the value itself.
+func (c *Cache) storeWeak(key string, item Item, weak weakValue) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	version := c.lastVersion
+	c.setItemLocked(key, item)
+
+	// The item was rejected.
+	if c.lastVersion == version {
+		return
+	}
+
+	item = c.items[key]
+	item.Value = weak
+	c.items[key] = item
+	c.mirrorLocked(key, item)
+}
+
+// deleteReclaimed deletes the key if its value was reclaimed by the GC.
+func (c *Cache) deleteReclaimed(key string) {
+	c.mu.Lock()
+	defer c.unlock()
+
+	if item, ok := c.items[key]; ok && reclaimed(item.Value) {
+		c.evictLocked(key, reasonReclaimed)
+	}
+}
@@ -0,0 +1,48 @@
+//go:build go1.24
+
+package incache
+
+import (
+	"runtime"
+	"time"
+	"weak"
+)
+
+// weakPointer is a weak reference to a value set by SetWeak.
+type weakPointer[T any] struct {
+	ptr weak.Pointer[T]
+}
+
+func (w weakPointer[T]) value() interface{} {
+	if v := w.ptr.Value(); v != nil {
+		return v
+	}
+
+	return nil
+}
+
+// SetWeak sets the key to hold a weak reference to value with the default
+// TTL of the cache. It's experimental and only available since Go 1.24.
+//
+// Once nothing else references value, the GC can reclaim it before its TTL
+// has passed. Get then returns nil and the key is evicted shortly after.
+// Events, hooks and persistence, e.g. snapshots, see the value itself.
+func SetWeak[T any](c *Cache, key string, value *T) {
+	SetWeakWithTTL(c, key, value, c.defaultTTL())
+}
+
+// SetWeakWithTTL works similar to SetWeak, but sets the key to hold
+// the weak reference for ttl.
+func SetWeakWithTTL[T any](c *Cache, key string, value *T, ttl time.Duration) {
+	c.hooks.beforeSet(key, value, ttl)
+
+	c.storeWeak(key, newItem(value, c.jitter(ttl)), weakPointer[T]{ptr: weak.Make(value)})
+	c.hooks.afterSet(key, value, ttl)
+
+	ref := weakRef(c)
+	runtime.AddCleanup(value, func(key string) {
+		if cache := ref(); cache != nil {
+			cache.deleteReclaimed(key)
+		}
+	}, key)
+}
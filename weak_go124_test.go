@@ -0,0 +1,65 @@
+//go:build go1.24
+
+package incache
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type weakTestValue struct {
+	data [64]byte
+	next *weakTestValue
+}
+
+func TestSetWeak(t *testing.T) {
+	cache := New(WithTTL(0), WithMetrics())
+	defer cache.Close()
+
+	value := &weakTestValue{}
+	SetWeak(cache, "key", value)
+
+	assert.Same(t, value, cache.Get("key"))
+	assert.Equal(t, 1, cache.Len())
+
+	runtime.KeepAlive(value)
+	value = nil
+
+	assert.Eventually(t, func() bool {
+		runtime.GC()
+		return cache.Get("key") == nil && cache.Len() == 0
+	}, time.Second, 10*time.Millisecond)
+
+	assert.EqualValues(t, 1, cache.Metrics().Evictions())
+}
+
+func TestSetWeakReferenced(t *testing.T) {
+	cache := New(WithTTL(0))
+	defer cache.Close()
+
+	value := &weakTestValue{}
+	SetWeakWithTTL(cache, "key", value, time.Hour)
+
+	runtime.GC()
+	runtime.GC()
+
+	assert.Same(t, value, cache.Get("key"))
+	runtime.KeepAlive(value)
+}
+
+func TestSetWeakOverwritten(t *testing.T) {
+	cache := New(WithTTL(0))
+	defer cache.Close()
+
+	SetWeak(cache, "key", &weakTestValue{})
+	cache.Set("key", "value")
+
+	runtime.GC()
+	runtime.GC()
+	time.Sleep(10 * time.Millisecond)
+
+	assert.Equal(t, "value", cache.Get("key"))
+}
@@ -0,0 +1,224 @@
+package incache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// writeBehind buffers write operations and flushes them to the backend
+// in batches. Operations on the same key are coalesced, so only the latest
+// one is written.
+type writeBehind struct {
+	backend   Backend
+	interval  time.Duration
+	queueSize int
+
+	mu      sync.Mutex
+	notFull *sync.Cond
+	pending map[string]BackendOp
+	// Keys of pending operations in the order they were first queued.
+	order []string
+
+	// Serializes flushes, so older operations never overwrite newer ones.
+	flushMu sync.Mutex
+	flushCh chan struct{}
+
+	closeCh   chan struct{}
+	doneCh    chan struct{}
+	closeOnce sync.Once
+}
+
+func newWriteBehind(backend Backend, interval time.Duration, queueSize int) *writeBehind {
+	if queueSize <= 0 {
+		queueSize = 1
+	}
+
+	wb := &writeBehind{
+		backend:   backend,
+		interval:  interval,
+		queueSize: queueSize,
+
+		pending: make(map[string]BackendOp),
+		flushCh: make(chan struct{}, 1),
+		closeCh: make(chan struct{}),
+		doneCh:  make(chan struct{}),
+	}
+	wb.notFull = sync.NewCond(&wb.mu)
+
+	return wb
+}
+
+// push adds the operations to the queue. It never blocks, so it can be
+// called with the lock of the cache held, which keeps the operations in
+// the order they were applied to the cache. The queue may grow above its
+// size until waitForRoom is called.
+func (wb *writeBehind) push(ops []BackendOp) {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+
+	for _, op := range ops {
+		if _, ok := wb.pending[op.Key]; !ok {
+			wb.order = append(wb.order, op.Key)
+		}
+
+		wb.pending[op.Key] = op
+	}
+}
+
+// waitForRoom applies backpressure to writers: if the queue is full, it
+// triggers a flush and blocks until there is a free space. It must be
+// called without the lock of the cache held, so a slow backend doesn't
+// block readers and other writers.
+func (wb *writeBehind) waitForRoom() {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+
+	for len(wb.pending) > wb.queueSize {
+		if wb.closed() {
+			// There is no background process to wait for anymore.
+			wb.mu.Unlock()
+			err := wb.flush(context.Background())
+			wb.mu.Lock()
+
+			if err != nil {
+				// The operations stay queued until the next Flush.
+				return
+			}
+
+			continue
+		}
+
+		wb.triggerFlush()
+		wb.notFull.Wait()
+	}
+}
+
+func (wb *writeBehind) closed() bool {
+	select {
+	case <-wb.closeCh:
+		return true
+	default:
+		return false
+	}
+}
+
+func (wb *writeBehind) triggerFlush() {
+	select {
+	case wb.flushCh <- struct{}{}:
+	default:
+	}
+}
+
+// flush writes all pending operations to the backend. Operations that
+// failed to be written are put back to the queue unless they were
+// superseded by newer ones.
+func (wb *writeBehind) flush(ctx context.Context) error {
+	wb.flushMu.Lock()
+	defer wb.flushMu.Unlock()
+
+	wb.mu.Lock()
+	ops := make([]BackendOp, 0, len(wb.order))
+	for _, key := range wb.order {
+		ops = append(ops, wb.pending[key])
+	}
+
+	wb.pending = make(map[string]BackendOp, wb.queueSize)
+	wb.order = nil
+	wb.notFull.Broadcast()
+	wb.mu.Unlock()
+
+	if len(ops) == 0 {
+		return nil
+	}
+
+	err := applyBackendOps(ctx, wb.backend, ops)
+	if err != nil {
+		wb.requeue(ops)
+	}
+
+	return err
+}
+
+func (wb *writeBehind) requeue(ops []BackendOp) {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+
+	order := make([]string, 0, len(ops)+len(wb.order))
+	for _, op := range ops {
+		if _, ok := wb.pending[op.Key]; ok {
+			continue
+		}
+
+		wb.pending[op.Key] = op
+		order = append(order, op.Key)
+	}
+
+	wb.order = append(order, wb.order...)
+}
+
+func (wb *writeBehind) start(c *Cache) {
+	go func() {
+		defer close(wb.doneCh)
+
+		ticker := time.NewTicker(wb.interval)
+		defer ticker.Stop()
+
+		var failed bool
+		for {
+			// After a failure, flushes triggered by writers waiting for
+			// room are postponed until the next tick, so a failing backend
+			// isn't retried in a tight loop.
+			flushCh := wb.flushCh
+			if failed {
+				flushCh = nil
+			}
+
+			select {
+			case <-ticker.C:
+			case <-flushCh:
+			case <-wb.closeCh:
+				return
+			}
+
+			err := wb.flush(context.Background())
+			if err != nil {
+				c.config.debugf("[write-behind] failed to flush: %v", err)
+			}
+
+			failed = err != nil
+		}
+	}()
+}
+
+// close stops the background process. It's safe to call it multiple times.
+func (wb *writeBehind) close() {
+	wb.closeOnce.Do(func() {
+		close(wb.closeCh)
+
+		// Writers waiting for the background process flush by themselves.
+		wb.mu.Lock()
+		wb.notFull.Broadcast()
+		wb.mu.Unlock()
+	})
+
+	<-wb.doneCh
+}
+
+// writeBehindLocked queues the operation to be handed to the write-behind
+// queue by unlock.
+// It must be called with the write lock held.
+func (c *Cache) writeBehindLocked(op BackendOp) {
+	c.pendingWrites = append(c.pendingWrites, op)
+}
+
+// Flush writes all operations buffered in write-behind mode to the backend.
+// Call it on graceful shutdown to make sure nothing is lost.
+// It does nothing if write-behind mode isn't enabled.
+func (c *Cache) Flush(ctx context.Context) error {
+	if c.writeBehind == nil {
+		return nil
+	}
+
+	return c.writeBehind.flush(ctx)
+}
@@ -0,0 +1,234 @@
+package incache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteBehindFlush(t *testing.T) {
+	backend := newMemoryBackend()
+	cache := New(WithTTL(0), WithWriteBehind(backend, time.Hour, 100))
+	defer cache.Close()
+
+	cache.Set("key1", "value1")
+	cache.SetWithTTL("key2", "value2", time.Minute)
+	cache.Set("key3", "value3")
+	cache.Delete("key3")
+
+	assert.Zero(t, backend.len())
+
+	require.NoError(t, cache.Flush(context.Background()))
+
+	value, ok := backend.get("key1")
+	assert.True(t, ok)
+	assert.Equal(t, "value1", value)
+
+	_, ttl, err := backend.Get(context.Background(), "key2")
+	require.NoError(t, err)
+	assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+
+	_, ok = backend.get("key3")
+	assert.False(t, ok)
+}
+
+func TestWriteBehindCoalescesOperations(t *testing.T) {
+	backend := batchMemoryBackend{newMemoryBackend()}
+	cache := New(WithTTL(0), WithWriteBehind(backend, time.Hour, 100))
+	defer cache.Close()
+
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+	cache.Set("key1", "value3")
+
+	require.NoError(t, cache.Flush(context.Background()))
+
+	require.Len(t, backend.batches, 1)
+	assert.Equal(t, []BackendOp{
+		{Key: "key1", Value: "value3"},
+		{Key: "key2", Value: "value2"},
+	}, backend.batches[0])
+}
+
+func TestWriteBehindInterval(t *testing.T) {
+	backend := newMemoryBackend()
+	cache := New(WithWriteBehind(backend, 5*time.Millisecond, 100))
+	defer cache.Close()
+
+	cache.Set("key1", "value1")
+
+	assert.Eventually(t, func() bool {
+		_, ok := backend.get("key1")
+		return ok
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestWriteBehindFullQueue(t *testing.T) {
+	backend := newMemoryBackend()
+	cache := New(WithWriteBehind(backend, time.Hour, 2))
+	defer cache.Close()
+
+	for i := 0; i < 10; i++ {
+		cache.Set(fmt.Sprint("key", i), i)
+	}
+
+	require.NoError(t, cache.Flush(context.Background()))
+	assert.Equal(t, 10, backend.len())
+}
+
+// blockingBackend blocks writes until release is closed.
+type blockingBackend struct {
+	*memoryBackend
+
+	blocked     chan struct{}
+	blockedOnce sync.Once
+	release     chan struct{}
+}
+
+func (b *blockingBackend) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	b.blockedOnce.Do(func() { close(b.blocked) })
+	<-b.release
+
+	return b.memoryBackend.Set(ctx, key, value, ttl)
+}
+
+func TestWriteBehindFullQueueDoesNotBlockReaders(t *testing.T) {
+	backend := &blockingBackend{
+		memoryBackend: newMemoryBackend(),
+		blocked:       make(chan struct{}),
+		release:       make(chan struct{}),
+	}
+	cache := New(WithTTL(0), WithWriteBehind(backend, time.Hour, 1))
+	defer cache.Close()
+
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+	<-backend.blocked
+
+	// The flush is stuck in the backend, so the writer waits for room
+	// in the queue.
+	written := make(chan struct{})
+	go func() {
+		cache.Set("key3", "value3")
+		cache.Set("key4", "value4")
+		close(written)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+
+	read := make(chan interface{})
+	go func() { read <- cache.Get("key1") }()
+
+	select {
+	case value := <-read:
+		assert.Equal(t, "value1", value)
+	case <-time.After(time.Second):
+		t.Fatal("Get is blocked by the write-behind queue")
+	}
+
+	close(backend.release)
+	<-written
+
+	require.NoError(t, cache.Flush(context.Background()))
+	assert.Equal(t, 4, backend.len())
+}
+
+func TestWriteBehindRetriesFailedOperations(t *testing.T) {
+	backend := newMemoryBackend()
+	cache := New(WithWriteBehind(backend, time.Hour, 100))
+	defer cache.Close()
+
+	backend.setDown(true)
+
+	cache.Set("key1", "value1")
+	cache.Set("key2", "value2")
+
+	assert.ErrorIs(t, cache.Flush(context.Background()), errBackendDown)
+
+	cache.Set("key2", "value3")
+	backend.setDown(false)
+
+	require.NoError(t, cache.Flush(context.Background()))
+
+	value, _ := backend.get("key1")
+	assert.Equal(t, "value1", value)
+
+	value, _ = backend.get("key2")
+	assert.Equal(t, "value3", value)
+}
+
+// countingBackend counts the writes, including failed ones.
+type countingBackend struct {
+	*memoryBackend
+
+	writes int32
+}
+
+func (b *countingBackend) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	atomic.AddInt32(&b.writes, 1)
+
+	return b.memoryBackend.Set(ctx, key, value, ttl)
+}
+
+func TestWriteBehindFullQueueBacksOffFailingBackend(t *testing.T) {
+	backend := &countingBackend{memoryBackend: newMemoryBackend()}
+	cache := New(WithWriteBehind(backend, 50*time.Millisecond, 1))
+	defer cache.Close()
+
+	backend.setDown(true)
+
+	written := make(chan struct{})
+	go func() {
+		for i := 0; i < 10; i++ {
+			cache.Set(fmt.Sprint("key", i), i)
+		}
+		close(written)
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+
+	// The writer waits for room, but the backend is only retried every
+	// interval.
+	assert.LessOrEqual(t, atomic.LoadInt32(&backend.writes), int32(10))
+
+	backend.setDown(false)
+
+	select {
+	case <-written:
+	case <-time.After(time.Second):
+		t.Fatal("writer is still waiting for room")
+	}
+
+	require.NoError(t, cache.Flush(context.Background()))
+	assert.Equal(t, 10, backend.len())
+}
+
+func TestWriteBehindCloseFlushes(t *testing.T) {
+	backend := newMemoryBackend()
+	cache := New(WithWriteBehind(backend, time.Hour, 1))
+
+	cache.Set("key1", "value1")
+	cache.Close()
+
+	_, ok := backend.get("key1")
+	assert.True(t, ok)
+
+	// The cache keeps working after Close, even if the queue is full.
+	cache.Set("key2", "value2")
+	cache.Set("key3", "value3")
+
+	require.NoError(t, cache.Flush(context.Background()))
+	assert.Equal(t, 3, backend.len())
+}
+
+func TestFlushWithoutWriteBehind(t *testing.T) {
+	cache := New()
+
+	assert.NoError(t, cache.Flush(context.Background()))
+}